@@ -3,6 +3,7 @@
 // Program ini:
 //   - Memuat konfigurasi dari file .env (database, JWT, port, dll.)
 //   - Menghubungkan ke database PostgreSQL
+//   - Menjalankan background job periodik (review akses laporan tiap JOB_INTERVAL; kedaluwarsa grant akses harian; deteksi anomali; peringatan keamanan; live feed aktivitas untuk /api/dashboard/stream)
 //   - Mendaftarkan semua route API (auth, dashboard, search, content, report, dll.)
 //   - Menjalankan server HTTP di port yang ditentukan (default: 8080)
 //
@@ -16,7 +17,9 @@ import (
 	"log"
 	"os"
 
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
 	"github.com/bpk-ri/dashboard-monitoring/internal/server"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 	"github.com/joho/godotenv"
 )
@@ -39,6 +42,20 @@ func main() {
 
 	log.Println("Connected to database:", os.Getenv("DB_NAME"))
 
+	// Background job: pengingat review akses laporan (tiap JOB_INTERVAL), kedaluwarsa + pemberitahuan grant akses (harian), deteksi anomali aktivitas, peringatan keamanan aktivitas baru (tiap JOB_INTERVAL), aturan peringatan admin (tiap 5 menit), rekonstruksi sesi aktivitas user (tiap JOB_INTERVAL), live feed aktivitas baru (tiap STREAM_POLL_INTERVAL).
+	jobs := service.NewJobRunner(
+		service.AccessReviewJob(database.GetDB(), config.JobInterval()),
+		service.AccessExpiryJob(database.GetDB(), config.AccessExpiryJobInterval),
+		service.AnomalyDetectionJob(database.GetDB(), config.AnomalyJobInterval),
+		service.SecurityAlertJob(database.GetDB(), config.JobInterval()),
+		service.AlertRuleJob(database.GetDB(), config.AlertRuleJobInterval),
+		service.SessionizationJob(database.GetDB(), config.JobInterval()),
+		service.UserEngagementJob(database.GetDB(), config.UserEngagementJobInterval),
+		service.ActivityFeedJob(database.GetDB(), config.GetStreamConfig().PollInterval),
+	)
+	jobs.Start()
+	defer jobs.Stop()
+
 	// Port server; default 8080 jika PORT tidak diset di .env.
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,83 @@
+// File main.go: CLI verifikasi hash chain audit_events dan penulisan checkpoint harian bertanda tangan.
+//
+// Alur singkat:
+//   - Muat .env, koneksi DB.
+//   - Telusuri audit_events urut id (service.AuditService.VerifyChain); cetak jumlah baris berantai/belum berantai dan tautan rusak pertama.
+//   - Jika AUDIT_CHECKPOINT_KEY diset: cocokkan semua checkpoint di file (AUDIT_CHECKPOINT_FILE) dengan hash di DB.
+//   - Dengan -checkpoint: tambahkan checkpoint baru (id + hash terakhir, HMAC-SHA256) ke file. Jalankan harian lewat cron/Task Scheduler.
+//   - Exit code 1 jika rantai atau checkpoint rusak.
+//
+// Flag:
+//
+//	-checkpoint       tulis checkpoint baru setelah verifikasi berhasil (butuh AUDIT_CHECKPOINT_KEY)
+//	-file <path>      file checkpoint (default: AUDIT_CHECKPOINT_FILE atau audit_checkpoints.jsonl)
+//
+// Cara menjalankan (dari root folder backend):
+//
+//	go run cmd/auditverify/main.go [-checkpoint] [-file <path>]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	writeCheckpoint := flag.Bool("checkpoint", false, "tulis checkpoint baru setelah verifikasi berhasil")
+	file := flag.String("file", "", "file checkpoint (default: AUDIT_CHECKPOINT_FILE)")
+	flag.Parse()
+
+	// Muat .env: coba dari working directory (.env), lalu dari parent (../.env). Jika gagal, pakai env sistem.
+	if err := godotenv.Load(".env"); err != nil {
+		if err2 := godotenv.Load(filepath.Join("..", ".env")); err2 != nil {
+			log.Println("No .env file found, using system environment")
+		}
+	}
+
+	if err := database.InitDB(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer database.CloseDB()
+
+	path := *file
+	if path == "" {
+		path = config.AuditCheckpointFile()
+	}
+	key := config.AuditCheckpointKey()
+	audit := service.NewAuditService(database.GetDB())
+
+	report, err := audit.VerifyChain()
+	if err != nil {
+		log.Fatal("Failed to verify audit chain:", err)
+	}
+	if report.Valid && key != nil {
+		if err := audit.VerifyCheckpoints(report, path, key); err != nil {
+			log.Fatal("Failed to verify checkpoints:", err)
+		}
+	}
+
+	fmt.Printf("chained=%d unchained=%d last_event_id=%d last_hash=%s checkpoints=%d\n",
+		report.Checked, report.Unchained, report.LastEventID, report.LastHash, report.CheckpointCount)
+	if !report.Valid {
+		b := report.FirstBroken
+		fmt.Printf("BROKEN at event %d: %s (expected=%s actual=%s)\n", b.EventID, b.Reason, b.Expected, b.Actual)
+		os.Exit(1)
+	}
+	fmt.Println("OK: audit chain intact")
+
+	if *writeCheckpoint {
+		cp, err := audit.WriteCheckpoint(path, key)
+		if err != nil {
+			log.Fatal("Failed to write checkpoint:", err)
+		}
+		log.Printf("Checkpoint written to %s: date=%s event_id=%d hash=%s\n", path, cp.Date, cp.EventID, cp.Hash)
+	}
+}
@@ -0,0 +1,53 @@
+// File main.go: CLI backfill aktivitas profil — hitung ulang user_profiles.first_activity, last_activity, dan is_active dari activity_logs_normalized.
+//
+// Alur singkat:
+//   - Muat .env, koneksi DB.
+//   - Hitung ulang rentang aktivitas semua profil (NULL jika tanpa aktivitas) dan is_active (aktivitas terakhir dalam USER_DORMANT_DAYS hari) dalam satu transaksi.
+//   - Hanya profil yang nilainya berubah yang ditulis, sehingga aman dijalankan berulang.
+//
+// Jalankan setelah data aktivitas dihapus/diubah di luar cmd/import atau setelah restore dump; impor biasa sudah memperbarui profil per baris.
+//
+// Cara menjalankan (dari root folder backend):
+//
+//	go run cmd/backfill/main.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Muat .env: coba dari working directory (.env), lalu dari parent (../.env). Jika gagal, pakai env sistem.
+	if err := godotenv.Load(".env"); err != nil {
+		if err2 := godotenv.Load(filepath.Join("..", ".env")); err2 != nil {
+			log.Println("No .env file found, using system environment")
+		}
+	}
+
+	if err := database.InitDB(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer database.CloseDB()
+
+	db := database.GetDB()
+	result, err := service.NewUserEngagementService(db).Backfill(time.Now())
+	if err != nil {
+		log.Fatal("Failed to backfill user profile activity:", err)
+	}
+	fmt.Printf("user profiles backfilled: spans_updated=%d is_active_updated=%d\n", result.Spans, result.Statuses)
+
+	// Kohort retensi membaca first_activity lewat cache analitik; naikkan versi data jika ada yang berubah.
+	if result.Spans > 0 {
+		if _, err := repository.BumpDataVersion(db, repository.DataVersionActivity); err != nil {
+			log.Printf("Failed to bump data version (cache analitik kedaluwarsa setelah CACHE_TTL): %v\n", err)
+		}
+	}
+}
@@ -5,7 +5,9 @@
 //   - Baca CSV (delimiter ;), baris pertama = header, buat peta nama kolom -> index.
 //   - Untuk tiap baris data: parse id_trans (UUID), tanggal (dua format), ambil nama/satker/aktifitas/scope/lokasi/cluster/token/status.
 //   - Resolve ID referensi (cluster, activity_type, location, satker, user) via getOrCreate + cache in-memory.
-//   - Insert ActivityLog dengan ON CONFLICT (id_trans) DO NOTHING agar duplikat tidak menimpa.
+//   - Insert ActivityLog dengan ON CONFLICT (id_trans) DO NOTHING agar duplikat tidak menimpa; di transaksi yang sama perbarui
+//     first_activity/last_activity/is_active profil.
+//   - Setelah selesai, naikkan versi data (invalidasi cache analitik), nilai aktivitas baru untuk peringatan keamanan, dan perbarui rollup activity_rollup_hourly secara inkremental (baris baru saja).
 //
 // Format CSV: header di baris pertama (case-insensitive), pemisah kolom = ; (titik-koma).
 // Kolom yang dipakai: id_trans, nama, satker, aktifitas, scope, lokasi, cluster, tanggal, token, status.
@@ -26,6 +28,8 @@ import (
 	"time"
 
 	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
@@ -99,6 +103,9 @@ func main() {
 	totalInserted := 0
 	skipped := 0
 
+	// Batas status active untuk user_profiles.is_active (aktivitas terakhir dalam USER_DORMANT_DAYS hari).
+	activeSince := service.NewUserEngagementService(db).ActiveSince(time.Now())
+
 	for i := 1; i < len(records); i++ {
 		record := records[i]
 
@@ -213,10 +220,18 @@ func main() {
 		}
 
 		// INSERT dengan ON CONFLICT (id_trans) DO NOTHING: jika id_trans sudah ada di DB, baris ini di-skip (tidak error).
-		if err := db.Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "id_trans"}},
-			DoNothing: true,
-		}).Create(&activity).Error; err != nil {
+		// Aktivitas pertama/terakhir profil diperbarui di transaksi yang sama, hanya jika baris benar-benar tersimpan.
+		err = db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id_trans"}},
+				DoNothing: true,
+			}).Create(&activity)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			return repository.NewUserEngagementRepository(tx).RecordActivity(userID, tanggal, activeSince)
+		})
+		if err != nil {
 			log.Printf("Row %d: Failed to insert: %v\n", i+1, err)
 			skipped++
 			continue
@@ -234,6 +249,37 @@ func main() {
 	log.Printf("  Successfully imported: %d\n", totalInserted)
 	log.Printf("  Skipped: %d\n", skipped)
 	log.Println("\n CSV import completed!")
+
+	// Naikkan versi data agar hasil query analitik yang di-cache API server tidak dipakai lagi.
+	if totalInserted > 0 {
+		if _, err := repository.BumpDataVersion(db, repository.DataVersionActivity); err != nil {
+			log.Printf("Failed to bump data version (cache analitik kedaluwarsa setelah CACHE_TTL): %v\n", err)
+		}
+	}
+
+	// Nilai aktivitas baru untuk peringatan keamanan (di luar jam kerja, lokasi tidak biasa); jika gagal, job API server mencobanya lagi.
+	alerts, err := service.NewSecurityAlertService(db).Evaluate()
+	if err != nil {
+		log.Printf("Failed to evaluate security alerts (dinilai ulang oleh job security-alerts): %v\n", err)
+	} else if alerts.Processed > 0 {
+		log.Printf("  Security alerts: %d activities evaluated, %d off-hours, %d unusual location\n", alerts.Processed, alerts.OffHours, alerts.UnusualLocation)
+	}
+
+	// Bangun sesi aktivitas user dari event LOGIN/LOGOUT yang baru diimpor; jika gagal, job API server mencobanya lagi.
+	sessions, err := service.NewUserSessionService(db).Sessionize(time.Now())
+	if err != nil {
+		log.Printf("Failed to rebuild user sessions (dibangun ulang oleh job sessionization): %v\n", err)
+	} else if sessions.Users > 0 {
+		log.Printf("  User sessions: %d users, %d sessions rebuilt\n", sessions.Users, sessions.Sessions)
+	}
+
+	// Perbarui rollup aktivitas (hitung ulang hari terdampak) agar dashboard langsung membaca data baru.
+	rollup, err := repository.NewActivityRollupRepository(db).Refresh()
+	if err != nil {
+		log.Printf("Failed to refresh activity rollups (jalankan go run cmd/rollup/main.go): %v\n", err)
+		return
+	}
+	log.Printf("  Rollup refreshed: log id %d..%d, %d days, %d groups\n", rollup.FromLogID, rollup.ToLogID, rollup.Days, rollup.Groups)
 }
 
 // getOrCreateCluster mencari baris di tabel clusters dengan name = name; jika tidak ada, INSERT baris baru dengan Name: name, lalu mengembalikan ID.
@@ -0,0 +1,102 @@
+// File main.go: CLI untuk provisioning user dashboard secara massal dari file CSV/XLSX.
+//
+// Alur singkat:
+//   - Muat .env, koneksi DB, baca flag dan path file dari argumen.
+//   - Parse file (service.ParseProvisionFile): kolom username, email, full_name, role, satker (id atau nama); baris pertama = header.
+//   - Proses tiap baris (service.UserProvisioningService.Provision): user baru dibuat, user lama (by username) diperbarui; setiap perubahan dicatat ke audit_events.
+//   - Cetak hasil per baris dan ringkasan. Exit code 1 jika ada baris gagal.
+//
+// Flag:
+//
+//	-send-activation  kirim link aktivasi sekali pakai ke email user baru (lewat MAIL_DRIVER); tanpa flag ini dicetak password sementara
+//	-dry-run          validasi dan simulasikan tanpa menyimpan perubahan
+//
+// Cara menjalankan (dari root folder backend):
+//
+//	go run cmd/provision/main.go [-send-activation] [-dry-run] <path-to-csv-or-xlsx>
+//
+// Contoh: go run cmd/provision/main.go -send-activation data/tim_audit_baru.xlsx
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	sendActivation := flag.Bool("send-activation", false, "kirim link aktivasi ke email user baru")
+	dryRun := flag.Bool("dry-run", false, "validasi tanpa menyimpan perubahan")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: go run cmd/provision/main.go [-send-activation] [-dry-run] <path-to-csv-or-xlsx>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Muat .env: coba dari working directory (.env), lalu dari parent (../.env). Jika gagal, pakai env sistem.
+	if err := godotenv.Load(".env"); err != nil {
+		if err2 := godotenv.Load(filepath.Join("..", ".env")); err2 != nil {
+			log.Println("No .env file found, using system environment")
+		}
+	}
+
+	if err := database.InitDB(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer database.CloseDB()
+
+	path := flag.Arg(0)
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal("Failed to open file:", err)
+	}
+	defer file.Close()
+
+	rows, err := service.ParseProvisionFile(path, file)
+	if err != nil {
+		log.Fatal("Failed to parse file:", err)
+	}
+	log.Printf("Found %d data rows in %s\n", len(rows), path)
+
+	// Pelaku audit = CLI (tanpa user_id); user agent menandai sumber perubahan.
+	actor := service.AuditActor{UserAgent: "cmd/provision"}
+	opts := service.ProvisionOptions{SendActivation: *sendActivation, DryRun: *dryRun}
+	results, summary := service.NewUserProvisioningService(database.GetDB(), nil).Provision(actor, rows, opts)
+
+	for _, r := range results {
+		line := fmt.Sprintf("line %-4d %-9s %-20s %s", r.Line, r.Status, r.Username, r.Email)
+		if r.UserID != 0 {
+			line += fmt.Sprintf(" (id=%d)", r.UserID)
+		}
+		if r.TemporaryPassword != "" {
+			line += " temporary_password=" + r.TemporaryPassword
+		}
+		if r.ActivationSent {
+			line += " activation_sent"
+		}
+		if r.Error != "" {
+			line += " error: " + r.Error
+		}
+		fmt.Println(line)
+	}
+
+	mode := ""
+	if summary.DryRun {
+		mode = " [dry-run]"
+	}
+	log.Printf("Done%s: total=%d created=%d updated=%d unchanged=%d failed=%d\n",
+		mode, summary.Total, summary.Created, summary.Updated, summary.Unchanged, summary.Failed)
+	if summary.Failed > 0 {
+		os.Exit(1)
+	}
+}
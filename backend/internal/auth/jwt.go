@@ -4,7 +4,7 @@
 //   - JWT_SECRET: rahasia untuk menandatangani token (wajib; jika kosong kembalikan ErrJWTSecretNotSet).
 //   - JWT_EXPIRY: lama berlaku token, di-parse di internal/config (misalnya "24h").
 //
-// Claims berisi user_id, role, dan RegisteredClaims (exp, iat). Dipakai oleh handler login dan middleware auth.
+// Claims berisi user_id, role, penanda wajib ganti password, dan RegisteredClaims (jti = id sesi login, exp, iat). Dipakai oleh handler login dan middleware auth.
 package auth
 
 import (
@@ -19,15 +19,32 @@ import (
 // ErrJWTSecretNotSet dikembalikan ketika JWT_SECRET tidak diset di environment (untuk keamanan tidak ada default).
 var ErrJWTSecretNotSet = errors.New("JWT_SECRET is not set; set it in environment for security")
 
-// Claims menyimpan klaim kustom JWT (user_id, role) dan klaim standar (ExpiresAt, IssuedAt dari RegisteredClaims).
+// Claims menyimpan klaim kustom JWT (user_id, role) dan klaim standar (ID/jti = id sesi di auth_sessions, ExpiresAt, IssuedAt dari RegisteredClaims).
+// PasswordChangeRequired = true berarti token hanya boleh dipakai untuk endpoint ganti password (dicek di AuthMiddleware).
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Role   string `json:"role"`
+	UserID                 int    `json:"user_id"`
+	Role                   string `json:"role"`
+	PasswordChangeRequired bool   `json:"pwd_change,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken membuat JWT untuk user yang diberikan. Memakai JWT_SECRET dan JWT_EXPIRY (dari config). Mengembalikan ErrJWTSecretNotSet jika JWT_SECRET kosong.
-func GenerateToken(userID int, role string) (string, error) {
+// SessionID mengembalikan id sesi login yang terikat ke token (klaim jti); kosong untuk token lama sebelum sesi diperkenalkan.
+func (c *Claims) SessionID() string {
+	return c.ID
+}
+
+// GenerateToken membuat JWT untuk user yang diberikan dan mengikatnya ke sesi login sessionID (klaim jti). Memakai JWT_SECRET dan JWT_EXPIRY (dari config). Mengembalikan ErrJWTSecretNotSet jika JWT_SECRET kosong.
+func GenerateToken(userID int, role, sessionID string) (string, error) {
+	return signToken(userID, role, sessionID, false)
+}
+
+// GeneratePasswordChangeToken sama seperti GenerateToken tetapi menandai token dengan PasswordChangeRequired; dipakai saat login user yang password-nya kedaluwarsa atau direset admin.
+func GeneratePasswordChangeToken(userID int, role, sessionID string) (string, error) {
+	return signToken(userID, role, sessionID, true)
+}
+
+// signToken membuat dan menandatangani JWT HS256 dengan klaim user_id, role, pwd_change, jti, iat, exp.
+func signToken(userID int, role, sessionID string, passwordChangeRequired bool) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		return "", ErrJWTSecretNotSet
@@ -36,11 +53,13 @@ func GenerateToken(userID int, role string) (string, error) {
 	// Durasi berlaku token (misalnya 24h); diambil dari config yang baca JWT_EXPIRY.
 	expiry := config.GetJWTExpiry()
 
-	// Klaim: user_id, role, waktu terbit (iat), waktu kadaluarsa (exp).
+	// Klaim: user_id, role, id sesi (jti), waktu terbit (iat), waktu kadaluarsa (exp).
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:                 userID,
+		Role:                   role,
+		PasswordChangeRequired: passwordChangeRequired,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -53,9 +72,18 @@ func GenerateToken(userID int, role string) (string, error) {
 
 // ValidateToken mem-parse dan memvalidasi string JWT; mengembalikan userID dan role. Jika JWT_SECRET kosong mengembalikan ErrJWTSecretNotSet; error lain: algoritma bukan HMAC, token kedaluwarsa/rusak, atau klaim tidak valid.
 func ValidateToken(tokenString string) (userID int, role string, err error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return 0, "", err
+	}
+	return claims.UserID, claims.Role, nil
+}
+
+// ParseToken sama seperti ValidateToken tetapi mengembalikan seluruh klaim (termasuk PasswordChangeRequired).
+func ParseToken(tokenString string) (*Claims, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
-		return 0, "", ErrJWTSecretNotSet
+		return nil, ErrJWTSecretNotSet
 	}
 
 	// Parse token: key function dipanggil untuk dapat secret; di sini kita cek metode signing harus HMAC lalu kembalikan []byte(secret) untuk verifikasi signature.
@@ -66,14 +94,14 @@ func ValidateToken(tokenString string) (userID int, role string, err error) {
 		return []byte(secret), nil
 	})
 	if err != nil {
-		return 0, "", err
+		return nil, err
 	}
 
 	// Klaim harus bertipe *Claims dan token harus valid (signature + exp sudah dicek oleh library).
 	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
-		return 0, "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
-	return claims.UserID, claims.Role, nil
+	return claims, nil
 }
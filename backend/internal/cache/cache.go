@@ -0,0 +1,152 @@
+// Package cache berisi cache hasil query analitik (dashboard, regional, konten).
+//
+// File cache.go: antarmuka Backend (pluggable), backend aktif tingkat proses, Fetch (ambil dari cache atau muat lalu simpan), dan Key (kunci ternormalisasi).
+// Nilai disimpan sebagai JSON agar backend eksternal (mis. Redis) bisa dipasang lewat SetBackend tanpa mengubah pemanggil.
+// Default dari env CACHE_BACKEND: "lru" (LRU in-process, lihat lru.go) atau "none". Invalidasi lewat versi data di kunci (lihat repository/data_version_repository.go).
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+)
+
+// Backend penyimpanan cache: Get mengembalikan nilai jika ada dan belum kedaluwarsa, Set menyimpan dengan TTL (0 = tanpa batas umur), Purge mengosongkan semua entri.
+type Backend interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Purge()
+}
+
+// None backend tanpa penyimpanan (cache nonaktif); setiap Fetch memuat ulang.
+type None struct{}
+
+// Get selalu miss.
+func (None) Get(string) ([]byte, bool) { return nil, false }
+
+// Set tidak menyimpan apa pun.
+func (None) Set(string, []byte, time.Duration) {}
+
+// Purge tidak melakukan apa pun.
+func (None) Purge() {}
+
+var (
+	mu         sync.RWMutex
+	backend    Backend
+	defaultTTL time.Duration
+	initOnce   sync.Once
+)
+
+// initFromConfig memasang backend dan TTL dari env (GetCacheConfig) pada pemakaian pertama.
+func initFromConfig() {
+	cfg := config.GetCacheConfig()
+	mu.Lock()
+	defer mu.Unlock()
+	defaultTTL = cfg.TTL
+	switch cfg.Backend {
+	case "none", "off":
+		backend = None{}
+	case "lru":
+		backend = NewLRU(cfg.MaxEntries)
+	default:
+		log.Printf("[WARN] CACHE_BACKEND %q tidak dikenal, memakai lru", cfg.Backend)
+		backend = NewLRU(cfg.MaxEntries)
+	}
+}
+
+// SetBackend mengganti backend cache aktif (mis. backend terdistribusi saat start server). Entri di backend lama tidak dipindahkan.
+func SetBackend(b Backend) {
+	initOnce.Do(initFromConfig)
+	mu.Lock()
+	backend = b
+	mu.Unlock()
+}
+
+// Current mengembalikan backend cache aktif.
+func Current() Backend {
+	initOnce.Do(initFromConfig)
+	mu.RLock()
+	defer mu.RUnlock()
+	return backend
+}
+
+// Fetch mengembalikan nilai untuk key dari cache; jika miss, memanggil load lalu menyimpan hasilnya (error tidak disimpan).
+// Angka di dalam interface{} (mis. map[string]interface{}) dikembalikan sebagai json.Number agar nilainya tidak berubah saat di-encode ulang.
+func Fetch[T any](key string, load func() (T, error)) (T, error) {
+	b := Current()
+	if raw, ok := b.Get(key); ok {
+		var cached T
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	if raw, err := json.Marshal(value); err == nil {
+		mu.RLock()
+		ttl := defaultTTL
+		mu.RUnlock()
+		b.Set(key, raw, ttl)
+	}
+	return value, nil
+}
+
+// Key menyusun kunci cache ternormalisasi dari namespace dan parameter: pointer nil dan string kosong menjadi "-", string di-trim,
+// slice ID diurutkan (urutan filter IN tidak memengaruhi hasil). Bagian dipisah "|".
+func Key(namespace string, parts ...interface{}) string {
+	var sb strings.Builder
+	sb.WriteString(namespace)
+	for _, p := range parts {
+		sb.WriteByte('|')
+		switch v := p.(type) {
+		case nil:
+			sb.WriteByte('-')
+		case *string:
+			if v == nil {
+				sb.WriteByte('-')
+			} else {
+				sb.WriteString(normalizeString(*v))
+			}
+		case string:
+			sb.WriteString(normalizeString(v))
+		case []int64:
+			ids := append([]int64(nil), v...)
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			for i, id := range ids {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+				sb.WriteString(strconv.FormatInt(id, 10))
+			}
+		case int:
+			sb.WriteString(strconv.Itoa(v))
+		case int64:
+			sb.WriteString(strconv.FormatInt(v, 10))
+		default:
+			raw, _ := json.Marshal(v)
+			sb.Write(raw)
+		}
+	}
+	return sb.String()
+}
+
+// normalizeString memangkas spasi; string kosong ditulis "-" (sama dengan filter tidak diisi).
+func normalizeString(s string) string {
+	if s = strings.TrimSpace(s); s == "" {
+		return "-"
+	}
+	// Pemisah kunci di-escape agar nilai filter tidak bisa menyamar sebagai parameter lain.
+	return strings.ReplaceAll(s, "|", "\\|")
+}
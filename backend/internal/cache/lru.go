@@ -0,0 +1,90 @@
+// File lru.go: backend cache LRU in-process dengan kapasitas entri tetap dan TTL per entri.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruEntry satu entri LRU: kunci, nilai, dan waktu kedaluwarsa (zero = tanpa batas).
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRU cache in-process: entri yang paling lama tidak dipakai dibuang saat kapasitas penuh; aman dipakai banyak goroutine.
+type LRU struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // Depan = paling baru dipakai.
+	items      map[string]*list.Element
+}
+
+// NewLRU membuat LRU dengan kapasitas maxEntries (minimal 1).
+func NewLRU(maxEntries int) *LRU {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &LRU{maxEntries: maxEntries, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get mengembalikan nilai dan memindahkannya ke depan; entri kedaluwarsa dibuang dan dianggap miss.
+func (l *LRU) Get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		l.removeElement(el)
+		return nil, false
+	}
+	l.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// Set menyimpan atau mengganti nilai; jika kapasitas terlampaui, entri paling lama tidak dipakai dibuang.
+func (l *LRU) Set(key string, value []byte, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if el, ok := l.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		l.order.MoveToFront(el)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for l.order.Len() > l.maxEntries {
+		l.removeElement(l.order.Back())
+	}
+}
+
+// Purge mengosongkan semua entri.
+func (l *LRU) Purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order.Init()
+	l.items = make(map[string]*list.Element)
+}
+
+// Len mengembalikan jumlah entri saat ini (termasuk yang kedaluwarsa tetapi belum dibuang).
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// removeElement membuang el dari daftar dan map; pemanggil memegang l.mu.
+func (l *LRU) removeElement(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.items, el.Value.(*lruEntry).key)
+}
@@ -4,9 +4,10 @@
 //   - Batas paginasi dan limit untuk aktivitas, unit, search, org tree, top lokasi, dll.
 //   - Default dan parsing JWT_EXPIRY (durasi berlaku token).
 //   - CORS: AllowedOrigins (ALLOWED_ORIGINS) dan CORSOrigin(origin) untuk header Access-Control-Allow-Origin.
-//   - IntEnv(key, fallback) untuk baca variabel env bertipe integer.
+//   - IntEnv(key, fallback), BoolEnv(key, fallback), DurationEnv(key, fallback) untuk baca variabel env bertipe integer/boolean/durasi.
+//   - Kebijakan password (panjang minimal, kelas karakter, riwayat, masa berlaku) dari env PASSWORD_*.
 //
-// Digunakan oleh internal/server (CORS), internal/auth (JWT expiry), dan handler/repo yang memakai limit/pagination.
+// Digunakan oleh internal/server (CORS), internal/auth (JWT expiry), internal/service (kebijakan password), dan handler/repo yang memakai limit/pagination.
 package config
 
 import (
@@ -18,7 +19,7 @@ import (
 
 // Paginasi dan batas ukuran halaman untuk endpoint aktivitas (activity log).
 const (
-	DefaultPageSizeActivities = 10  // Ukuran halaman default jika client tidak mengirim page_size.
+	DefaultPageSizeActivities = 10    // Ukuran halaman default jika client tidak mengirim page_size.
 	MaxPageSizeActivities     = 10000 // Batas maksimal page_size agar query tidak terlalu berat.
 )
 
@@ -28,6 +29,15 @@ const (
 	MaxPageSizeUnits     = 100 // Batas maksimal page_size.
 )
 
+// Paginasi untuk endpoint admin (manajemen user, audit).
+const (
+	DefaultPageSizeAdmin = 20  // Ukuran halaman default.
+	MaxPageSizeAdmin     = 100 // Batas maksimal page_size.
+)
+
+// MaxAuditExportRows batas jumlah baris per ekspor CSV audit trail (GET /api/admin/audit-events/export).
+const MaxAuditExportRows = 100000
+
 // Limit untuk endpoint "top N" dan daftar (kontributor, error logout, unduhan terbaru, dll.).
 const (
 	DefaultLimit = 10  // Default jumlah item yang dikembalikan.
@@ -50,9 +60,61 @@ const (
 	TopLokasiLimit = 10
 )
 
+// MaxFilterValues batas jumlah nilai per filter multi-nilai (cluster, status, jenis aktivitas, provinsi, user, satker) pada ActivityFilter.
+const MaxFilterValues = 100
+
+// Time-series aktivitas (GET /api/dashboard/timeseries).
+const (
+	MaxTimeSeriesBuckets = 2000 // Batas jumlah bucket per series (mis. 2000 menit ≈ 33 jam).
+	MaxRollingWindow     = 90   // Batas jendela rata-rata bergulir (jumlah bucket).
+)
+
+// Analisis kohort retensi user (GET /api/insights/cohorts, template laporan user-retention).
+const (
+	DefaultCohortPeriods = 12  // Jumlah periode lanjutan default per kohort.
+	MaxCohortPeriods     = 52  // Batas jumlah periode lanjutan per kohort.
+	MaxCohorts           = 104 // Batas jumlah kohort (minggu/bulan aktivitas pertama) dalam satu matriks.
+)
+
+// Analisis funnel jenis aktivitas (GET /api/insights/funnel).
+const (
+	MaxFunnelSteps         = 8                   // Batas jumlah langkah funnel (minimal 2).
+	DefaultFunnelWindow    = 24 * time.Hour      // Batas waktu dari langkah pertama sampai langkah terakhir jika window tidak diisi.
+	MaxFunnelWindow        = 30 * 24 * time.Hour // Batas window maksimum.
+	DefaultFunnelRangeDays = 30                  // Rentang tanggal langkah pertama jika start_date kosong (hari, sampai end_date/hari ini).
+	MaxFunnelRangeDays     = 92                  // Batas rentang tanggal langkah pertama.
+)
+
+// MaxComparisonRows batas baris breakdown periode pembanding yang dibaca untuk dicocokkan ke satu halaman breakdown berpaginasi (per satker).
+const MaxComparisonRows = 5000
+
+// Detektor anomali (job anomaly-detection, GET /api/insights/anomalies).
+const (
+	AnomalyBaselineWeeks  = 8             // Baseline = hari yang sama pada N minggu sebelumnya (hari libur tidak dihitung).
+	AnomalyMinBaseline    = 4             // Minimal hari baseline; subjek dengan baseline lebih pendek tidak dinilai.
+	AnomalyLookbackDays   = 3             // Setiap run menilai ulang N hari lengkap terakhir (data yang terlambat diimpor ikut terhitung).
+	AnomalyMinDeviation   = 20            // Selisih absolut minimal terhadap median agar dianggap anomali (abaikan volume kecil).
+	AnomalyScoreThreshold = 3.5           // Skor z robust minimal (low).
+	AnomalyScoreMedium    = 5.0           // Skor z robust minimal untuk severity medium.
+	AnomalyScoreHigh      = 8.0           // Skor z robust minimal untuk severity high.
+	AnomalyJobInterval    = 6 * time.Hour // Jarak antar run job deteksi anomali.
+)
+
+// Aturan peringatan admin (job alert-rules, /api/admin/alert-rules).
+const (
+	AlertRuleMinWindow     = 5               // Jendela evaluasi minimal (menit).
+	AlertRuleMaxWindow     = 31 * 24 * 60    // Jendela evaluasi maksimal (menit, 31 hari).
+	AlertRuleMaxCooldown   = 7 * 24 * 60     // Cooldown maksimal (menit, 7 hari).
+	AlertRuleMaxRecipients = 50              // Batas jumlah user penerima eksplisit per aturan.
+	AlertRuleJobInterval   = 5 * time.Minute // Jarak antar run job evaluasi aturan.
+)
+
 // Default lama berlaku token JWT; bisa diganti lewat env JWT_EXPIRY (format duration, misalnya "24h", "30m").
 const DefaultJWTExpiry = 24 * time.Hour
 
+// SessionTouchInterval jarak minimal antar pembaruan auth_sessions.last_seen_at untuk satu sesi (mengurangi tulis ke DB di setiap request).
+const SessionTouchInterval = time.Minute
+
 // GetJWTExpiry mengembalikan durasi berlaku JWT dari env JWT_EXPIRY; jika kosong atau invalid, pakai DefaultJWTExpiry.
 func GetJWTExpiry() time.Duration {
 	if s := os.Getenv("JWT_EXPIRY"); s != "" {
@@ -101,3 +163,468 @@ func IntEnv(key string, fallback int) int {
 	}
 	return v
 }
+
+// BoolEnv membaca nilai boolean dari variabel env key ("true"/"false", "1"/"0", dll. sesuai strconv.ParseBool); jika tidak diset atau invalid, mengembalikan fallback.
+func BoolEnv(key string, fallback bool) bool {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// DurationEnv membaca durasi dari variabel env key (format time.ParseDuration, misalnya "720h"); jika tidak diset atau invalid, mengembalikan fallback.
+func DurationEnv(key string, fallback time.Duration) time.Duration {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// Default kebijakan password; semua bisa diganti lewat env PASSWORD_*.
+const (
+	DefaultPasswordMinLength    = 8  // Panjang minimal password (PASSWORD_MIN_LENGTH).
+	DefaultPasswordMaxLength    = 72 // Batas atas bcrypt; byte setelah 72 diabaikan bcrypt (PASSWORD_MAX_LENGTH).
+	DefaultPasswordHistorySize  = 5  // Jumlah hash terakhir yang tidak boleh dipakai ulang (PASSWORD_HISTORY_SIZE).
+	DefaultPasswordMinCharClass = 3  // Minimal kelas karakter berbeda (huruf kecil, huruf besar, angka, simbol) (PASSWORD_MIN_CHAR_CLASSES).
+)
+
+// PasswordPolicyConfig berisi nilai kebijakan password yang dibaca dari environment.
+type PasswordPolicyConfig struct {
+	MinLength      int           // Panjang minimal (karakter).
+	MaxLength      int           // Panjang maksimal (byte); bcrypt hanya memakai 72 byte pertama.
+	MinCharClasses int           // Minimal jumlah kelas karakter berbeda yang harus ada (0–4).
+	RequireUpper   bool          // Wajib ada huruf besar.
+	RequireLower   bool          // Wajib ada huruf kecil.
+	RequireDigit   bool          // Wajib ada angka.
+	RequireSymbol  bool          // Wajib ada simbol/karakter non-alfanumerik.
+	HistorySize    int           // Jumlah password terakhir yang tidak boleh dipakai ulang; 0 = nonaktif.
+	MaxAge         time.Duration // Masa berlaku password; 0 = tidak pernah kedaluwarsa.
+}
+
+// GetPasswordPolicy membaca kebijakan password dari env: PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH, PASSWORD_MIN_CHAR_CLASSES,
+// PASSWORD_REQUIRE_UPPER/LOWER/DIGIT/SYMBOL, PASSWORD_HISTORY_SIZE, PASSWORD_MAX_AGE (durasi, misalnya "2160h" untuk 90 hari; kosong = nonaktif).
+func GetPasswordPolicy() PasswordPolicyConfig {
+	return PasswordPolicyConfig{
+		MinLength:      IntEnv("PASSWORD_MIN_LENGTH", DefaultPasswordMinLength),
+		MaxLength:      IntEnv("PASSWORD_MAX_LENGTH", DefaultPasswordMaxLength),
+		MinCharClasses: IntEnv("PASSWORD_MIN_CHAR_CLASSES", DefaultPasswordMinCharClass),
+		RequireUpper:   BoolEnv("PASSWORD_REQUIRE_UPPER", false),
+		RequireLower:   BoolEnv("PASSWORD_REQUIRE_LOWER", false),
+		RequireDigit:   BoolEnv("PASSWORD_REQUIRE_DIGIT", false),
+		RequireSymbol:  BoolEnv("PASSWORD_REQUIRE_SYMBOL", false),
+		HistorySize:    IntEnv("PASSWORD_HISTORY_SIZE", DefaultPasswordHistorySize),
+		MaxAge:         DurationEnv("PASSWORD_MAX_AGE", 0),
+	}
+}
+
+// Default provisioning user massal dan link aktivasi.
+const (
+	DefaultActivationTokenTTL = 72 * time.Hour                   // Lama berlaku link aktivasi (ACTIVATION_TOKEN_TTL).
+	DefaultActivationURL      = "http://localhost:3000/activate" // Halaman frontend penerima token (ACTIVATION_URL).
+	MaxProvisionFileSize      = 5 << 20                          // Batas ukuran file upload provisioning (5 MB).
+	MaxProvisionRows          = 1000                             // Batas jumlah baris data per file provisioning.
+)
+
+// MailConfig berisi konfigurasi pengiriman email (dipakai mailer untuk link aktivasi dan notifikasi).
+type MailConfig struct {
+	Driver   string // "log" (default: hanya tulis ke log server) atau "smtp".
+	Host     string // SMTP_HOST.
+	Port     int    // SMTP_PORT (default 587).
+	Username string // SMTP_USERNAME (kosong = tanpa autentikasi).
+	Password string // SMTP_PASSWORD.
+	From     string // MAIL_FROM, alamat pengirim.
+}
+
+// GetMailConfig membaca konfigurasi email dari env: MAIL_DRIVER, SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, MAIL_FROM.
+func GetMailConfig() MailConfig {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_DRIVER")))
+	if driver == "" {
+		driver = "log"
+	}
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@bpk.go.id"
+	}
+	return MailConfig{
+		Driver:   driver,
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     IntEnv("SMTP_PORT", 587),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}
+}
+
+// ActivationURL mengembalikan URL halaman aktivasi akun (env ACTIVATION_URL); token ditambahkan sebagai query ?token=.
+func ActivationURL() string {
+	if s := strings.TrimSpace(os.Getenv("ACTIVATION_URL")); s != "" {
+		return s
+	}
+	return DefaultActivationURL
+}
+
+// ActivationTokenTTL mengembalikan lama berlaku link aktivasi (env ACTIVATION_TOKEN_TTL, format durasi).
+func ActivationTokenTTL() time.Duration {
+	return DurationEnv("ACTIVATION_TOKEN_TTL", DefaultActivationTokenTTL)
+}
+
+// DefaultAuditCheckpointFile file checkpoint hash chain audit (AUDIT_CHECKPOINT_FILE). Sebaiknya di luar server DB / disalin ke penyimpanan terpisah.
+const DefaultAuditCheckpointFile = "audit_checkpoints.jsonl"
+
+// AuditCheckpointFile mengembalikan path file checkpoint audit (env AUDIT_CHECKPOINT_FILE).
+func AuditCheckpointFile() string {
+	if s := strings.TrimSpace(os.Getenv("AUDIT_CHECKPOINT_FILE")); s != "" {
+		return s
+	}
+	return DefaultAuditCheckpointFile
+}
+
+// AuditCheckpointKey mengembalikan kunci HMAC untuk menandatangani checkpoint audit (env AUDIT_CHECKPOINT_KEY); nil jika tidak diset.
+func AuditCheckpointKey() []byte {
+	if s := os.Getenv("AUDIT_CHECKPOINT_KEY"); s != "" {
+		return []byte(s)
+	}
+	return nil
+}
+
+// Default workflow persetujuan akses laporan; semua bisa diganti lewat env ACCESS_*.
+const (
+	DefaultAccessApprovalSteps  = "unit_head,admin"    // Urutan role penyetuju (ACCESS_APPROVAL_STEPS).
+	DefaultAccessGrantDuration  = 180 * 24 * time.Hour // Lama berlaku akses setelah disetujui (ACCESS_GRANT_DURATION; 0 = tanpa kedaluwarsa).
+	DefaultAccessReviewInterval = 90 * 24 * time.Hour  // Jarak review berkala akses yang disetujui (ACCESS_REVIEW_INTERVAL; 0 = tanpa review).
+	DefaultAccessExpiryNotice   = 7 * 24 * time.Hour   // Pemberitahuan ke pemegang akses sebelum kedaluwarsa (ACCESS_EXPIRY_NOTICE; 0 = tanpa pemberitahuan).
+	DefaultJobInterval          = time.Hour            // Jarak antar run background job (JOB_INTERVAL).
+	AccessExpiryJobInterval     = 24 * time.Hour       // Job harian: kedaluwarsa grant akses dan pemberitahuan sebelum kedaluwarsa.
+	MinAccessReasonLength       = 10                   // Panjang minimal alasan pengajuan, penolakan, dan pencabutan.
+)
+
+// AccessWorkflowConfig berisi konfigurasi workflow persetujuan akses laporan.
+type AccessWorkflowConfig struct {
+	ApprovalSteps  []string      // Role penyetuju per tahap, berurutan.
+	GrantDuration  time.Duration // Lama berlaku akses; 0 = tidak kedaluwarsa.
+	ReviewInterval time.Duration // Jarak review berkala; 0 = tanpa review.
+	ExpiryNotice   time.Duration // Pemberitahuan sebelum kedaluwarsa; 0 = tanpa pemberitahuan.
+}
+
+// GetAccessWorkflowConfig membaca ACCESS_APPROVAL_STEPS (role dipisah koma, mis. "unit_head,admin"), ACCESS_GRANT_DURATION, ACCESS_REVIEW_INTERVAL, dan ACCESS_EXPIRY_NOTICE (durasi, mis. "4320h").
+// Role kosong dibuang; jika hasilnya kosong dipakai satu tahap admin.
+func GetAccessWorkflowConfig() AccessWorkflowConfig {
+	raw := os.Getenv("ACCESS_APPROVAL_STEPS")
+	if strings.TrimSpace(raw) == "" {
+		raw = DefaultAccessApprovalSteps
+	}
+	var steps []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			steps = append(steps, s)
+		}
+	}
+	if len(steps) == 0 {
+		steps = []string{"admin"}
+	}
+	return AccessWorkflowConfig{
+		ApprovalSteps:  steps,
+		GrantDuration:  DurationEnv("ACCESS_GRANT_DURATION", DefaultAccessGrantDuration),
+		ReviewInterval: DurationEnv("ACCESS_REVIEW_INTERVAL", DefaultAccessReviewInterval),
+		ExpiryNotice:   DurationEnv("ACCESS_EXPIRY_NOTICE", DefaultAccessExpiryNotice),
+	}
+}
+
+// JobInterval mengembalikan jarak antar run background job (env JOB_INTERVAL, format durasi; default 1 jam).
+func JobInterval() time.Duration {
+	if d := DurationEnv("JOB_INTERVAL", DefaultJobInterval); d > 0 {
+		return d
+	}
+	return DefaultJobInterval
+}
+
+// DefaultActivitySessionIdleTimeout: sesi aktivitas tanpa LOGOUT dianggap berakhir jika tidak ada aktivitas selama ini (ACTIVITY_SESSION_IDLE_TIMEOUT).
+const DefaultActivitySessionIdleTimeout = 30 * time.Minute
+
+// ActivitySessionIdleTimeout mengembalikan batas jeda aktivitas sebelum sesi dianggap berakhir (env ACTIVITY_SESSION_IDLE_TIMEOUT, format durasi; default 30 menit).
+func ActivitySessionIdleTimeout() time.Duration {
+	if d := DurationEnv("ACTIVITY_SESSION_IDLE_TIMEOUT", DefaultActivitySessionIdleTimeout); d > 0 {
+		return d
+	}
+	return DefaultActivitySessionIdleTimeout
+}
+
+// Status engagement profil aktivitas (GET /api/users/engagement, job user-engagement).
+const (
+	DefaultUserDormantDays    = 30             // Profil tanpa aktivitas selama N hari dianggap dorman (USER_DORMANT_DAYS).
+	UserEngagementJobInterval = 24 * time.Hour // Job harian: perbarui user_profiles.is_active dari aktivitas terakhir.
+)
+
+// UserDormantDays mengembalikan jumlah hari tanpa aktivitas sebelum profil dianggap dorman (env USER_DORMANT_DAYS; default 30).
+func UserDormantDays() int {
+	if n := IntEnv("USER_DORMANT_DAYS", DefaultUserDormantDays); n > 0 {
+		return n
+	}
+	return DefaultUserDormantDays
+}
+
+// Deteksi akun dorman dan yatim (template laporan account-hygiene, GET /api/admin/users/flagged).
+const DefaultAccountDormantDays = 90 // Akun users aktif tanpa login selama N hari ditandai dorman (ACCOUNT_DORMANT_DAYS).
+
+// AccountDormantDays mengembalikan jumlah hari tanpa login sebelum akun ditandai dorman (env ACCOUNT_DORMANT_DAYS; default 90).
+func AccountDormantDays() int {
+	if n := IntEnv("ACCOUNT_DORMANT_DAYS", DefaultAccountDormantDays); n > 0 {
+		return n
+	}
+	return DefaultAccountDormantDays
+}
+
+// Default live feed aktivitas (GET /api/dashboard/stream); semua bisa diganti lewat env STREAM_*.
+const (
+	DefaultStreamPollInterval      = 2 * time.Second  // Jarak baca aktivitas baru dari DB oleh job activity-feed (STREAM_POLL_INTERVAL).
+	DefaultStreamHeartbeatInterval = 15 * time.Second // Jarak komentar heartbeat agar proxy tidak menutup koneksi diam (STREAM_HEARTBEAT_INTERVAL).
+	DefaultStreamKPIInterval       = 30 * time.Second // Jarak event kpi; hanya dikirim jika ada aktivitas baru sejak KPI terakhir (STREAM_KPI_INTERVAL).
+	DefaultStreamBufferSize        = 256              // Antrean event per koneksi; publikasi dipecah per potongan sebesar ini (STREAM_BUFFER_SIZE).
+	DefaultStreamLagGrace          = 2 * time.Second  // Tenggang menunggu koneksi yang antreannya penuh per potongan sebelum diputus (STREAM_LAG_GRACE).
+	DefaultStreamReplayLimit       = 1000             // Maksimal aktivitas yang diputar ulang dari Last-Event-ID (STREAM_REPLAY_LIMIT).
+	StreamPollBatchSize            = 1000             // Jumlah aktivitas baru yang dibaca per query poll.
+	StreamRetry                    = 3 * time.Second  // Saran jeda reconnect untuk EventSource (field retry).
+)
+
+// StreamConfig berisi konfigurasi live feed aktivitas.
+type StreamConfig struct {
+	PollInterval      time.Duration
+	HeartbeatInterval time.Duration
+	KPIInterval       time.Duration
+	BufferSize        int
+	LagGrace          time.Duration
+	ReplayLimit       int
+}
+
+// GetStreamConfig membaca STREAM_POLL_INTERVAL, STREAM_HEARTBEAT_INTERVAL, STREAM_KPI_INTERVAL, STREAM_BUFFER_SIZE, STREAM_LAG_GRACE, dan STREAM_REPLAY_LIMIT; nilai tidak positif diganti default.
+func GetStreamConfig() StreamConfig {
+	cfg := StreamConfig{
+		PollInterval:      DurationEnv("STREAM_POLL_INTERVAL", DefaultStreamPollInterval),
+		HeartbeatInterval: DurationEnv("STREAM_HEARTBEAT_INTERVAL", DefaultStreamHeartbeatInterval),
+		KPIInterval:       DurationEnv("STREAM_KPI_INTERVAL", DefaultStreamKPIInterval),
+		BufferSize:        IntEnv("STREAM_BUFFER_SIZE", DefaultStreamBufferSize),
+		LagGrace:          DurationEnv("STREAM_LAG_GRACE", DefaultStreamLagGrace),
+		ReplayLimit:       IntEnv("STREAM_REPLAY_LIMIT", DefaultStreamReplayLimit),
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultStreamPollInterval
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = DefaultStreamHeartbeatInterval
+	}
+	if cfg.KPIInterval <= 0 {
+		cfg.KPIInterval = DefaultStreamKPIInterval
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultStreamBufferSize
+	}
+	if cfg.LagGrace <= 0 {
+		cfg.LagGrace = DefaultStreamLagGrace
+	}
+	if cfg.ReplayLimit <= 0 {
+		cfg.ReplayLimit = DefaultStreamReplayLimit
+	}
+	return cfg
+}
+
+// RollupsEnabled mengembalikan true jika query dashboard boleh membaca tabel rollup activity_rollup_hourly (env ROLLUP_ENABLED, default true).
+// Walau aktif, rollup hanya dipakai jika sudah mencakup semua baris activity_logs_normalized.
+func RollupsEnabled() bool {
+	return BoolEnv("ROLLUP_ENABLED", true)
+}
+
+// Default jendela proses ulang refresh rollup; bisa diganti lewat env ROLLUP_RESCAN_*.
+const (
+	DefaultRollupRescanIDs  = 50000 // Hari dari baris dengan id dalam N id di bawah watermark dihitung ulang (ROLLUP_RESCAN_IDS): baris yang commit terlambat.
+	DefaultRollupRescanDays = 2     // N hari terakhir rollup dihitung ulang (ROLLUP_RESCAN_DAYS): baris terbaru yang diubah atau dihapus.
+)
+
+// RollupConfig jendela yang diproses ulang setiap refresh rollup.
+type RollupConfig struct {
+	RescanIDs  int64
+	RescanDays int
+}
+
+// GetRollupConfig membaca ROLLUP_RESCAN_IDS dan ROLLUP_RESCAN_DAYS; nilai negatif atau invalid → default (0 = tanpa jendela tersebut).
+func GetRollupConfig() RollupConfig {
+	cfg := RollupConfig{
+		RescanIDs:  int64(IntEnv("ROLLUP_RESCAN_IDS", DefaultRollupRescanIDs)),
+		RescanDays: IntEnv("ROLLUP_RESCAN_DAYS", DefaultRollupRescanDays),
+	}
+	if cfg.RescanIDs < 0 {
+		cfg.RescanIDs = DefaultRollupRescanIDs
+	}
+	if cfg.RescanDays < 0 {
+		cfg.RescanDays = DefaultRollupRescanDays
+	}
+	return cfg
+}
+
+// Default cache respons analitik; semua bisa diganti lewat env CACHE_*.
+const (
+	DefaultCacheBackend      = "lru"            // Backend cache (CACHE_BACKEND): "lru" (in-process) atau "none".
+	DefaultCacheMaxEntries   = 2000             // Jumlah entri maksimal cache LRU (CACHE_MAX_ENTRIES).
+	DefaultCacheTTL          = 10 * time.Minute // Umur maksimal entri cache (CACHE_TTL); batas basi untuk perubahan data di luar impor.
+	DefaultCacheVersionCheck = 5 * time.Second  // Jarak baca ulang versi data dari DB (CACHE_VERSION_CHECK).
+)
+
+// CacheConfig berisi konfigurasi cache hasil query analitik.
+type CacheConfig struct {
+	Backend      string        // "lru" atau "none".
+	MaxEntries   int           // Kapasitas LRU (entri).
+	TTL          time.Duration // Umur entri; 0 = hanya dibatasi versi data dan kapasitas.
+	VersionCheck time.Duration // Jarak baca ulang versi data; impor dari proses lain terlihat paling lambat setelah selang ini.
+}
+
+// GetCacheConfig membaca CACHE_BACKEND, CACHE_MAX_ENTRIES, CACHE_TTL, dan CACHE_VERSION_CHECK.
+func GetCacheConfig() CacheConfig {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("CACHE_BACKEND")))
+	if backend == "" {
+		backend = DefaultCacheBackend
+	}
+	maxEntries := IntEnv("CACHE_MAX_ENTRIES", DefaultCacheMaxEntries)
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	return CacheConfig{
+		Backend:      backend,
+		MaxEntries:   maxEntries,
+		TTL:          DurationEnv("CACHE_TTL", DefaultCacheTTL),
+		VersionCheck: DurationEnv("CACHE_VERSION_CHECK", DefaultCacheVersionCheck),
+	}
+}
+
+// Zona waktu Indonesia (tanpa DST) dan selisihnya terhadap UTC dalam jam; dipakai aturan jam kerja peringatan keamanan.
+var TimezoneOffsets = map[string]int{"WIB": 7, "WITA": 8, "WIT": 9}
+
+// DefaultActivityTimezone zona jam dinding yang ditulis sumber CSV ke activity_logs_normalized.tanggal (ACTIVITY_TIMEZONE).
+const DefaultActivityTimezone = "WIB"
+
+// ActivityTimezone membaca ACTIVITY_TIMEZONE (WIB/WITA/WIT): zona jam dinding kolom tanggal hasil impor, dipakai untuk menerjemahkan "sekarang"
+// ke konvensi tanggal (jendela aturan peringatan, batas idle sesi, sesi terbuka di grafik sesi bersamaan). Nilai tidak dikenal → DefaultActivityTimezone.
+func ActivityTimezone() string {
+	tz := strings.ToUpper(strings.TrimSpace(os.Getenv("ACTIVITY_TIMEZONE")))
+	if _, ok := TimezoneOffsets[tz]; !ok {
+		return DefaultActivityTimezone
+	}
+	return tz
+}
+
+// ActivityNow menerjemahkan now ke konvensi kolom tanggal: jam dinding ActivityTimezone saat now, ditulis sebagai waktu UTC.
+// Semua pembandingan "sekarang" dengan tanggal (atau kolom turunannya seperti user_sessions) harus lewat fungsi ini.
+func ActivityNow(now time.Time) time.Time {
+	return now.UTC().Add(time.Duration(TimezoneOffsets[ActivityTimezone()]) * time.Hour)
+}
+
+// Default peringatan keamanan (akses di luar jam kerja, lokasi tidak biasa); semua bisa diganti lewat env SECURITY_*.
+const (
+	DefaultSecurityWorkingHours     = "07:00-19:00"       // Jendela jam kerja lokal (SECURITY_WORKING_HOURS).
+	DefaultSecurityTimezone         = "WIB"               // Zona jika provinsi lokasi tidak diketahui (SECURITY_DEFAULT_TIMEZONE).
+	DefaultSecurityLearningWindow   = 90 * 24 * time.Hour // Riwayat yang dipelajari untuk lokasi normal user (SECURITY_LOCATION_LEARNING_WINDOW).
+	DefaultSecurityLocationHistory  = 20                  // Minimal aktivitas dalam riwayat sebelum aturan lokasi berlaku (SECURITY_LOCATION_MIN_HISTORY).
+	DefaultSecurityLocationMinCount = 3                   // Lokasi dianggap normal jika muncul minimal N kali di riwayat (SECURITY_LOCATION_MIN_COUNT).
+	SecurityAlertBatchSize          = 5000                // Jumlah aktivitas baru yang dinilai per transaksi.
+	SecurityAlertNotifyLimit        = 10                  // Lebih dari ini per batch → satu notifikasi ringkasan per penerima.
+)
+
+// WorkingHours jendela jam kerja lokal dalam menit sejak 00:00: [Start, End).
+type WorkingHours struct {
+	Start int
+	End   int
+}
+
+// SecurityAlertConfig berisi aturan peringatan keamanan.
+type SecurityAlertConfig struct {
+	Enabled            bool
+	WorkingHours       map[string]WorkingHours // Per zona (WIB, WITA, WIT).
+	DefaultTimezone    string
+	WeekendsOffHours   bool          // Sabtu/Minggu dianggap di luar jam kerja.
+	HolidaysOffHours   bool          // Tanggal di ref_holidays dianggap di luar jam kerja.
+	LearningWindow     time.Duration // Rentang riwayat untuk lokasi normal.
+	LocationMinHistory int           // Minimal aktivitas riwayat agar user dinilai.
+	LocationMinCount   int           // Minimal kemunculan lokasi agar dianggap normal.
+	RecipientRole      string        // Role penerima notifikasi (tim keamanan).
+}
+
+// GetSecurityAlertConfig membaca SECURITY_ALERTS_ENABLED, SECURITY_WORKING_HOURS ("07:00-19:00" untuk semua zona, atau per zona: "WIB=07:00-18:00,WIT=08:00-17:00"),
+// SECURITY_DEFAULT_TIMEZONE, SECURITY_WEEKENDS_OFF_HOURS, SECURITY_HOLIDAYS_OFF_HOURS, SECURITY_LOCATION_LEARNING_WINDOW, SECURITY_LOCATION_MIN_HISTORY,
+// SECURITY_LOCATION_MIN_COUNT, dan SECURITY_ALERT_ROLE. Entri jam kerja yang tidak valid diabaikan.
+func GetSecurityAlertConfig() SecurityAlertConfig {
+	def, _ := parseWorkingHours(DefaultSecurityWorkingHours)
+	hours := map[string]WorkingHours{}
+	for zone := range TimezoneOffsets {
+		hours[zone] = def
+	}
+	for _, entry := range strings.Split(os.Getenv("SECURITY_WORKING_HOURS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		zone, window, scoped := strings.Cut(entry, "=")
+		if !scoped {
+			window = zone
+		}
+		wh, ok := parseWorkingHours(window)
+		if !ok {
+			continue
+		}
+		if !scoped {
+			for z := range hours {
+				hours[z] = wh
+			}
+		} else if _, known := TimezoneOffsets[strings.ToUpper(strings.TrimSpace(zone))]; known {
+			hours[strings.ToUpper(strings.TrimSpace(zone))] = wh
+		}
+	}
+
+	tz := strings.ToUpper(strings.TrimSpace(os.Getenv("SECURITY_DEFAULT_TIMEZONE")))
+	if _, ok := TimezoneOffsets[tz]; !ok {
+		tz = DefaultSecurityTimezone
+	}
+	role := strings.TrimSpace(os.Getenv("SECURITY_ALERT_ROLE"))
+	if role == "" {
+		role = "admin"
+	}
+	return SecurityAlertConfig{
+		Enabled:            BoolEnv("SECURITY_ALERTS_ENABLED", true),
+		WorkingHours:       hours,
+		DefaultTimezone:    tz,
+		WeekendsOffHours:   BoolEnv("SECURITY_WEEKENDS_OFF_HOURS", true),
+		HolidaysOffHours:   BoolEnv("SECURITY_HOLIDAYS_OFF_HOURS", true),
+		LearningWindow:     DurationEnv("SECURITY_LOCATION_LEARNING_WINDOW", DefaultSecurityLearningWindow),
+		LocationMinHistory: IntEnv("SECURITY_LOCATION_MIN_HISTORY", DefaultSecurityLocationHistory),
+		LocationMinCount:   IntEnv("SECURITY_LOCATION_MIN_COUNT", DefaultSecurityLocationMinCount),
+		RecipientRole:      role,
+	}
+}
+
+// parseWorkingHours mengurai "HH:MM-HH:MM" menjadi WorkingHours; ok=false jika format salah atau mulai tidak sebelum selesai.
+func parseWorkingHours(s string) (WorkingHours, bool) {
+	start, end, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		return WorkingHours{}, false
+	}
+	parse := func(v string) (int, bool) {
+		t, err := time.Parse("15:04", strings.TrimSpace(v))
+		if err != nil {
+			return 0, false
+		}
+		return t.Hour()*60 + t.Minute(), true
+	}
+	from, okFrom := parse(start)
+	to, okTo := parse(end)
+	if !okFrom || !okTo || from >= to {
+		return WorkingHours{}, false
+	}
+	return WorkingHours{Start: from, End: to}, true
+}
@@ -1,10 +1,11 @@
 // Package entity mendefinisikan model domain dan struktur request/response yang dipetakan ke database.
 //
 // File activity_log.go berisi entitas terkait log aktivitas: ActivityLog (tabel ter-normalisasi) serta
-// entitas referensi UserProfile, SatkerUnit, ActivityType, Cluster, Location beserta nama tabelnya untuk GORM.
+// entitas referensi UserProfile, SatkerUnit, ActivityType, Cluster, Location, Holiday beserta nama tabelnya untuk GORM.
 package entity
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -39,8 +40,17 @@ func (ActivityLog) TableName() string {
 	return "activity_logs_normalized"
 }
 
+// Status engagement profil aktivitas, diturunkan dari user_profiles.last_activity (lihat config.UserDormantDays).
+const (
+	UserEngagementActive      = "active"       // Aktivitas terakhir dalam N hari terakhir.
+	UserEngagementDormant     = "dormant"      // Pernah aktif, tetapi tidak ada aktivitas selama N hari.
+	UserEngagementNeverActive = "never_active" // Belum pernah tercatat beraktivitas.
+)
+
 // UserProfile merepresentasikan profil pengguna yang tercatat dari log aktivitas (nama, token, satker).
 // Dipakai untuk menampilkan siapa yang melakukan aktivitas; bisa punya atau tidak punya akun login (users).
+// FirstActivity/LastActivity diperbarui cmd/import dalam transaksi yang sama dengan insert aktivitas; IsActive = status engagement active
+// (diperbarui saat impor dan oleh job harian user-engagement).
 type UserProfile struct {
 	ID            int64      `gorm:"primaryKey;column:id;autoIncrement" json:"id"`
 	Nama          string     `gorm:"column:nama" json:"nama"`
@@ -108,3 +118,32 @@ type Location struct {
 func (Location) TableName() string {
 	return "ref_locations"
 }
+
+// Holiday merepresentasikan satu hari libur nasional/cuti bersama (referensi ref_holidays, dipelihara admin).
+type Holiday struct {
+	ID          int64     `gorm:"primaryKey;column:id;autoIncrement" json:"id"`
+	HolidayDate time.Time `gorm:"column:holiday_date;type:date" json:"-"`
+	Name        string    `gorm:"column:name" json:"name"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk Holiday.
+func (Holiday) TableName() string {
+	return "ref_holidays"
+}
+
+// CreateHolidayRequest payload admin untuk menambah hari libur.
+type CreateHolidayRequest struct {
+	HolidayDate string `json:"holiday_date" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+}
+
+// MarshalJSON menulis holiday_date sebagai YYYY-MM-DD (tanpa jam/zona).
+func (h Holiday) MarshalJSON() ([]byte, error) {
+	type alias Holiday
+	return json.Marshal(struct {
+		alias
+		HolidayDate string `json:"holiday_date"`
+	}{alias(h), h.HolidayDate.Format("2006-01-02")})
+}
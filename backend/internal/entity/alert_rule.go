@@ -0,0 +1,154 @@
+package entity
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrik aturan peringatan (alert_rules.metric); masing-masing dihitung dengan agregat ActivityLogRepository.
+const (
+	AlertMetricTotalActivities = "total_activities"  // Jumlah aktivitas (GetTotalCount).
+	AlertMetricSuccessLogins   = "successful_logins" // Login sukses (GetCountByStatus SUCCESS).
+	AlertMetricLogoutErrors    = "logout_errors"     // Error logout (GetCountByStatus FAILED).
+	AlertMetricUniqueUsers     = "unique_users"      // User unik (GetUniqueUsersCount).
+)
+
+// AlertMetrics daftar metrik yang didukung aturan peringatan.
+var AlertMetrics = []string{AlertMetricTotalActivities, AlertMetricSuccessLogins, AlertMetricLogoutErrors, AlertMetricUniqueUsers}
+
+// Pembanding nilai metrik terhadap ambang (alert_rules.comparator).
+const (
+	AlertComparatorGT  = "gt"
+	AlertComparatorGTE = "gte"
+	AlertComparatorLT  = "lt"
+	AlertComparatorLTE = "lte"
+	AlertComparatorEQ  = "eq"
+)
+
+// AlertComparators daftar pembanding yang didukung beserta simbolnya (untuk pesan notifikasi).
+var AlertComparators = map[string]string{
+	AlertComparatorGT:  ">",
+	AlertComparatorGTE: "≥",
+	AlertComparatorLT:  "<",
+	AlertComparatorLTE: "≤",
+	AlertComparatorEQ:  "=",
+}
+
+// Status firing aturan peringatan (alert_rule_firings.status).
+const (
+	AlertFiringStatusFiring   = "firing"
+	AlertFiringStatusResolved = "resolved"
+)
+
+// AlertRule aturan peringatan yang didefinisikan admin (tabel alert_rules): metrik atas aktivitas yang cocok dengan Filter dalam jendela bergulir
+// WindowMinutes dibandingkan dengan Threshold. Filter disimpan sebagai JSON ActivityFilter tanpa tanggal. RecipientRoles (role dipisah koma) dan
+// RecipientUserIDs (users.id dipisah koma) menerima notifikasi saat aturan firing dan resolved.
+type AlertRule struct {
+	ID               int64      `gorm:"primaryKey" json:"id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Metric           string     `json:"metric"`
+	Filter           string     `gorm:"type:jsonb" json:"-"`
+	WindowMinutes    int        `json:"window_minutes"`
+	Comparator       string     `json:"comparator"`
+	Threshold        float64    `json:"threshold"`
+	CooldownMinutes  int        `json:"cooldown_minutes"`
+	RecipientRoles   string     `json:"-"`
+	RecipientUserIDs string     `gorm:"column:recipient_user_ids" json:"-"`
+	IsActive         bool       `json:"is_active"`
+	LastValue        *float64   `json:"last_value,omitempty"`
+	LastEvaluatedAt  *time.Time `json:"last_evaluated_at,omitempty"`
+	CreatedBy        *int       `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk AlertRule.
+func (AlertRule) TableName() string {
+	return "alert_rules"
+}
+
+// RoleList mengembalikan role penerima notifikasi.
+func (r *AlertRule) RoleList() []string {
+	var roles []string
+	for _, v := range strings.Split(r.RecipientRoles, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			roles = append(roles, v)
+		}
+	}
+	return roles
+}
+
+// UserIDList mengembalikan users.id penerima notifikasi. Nilai yang tidak valid diabaikan.
+func (r *AlertRule) UserIDList() []int {
+	var ids []int
+	for _, v := range strings.Split(r.RecipientUserIDs, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// MarshalJSON menulis filter sebagai objek JSON dan penerima sebagai array.
+func (r AlertRule) MarshalJSON() ([]byte, error) {
+	type alias AlertRule
+	filter := json.RawMessage(r.Filter)
+	if len(filter) == 0 {
+		filter = json.RawMessage("{}")
+	}
+	roles, users := r.RoleList(), r.UserIDList()
+	if roles == nil {
+		roles = []string{}
+	}
+	if users == nil {
+		users = []int{}
+	}
+	return json.Marshal(struct {
+		alias
+		Filter           json.RawMessage `json:"filter"`
+		RecipientRoles   []string        `json:"recipient_roles"`
+		RecipientUserIDs []int           `json:"recipient_user_ids"`
+	}{alias(r), filter, roles, users})
+}
+
+// AlertRuleFiring satu kejadian firing aturan (tabel alert_rule_firings): dibuka saat kondisi terpenuhi, diperbarui selama masih terpenuhi,
+// dan ditutup (resolved) saat kondisi tidak lagi terpenuhi. Threshold dan Comparator disalin dari aturan saat firing dimulai.
+type AlertRuleFiring struct {
+	ID              int64      `gorm:"primaryKey" json:"id"`
+	RuleID          int64      `json:"rule_id"`
+	RuleName        string     `gorm:"->" json:"rule_name,omitempty"`
+	Status          string     `json:"status"`
+	Value           float64    `json:"value"`
+	PeakValue       float64    `json:"peak_value"`
+	Threshold       float64    `json:"threshold"`
+	Comparator      string     `json:"comparator"`
+	Message         string     `json:"message"`
+	FiredAt         time.Time  `json:"fired_at"`
+	LastEvaluatedAt time.Time  `json:"last_evaluated_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	ResolvedValue   *float64   `json:"resolved_value,omitempty"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk AlertRuleFiring.
+func (AlertRuleFiring) TableName() string {
+	return "alert_rule_firings"
+}
+
+// AlertRuleRequest payload admin untuk membuat atau mengganti aturan peringatan (POST /api/admin/alert-rules, PUT /api/admin/alert-rules/:id).
+// Filter = objek ActivityFilter tanpa tanggal (mis. {"clusters":["X"]}); IsActive nil = aktif.
+type AlertRuleRequest struct {
+	Name             string          `json:"name" binding:"required"`
+	Description      string          `json:"description"`
+	Metric           string          `json:"metric" binding:"required"`
+	Filter           json.RawMessage `json:"filter"`
+	WindowMinutes    int             `json:"window_minutes" binding:"required"`
+	Comparator       string          `json:"comparator" binding:"required"`
+	Threshold        float64         `json:"threshold"`
+	CooldownMinutes  int             `json:"cooldown_minutes"`
+	RecipientRoles   []string        `json:"recipient_roles"`
+	RecipientUserIDs []int           `json:"recipient_user_ids"`
+	IsActive         *bool           `json:"is_active"`
+}
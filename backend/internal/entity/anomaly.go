@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Detektor anomali (anomalies.detector).
+const (
+	AnomalyDetectorSatkerVolume  = "satker_volume"  // Volume aktivitas harian per satker.
+	AnomalyDetectorUserDownloads = "user_downloads" // Jumlah unduhan harian per user.
+	AnomalyDetectorFailedLogouts = "failed_logouts" // Jumlah error logout harian (seluruh data).
+)
+
+// Tipe subjek anomali (anomalies.subject_type).
+const (
+	AnomalySubjectSatker = "satker"
+	AnomalySubjectUser   = "user"
+	AnomalySubjectGlobal = "global"
+)
+
+// Arah penyimpangan terhadap baseline.
+const (
+	AnomalySpike = "spike"
+	AnomalyDrop  = "drop"
+)
+
+// Tingkat keparahan anomali (berdasarkan skor absolut).
+const (
+	AnomalySeverityLow    = "low"
+	AnomalySeverityMedium = "medium"
+	AnomalySeverityHigh   = "high"
+)
+
+// Status tindak lanjut anomali.
+const (
+	AnomalyStatusOpen         = "open"
+	AnomalyStatusAcknowledged = "acknowledged"
+	AnomalyStatusDismissed    = "dismissed"
+)
+
+// Anomaly satu temuan detektor (tabel anomalies): subjek, hari, nilai teramati vs baseline (median + MAD hari yang sama pada minggu-minggu sebelumnya),
+// skor z robust, arah, keparahan, penjelasan, dan status tindak lanjut (open → acknowledged / dismissed).
+type Anomaly struct {
+	ID              int64      `gorm:"primaryKey" json:"id"`
+	Detector        string     `json:"detector"`
+	SubjectType     string     `json:"subject_type"`
+	SubjectID       int64      `json:"subject_id"`
+	SubjectName     string     `json:"subject_name"`
+	Day             time.Time  `gorm:"type:date" json:"-"`
+	Observed        int64      `json:"observed"`
+	BaselineMedian  float64    `json:"baseline_median"`
+	BaselineMAD     float64    `gorm:"column:baseline_mad" json:"baseline_mad"`
+	Score           float64    `json:"score"`
+	Direction       string     `json:"direction"`
+	Severity        string     `json:"severity"`
+	Explanation     string     `json:"explanation"`
+	Status          string     `json:"status"`
+	StatusNote      *string    `json:"status_note,omitempty"`
+	StatusChangedBy *int       `json:"status_changed_by,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	DetectedAt      time.Time  `json:"detected_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk Anomaly.
+func (Anomaly) TableName() string {
+	return "anomalies"
+}
+
+// MarshalJSON menulis day sebagai YYYY-MM-DD (tanpa jam/zona).
+func (a Anomaly) MarshalJSON() ([]byte, error) {
+	type alias Anomaly
+	return json.Marshal(struct {
+		alias
+		Day string `json:"day"`
+	}{alias(a), a.Day.Format("2006-01-02")})
+}
+
+// AnomalyStatusRequest payload acknowledge/dismiss anomali; Note opsional.
+type AnomalyStatusRequest struct {
+	Note string `json:"note"`
+}
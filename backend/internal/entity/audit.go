@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// AuditEvent merepresentasikan satu catatan audit tindakan administratif/keamanan (siapa, melakukan apa, terhadap apa, dari mana).
+// BeforeData/AfterData berisi snapshot JSON target sebelum dan sesudah perubahan; Diff hanya field yang berubah. Tabel bersifat append-only (trigger DB menolak UPDATE/DELETE).
+// Hash = SHA-256 dari PrevHash + isi baris (lihat service.AuditChainHash) sehingga perubahan atau penghapusan baris memutus rantai; NULL untuk baris sebelum hash chain aktif.
+type AuditEvent struct {
+	ID            int64     `gorm:"primaryKey" json:"id"`
+	ActorID       *int      `json:"actor_id,omitempty"`
+	ActorUsername string    `json:"actor_username,omitempty"`
+	Action        string    `gorm:"not null" json:"action"`
+	TargetType    string    `json:"target_type,omitempty"`
+	TargetID      string    `json:"target_id,omitempty"`
+	BeforeData    *string   `gorm:"type:jsonb" json:"before,omitempty"`
+	AfterData     *string   `gorm:"type:jsonb" json:"after,omitempty"`
+	Diff          *string   `gorm:"type:jsonb" json:"diff,omitempty"`
+	IP            string    `gorm:"column:ip" json:"ip,omitempty"`
+	UserAgent     string    `json:"user_agent,omitempty"`
+	RequestID     string    `json:"request_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	PrevHash      *string   `json:"prev_hash,omitempty"`
+	Hash          *string   `json:"hash,omitempty"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk AuditEvent.
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
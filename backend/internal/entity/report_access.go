@@ -1,19 +1,79 @@
 package entity
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Template laporan yang bisa di-generate dan dicakup grant akses (report_access_requests.templates).
+const (
+	ReportTemplateOrgPerformance = "org-performance"
+	ReportTemplateUserActivity   = "user-activity"
+	ReportTemplateFeatureUsage   = "feature-usage"
+	ReportTemplateUserRetention  = "user-retention"
+	ReportTemplateAccountHygiene = "account-hygiene"
+)
+
+// ReportTemplateIDs daftar semua template laporan, urut tampilan.
+var ReportTemplateIDs = []string{ReportTemplateOrgPerformance, ReportTemplateUserActivity, ReportTemplateFeatureUsage, ReportTemplateUserRetention, ReportTemplateAccountHygiene}
+
+// IsValidReportTemplate mengembalikan true jika id adalah template laporan yang dikenal.
+func IsValidReportTemplate(id string) bool {
+	for _, t := range ReportTemplateIDs {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminOnlyReportTemplateIDs template yang hanya boleh di-generate admin; tidak bisa diajukan lewat permintaan akses laporan.
+var AdminOnlyReportTemplateIDs = []string{ReportTemplateAccountHygiene}
+
+// IsAdminOnlyReportTemplate mengembalikan true jika id hanya boleh di-generate admin.
+func IsAdminOnlyReportTemplate(id string) bool {
+	for _, t := range AdminOnlyReportTemplateIDs {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Status permintaan akses laporan (report_access_requests.status).
+const (
+	AccessRequestPending  = "pending"
+	AccessRequestApproved = "approved"
+	AccessRequestRejected = "rejected"
+	AccessRequestRevoked  = "revoked"
+	AccessRequestExpired  = "expired"
+)
 
 // ReportAccessRequest merepresentasikan permintaan akses laporan dari user (request akses report).
-// Status: pending, approved, rejected. ProcessedAt/ProcessedBy diisi saat admin menyetujui/menolak.
+// Status: pending, approved, rejected, revoked, expired. ApprovalSteps = snapshot tahap persetujuan (role dipisah koma, mis. "unit_head,admin") saat diajukan;
+// CurrentStep = indeks tahap yang sedang menunggu. ProcessedAt/ProcessedBy diisi saat keputusan akhir. ExpiresAt/ReviewDueAt berlaku setelah disetujui.
+// Permintaan yang disetujui dan belum kedaluwarsa adalah grant akses: Templates (id template dipisah koma) dan SatkerIDs (id root pohon satker dipisah koma)
+// membatasi laporan yang boleh di-generate; kosong = semua.
 type ReportAccessRequest struct {
-	ID          int        `gorm:"primaryKey" json:"id"`
-	UserID      int        `gorm:"not null" json:"user_id"`
-	User        *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Reason      string     `json:"reason,omitempty"`
-	Status      string     `gorm:"default:pending" json:"status"` // pending, approved, rejected
-	RequestedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"requested_at"`
-	ProcessedAt *time.Time `json:"processed_at,omitempty"`
-	ProcessedBy *int       `json:"processed_by,omitempty"`
-	AdminNotes  string     `json:"admin_notes,omitempty"`
+	ID               int                        `gorm:"primaryKey" json:"id"`
+	UserID           int                        `gorm:"not null" json:"user_id"`
+	User             *User                      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Reason           string                     `json:"reason,omitempty"`
+	Status           string                     `gorm:"default:pending" json:"status"` // pending, approved, rejected, revoked, expired
+	ApprovalSteps    string                     `json:"approval_steps"`
+	CurrentStep      int                        `gorm:"default:0" json:"current_step"`
+	Templates        string                     `json:"templates"`
+	SatkerIDs        string                     `gorm:"column:satker_ids" json:"satker_ids"`
+	RequestedAt      time.Time                  `gorm:"default:CURRENT_TIMESTAMP" json:"requested_at"`
+	ProcessedAt      *time.Time                 `json:"processed_at,omitempty"`
+	ProcessedBy      *int                       `json:"processed_by,omitempty"`
+	AdminNotes       string                     `json:"admin_notes,omitempty"`
+	ExpiresAt        *time.Time                 `json:"expires_at,omitempty"`
+	ReviewDueAt      *time.Time                 `json:"review_due_at,omitempty"`
+	ReviewNotifiedAt *time.Time                 `json:"-"`
+	ExpiryNotifiedAt *time.Time                 `json:"-"`
+	Events           []ReportAccessRequestEvent `gorm:"foreignKey:RequestID" json:"events,omitempty"`
 }
 
 // TableName mengembalikan nama tabel GORM untuk ReportAccessRequest.
@@ -21,6 +81,97 @@ func (ReportAccessRequest) TableName() string {
 	return "report_access_requests"
 }
 
+// Steps mengembalikan daftar role tahap persetujuan dari snapshot ApprovalSteps (kosong → satu tahap admin).
+func (r *ReportAccessRequest) Steps() []string {
+	var steps []string
+	for _, s := range strings.Split(r.ApprovalSteps, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			steps = append(steps, s)
+		}
+	}
+	if len(steps) == 0 {
+		steps = []string{RoleAdmin}
+	}
+	return steps
+}
+
+// CurrentStepRole mengembalikan role penyetuju tahap aktif ("" jika CurrentStep di luar rentang).
+func (r *ReportAccessRequest) CurrentStepRole() string {
+	steps := r.Steps()
+	if r.CurrentStep < 0 || r.CurrentStep >= len(steps) {
+		return ""
+	}
+	return steps[r.CurrentStep]
+}
+
+// TemplateList mengembalikan id template yang dicakup grant (kosong = semua template).
+func (r *ReportAccessRequest) TemplateList() []string {
+	var templates []string
+	for _, t := range strings.Split(r.Templates, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			templates = append(templates, t)
+		}
+	}
+	return templates
+}
+
+// SatkerIDList mengembalikan id root pohon satker yang dicakup grant (kosong = semua satker). Nilai yang tidak valid diabaikan.
+func (r *ReportAccessRequest) SatkerIDList() []int64 {
+	var ids []int64
+	for _, v := range strings.Split(r.SatkerIDs, ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// CoversTemplate mengembalikan true jika grant mencakup template id.
+func (r *ReportAccessRequest) CoversTemplate(id string) bool {
+	templates := r.TemplateList()
+	if len(templates) == 0 {
+		return true
+	}
+	for _, t := range templates {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Aksi pada riwayat permintaan akses (report_access_request_events.action).
+const (
+	AccessEventSubmitted    = "submitted"
+	AccessEventStepApproved = "step_approved"
+	AccessEventStepSkipped  = "step_skipped"
+	AccessEventApproved     = "approved"
+	AccessEventRejected     = "rejected"
+	AccessEventRevoked      = "revoked"
+	AccessEventReviewed     = "reviewed"
+	AccessEventExpired      = "expired"
+)
+
+// ReportAccessRequestEvent satu langkah di riwayat permintaan akses (siapa, tahap mana, aksi apa, alasan). ActorID nil untuk aksi sistem (skip otomatis, kedaluwarsa).
+type ReportAccessRequestEvent struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	RequestID  int       `gorm:"not null" json:"request_id"`
+	Step       *int      `json:"step,omitempty"`
+	StepRole   string    `json:"step_role,omitempty"`
+	Action     string    `gorm:"not null" json:"action"`
+	ActorID    *int      `json:"actor_id,omitempty"`
+	Actor      *User     `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk ReportAccessRequestEvent.
+func (ReportAccessRequestEvent) TableName() string {
+	return "report_access_request_events"
+}
+
 // ReportDownload merepresentasikan satu kali unduhan laporan (siapa, laporan apa, format, kapan).
 type ReportDownload struct {
 	ID          int       `gorm:"primaryKey" json:"id"`
@@ -30,6 +181,8 @@ type ReportDownload struct {
 	TemplateID  string    `gorm:"not null" json:"template_id"`
 	Format      string    `gorm:"not null" json:"format"`
 	FileSize    string    `json:"file_size,omitempty"`
+	Filename    string    `gorm:"column:filename" json:"filename,omitempty"`   // Nama file di generated_reports; kunci otorisasi unduhan.
+	SatkerID    *int64    `gorm:"column:satker_id" json:"satker_id,omitempty"` // Root pohon satker saat generate; diotorisasi ulang saat unduh.
 	StartDate   *string   `json:"start_date,omitempty"`
 	EndDate     *string   `json:"end_date,omitempty"`
 	GeneratedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"generated_at"`
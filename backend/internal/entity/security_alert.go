@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+// Aturan peringatan keamanan (security_alerts.rule).
+const (
+	SecurityRuleOffHours        = "off_hours"        // Akses di luar jam kerja lokal, akhir pekan, atau hari libur.
+	SecurityRuleUnusualLocation = "unusual_location" // Akses dari lokasi yang tidak termasuk lokasi normal user.
+)
+
+// SecurityAlert satu peringatan keamanan dari aktivitas baru (tabel security_alerts). UserID = user_profiles.id (pelaku aktivitas).
+// LocalTime = waktu aktivitas di zona waktu lokasi akses (mis. "2026-10-18 22:14 WITA").
+type SecurityAlert struct {
+	ID            int64     `gorm:"primaryKey" json:"id"`
+	Rule          string    `json:"rule"`
+	DedupKey      string    `json:"-"`
+	ActivityLogID int64     `json:"activity_log_id"`
+	UserID        int64     `json:"user_id"`
+	UserName      string    `json:"user_name"`
+	SatkerID      *int64    `json:"satker_id,omitempty"`
+	LocationID    *int64    `json:"location_id,omitempty"`
+	LocationName  string    `json:"location_name,omitempty"`
+	ActivityAt    time.Time `json:"activity_at"`
+	LocalTime     string    `json:"local_time"`
+	Explanation   string    `json:"explanation"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk SecurityAlert.
+func (SecurityAlert) TableName() string {
+	return "security_alerts"
+}
@@ -0,0 +1,55 @@
+package entity
+
+import "time"
+
+// AuthSession merepresentasikan satu sesi login (tabel auth_sessions). ID dipakai sebagai klaim jti di JWT; token ditolak jika sesi dicabut (RevokedAt) atau kedaluwarsa.
+// Current tidak disimpan di DB; diisi handler untuk menandai sesi milik token yang sedang dipakai.
+type AuthSession struct {
+	ID            string     `gorm:"primaryKey" json:"id"`
+	UserID        int        `gorm:"not null" json:"user_id"`
+	Device        string     `json:"device"`
+	IP            string     `gorm:"column:ip" json:"ip"`
+	UserAgent     string     `json:"user_agent"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+	Current       bool       `gorm:"-" json:"current"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk AuthSession.
+func (AuthSession) TableName() string {
+	return "auth_sessions"
+}
+
+// Alasan berakhirnya sesi aktivitas (user_sessions.end_reason).
+const (
+	UserSessionEndLogout  = "logout"  // Event LOGOUT.
+	UserSessionEndRelogin = "relogin" // LOGIN berikutnya sebelum LOGOUT.
+	UserSessionEndTimeout = "timeout" // Tidak ada aktivitas selama ACTIVITY_SESSION_IDLE_TIMEOUT.
+)
+
+// UserSession satu sesi aktivitas user_profiles hasil rekonstruksi dari event LOGIN/LOGOUT (tabel user_sessions); berbeda dengan AuthSession
+// yang mencatat login ke aplikasi dashboard ini. EndedAt, EndReason, dan DurationSeconds nil selama sesi masih terbuka.
+type UserSession struct {
+	ID              int64      `gorm:"primaryKey" json:"id"`
+	UserID          int64      `json:"user_id"`
+	SatkerID        *int64     `json:"satker_id,omitempty"`
+	LocationID      *int64     `json:"location_id,omitempty"`
+	LoginLogID      int64      `json:"login_log_id"`
+	LogoutLogID     *int64     `json:"logout_log_id,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	LastActivityAt  time.Time  `json:"last_activity_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	EndReason       *string    `json:"end_reason,omitempty"`
+	DurationSeconds *int64     `json:"duration_seconds,omitempty"`
+	ActionCount     int        `json:"action_count"`
+	ErrorLogout     bool       `json:"error_logout"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk UserSession.
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
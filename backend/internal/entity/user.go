@@ -2,8 +2,42 @@ package entity
 
 import "time"
 
+// Role user yang dikenal sistem (kolom users.role).
+const (
+	RoleUser     = "user"
+	RoleAdmin    = "admin"
+	RoleUnitHead = "unit_head" // Kepala unit: penyetuju tahap pertama permintaan akses laporan untuk user satker-nya.
+)
+
+// IsValidRole mengembalikan true jika role termasuk role yang dikenal sistem.
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleAdmin, RoleUnitHead:
+		return true
+	}
+	return false
+}
+
+// Alasan akun users ditandai pada deteksi akun dorman/yatim (template laporan account-hygiene, GET /api/admin/users/flagged).
+const (
+	AccountFlagDormantLogin   = "dormant_login"   // Tidak login (atau belum pernah login sejak dibuat) selama ACCOUNT_DORMANT_DAYS hari.
+	AccountFlagOrphanedSatker = "orphaned_satker" // users.satker_id menunjuk satker yang sudah tidak ada di ref_satker_units.
+)
+
+// IsValidReportAccessStatus mengembalikan true jika status termasuk nilai users.report_access_status yang valid (none, pending, approved, rejected).
+func IsValidReportAccessStatus(status string) bool {
+	switch status {
+	case "none", "pending", "approved", "rejected":
+		return true
+	}
+	return false
+}
+
 // User merepresentasikan akun pengguna di sistem (login, role, akses laporan, profil).
 // PasswordHash tidak di-expose di JSON (tag json:"-"). ReportAccessStatus: none, pending, approved, rejected.
+// PasswordChangedAt dipakai untuk cek masa berlaku password; MustChangePassword = true memaksa user ganti password saat login berikutnya.
+// EmailVerifiedAt syarat auto-link profil aktivitas by email (lihat ProfileLinkService.EnsureLinked).
+// DeactivatedAt diisi saat admin menonaktifkan akun; akun seperti ini tidak bisa diaktifkan lewat link aktivasi.
 type User struct {
 	ID                 int        `gorm:"primaryKey" json:"id"`
 	Username           string     `gorm:"unique;not null" json:"username"`
@@ -12,11 +46,19 @@ type User struct {
 	FullName           string     `json:"full_name,omitempty"`
 	Email              string     `json:"email,omitempty"`
 	ProfilePhoto       string     `json:"profile_photo,omitempty"`
+	SatkerID           *int64     `gorm:"column:satker_id" json:"satker_id,omitempty"`                     // Satker asal (ref_satker_units.id)
+	ProfileID          *int64     `gorm:"column:profile_id" json:"profile_id,omitempty"`                   // Profil aktivitas tertaut (user_profiles.id)
+	ProfileLinkMethod  string     `gorm:"column:profile_link_method" json:"profile_link_method,omitempty"` // email, name, manual
+	ProfileLinkedAt    *time.Time `gorm:"column:profile_linked_at" json:"profile_linked_at,omitempty"`
 	IsActive           bool       `gorm:"default:true" json:"is_active"`
 	ReportAccessStatus string     `gorm:"default:none" json:"report_access_status"` // none, pending, approved, rejected
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 	LastLogin          *time.Time `json:"last_login,omitempty"`
+	PasswordChangedAt  *time.Time `json:"password_changed_at,omitempty"`
+	MustChangePassword bool       `gorm:"default:false" json:"must_change_password"`
+	EmailVerifiedAt    *time.Time `gorm:"column:email_verified_at" json:"email_verified_at,omitempty"` // Email terbukti milik user (aktivasi lewat link atau diisi admin); nil untuk registrasi mandiri
+	DeactivatedAt      *time.Time `gorm:"column:deactivated_at" json:"deactivated_at,omitempty"`       // Dinonaktifkan admin; dikosongkan saat admin mengaktifkan ulang
 }
 
 // TableName mengembalikan nama tabel GORM untuk User.
@@ -24,16 +66,51 @@ func (User) TableName() string {
 	return "users"
 }
 
+// UserActivationToken menyimpan token aktivasi akun sekali pakai (hanya hash SHA-256 token yang disimpan).
+type UserActivationToken struct {
+	ID        int        `gorm:"primaryKey" json:"id"`
+	UserID    int        `gorm:"not null" json:"user_id"`
+	TokenHash string     `gorm:"not null;unique" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk UserActivationToken.
+func (UserActivationToken) TableName() string {
+	return "user_activation_tokens"
+}
+
+// ActivateAccountRequest payload untuk aktivasi akun lewat link (token) sekaligus menetapkan password.
+type ActivateAccountRequest struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required"`
+}
+
+// PasswordHistory menyimpan hash password lama milik user agar password yang sama tidak dipakai ulang (N terakhir, lihat PASSWORD_HISTORY_SIZE).
+type PasswordHistory struct {
+	ID           int       `gorm:"primaryKey" json:"id"`
+	UserID       int       `gorm:"not null" json:"user_id"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName mengembalikan nama tabel GORM untuk PasswordHistory.
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
 // LoginRequest payload untuk endpoint login (username dan password).
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
 
-// RegisterRequest payload untuk endpoint registrasi (username, password, email, dll.).
+// RegisterRequest payload untuk endpoint registrasi (username, password, email, dll.). Kekuatan password divalidasi oleh service.PasswordPolicy, bukan tag binding.
 type RegisterRequest struct {
 	Username        string `json:"username" binding:"required,min=3,max=100"`
-	Password        string `json:"password" binding:"required,min=6"`
+	Password        string `json:"password" binding:"required"`
 	ConfirmPassword string `json:"confirm_password" binding:"required"`
 	FullName        string `json:"full_name"`
 	Email           string `json:"email" binding:"required,email"`
@@ -42,22 +119,60 @@ type RegisterRequest struct {
 // ForgotPasswordRequest payload untuk reset password (user lupa password; biasanya dengan verifikasi).
 type ForgotPasswordRequest struct {
 	Username        string `json:"username" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=6"`
+	NewPassword     string `json:"new_password" binding:"required"`
 	ConfirmPassword string `json:"confirm_password" binding:"required"`
 }
 
 // ChangePasswordRequest payload untuk ganti password (user sudah login; butuh old password).
 type ChangePasswordRequest struct {
 	OldPassword     string `json:"old_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=6"`
+	NewPassword     string `json:"new_password" binding:"required"`
 	ConfirmPassword string `json:"confirm_password" binding:"required"`
 }
 
 // LoginResponse response endpoint login (token JWT, data user, pesan).
+// MustChangePassword = true berarti token hanya boleh dipakai untuk ganti password (password kedaluwarsa atau direset admin).
 type LoginResponse struct {
-	Token   string `json:"token"`
-	User    User   `json:"user"`
-	Message string `json:"message"`
+	Token              string `json:"token"`
+	User               User   `json:"user"`
+	Message            string `json:"message"`
+	MustChangePassword bool   `json:"must_change_password"`
+}
+
+// AdminCreateUserRequest payload admin untuk membuat user (POST /api/admin/users). Password opsional: jika kosong dibuat password sementara dan user wajib ganti password saat login.
+type AdminCreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=100"`
+	Email    string `json:"email" binding:"required,email"`
+	FullName string `json:"full_name"`
+	Role     string `json:"role"`
+	SatkerID *int64 `json:"satker_id"`
+	Password string `json:"password"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// AdminUpdateUserRequest payload admin untuk mengubah user (PUT /api/admin/users/:id). Field nil tidak diubah.
+type AdminUpdateUserRequest struct {
+	Email              *string `json:"email"`
+	FullName           *string `json:"full_name"`
+	Role               *string `json:"role"`
+	SatkerID           *int64  `json:"satker_id"`
+	IsActive           *bool   `json:"is_active"`
+	ReportAccessStatus *string `json:"report_access_status"` // Ditolak (400): status hanya berubah lewat workflow akses laporan.
+}
+
+// LinkProfileRequest payload admin untuk menautkan user ke user_profiles secara manual.
+type LinkProfileRequest struct {
+	ProfileID int64 `json:"profile_id" binding:"required"`
+}
+
+// AdminResetPasswordRequest payload admin untuk reset paksa password user. NewPassword kosong = dibuatkan password sementara acak.
+type AdminResetPasswordRequest struct {
+	NewPassword string `json:"new_password"`
+}
+
+// AdminDeactivateFlaggedRequest payload admin untuk penonaktifan massal akun dorman/yatim. UserIDs kosong = semua akun yang sedang ditandai.
+type AdminDeactivateFlaggedRequest struct {
+	UserIDs []int `json:"user_ids"`
 }
 
 // UpdateProfilePhotoRequest payload untuk update foto profil (URL atau path).
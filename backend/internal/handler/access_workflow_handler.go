@@ -0,0 +1,212 @@
+// File access_workflow_handler.go: HTTP handler workflow persetujuan akses laporan (prefix /api/report-access, butuh JWT).
+//
+// Endpoint: ListAccessApprovals (antrian penyetuju: permintaan yang tahap aktifnya boleh diputuskan user login; admin bisa filter status + paginasi),
+// ListMyAccessRequests (permintaan milik sendiri), GetAccessRequestDetail (detail + riwayat lengkap), ApproveAccessRequest / RejectAccessRequest (tahap aktif),
+// RevokeAccessRequest / ReviewAccessRequest (admin, akses yang sudah disetujui). Body keputusan: {"reason": "..."}; wajib untuk reject dan revoke.
+// Approve tahap terakhir oleh admin boleh mengganti cakupan grant: templates, satker_ids, expires_at (YYYY-MM-DD, akses berlaku sampai akhir hari itu).
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// accessDecisionRequest body keputusan workflow akses (approve/reject/revoke/review). Templates/SatkerIDs/ExpiresAt hanya untuk approve oleh admin.
+type accessDecisionRequest struct {
+	Reason    string   `json:"reason"`
+	Templates []string `json:"templates"`
+	SatkerIDs []int64  `json:"satker_ids"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+// accessSubmitRequest body pengajuan akses laporan: alasan wajib, cakupan template dan root satker opsional.
+type accessSubmitRequest struct {
+	Reason    string   `json:"reason"`
+	Templates []string `json:"templates"`
+	SatkerIDs []int64  `json:"satker_ids"`
+}
+
+// scope mengembalikan cakupan grant yang diminta.
+func (r accessSubmitRequest) scope() service.AccessScope {
+	return service.AccessScope{Templates: r.Templates, SatkerIDs: r.SatkerIDs}
+}
+
+// override mengembalikan perubahan grant dari body approve (nil jika tidak ada); expires_at diubah ke akhir hari (awal hari berikutnya, waktu lokal).
+func (r accessDecisionRequest) override() (*service.GrantOverride, error) {
+	var o service.GrantOverride
+	if r.Templates != nil || r.SatkerIDs != nil {
+		o.Scope = &service.AccessScope{Templates: r.Templates, SatkerIDs: r.SatkerIDs}
+	}
+	if r.ExpiresAt != "" {
+		t, err := time.ParseInLocation("2006-01-02", r.ExpiresAt, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		t = t.AddDate(0, 0, 1)
+		o.ExpiresAt = &t
+	}
+	if o.Scope == nil && o.ExpiresAt == nil {
+		return nil, nil
+	}
+	return &o, nil
+}
+
+// respondAccessWorkflowError memetakan error dari AccessWorkflowService ke status HTTP (404, 403, 409, 400, lainnya 500).
+func respondAccessWorkflowError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAccessRequestNotFound), errors.Is(err, service.ErrAccountNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAccessNotApprover), errors.Is(err, service.ErrAccessSelfApproval):
+		response.Error(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrAccessAlreadyPending), errors.Is(err, service.ErrAccessAlreadyGranted),
+		errors.Is(err, service.ErrAccessNotPending), errors.Is(err, service.ErrAccessNotApproved):
+		response.Error(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrAccessReasonRequired):
+		response.Error(c, http.StatusBadRequest, "Alasan wajib diisi minimal "+strconv.Itoa(config.MinAccessReasonLength)+" karakter")
+	case errors.Is(err, errAccessExpiryFormat), errors.Is(err, service.ErrAccessNotNeeded), errors.Is(err, service.ErrInvalidReportTemplate),
+		errors.Is(err, service.ErrInvalidSatkerScope), errors.Is(err, service.ErrInvalidGrantExpiry):
+		response.Error(c, http.StatusBadRequest, err.Error())
+	default:
+		response.Internal(c, err)
+	}
+}
+
+// ListAccessApprovals mengembalikan antrian persetujuan user login. Admin dengan query status (pending/approved/rejected/revoked/expired) mendapat daftar lengkap berpaginasi.
+func ListAccessApprovals(c *gin.Context) {
+	workflow := service.NewAccessWorkflowService(database.GetDB())
+	userID := c.GetInt("user_id")
+
+	if status := c.Query("status"); status != "" && c.GetString("user_role") == entity.RoleAdmin {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeAdmin)))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > config.MaxPageSizeAdmin {
+			pageSize = config.DefaultPageSizeAdmin
+		}
+		requests, total, err := workflow.List(service.AccessRequestFilter{Status: status, Page: page, PageSize: pageSize})
+		if err != nil {
+			response.Internal(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data":        requests,
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		})
+		return
+	}
+
+	requests, err := workflow.ListActionable(userID)
+	if err != nil {
+		respondAccessWorkflowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": requests, "count": len(requests)})
+}
+
+// ListMyAccessRequests mengembalikan semua permintaan akses milik user login (terbaru dulu).
+func ListMyAccessRequests(c *gin.Context) {
+	requests, _, err := service.NewAccessWorkflowService(database.GetDB()).List(service.AccessRequestFilter{
+		UserID:   c.GetInt("user_id"),
+		Page:     1,
+		PageSize: config.MaxPageSizeAdmin,
+	})
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": requests, "count": len(requests)})
+}
+
+// GetAccessRequestDetail mengembalikan detail permintaan (path :id) beserta riwayat. Hanya pemohon, admin, atau penyetuju tahapnya.
+func GetAccessRequestDetail(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	workflow := service.NewAccessWorkflowService(database.GetDB())
+	req, err := workflow.Get(id)
+	if err != nil {
+		respondAccessWorkflowError(c, err)
+		return
+	}
+	allowed, err := workflow.CanView(c.GetInt("user_id"), req)
+	if err != nil {
+		respondAccessWorkflowError(c, err)
+		return
+	}
+	if !allowed {
+		response.Error(c, http.StatusForbidden, "Tidak berwenang melihat permintaan akses ini")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": req})
+}
+
+// ApproveAccessRequest menyetujui tahap aktif permintaan (path :id); tahap terakhir membuat akses berlaku. Admin boleh mengganti cakupan/kedaluwarsa grant (lihat header file).
+func ApproveAccessRequest(c *gin.Context) {
+	decideAccessRequest(c, func(w *service.AccessWorkflowService, actor service.AuditActor, id int, body accessDecisionRequest) (*entity.ReportAccessRequest, error) {
+		override, err := body.override()
+		if err != nil {
+			return nil, errAccessExpiryFormat
+		}
+		return w.Decide(actor, id, true, body.Reason, override)
+	})
+}
+
+// RejectAccessRequest menolak permintaan (path :id) di tahap aktif; alasan wajib.
+func RejectAccessRequest(c *gin.Context) {
+	decideAccessRequest(c, func(w *service.AccessWorkflowService, actor service.AuditActor, id int, body accessDecisionRequest) (*entity.ReportAccessRequest, error) {
+		return w.Decide(actor, id, false, body.Reason, nil)
+	})
+}
+
+// RevokeAccessRequest mencabut akses yang sudah disetujui (path :id); hanya admin, alasan wajib.
+func RevokeAccessRequest(c *gin.Context) {
+	decideAccessRequest(c, func(w *service.AccessWorkflowService, actor service.AuditActor, id int, body accessDecisionRequest) (*entity.ReportAccessRequest, error) {
+		return w.Revoke(actor, id, body.Reason)
+	})
+}
+
+// ReviewAccessRequest menandai akses yang disetujui sudah ditinjau dan memperpanjang masa berlakunya (path :id); hanya admin.
+func ReviewAccessRequest(c *gin.Context) {
+	decideAccessRequest(c, func(w *service.AccessWorkflowService, actor service.AuditActor, id int, body accessDecisionRequest) (*entity.ReportAccessRequest, error) {
+		return w.Review(actor, id, body.Reason)
+	})
+}
+
+// errAccessExpiryFormat dikirim sebagai 400 jika expires_at pada body approve bukan YYYY-MM-DD.
+var errAccessExpiryFormat = errors.New("expires_at harus berformat YYYY-MM-DD")
+
+// decideAccessRequest membaca path :id dan body (opsional), menjalankan aksi workflow, lalu mengirim permintaan terbaru.
+func decideAccessRequest(c *gin.Context, action func(*service.AccessWorkflowService, service.AuditActor, int, accessDecisionRequest) (*entity.ReportAccessRequest, error)) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var body accessDecisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request")
+			return
+		}
+	}
+	req, err := action(service.NewAccessWorkflowService(database.GetDB()), auditActor(c), id, body)
+	if err != nil {
+		respondAccessWorkflowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": req})
+}
@@ -0,0 +1,125 @@
+// File activity_filter.go: parsing query string menjadi repository.ActivityFilter, dipakai semua endpoint dashboard, regional, konten, pencarian, dan my-activity.
+//
+// Parameter multi-nilai boleh diulang (?status=SUCCESS&status=FAILED) atau dipisah koma (?status=SUCCESS,FAILED).
+// parseComparison mengurai permintaan perbandingan periode (compare, compare_start_date, compare_end_date) untuk KPI dan breakdown.
+// Nama parameter lama camelCase (startDate, satkerIds, activityTypes, dateRange) tetap diterima.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"github.com/gin-gonic/gin"
+)
+
+// parseActivityFilter mengurai, menormalkan, dan memvalidasi filter aktivitas dari query. Jika tidak valid, response 400 sudah ditulis dan ok=false.
+//
+// Parameter: start_date, end_date (YYYY-MM-DD), date_range (today|7days|30days|90days|custom; selain custom menimpa start/end), cluster, eselon,
+// root_satker_id, satker_ids, status, type (alias activity_type, activityTypes), province, user_id.
+func parseActivityFilter(c *gin.Context) (repository.ActivityFilter, bool) {
+	filter := repository.ActivityFilter{
+		StartDate:     firstQuery(c, "start_date", "startDate"),
+		EndDate:       firstQuery(c, "end_date", "endDate"),
+		Clusters:      queryValues(c, "cluster"),
+		Eselon:        c.Query("eselon"),
+		Statuses:      queryValues(c, "status"),
+		ActivityTypes: queryValues(c, "type", "activity_type", "activityTypes"),
+		Provinces:     queryValues(c, "province"),
+	}
+
+	var err error
+	if filter.RootSatkerIDs, err = queryIDs(c, "root_satker_id"); err == nil {
+		if filter.SatkerIDs, err = queryIDs(c, "satker_ids", "satkerIds"); err == nil {
+			filter.UserIDs, err = queryIDs(c, "user_id")
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": repository.ErrInvalidFilterID.Error()})
+		return filter, false
+	}
+
+	if start, end, ok := dateRangeBounds(firstQuery(c, "date_range", "dateRange"), time.Now()); ok {
+		filter.StartDate, filter.EndDate = start, end
+	}
+
+	filter = filter.Normalize()
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
+}
+
+// dateRangeBounds mengembalikan tanggal awal–akhir (inklusif, YYYY-MM-DD) untuk preset today/7days/30days/90days; ok=false untuk custom/kosong.
+func dateRangeBounds(dateRange string, now time.Time) (string, string, bool) {
+	days := map[string]int{"today": 0, "7days": 7, "30days": 30, "90days": 90}
+	n, ok := days[dateRange]
+	if !ok {
+		return "", "", false
+	}
+	return now.AddDate(0, 0, -n).Format(sqlbuilder.DateLayout), now.Format(sqlbuilder.DateLayout), true
+}
+
+// firstQuery mengembalikan nilai query pertama yang tidak kosong dari daftar nama parameter (nama baru dulu, lalu alias lama).
+func firstQuery(c *gin.Context, names ...string) string {
+	for _, name := range names {
+		if v := strings.TrimSpace(c.Query(name)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// queryValues mengumpulkan semua nilai parameter (diulang dan/atau dipisah koma) dari daftar nama parameter.
+func queryValues(c *gin.Context, names ...string) []string {
+	var values []string
+	for _, name := range names {
+		for _, raw := range c.QueryArray(name) {
+			for _, v := range strings.Split(raw, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+	}
+	return values
+}
+
+// queryIDs seperti queryValues tetapi mengurai setiap nilai sebagai int64; error jika ada nilai yang bukan angka.
+func queryIDs(c *gin.Context, names ...string) ([]int64, error) {
+	var ids []int64
+	for _, v := range queryValues(c, names...) {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseComparison mengurai compare (previous|custom), compare_start_date, compare_end_date menjadi filter periode pembanding.
+// compare_start_date/compare_end_date tanpa compare dianggap custom. nil jika tidak diminta; response 400 sudah ditulis jika tidak valid (ok=false).
+func parseComparison(c *gin.Context, filter repository.ActivityFilter) (*repository.ActivityFilter, bool) {
+	mode := c.Query("compare")
+	start, end := c.Query("compare_start_date"), c.Query("compare_end_date")
+	if mode == service.CompareNone && (start != "" || end != "") {
+		mode = service.CompareCustom
+	}
+	compare, err := service.ComparisonFilter(filter, mode, start, end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return compare, true
+}
+
+// comparisonPeriod bentuk response rentang periode pembanding.
+func comparisonPeriod(compare *repository.ActivityFilter) gin.H {
+	return gin.H{"start_date": compare.StartDate, "end_date": compare.EndDate}
+}
@@ -0,0 +1,49 @@
+// File admin_account_hygiene_handler.go: HTTP handler akun dorman/yatim untuk admin (tidak login selama ACCOUNT_DORMANT_DAYS hari, satker tidak ada).
+//
+// Endpoint: ListFlaggedAccounts (daftar akun ditandai), DeactivateFlaggedAccounts (nonaktifkan massal, hasil per akun). Laporan berkala tersedia
+// sebagai template laporan account-hygiene.
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ListFlaggedAccounts mengembalikan akun aktif yang ditandai dorman (tidak login) atau yatim (satker tidak ada), login terlama dulu.
+func ListFlaggedAccounts(c *gin.Context) {
+	flagged, err := service.NewAccountHygieneService(database.GetDB()).Flagged(time.Now())
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": flagged})
+}
+
+// DeactivateFlaggedAccounts menonaktifkan akun yang ditandai (body opsional: user_ids; kosong = semua akun ditandai).
+// Akun yang tidak ditandai dilewati; kegagalan satu akun (admin terakhir, akun sendiri) tidak membatalkan akun lain.
+func DeactivateFlaggedAccounts(c *gin.Context) {
+	var req entity.AdminDeactivateFlaggedRequest
+	// Body boleh kosong; hanya tolak jika ada body tapi formatnya salah.
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+
+	results, summary, err := service.NewAccountHygieneService(database.GetDB()).DeactivateFlagged(auditActor(c), req.UserIDs, time.Now())
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+		"results": results,
+	})
+}
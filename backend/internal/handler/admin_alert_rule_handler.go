@@ -0,0 +1,145 @@
+// File admin_alert_rule_handler.go: HTTP handler aturan peringatan admin (tabel alert_rules) dan riwayat firing-nya.
+//
+// Endpoint: ListAlertRules, GetAlertRule, CreateAlertRule, UpdateAlertRule, DeleteAlertRule, ListAlertFirings.
+// Validasi, audit, dan evaluasi (job alert-rules) ada di service.AlertRuleService.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ListAlertRules mengembalikan semua aturan peringatan terurut nama.
+func ListAlertRules(c *gin.Context) {
+	rules, err := service.NewAlertRuleService(database.GetDB()).List()
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules, "total": len(rules)})
+}
+
+// GetAlertRule mengembalikan satu aturan (path :id) beserta firing yang sedang terbuka (open_firing, null jika tidak ada).
+func GetAlertRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	rule, firing, err := service.NewAlertRuleService(database.GetDB()).Get(int64(id))
+	if err != nil {
+		respondAlertRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rule, "open_firing": firing})
+}
+
+// CreateAlertRule membuat aturan peringatan (body: entity.AlertRuleRequest).
+func CreateAlertRule(c *gin.Context) {
+	var req entity.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	rule, err := service.NewAlertRuleService(database.GetDB()).Create(auditActor(c), req)
+	if err != nil {
+		respondAlertRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Aturan peringatan berhasil dibuat", "data": rule})
+}
+
+// UpdateAlertRule mengganti definisi aturan (path :id, body: entity.AlertRuleRequest lengkap).
+func UpdateAlertRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req entity.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	rule, err := service.NewAlertRuleService(database.GetDB()).Update(auditActor(c), int64(id), req)
+	if err != nil {
+		respondAlertRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Aturan peringatan berhasil diperbarui", "data": rule})
+}
+
+// DeleteAlertRule menghapus aturan beserta riwayat firing-nya (path :id).
+func DeleteAlertRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := service.NewAlertRuleService(database.GetDB()).Delete(auditActor(c), int64(id)); err != nil {
+		respondAlertRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Aturan peringatan berhasil dihapus"})
+}
+
+// ListAlertFirings mengembalikan riwayat firing terbaru dulu. Query: rule_id, status (firing|resolved), page, page_size.
+func ListAlertFirings(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeAdmin)))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > config.MaxPageSizeAdmin {
+		pageSize = config.DefaultPageSizeAdmin
+	}
+
+	filter := service.AlertFiringListFilter{Status: c.Query("status"), Page: page, PageSize: pageSize}
+	if !oneOf(filter.Status, entity.AlertFiringStatusFiring, entity.AlertFiringStatusResolved) {
+		response.Error(c, http.StatusBadRequest, "status harus salah satu dari: firing, resolved")
+		return
+	}
+	if v := c.Query("rule_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.Error(c, http.StatusBadRequest, "rule_id tidak valid")
+			return
+		}
+		filter.RuleID = id
+	}
+
+	firings, total, err := service.NewAlertRuleService(database.GetDB()).Firings(filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        firings,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// respondAlertRuleError memetakan error aturan peringatan ke status HTTP.
+func respondAlertRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAlertRuleNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAlertRuleNameEmpty), errors.Is(err, service.ErrAlertRuleMetric),
+		errors.Is(err, service.ErrAlertRuleComparator), errors.Is(err, service.ErrAlertRuleWindow),
+		errors.Is(err, service.ErrAlertRuleCooldown), errors.Is(err, service.ErrAlertRuleThreshold),
+		errors.Is(err, service.ErrAlertRuleFilter), errors.Is(err, service.ErrAlertRuleFilterDates),
+		errors.Is(err, service.ErrAlertRuleNoRecipients), errors.Is(err, service.ErrAlertRuleRecipientRole),
+		errors.Is(err, service.ErrAlertRuleRecipientUser), errors.Is(err, service.ErrAlertRuleTooManyTargets):
+		response.Error(c, http.StatusBadRequest, err.Error())
+	default:
+		response.Internal(c, err)
+	}
+}
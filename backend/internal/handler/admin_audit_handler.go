@@ -0,0 +1,180 @@
+// File admin_audit_handler.go: HTTP handler audit trail untuk admin (prefix /api/admin/audit-events, butuh AuthMiddleware + AdminMiddleware).
+//
+// Endpoint: ListAuditEvents (paginasi + filter), GetAuditEvent (detail termasuk before/after/diff), ExportAuditEvents (CSV dengan filter yang sama),
+// VerifyAuditChain (verifikasi hash chain + checkpoint).
+// Filter query: actor_id, actor (username), action (exact atau prefix "user.*"), target_type, target_id, request_id, ip, start_date, end_date (YYYY-MM-DD, inklusif).
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// parseAuditEventFilter membaca filter audit dari query string; jika ada parameter tidak valid kirim 400 dan kembalikan false.
+func parseAuditEventFilter(c *gin.Context) (service.AuditEventFilter, bool) {
+	filter := service.AuditEventFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		RequestID:  c.Query("request_id"),
+		IP:         c.Query("ip"),
+	}
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			response.Error(c, http.StatusBadRequest, "actor_id tidak valid")
+			return filter, false
+		}
+		filter.ActorID = &id
+	}
+	if v := c.Query("start_date"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "start_date harus berformat YYYY-MM-DD")
+			return filter, false
+		}
+		filter.From = &t
+	}
+	if v := c.Query("end_date"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "end_date harus berformat YYYY-MM-DD")
+			return filter, false
+		}
+		// end_date inklusif: batas atas = awal hari berikutnya.
+		t = t.AddDate(0, 0, 1)
+		filter.Until = &t
+	}
+	return filter, true
+}
+
+// ListAuditEvents mengembalikan audit trail terbaru dulu dengan paginasi (page, page_size) dan filter (lihat header file).
+func ListAuditEvents(c *gin.Context) {
+	filter, ok := parseAuditEventFilter(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeAdmin)))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > config.MaxPageSizeAdmin {
+		pageSize = config.DefaultPageSizeAdmin
+	}
+	filter.Page, filter.PageSize = page, pageSize
+
+	events, total, err := service.NewAuditService(database.GetDB()).List(filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        events,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// GetAuditEvent mengembalikan detail satu audit event (path :id).
+func GetAuditEvent(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	event, err := service.NewAuditService(database.GetDB()).Get(int64(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "Audit event tidak ditemukan")
+			return
+		}
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": event})
+}
+
+// ExportAuditEvents mengirim audit trail sebagai file CSV (filter sama dengan ListAuditEvents, urut id naik, maksimal config.MaxAuditExportRows baris).
+func ExportAuditEvents(c *gin.Context) {
+	filter, ok := parseAuditEventFilter(c)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("audit_events_%s.csv", time.Now().Format("20060102_150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "created_at", "actor_id", "actor_username", "action", "target_type", "target_id", "ip", "user_agent", "request_id", "before", "after", "diff", "prev_hash", "hash"})
+
+	err := service.NewAuditService(database.GetDB()).Export(filter, config.MaxAuditExportRows, func(e entity.AuditEvent) error {
+		actorID := ""
+		if e.ActorID != nil {
+			actorID = strconv.Itoa(*e.ActorID)
+		}
+		return w.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.Format(time.RFC3339),
+			actorID,
+			e.ActorUsername,
+			e.Action,
+			e.TargetType,
+			e.TargetID,
+			e.IP,
+			e.UserAgent,
+			e.RequestID,
+			derefString(e.BeforeData),
+			derefString(e.AfterData),
+			derefString(e.Diff),
+			derefString(e.PrevHash),
+			derefString(e.Hash),
+		})
+	})
+	w.Flush()
+	if err != nil {
+		// Header dan sebagian isi mungkin sudah terkirim; cukup catat di log.
+		c.Error(err)
+	}
+}
+
+// derefString mengembalikan isi pointer string atau "" jika nil.
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// VerifyAuditChain menelusuri hash chain audit_events dan mengembalikan laporan (valid, jumlah baris, tautan rusak pertama). Jika AUDIT_CHECKPOINT_KEY diset, checkpoint di file juga dicocokkan.
+func VerifyAuditChain(c *gin.Context) {
+	audit := service.NewAuditService(database.GetDB())
+	report, err := audit.VerifyChain()
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	if key := config.AuditCheckpointKey(); report.Valid && key != nil {
+		if err := audit.VerifyCheckpoints(report, config.AuditCheckpointFile(), key); err != nil {
+			response.Internal(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
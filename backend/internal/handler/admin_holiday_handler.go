@@ -0,0 +1,77 @@
+// File admin_holiday_handler.go: HTTP handler kalender hari libur nasional (ref_holidays) untuk admin.
+//
+// Endpoint: ListHolidays, CreateHoliday, DeleteHoliday. Validasi dan audit ada di service.HolidayService.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ListHolidays mengembalikan daftar hari libur terurut tanggal. Query year (opsional) membatasi ke satu tahun.
+func ListHolidays(c *gin.Context) {
+	year := 0
+	if v := c.Query("year"); v != "" {
+		y, err := strconv.Atoi(v)
+		if err != nil || y <= 0 {
+			response.Error(c, http.StatusBadRequest, "year harus berupa angka tahun")
+			return
+		}
+		year = y
+	}
+	holidays, err := service.NewHolidayService(database.GetDB()).List(year)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": holidays, "total": len(holidays)})
+}
+
+// CreateHoliday menambahkan hari libur (body: holiday_date YYYY-MM-DD, name).
+func CreateHoliday(c *gin.Context) {
+	var req entity.CreateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	holiday, err := service.NewHolidayService(database.GetDB()).Create(auditActor(c), req.HolidayDate, req.Name)
+	if err != nil {
+		respondHolidayError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Hari libur berhasil ditambahkan", "data": holiday})
+}
+
+// DeleteHoliday menghapus hari libur (path :id).
+func DeleteHoliday(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := service.NewHolidayService(database.GetDB()).Delete(auditActor(c), int64(id)); err != nil {
+		respondHolidayError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Hari libur berhasil dihapus"})
+}
+
+// respondHolidayError memetakan error kalender hari libur ke status HTTP.
+func respondHolidayError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidHolidayDate), errors.Is(err, service.ErrHolidayNameEmpty):
+		response.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrHolidayExists):
+		response.Error(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrHolidayNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	default:
+		response.Internal(c, err)
+	}
+}
@@ -0,0 +1,121 @@
+// File admin_profile_link_handler.go: HTTP handler rekonsiliasi akun (users) ↔ profil aktivitas (user_profiles) untuk admin.
+//
+// Endpoint: GetProfileLinkReconciliation (daftar akun belum tertaut beserta kandidat: matched / ambiguous / unmatched), AutoLinkProfiles (tautkan semua yang cocok unik),
+// LinkUserProfile (tautkan manual), UnlinkUserProfile (lepas tautan). Logika pencocokan dan audit ada di service.ProfileLinkService.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// GetProfileLinkReconciliation mengembalikan akun yang belum tertaut ke profil aktivitas. Query status: matched, ambiguous, unmatched (kosong = ambiguous + unmatched).
+func GetProfileLinkReconciliation(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", "all", service.ProfileMatchUnique, service.ProfileMatchAmbiguous, service.ProfileMatchNone:
+	default:
+		response.Error(c, http.StatusBadRequest, "status harus salah satu dari: all, matched, ambiguous, unmatched")
+		return
+	}
+
+	filter := status
+	if status == "all" || status == "" {
+		filter = ""
+	}
+	items, err := service.NewProfileLinkService(database.GetDB()).Reconcile(filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	// Default: hanya yang perlu penanganan manual.
+	if status == "" {
+		unresolved := make([]service.ProfileReconciliationItem, 0, len(items))
+		for _, it := range items {
+			if it.Status != service.ProfileMatchUnique {
+				unresolved = append(unresolved, it)
+			}
+		}
+		items = unresolved
+	}
+
+	counts := map[string]int{service.ProfileMatchUnique: 0, service.ProfileMatchAmbiguous: 0, service.ProfileMatchNone: 0}
+	for _, it := range items {
+		counts[it.Status]++
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":   items,
+		"total":  len(items),
+		"counts": counts,
+	})
+}
+
+// AutoLinkProfiles menautkan semua akun yang punya tepat satu kandidat profil (email lalu nama). Query dry_run=true untuk simulasi.
+func AutoLinkProfiles(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	summary, unresolved, err := service.NewProfileLinkService(database.GetDB()).AutoLink(auditActor(c), dryRun)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"summary":    summary,
+		"unresolved": unresolved,
+	})
+}
+
+// LinkUserProfile menautkan user (path :id) ke profil aktivitas (body: profile_id) secara manual.
+func LinkUserProfile(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req entity.LinkProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user, err := service.NewProfileLinkService(database.GetDB()).Link(auditActor(c), id, req.ProfileID)
+	if err != nil {
+		respondProfileLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Profil aktivitas berhasil ditautkan", "data": user})
+}
+
+// UnlinkUserProfile melepas tautan profil aktivitas dari user (path :id).
+func UnlinkUserProfile(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	user, err := service.NewProfileLinkService(database.GetDB()).Unlink(auditActor(c), id)
+	if err != nil {
+		respondProfileLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tautan profil aktivitas dilepas", "data": user})
+}
+
+// respondProfileLinkError memetakan error penautan ke status HTTP.
+func respondProfileLinkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAccountNotFound):
+		response.Error(c, http.StatusNotFound, "User tidak ditemukan")
+	case errors.Is(err, service.ErrProfileNotFound):
+		response.Error(c, http.StatusNotFound, "Profil aktivitas tidak ditemukan")
+	case errors.Is(err, service.ErrProfileAlreadyLinked):
+		response.Error(c, http.StatusConflict, "Profil aktivitas sudah tertaut ke akun lain")
+	default:
+		response.Internal(c, err)
+	}
+}
@@ -0,0 +1,69 @@
+// File admin_security_alert_handler.go: HTTP handler daftar peringatan keamanan (tabel security_alerts) untuk admin.
+//
+// Peringatan dibuat oleh service.SecurityAlertService (job security-alerts dan cmd/import); handler ini hanya membaca.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSecurityAlerts mengembalikan peringatan keamanan terbaru dulu. Query: rule (off_hours|unusual_location), user_id (id profil aktivitas),
+// start_date, end_date (YYYY-MM-DD, waktu aktivitas), page, page_size.
+func ListSecurityAlerts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeAdmin)))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > config.MaxPageSizeAdmin {
+		pageSize = config.DefaultPageSizeAdmin
+	}
+
+	filter := service.SecurityAlertListFilter{
+		Rule:      c.Query("rule"),
+		StartDate: c.Query("start_date"),
+		EndDate:   c.Query("end_date"),
+		Page:      page,
+		PageSize:  pageSize,
+	}
+	if !oneOf(filter.Rule, entity.SecurityRuleOffHours, entity.SecurityRuleUnusualLocation) {
+		response.Error(c, http.StatusBadRequest, "rule harus salah satu dari: off_hours, unusual_location")
+		return
+	}
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.Error(c, http.StatusBadRequest, "user_id tidak valid")
+			return
+		}
+		filter.UserID = id
+	}
+
+	alerts, total, err := service.NewSecurityAlertService(database.GetDB()).List(filter)
+	if errors.Is(err, sqlbuilder.ErrInvalidDate) {
+		response.Error(c, http.StatusBadRequest, "start_date dan end_date harus berformat YYYY-MM-DD")
+		return
+	}
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        alerts,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
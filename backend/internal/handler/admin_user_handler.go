@@ -0,0 +1,261 @@
+// File admin_user_handler.go: HTTP handler manajemen user untuk admin (prefix /api/admin/users, butuh AuthMiddleware + AdminMiddleware).
+//
+// Endpoint: ListAdminUsers (paginasi + filter role, is_active, report_access_status, q), GetAdminUser, CreateAdminUser, UpdateAdminUser,
+// DeactivateAdminUser (soft delete), ResetAdminUserPassword (reset paksa; user wajib ganti password saat login berikutnya),
+// ImportAdminUsers (provisioning massal dari CSV/XLSX dengan hasil per baris), ResendAdminUserActivation (terbitkan ulang link aktivasi).
+// Logika bisnis dan pencatatan audit ada di service.UserAdminService; handler hanya parsing request dan memetakan error ke status HTTP.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// parseIDParam membaca path param bilangan bulat positif; jika tidak valid kirim 400 dan kembalikan false.
+func parseIDParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondUserAdminError memetakan error dari UserAdminService ke status HTTP (404, 409, 400, lainnya 500).
+func respondUserAdminError(c *gin.Context, err error) {
+	if respondPasswordPolicyError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrAccountNotFound):
+		response.Error(c, http.StatusNotFound, "User tidak ditemukan")
+	case errors.Is(err, service.ErrUsernameExists):
+		response.Error(c, http.StatusConflict, "Username sudah digunakan")
+	case errors.Is(err, service.ErrEmailExists):
+		response.Error(c, http.StatusConflict, "Email sudah digunakan")
+	case errors.Is(err, service.ErrInvalidEmail):
+		response.Error(c, http.StatusBadRequest, "Email harus menggunakan domain @bpk.go.id")
+	case errors.Is(err, service.ErrSatkerNotFound):
+		response.Error(c, http.StatusBadRequest, "Satker tidak ditemukan")
+	case errors.Is(err, service.ErrInvalidRole):
+		response.Error(c, http.StatusBadRequest, "Role tidak valid")
+	case errors.Is(err, service.ErrReportAccessStatusManaged):
+		response.Error(c, http.StatusBadRequest, "report_access_status tidak bisa diubah langsung; gunakan workflow akses laporan (approve/reject/revoke)")
+	case errors.Is(err, service.ErrSelfModification):
+		response.Error(c, http.StatusConflict, "Admin tidak dapat menonaktifkan atau menurunkan role akun sendiri")
+	case errors.Is(err, service.ErrLastAdmin):
+		response.Error(c, http.StatusConflict, "Harus ada minimal satu admin aktif")
+	case errors.Is(err, service.ErrActivationNotPending):
+		response.Error(c, http.StatusConflict, "User tidak sedang menunggu aktivasi")
+	case errors.Is(err, service.ErrActivationEmailFailed):
+		response.Error(c, http.StatusBadGateway, "Link aktivasi baru dibuat, tetapi email gagal dikirim; coba lagi")
+	default:
+		response.Internal(c, err)
+	}
+}
+
+// ListAdminUsers mengembalikan daftar user dengan paginasi. Query: page, page_size, role, is_active (true/false), report_access_status, q (cari username/email/nama).
+func ListAdminUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeAdmin)))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > config.MaxPageSizeAdmin {
+		pageSize = config.DefaultPageSizeAdmin
+	}
+
+	filter := service.UserListFilter{
+		Role:               c.Query("role"),
+		ReportAccessStatus: c.Query("report_access_status"),
+		Search:             c.Query("q"),
+		Page:               page,
+		PageSize:           pageSize,
+	}
+	if filter.Role != "" && !entity.IsValidRole(filter.Role) {
+		response.Error(c, http.StatusBadRequest, "Role tidak valid")
+		return
+	}
+	if filter.ReportAccessStatus != "" && !entity.IsValidReportAccessStatus(filter.ReportAccessStatus) {
+		response.Error(c, http.StatusBadRequest, "report_access_status harus salah satu dari: none, pending, approved, rejected")
+		return
+	}
+	if v := c.Query("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "is_active harus true atau false")
+			return
+		}
+		filter.IsActive = &active
+	}
+
+	users, total, err := service.NewUserAdminService(database.GetDB()).List(filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        users,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// GetAdminUser mengembalikan detail satu user (path :id).
+func GetAdminUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	user, err := service.NewUserAdminService(database.GetDB()).Get(id)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+// CreateAdminUser membuat user baru (body: AdminCreateUserRequest). Jika password tidak dikirim, response memuat temporary_password yang harus diteruskan ke user.
+func CreateAdminUser(c *gin.Context) {
+	var req entity.AdminCreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user, tempPassword, err := service.NewUserAdminService(database.GetDB()).Create(auditActor(c), req)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	resp := gin.H{"message": "User berhasil dibuat", "data": user}
+	if tempPassword != "" {
+		resp["temporary_password"] = tempPassword
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateAdminUser mengubah user (path :id, body: AdminUpdateUserRequest; hanya field yang dikirim yang diubah).
+func UpdateAdminUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req entity.AdminUpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user, err := service.NewUserAdminService(database.GetDB()).Update(auditActor(c), id, req)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User berhasil diperbarui", "data": user})
+}
+
+// DeactivateAdminUser menonaktifkan user (path :id). Soft delete: baris users tetap ada agar riwayat aktivitas dan audit tetap utuh.
+func DeactivateAdminUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	user, err := service.NewUserAdminService(database.GetDB()).Deactivate(auditActor(c), id)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User berhasil dinonaktifkan", "data": user})
+}
+
+// ResetAdminUserPassword mereset password user (path :id, body opsional: new_password). Tanpa new_password dibuat password sementara yang dikembalikan di response.
+func ResetAdminUserPassword(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req entity.AdminResetPasswordRequest
+	// Body boleh kosong; hanya tolak jika ada body tapi formatnya salah.
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+
+	user, tempPassword, err := service.NewUserAdminService(database.GetDB()).ResetPassword(auditActor(c), id, req.NewPassword)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	resp := gin.H{"message": "Password user berhasil direset; user wajib mengganti password saat login berikutnya", "data": user}
+	if tempPassword != "" {
+		resp["temporary_password"] = tempPassword
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ResendAdminUserActivation menerbitkan ulang link aktivasi (path :id) untuk user hasil provisioning yang belum aktivasi; link lama tidak berlaku lagi.
+func ResendAdminUserActivation(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	user, err := service.NewUserProvisioningService(database.GetDB(), nil).ResendActivation(auditActor(c), id)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Link aktivasi baru telah dikirim ke email user", "data": user})
+}
+
+// ImportAdminUsers membuat/memperbarui user secara massal dari file CSV/XLSX (multipart field "file"; kolom username, email, full_name, role, satker).
+// Form opsional: send_activation=true (kirim link aktivasi ke user baru), dry_run=true (validasi tanpa menyimpan). Response: summary + hasil per baris.
+func ImportAdminUsers(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxProvisionFileSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "File wajib diunggah (field: file, maksimal 5 MB)")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	defer file.Close()
+
+	rows, err := service.ParseProvisionFile(fileHeader.Filename, file)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		response.Error(c, http.StatusBadRequest, "File tidak berisi baris data")
+		return
+	}
+
+	opts := service.ProvisionOptions{}
+	opts.SendActivation, _ = strconv.ParseBool(c.PostForm("send_activation"))
+	opts.DryRun, _ = strconv.ParseBool(c.PostForm("dry_run"))
+
+	results, summary := service.NewUserProvisioningService(database.GetDB(), nil).Provision(auditActor(c), rows, opts)
+	c.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+		"results": results,
+	})
+}
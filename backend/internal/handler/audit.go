@@ -0,0 +1,28 @@
+// File audit.go: helper audit trail untuk handler — membangun service.AuditActor dari request dan mencatat kejadian ke audit_events.
+package handler
+
+import (
+	"log"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// auditActor membangun service.AuditActor dari request: user_id (diset AuthMiddleware), IP client, User-Agent, dan request_id (diset RequestID middleware).
+func auditActor(c *gin.Context) service.AuditActor {
+	actor := service.AuditActor{IP: c.ClientIP(), UserAgent: c.Request.UserAgent(), RequestID: c.GetString("request_id")}
+	if v, ok := c.Get("user_id"); ok {
+		if id, ok := v.(int); ok {
+			actor.UserID = &id
+		}
+	}
+	return actor
+}
+
+// recordAudit mencatat satu kejadian audit di luar transaksi data (mis. login, unduh laporan). Gagal mencatat hanya di-log agar tidak menggagalkan request.
+func recordAudit(actor service.AuditActor, entry service.AuditEntry) {
+	if err := service.NewAuditService(database.GetDB()).Record(actor, entry); err != nil {
+		log.Printf("[ERROR] audit %s: %v", entry.Action, err)
+	}
+}
@@ -1,25 +1,45 @@
 // File auth_handler.go: HTTP handler untuk endpoint autentikasi Dashboard Monitoring BIDICS BPK RI.
 //
 // Endpoint: Login (username/email + password → JWT), Register (email @bpk.go.id, konfirmasi password),
-// ForgotPassword (reset by username), Logout (pesan sukses; token dihapus di client), ChangePassword (user login, old + new + confirm).
+// ForgotPassword (reset by username), Logout (cabut sesi login token; token juga dihapus di client), ChangePassword (user login, old + new + confirm),
+// ActivateAccount (aktivasi akun hasil provisioning massal lewat token sekali pakai + password baru).
+// Semua password baru divalidasi service.PasswordPolicy (panjang, kelas karakter, daftar password umum, riwayat N password terakhir).
 // Request/response memakai entity.LoginRequest, RegisterRequest, ForgotPasswordRequest, ChangePasswordRequest dan response JSON.
 package handler
 
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bpk-ri/dashboard-monitoring/internal/auth"
 	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
 	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-// Login memproses login: bind body ke LoginRequest, cari user by username atau email, verifikasi bcrypt, update last_login, generate JWT, kembalikan LoginResponse.
+// respondPasswordPolicyError mengirim 400 jika err berasal dari kebijakan password (PasswordPolicyError / ErrPasswordReused) dan mengembalikan true; selain itu false (pemanggil menangani error lain).
+func respondPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *service.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Error(), "reasons": policyErr.Reasons})
+		return true
+	}
+	if errors.Is(err, service.ErrPasswordReused) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password baru tidak boleh sama dengan password yang pernah dipakai sebelumnya"})
+		return true
+	}
+	return false
+}
+
+// Login memproses login: bind body ke LoginRequest, cari user by username atau email, verifikasi bcrypt, update last_login, buat sesi login, generate JWT terikat sesi, kembalikan LoginResponse.
+// Jika password kedaluwarsa (PASSWORD_MAX_AGE) atau ditandai wajib ganti, token yang dikembalikan hanya berlaku untuk ganti password (must_change_password = true).
 func Login(c *gin.Context) {
 	var req entity.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -36,21 +56,50 @@ func Login(c *gin.Context) {
 	} else {
 		err = db.Where("username = ? AND is_active = ?", req.Username, true).First(&user).Error
 	}
+	// Login gagal dicatat dengan username yang dicoba (belum ada user_id terautentikasi).
+	failedActor := auditActor(c)
+	failedActor.Username = req.Username
 	if err != nil {
+		recordAudit(failedActor, service.AuditEntry{
+			Action:     service.AuditActionLoginFailed,
+			TargetType: service.AuditTargetUser,
+			After:      gin.H{"reason": "unknown_or_inactive_user"},
+		})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Username/Email atau password salah"})
 		return
 	}
 	// Verifikasi password plain dengan hash di DB; pesan error sama agar tidak bocor info
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		recordAudit(failedActor, service.AuditEntry{
+			Action:     service.AuditActionLoginFailed,
+			TargetType: service.AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			After:      gin.H{"reason": "invalid_password"},
+		})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Username/Email atau password salah"})
 		return
 	}
 
 	now := time.Now()
 	user.LastLogin = &now
+	// Password kedaluwarsa → tandai wajib ganti agar tetap dipaksa walau PASSWORD_MAX_AGE nanti diubah.
+	mustChange := service.NewPasswordPolicy(db).RequiresChange(&user)
+	user.MustChangePassword = mustChange
 	db.Save(&user) // Update waktu login terakhir
 
-	token, err := auth.GenerateToken(user.ID, user.Role)
+	// Setiap login = satu sesi baru; token diikat ke sesi ini (bisa dilihat/dicabut di /api/account/sessions).
+	session, err := service.NewSessionService(db).Create(user.ID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	var token string
+	if mustChange {
+		token, err = auth.GeneratePasswordChangeToken(user.ID, user.Role, session.ID)
+	} else {
+		token, err = auth.GenerateToken(user.ID, user.Role, session.ID)
+	}
 	if err != nil {
 		// JWT_SECRET tidak diset di env → 503 Server misconfiguration
 		if errors.Is(err, auth.ErrJWTSecretNotSet) {
@@ -61,14 +110,28 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	actor := auditActor(c)
+	actor.UserID, actor.Username = &user.ID, user.Username
+	recordAudit(actor, service.AuditEntry{
+		Action:     service.AuditActionLogin,
+		TargetType: service.AuditTargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+		After:      gin.H{"must_change_password": mustChange, "session_id": session.ID, "device": session.Device},
+	})
+
+	message := "Login berhasil"
+	if mustChange {
+		message = "Password Anda harus diganti sebelum melanjutkan"
+	}
 	c.JSON(http.StatusOK, entity.LoginResponse{
-		Token:   token,
-		User:    user,
-		Message: "Login berhasil",
+		Token:              token,
+		User:               user,
+		Message:            message,
+		MustChangePassword: mustChange,
 	})
 }
 
-// Register memproses registrasi: bind body, validasi email @bpk.go.id dan konfirmasi password, cek duplikat username/email, hash password, INSERT user baru (role user, is_active true).
+// Register memproses registrasi: bind body, validasi email @bpk.go.id, konfirmasi password, dan kebijakan password, cek duplikat username/email, hash password, INSERT user baru (role user, is_active true).
 func Register(c *gin.Context) {
 	var req entity.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -99,24 +162,31 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		response.Internal(c, err)
-		return
-	}
-
 	newUser := entity.User{
-		Username:     req.Username,
-		PasswordHash: string(hashedPassword),
-		Role:         "user",
-		FullName:     req.FullName,
-		Email:        req.Email,
-		IsActive:     true,
+		Username: req.Username,
+		Role:     "user",
+		FullName: req.FullName,
+		Email:    req.Email,
+		IsActive: true,
+	}
+	if err := service.NewPasswordPolicy(db).ApplyNewPassword(db, &newUser, req.Password); err != nil {
+		if !respondPasswordPolicyError(c, err) {
+			response.Internal(c, err)
+		}
+		return
 	}
 	if err := db.Create(&newUser).Error; err != nil {
 		response.Internal(c, err)
 		return
 	}
+	actor := auditActor(c)
+	actor.UserID, actor.Username = &newUser.ID, newUser.Username
+	recordAudit(actor, service.AuditEntry{
+		Action:     service.AuditActionRegister,
+		TargetType: service.AuditTargetUser,
+		TargetID:   strconv.Itoa(newUser.ID),
+		After:      newUser,
+	})
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Akun berhasil dibuat",
@@ -124,7 +194,7 @@ func Register(c *gin.Context) {
 	})
 }
 
-// ForgotPassword memproses reset password: bind body, validasi new = confirm, cari user by username, hash password baru, simpan ke DB. Tidak butuh JWT (idealnya dikombinasi verifikasi email/OTP).
+// ForgotPassword memproses reset password: bind body, validasi new = confirm, cari user by username, validasi kebijakan password + riwayat, simpan hash baru dan catat hash lama ke password_history, cabut semua sesi login. Tidak butuh JWT (idealnya dikombinasi verifikasi email/OTP).
 func ForgotPassword(c *gin.Context) {
 	var req entity.ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -144,30 +214,52 @@ func ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		response.Internal(c, err)
-		return
-	}
-	user.PasswordHash = string(hashedPassword)
-	if err := db.Save(&user).Error; err != nil {
-		response.Internal(c, err)
+	if err := saveNewPassword(db, &user, req.NewPassword, service.SessionRevokedPasswordReset); err != nil {
+		if !respondPasswordPolicyError(c, err) {
+			response.Internal(c, err)
+		}
 		return
 	}
 
+	// Reset tanpa login: pelaku tidak terautentikasi; target = user yang password-nya direset.
+	recordAudit(auditActor(c), service.AuditEntry{
+		Action:     service.AuditActionPasswordForgot,
+		TargetType: service.AuditTargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Password berhasil diperbarui. Silakan login.",
 	})
 }
 
-// Logout mengembalikan JSON pesan sukses logout. Token tidak di-invalidate di server; client harus hapus token sendiri.
+// Logout mengembalikan JSON pesan sukses logout. Jika header Authorization berisi token valid, sesi login token tersebut dicabut (token tidak bisa dipakai lagi)
+// dan logout dicatat ke audit trail atas nama user tersebut. Client tetap harus menghapus token sendiri.
 func Logout(c *gin.Context) {
+	if tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		if claims, err := auth.ParseToken(strings.TrimSpace(tokenString)); err == nil {
+			if claims.SessionID() != "" {
+				if _, err := service.NewSessionService(database.GetDB()).Revoke(claims.UserID, claims.SessionID(), service.SessionRevokedLogout); err != nil && !errors.Is(err, service.ErrSessionNotFound) {
+					response.Internal(c, err)
+					return
+				}
+			}
+			actor := auditActor(c)
+			actor.UserID = &claims.UserID
+			recordAudit(actor, service.AuditEntry{
+				Action:     service.AuditActionLogout,
+				TargetType: service.AuditTargetUser,
+				TargetID:   strconv.Itoa(claims.UserID),
+				After:      gin.H{"session_id": claims.SessionID()},
+			})
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logout berhasil",
 	})
 }
 
-// ChangePassword mengubah password user yang login: bind body, ambil user_id dari context (middleware auth), validasi new=confirm dan new!=old, verifikasi old password, hash baru, save.
+// ChangePassword mengubah password user yang login: bind body, ambil user_id dari context (middleware auth), validasi new=confirm dan new!=old, verifikasi old password, validasi kebijakan + riwayat, hash baru, save (juga menghapus tanda wajib ganti password), cabut semua sesi login termasuk sesi saat ini.
 func ChangePassword(c *gin.Context) {
 	var req entity.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -202,18 +294,69 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		response.Internal(c, err)
+	if err := saveNewPassword(db, &user, req.NewPassword, service.SessionRevokedPasswordChange); err != nil {
+		if !respondPasswordPolicyError(c, err) {
+			response.Internal(c, err)
+		}
 		return
 	}
-	user.PasswordHash = string(hashedPassword)
-	if err := db.Save(&user).Error; err != nil {
-		response.Internal(c, err)
+
+	recordAudit(auditActor(c), service.AuditEntry{
+		Action:     service.AuditActionPasswordChange,
+		TargetType: service.AuditTargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Kata sandi berhasil diubah. Silakan login kembali.",
+	})
+}
+
+// ActivateAccount mengaktifkan akun hasil provisioning massal: bind body (token, new_password, confirm_password), verifikasi token sekali pakai, tetapkan password sesuai kebijakan, aktifkan user.
+func ActivateAccount(c *gin.Context) {
+	var req entity.ActivateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if req.NewPassword != req.ConfirmPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password dan konfirmasi password tidak cocok"})
+		return
+	}
+
+	_, err := service.NewUserProvisioningService(database.GetDB(), nil).ActivateAccount(auditActor(c), req.Token, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidActivationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Link aktivasi tidak valid atau sudah kedaluwarsa"})
+			return
+		}
+		if errors.Is(err, service.ErrAccountDeactivated) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Akun telah dinonaktifkan admin; hubungi admin untuk mengaktifkannya kembali"})
+			return
+		}
+		if !respondPasswordPolicyError(c, err) {
+			response.Internal(c, err)
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Kata sandi berhasil diubah. Silakan login kembali.",
+		"message": "Akun berhasil diaktifkan. Silakan login.",
+	})
+}
+
+// saveNewPassword menerapkan kebijakan password ke user lalu menyimpan perubahan dalam satu transaksi (hash baru + riwayat hash lama) dan mencabut semua sesi login user dengan alasan revokeReason.
+func saveNewPassword(db *gorm.DB, user *entity.User, password, revokeReason string) error {
+	policy := service.NewPasswordPolicy(db)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := policy.ApplyNewPassword(tx, user, password); err != nil {
+			return err
+		}
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		_, err := service.NewSessionService(tx).RevokeAll(user.ID, "", revokeReason)
+		return err
 	})
 }
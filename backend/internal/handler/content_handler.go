@@ -2,82 +2,97 @@
 //
 // Endpoint: peringkat dashboard (GetDashboardRankings), penggunaan modul pencarian (GetSearchModuleUsage),
 // statistik ekspor (GetExportStats), intensi operasional (GetOperationalIntents), chart Global Economics (GetGlobalEconomicsChart).
-// Query params umum: start_date, end_date, cluster (opsional), limit (default 10 untuk intensi).
+// Filter aktivitas diurai oleh parseActivityFilter (activity_filter.go); limit untuk intensi (default/max dari config).
+// Hasil query di-cache per filter (lihat repository/cached_repository.go); response membawa ETag dan mendukung If-None-Match (304).
 package handler
 
 import (
-	"net/http"
+	"strconv"
 
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
 	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
 	"github.com/bpk-ri/dashboard-monitoring/internal/response"
 	"github.com/gin-gonic/gin"
 )
 
-// GetDashboardRankings mengembalikan peringkat penggunaan dashboard (per kluster analitik) dalam rentang start_date–end_date.
+// GetDashboardRankings mengembalikan peringkat penggunaan dashboard (per kluster analitik) sesuai filter aktivitas.
 func GetDashboardRankings(c *gin.Context) {
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	rankings, err := repository.GetDashboardRankings(startDate, endDate)
+	rankings, err := repository.GetDashboardRankings(filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": rankings})
+	response.CachedJSON(c, gin.H{"data": rankings})
 }
 
-// GetSearchModuleUsage mengembalikan statistik penggunaan modul pencarian; filter oleh start_date, end_date, cluster (opsional).
+// GetSearchModuleUsage mengembalikan statistik penggunaan modul pencarian sesuai filter aktivitas.
 func GetSearchModuleUsage(c *gin.Context) {
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-	cluster := c.Query("cluster")
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	modules, err := repository.GetSearchModuleUsage(startDate, endDate, cluster)
+	modules, err := repository.GetSearchModuleUsage(filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": modules})
+	response.CachedJSON(c, gin.H{"data": modules})
 }
 
-// GetExportStats mengembalikan statistik pemantauan ekspor/unduhan data dalam rentang tanggal; cluster opsional.
+// GetExportStats mengembalikan statistik pemantauan ekspor/unduhan data sesuai filter aktivitas.
 func GetExportStats(c *gin.Context) {
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-	cluster := c.Query("cluster")
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	stats, err := repository.GetExportStats(startDate, endDate, cluster)
+	stats, err := repository.GetExportStats(filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": stats})
+	response.CachedJSON(c, gin.H{"data": stats})
 }
 
-// GetOperationalIntents mengembalikan top N intensi operasional; query: start_date, end_date, cluster (opsional), limit (default "10").
+// GetOperationalIntents mengembalikan top N intensi operasional; query limit (default/max dari config).
 func GetOperationalIntents(c *gin.Context) {
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-	limit := c.DefaultQuery("limit", "10")
-	cluster := c.Query("cluster")
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(config.DefaultLimit)))
+	if err != nil || limit <= 0 {
+		limit = config.DefaultLimit
+	}
+	if limit > config.MaxLimit {
+		limit = config.MaxLimit
+	}
 
-	intents, err := repository.GetOperationalIntents(startDate, endDate, cluster, limit)
+	intents, err := repository.GetOperationalIntents(filter, limit)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": intents})
+	response.CachedJSON(c, gin.H{"data": intents})
 }
 
-// GetGlobalEconomicsChart mengembalikan data chart Global Economics (NTPN, KOMDLNG, dll.) untuk rentang start_date–end_date.
+// GetGlobalEconomicsChart mengembalikan data chart Global Economics (NTPN, KOMDLNG, dll.) sesuai filter aktivitas.
 func GetGlobalEconomicsChart(c *gin.Context) {
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	data, err := repository.GetGlobalEconomicsChart(startDate, endDate)
+	data, err := repository.GetGlobalEconomicsChart(filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": data})
+	response.CachedJSON(c, gin.H{"data": data})
 }
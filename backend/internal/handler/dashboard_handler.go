@@ -1,12 +1,14 @@
 // File dashboard_handler.go: HTTP handler untuk dashboard monitoring aktivitas (activity log).
 //
 // Endpoint: GetDashboardStats (ringkas), GetActivities (daftar paginated + DTO), GetChartData (hourly/cluster/province),
-// GetAccessSuccessRate, GetProvinces, GetLokasi, GetUnits, GetClusters, GetHourlyDataForSatker, GetTopContributors, GetLogoutErrors.
-// Query params umum: start_date, end_date, cluster, eselon, root_satker_id (filter pohon satker), page, page_size, limit.
-// parseRegionalQueryParams mengurai filter tanggal/cluster/eselon/root_satker_id dan mengembalikan pointer + slice satkerIds untuk repo.
+// GetAccessSuccessRate, GetActivityTimeSeries, GetProvinces, GetLokasi, GetUnits, GetClusters, GetHourlyDataForSatker, GetActivityHeatmap, GetTopContributors, GetLogoutErrors.
+// Live feed aktivitas (StreamActivities, SSE) ada di dashboard_stream_handler.go.
+// Filter aktivitas diurai oleh parseActivityFilter (activity_filter.go); query lain: page, page_size, limit.
+// Hasil agregat di-cache per filter (getActivityLogRepo); response sukses membawa ETag dan mendukung If-None-Match (304).
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -14,87 +16,55 @@ import (
 	"github.com/bpk-ri/dashboard-monitoring/internal/dto"
 	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
 	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
-// parseRegionalQueryParams mengurai start_date, end_date, cluster, eselon, root_satker_id dari query. Jika root_satker_id valid: ambil ID root + anak via GetSatkerIdsUnderRoot, kembalikan satkerIds dan eselonPtr=nil. Jika tidak: kembalikan eselonPtr jika eselon diisi, satkerIds=nil.
-func parseRegionalQueryParams(c *gin.Context, repo repository.ActivityLogRepository) (startPtr, endPtr, clusterPtr, eselonPtr *string, satkerIds []int64) {
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-	cluster := c.Query("cluster")
-	eselon := c.Query("eselon")
-	rootIDStr := c.Query("root_satker_id")
-
-	if startDate != "" {
-		startPtr = &startDate
-	}
-	if endDate != "" {
-		endPtr = &endDate
-	}
-	if cluster != "" {
-		clusterPtr = &cluster
-	}
-	// Prioritas: root_satker_id → filter by pohon satker (root + semua anak); return satkerIds, eselonPtr=nil
-	if rootIDStr != "" {
-		rootID, err := strconv.ParseInt(rootIDStr, 10, 64)
-		if err == nil {
-			ids, err := repo.GetSatkerIdsUnderRoot(rootID)
-			if err == nil && len(ids) > 0 {
-				return startPtr, endPtr, clusterPtr, nil, ids
-			}
-		}
-	}
-	if eselon != "" {
-		eselonPtr = &eselon
-	}
-	return startPtr, endPtr, clusterPtr, eselonPtr, nil
-}
-
 // GetDashboardStats mengembalikan statistik ringkas: total user unik, login sukses (SUCCESS), total aktivitas, error logout (FAILED), jam tersibuk (0–23).
+// Dengan compare (parseComparison), response memuat comparison_period dan comparison (nilai pembanding + selisih absolut/persen per KPI).
 func GetDashboardStats(c *gin.Context) {
 	repo := getActivityLogRepo()
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
-
-	totalUsers, err := repo.GetUniqueUsersCount(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
-	if err != nil {
-		response.Internal(c, err)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
 		return
 	}
-
-	successLogins, err := repo.GetCountByStatus("SUCCESS", startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
-	if err != nil {
-		response.Internal(c, err)
+	compare, ok := parseComparison(c, filter)
+	if !ok {
 		return
 	}
 
-	totalActivities, err := repo.GetTotalCount(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	stats, err := service.LoadDashboardStats(repo, filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	logoutErrors, err := repo.GetCountByStatus("FAILED", startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
-	if err != nil {
-		response.Internal(c, err)
-		return
+	body := dashboardStatsBody(stats)
+	if compare != nil {
+		previous, err := service.LoadDashboardStats(repo, *compare)
+		if err != nil {
+			response.Internal(c, err)
+			return
+		}
+		body["comparison_period"] = comparisonPeriod(compare)
+		body["comparison"] = service.CompareDashboardStats(stats, previous)
 	}
 
-	busiestHour, count, err := repo.GetBusiestHour(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
-	if err != nil {
-		response.Internal(c, err)
-		return
-	}
+	response.CachedJSON(c, body)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"total_users":      totalUsers,
-		"success_logins":   successLogins,
-		"total_activities": totalActivities,
-		"logout_errors":    logoutErrors,
+// dashboardStatsBody menyusun body KPI dashboard; dipakai GetDashboardStats dan event kpi StreamActivities.
+func dashboardStatsBody(stats *service.DashboardStats) gin.H {
+	return gin.H{
+		"total_users":      stats.TotalUsers,
+		"success_logins":   stats.SuccessLogins,
+		"total_activities": stats.TotalActivities,
+		"logout_errors":    stats.LogoutErrors,
 		"busiest_hour": gin.H{
-			"hour":  busiestHour,
-			"count": count,
+			"hour":  stats.BusiestHour,
+			"count": stats.BusiestHourCount,
 		},
-	})
+	}
 }
 
 // GetActivities mengembalikan daftar aktivitas terbaru dengan paginasi; response berupa DTO datar (nama, satker, lokasi, dll.).
@@ -113,15 +83,18 @@ func GetActivities(c *gin.Context) {
 		pageSize = config.MaxPageSizeActivities
 	}
 
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	activities, err := repo.GetRecentActivities(page, pageSize, startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	activities, err := repo.GetRecentActivities(page, pageSize, filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	total, err := repo.GetTotalCount(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	total, err := repo.GetTotalCount(filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
@@ -132,7 +105,7 @@ func GetActivities(c *gin.Context) {
 		dtos[i] = dto.ToDTO(a)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response.CachedJSON(c, gin.H{
 		"data":        dtos,
 		"page":        page,
 		"page_size":   pageSize,
@@ -142,35 +115,48 @@ func GetActivities(c *gin.Context) {
 }
 
 // GetChartData mengembalikan data chart; type path: hourly (per jam 0–23), cluster (per scope), province (per provinsi).
+// cluster dan province mendukung compare (parseComparison): cluster → comparison per scope, province → kolom pembanding per baris.
 func GetChartData(c *gin.Context) {
 	chartType := c.Param("type")
 	repo := getActivityLogRepo()
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
 	switch chartType {
 	case "hourly":
-		data, err := repo.GetActivityCountByHour(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+		data, err := repo.GetActivityCountByHour(filter)
 		if err != nil {
 			response.Internal(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"data": data})
+		response.CachedJSON(c, gin.H{"data": data})
 
 	case "cluster":
-		data, err := repo.GetActivityCountByScope(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
-		if err != nil {
-			response.Internal(c, err)
+		compare, ok := parseComparison(c, filter)
+		if !ok {
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"data": data})
-
-	case "province":
-		data, err := repo.GetActivityCountByProvince(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+		data, err := repo.GetActivityCountByScope(filter)
 		if err != nil {
 			response.Internal(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"data": data})
+		body := gin.H{"data": data}
+		if compare != nil {
+			previous, err := repo.GetActivityCountByScope(*compare)
+			if err != nil {
+				response.Internal(c, err)
+				return
+			}
+			body["comparison_period"] = comparisonPeriod(compare)
+			body["comparison"] = service.CompareCountMap(data, previous)
+		}
+		response.CachedJSON(c, body)
+
+	case "province":
+		respondBreakdown(c, filter, "province", repo.GetActivityCountByProvince)
 
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chart type"})
@@ -180,46 +166,79 @@ func GetChartData(c *gin.Context) {
 // GetAccessSuccessRate mengembalikan tingkat sukses akses per tanggal (success vs failed per hari dalam rentang filter).
 func GetAccessSuccessRate(c *gin.Context) {
 	repo := getActivityLogRepo()
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	data, err := repo.GetAccessSuccessRateByDate(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	data, err := repo.GetAccessSuccessRateByDate(filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": data})
+	response.CachedJSON(c, gin.H{"data": data})
 }
 
-// GetProvinces mengembalikan statistik aktivitas per provinsi (sama seperti chart type province, dengan filter regional).
-func GetProvinces(c *gin.Context) {
-	repo := getActivityLogRepo()
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+// GetActivityTimeSeries mengembalikan deret waktu jumlah aktivitas. Query: granularity (minute|hour|day|week|month|quarter, default day),
+// split_by (cluster|status|category|eselon, opsional), rolling (jendela rata-rata bergulir dalam bucket, 0 = tanpa), plus filter aktivitas.
+// Parameter tidak valid atau rentang dengan terlalu banyak bucket → 400.
+func GetActivityTimeSeries(c *gin.Context) {
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	rolling, err := strconv.Atoi(c.DefaultQuery("rolling", "0"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, repository.ErrInvalidRollingWindow.Error())
+		return
+	}
+	q := repository.TimeSeriesQuery{
+		Granularity: c.DefaultQuery("granularity", repository.GranularityDay),
+		SplitBy:     c.Query("split_by"),
+		Rolling:     rolling,
+	}
+	if err := q.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	data, err := repo.GetActivityCountByProvince(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	data, err := getActivityLogRepo().GetActivityTimeSeries(q, filter)
+	if errors.Is(err, repository.ErrTooManyBuckets) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": data})
+	response.CachedJSON(c, gin.H{"data": data})
 }
 
-// GetLokasi mengembalikan statistik lokasi untuk peta (per satker + provinsi).
-func GetLokasi(c *gin.Context) {
+// GetProvinces mengembalikan statistik aktivitas per provinsi (sama seperti chart type province, dengan filter regional); mendukung compare.
+func GetProvinces(c *gin.Context) {
 	repo := getActivityLogRepo()
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	data, err := repo.GetActivityCountBySatkerProvince(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
-	if err != nil {
-		response.Internal(c, err)
+	respondBreakdown(c, filter, "province", repo.GetActivityCountByProvince)
+}
+
+// GetLokasi mengembalikan statistik lokasi untuk peta (per satker + provinsi); mendukung compare.
+func GetLokasi(c *gin.Context) {
+	repo := getActivityLogRepo()
+	filter, ok := parseActivityFilter(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": data})
+	respondBreakdown(c, filter, "lokasi", repo.GetActivityCountBySatkerProvince)
 }
 
-// GetUnits mengembalikan statistik aktivitas per unit/satker dengan paginasi (page, page_size).
+// GetUnits mengembalikan statistik aktivitas per unit/satker dengan paginasi (page, page_size); mendukung compare.
 func GetUnits(c *gin.Context) {
 	repo := getActivityLogRepo()
 
@@ -232,26 +251,46 @@ func GetUnits(c *gin.Context) {
 		pageSize = config.DefaultPageSizeUnits
 	}
 
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	data, err := repo.GetActivityCountBySatker(page, pageSize, startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	compare, ok := parseComparison(c, filter)
+	if !ok {
+		return
+	}
+
+	data, err := repo.GetActivityCountBySatker(page, pageSize, filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	total, err := repo.GetTotalCount(startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	total, err := repo.GetTotalCount(filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"data":        data,
 		"page":        page,
 		"page_size":   pageSize,
 		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-	})
+	}
+	if compare != nil {
+		// Satker di halaman ini bisa berada di halaman mana pun pada periode pembanding, jadi baca breakdown pembanding sekaligus.
+		previous, err := repo.GetActivityCountBySatker(1, config.MaxComparisonRows, *compare)
+		if err != nil {
+			response.Internal(c, err)
+			return
+		}
+		body["data"] = service.CompareBreakdown(data, previous, "satker")
+		body["comparison_period"] = comparisonPeriod(compare)
+	}
+
+	response.CachedJSON(c, body)
 }
 
 // GetClusters mengembalikan daftar cluster unik (untuk dropdown/filter di frontend).
@@ -264,7 +303,7 @@ func GetClusters(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": clusters})
+	response.CachedJSON(c, gin.H{"data": clusters})
 }
 
 // GetHourlyDataForSatker mengembalikan distribusi aktivitas per jam (0–23) untuk satu satker; query param satker wajib.
@@ -277,15 +316,48 @@ func GetHourlyDataForSatker(c *gin.Context) {
 		return
 	}
 
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	data, err := repo.GetActivityCountByHourForSatker(satker, startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	data, err := repo.GetActivityCountByHourForSatker(satker, filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": data})
+	response.CachedJSON(c, gin.H{"data": data})
+}
+
+// GetActivityHeatmap mengembalikan heatmap aktivitas hari (Senin–Minggu) × jam (0–23): jumlah aktivitas dan user unik per sel.
+// Query: normalize (kosong|satker; satker = setiap satker berbobot sama), exclude_holidays (true = tanggal di ref_holidays tidak dihitung), plus filter aktivitas.
+func GetActivityHeatmap(c *gin.Context) {
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	q := repository.HeatmapQuery{Normalize: c.Query("normalize")}
+	if v := c.Query("exclude_holidays"); v != "" {
+		exclude, err := strconv.ParseBool(v)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "exclude_holidays harus true atau false")
+			return
+		}
+		q.ExcludeHolidays = exclude
+	}
+	if err := q.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := getActivityLogRepo().GetActivityHeatmap(q, filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	response.CachedJSON(c, gin.H{"data": data})
 }
 
 // GetTopContributors mengembalikan top N kontributor (user dengan aktivitas terbanyak); query limit (default dari config, max MaxLimit).
@@ -301,15 +373,18 @@ func GetTopContributors(c *gin.Context) {
 		limit = config.MaxLimit
 	}
 
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	data, err := repo.GetTopContributors(limit, startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	data, err := repo.GetTopContributors(limit, filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": data})
+	response.CachedJSON(c, gin.H{"data": data})
 }
 
 // GetLogoutErrors mengembalikan user dengan error logout terbanyak (top N); query limit (default/max dari config).
@@ -325,13 +400,41 @@ func GetLogoutErrors(c *gin.Context) {
 		limit = config.MaxLimit
 	}
 
-	startPtr, endPtr, clusterPtr, eselonPtr, satkerIds := parseRegionalQueryParams(c, repo)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
 
-	data, err := repo.GetLogoutErrors(limit, startPtr, endPtr, clusterPtr, eselonPtr, satkerIds)
+	data, err := repo.GetLogoutErrors(limit, filter)
 	if err != nil {
 		response.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": data})
+	response.CachedJSON(c, gin.H{"data": data})
+}
+
+// respondBreakdown menulis breakdown hasil load (baris dengan kolom key dan count). Jika compare diminta, load dijalankan juga untuk
+// periode pembanding dan setiap baris diberi previous_count, delta, delta_pct (service.CompareBreakdown).
+func respondBreakdown(c *gin.Context, filter repository.ActivityFilter, key string, load func(repository.ActivityFilter) ([]map[string]interface{}, error)) {
+	compare, ok := parseComparison(c, filter)
+	if !ok {
+		return
+	}
+	data, err := load(filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	body := gin.H{"data": data}
+	if compare != nil {
+		previous, err := load(*compare)
+		if err != nil {
+			response.Internal(c, err)
+			return
+		}
+		body["data"] = service.CompareBreakdown(data, previous, key)
+		body["comparison_period"] = comparisonPeriod(compare)
+	}
+	response.CachedJSON(c, body)
 }
@@ -0,0 +1,245 @@
+// File dashboard_stream_handler.go: live feed aktivitas lewat Server-Sent Events (GET /api/dashboard/stream).
+//
+// Koneksi berlangganan service.LiveActivityFeed (diisi job activity-feed) dan menerima:
+//   - event "activity" (id = id aktivitas, data = dto.ActivityLogDTO) untuk aktivitas baru yang lolos filter yang sama dengan GetActivities;
+//   - event "kpi" (stats seperti GetDashboardStats + delta terhadap KPI sebelumnya) di awal dan tiap STREAM_KPI_INTERVAL jika ada aktivitas baru;
+//   - komentar heartbeat tiap STREAM_HEARTBEAT_INTERVAL.
+//
+// Reconnect: header Last-Event-ID (atau query last_event_id) memutar ulang aktivitas setelah id tersebut dari DB, maksimal STREAM_REPLAY_LIMIT;
+// jika lebih, dikirim event "reset" (klien memuat ulang daftar lewat GetActivities) dan feed berlanjut dari aktivitas terbaru.
+// Backpressure: koneksi yang antreannya tetap penuh melewati STREAM_LAG_GRACE diputus feed; handler mengirim event "lagged" lalu menutup stream agar klien menyambung ulang dengan Last-Event-ID.
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/dto"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// StreamActivities membuka stream SSE aktivitas baru dan delta KPI untuk filter aktivitas (parseActivityFilter).
+func StreamActivities(c *gin.Context) {
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	lastEventID, ok := parseLastEventID(c)
+	if !ok {
+		return
+	}
+	cfg := config.GetStreamConfig()
+	db := database.GetDB()
+	feedRepo := repository.NewActivityFeedRepository(db)
+	// KPI dibaca tanpa cache: versi data baru naik setelah impor selesai, sedangkan delta harus mengikuti aktivitas yang sudah dikirim.
+	statsRepo := repository.NewActivityLogRepository(db)
+
+	// Berlangganan sebelum replay agar aktivitas yang masuk selama replay tidak terlewat; duplikat disaring lewat lastSent.
+	feed := service.LiveActivityFeed()
+	sub := feed.Subscribe()
+	defer feed.Unsubscribe(sub)
+
+	var lastSent int64
+	var replay []dto.ActivityLogDTO
+	reset := false
+	if lastEventID > 0 {
+		activities, err := feedRepo.After(lastEventID, cfg.ReplayLimit+1, filter)
+		if err != nil {
+			response.Internal(c, err)
+			return
+		}
+		if len(activities) > cfg.ReplayLimit {
+			reset, lastSent = true, sub.From()
+		} else {
+			lastSent = lastEventID
+			for _, a := range activities {
+				replay = append(replay, dto.ToDTO(a))
+			}
+		}
+	}
+	previous, err := service.LoadDashboardStats(statsRepo, filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // Matikan buffering proxy nginx.
+	c.Status(http.StatusOK)
+
+	w := &sseWriter{w: c.Writer}
+	w.retry(config.StreamRetry)
+	if reset {
+		w.event(0, "reset", gin.H{"reason": "replay_limit_exceeded", "replay_limit": cfg.ReplayLimit})
+	}
+	for _, a := range replay {
+		w.event(a.ID, "activity", a)
+		lastSent = a.ID
+	}
+	w.event(0, "kpi", gin.H{"stats": dashboardStatsBody(previous), "delta": nil})
+	if w.flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+	kpi := time.NewTicker(cfg.KPIInterval)
+	defer kpi.Stop()
+	pending := false // Ada aktivitas terkirim sejak KPI terakhir.
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case ev, open := <-sub.Events():
+			if !open {
+				if sub.Lagged() {
+					w.event(0, "lagged", gin.H{"last_event_id": lastSent})
+					w.flush()
+				}
+				return
+			}
+			batch := drainFeed(sub, ev, cfg.BufferSize)
+			ids := make([]int64, 0, len(batch))
+			for _, e := range batch {
+				if e.ID > lastSent {
+					ids = append(ids, e.ID)
+				}
+			}
+			matched, err := feedRepo.MatchIDs(ids, filter)
+			if err != nil {
+				// Header sudah terkirim; tutup stream agar klien menyambung ulang dari Last-Event-ID.
+				c.Error(err)
+				return
+			}
+			keep := make(map[int64]bool, len(matched))
+			for _, id := range matched {
+				keep[id] = true
+			}
+			for _, e := range batch {
+				if keep[e.ID] {
+					w.event(e.ID, "activity", e.Activity)
+					lastSent = e.ID
+					pending = true
+				}
+			}
+			if w.flush() != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+			w.comment("heartbeat")
+			if w.flush() != nil {
+				return
+			}
+
+		case <-kpi.C:
+			if !pending {
+				continue
+			}
+			current, err := service.LoadDashboardStats(statsRepo, filter)
+			if err != nil {
+				c.Error(err)
+				continue
+			}
+			w.event(0, "kpi", gin.H{"stats": dashboardStatsBody(current), "delta": service.CompareDashboardStats(current, previous)})
+			if w.flush() != nil {
+				return
+			}
+			previous, pending = current, false
+		}
+	}
+}
+
+// parseLastEventID mengurai header Last-Event-ID (fallback query last_event_id); 0 jika kosong. Jika tidak valid, response 400 sudah ditulis dan ok=false.
+func parseLastEventID(c *gin.Context) (int64, bool) {
+	raw := strings.TrimSpace(c.GetHeader("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(c.Query("last_event_id"))
+	}
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// drainFeed mengambil first ditambah event yang sudah mengantre (tanpa menunggu), paling banyak limit, agar satu batch cukup dicocokkan dengan satu query.
+func drainFeed(sub *service.FeedSubscription, first service.ActivityFeedEvent, limit int) []service.ActivityFeedEvent {
+	batch := []service.ActivityFeedEvent{first}
+	for len(batch) < limit {
+		select {
+		case ev, open := <-sub.Events():
+			if !open {
+				return batch // Penutupan ditangani di iterasi berikutnya.
+			}
+			batch = append(batch, ev)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// sseWriter menulis frame SSE; error tulis pertama disimpan dan tulisan berikutnya diabaikan (klien terputus).
+type sseWriter struct {
+	w   gin.ResponseWriter
+	err error
+}
+
+// event menulis satu event bernama dengan data JSON; id > 0 ditulis sebagai field id (dipakai klien untuk Last-Event-ID).
+func (s *sseWriter) event(id int64, name string, data interface{}) {
+	if s.err != nil {
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		s.err = err
+		return
+	}
+	var b strings.Builder
+	if id > 0 {
+		fmt.Fprintf(&b, "id: %d\n", id)
+	}
+	fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", name, payload)
+	_, s.err = s.w.WriteString(b.String())
+}
+
+// comment menulis baris komentar (diabaikan EventSource); dipakai untuk heartbeat.
+func (s *sseWriter) comment(text string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(": " + text + "\n\n")
+	}
+}
+
+// retry menulis saran jeda reconnect untuk EventSource.
+func (s *sseWriter) retry(d time.Duration) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, "retry: %d\n\n", d.Milliseconds())
+	}
+}
+
+// flush mengirim data yang tertahan ke klien dan mengembalikan error tulis pertama (jika ada).
+func (s *sseWriter) flush() error {
+	if s.err == nil {
+		s.w.Flush()
+	}
+	return s.err
+}
@@ -0,0 +1,274 @@
+// File insights_handler.go: HTTP handler temuan detektor anomali (tabel anomalies), analitik sesi aktivitas (tabel user_sessions), kohort retensi user, dan funnel jenis aktivitas di bawah /api/insights.
+//
+// Endpoint: ListAnomalies (daftar + filter + paginasi), AcknowledgeAnomaly, DismissAnomaly (admin). Deteksi berjalan sebagai background job (service.AnomalyDetectionJob).
+// Sesi: GetSessionDurations, GetSessionConcurrency, GetUserSessionTimeline (admin). Sesi dibangun oleh job sessionization (service.SessionizationJob).
+// Retensi: GetUserCohorts (matriks kohort dari user_profiles.first_activity). Funnel: GetActivityFunnel (urutan jenis aktivitas dalam window atau sesi).
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ListAnomalies mengembalikan anomali terbaru dulu. Query: status (open|acknowledged|dismissed), detector, severity, subject_type, start_date, end_date (YYYY-MM-DD), page, page_size.
+func ListAnomalies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeAdmin)))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > config.MaxPageSizeAdmin {
+		pageSize = config.DefaultPageSizeAdmin
+	}
+
+	filter := repository.AnomalyListFilter{
+		Status:      c.Query("status"),
+		Detector:    c.Query("detector"),
+		Severity:    c.Query("severity"),
+		SubjectType: c.Query("subject_type"),
+		StartDate:   c.Query("start_date"),
+		EndDate:     c.Query("end_date"),
+		Page:        page,
+		PageSize:    pageSize,
+	}
+	if !oneOf(filter.Status, entity.AnomalyStatusOpen, entity.AnomalyStatusAcknowledged, entity.AnomalyStatusDismissed) {
+		response.Error(c, http.StatusBadRequest, "status harus salah satu dari: open, acknowledged, dismissed")
+		return
+	}
+	if !oneOf(filter.Detector, entity.AnomalyDetectorSatkerVolume, entity.AnomalyDetectorUserDownloads, entity.AnomalyDetectorFailedLogouts) {
+		response.Error(c, http.StatusBadRequest, "detector harus salah satu dari: satker_volume, user_downloads, failed_logouts")
+		return
+	}
+	if !oneOf(filter.Severity, entity.AnomalySeverityLow, entity.AnomalySeverityMedium, entity.AnomalySeverityHigh) {
+		response.Error(c, http.StatusBadRequest, "severity harus salah satu dari: low, medium, high")
+		return
+	}
+	if !oneOf(filter.SubjectType, entity.AnomalySubjectSatker, entity.AnomalySubjectUser, entity.AnomalySubjectGlobal) {
+		response.Error(c, http.StatusBadRequest, "subject_type harus salah satu dari: satker, user, global")
+		return
+	}
+
+	anomalies, total, err := service.NewAnomalyService(database.GetDB()).List(filter)
+	if errors.Is(err, service.ErrInvalidAnomalyQuery) {
+		response.Error(c, http.StatusBadRequest, "start_date dan end_date harus berformat YYYY-MM-DD")
+		return
+	}
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        anomalies,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// AcknowledgeAnomaly menandai anomali open (path :id) sudah ditindaklanjuti; body opsional: note.
+func AcknowledgeAnomaly(c *gin.Context) {
+	updateAnomalyStatus(c, (*service.AnomalyService).Acknowledge, "Anomali ditandai sudah ditindaklanjuti")
+}
+
+// DismissAnomaly menandai anomali open/acknowledged (path :id) sebagai bukan masalah; body opsional: note.
+func DismissAnomaly(c *gin.Context) {
+	updateAnomalyStatus(c, (*service.AnomalyService).Dismiss, "Anomali diabaikan")
+}
+
+// updateAnomalyStatus alur bersama acknowledge/dismiss: parse id + body, panggil service, petakan error.
+func updateAnomalyStatus(c *gin.Context, update func(*service.AnomalyService, service.AuditActor, int64, string) (*entity.Anomaly, error), message string) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req entity.AnomalyStatusRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+
+	anomaly, err := update(service.NewAnomalyService(database.GetDB()), auditActor(c), int64(id), req.Note)
+	switch {
+	case errors.Is(err, service.ErrAnomalyNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAnomalyStatus):
+		response.Error(c, http.StatusConflict, err.Error())
+	case err != nil:
+		response.Internal(c, err)
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": message, "data": anomaly})
+	}
+}
+
+// oneOf mengembalikan true jika v kosong atau sama dengan salah satu allowed.
+func oneOf(v string, allowed ...string) bool {
+	if v == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSessionDurations mengembalikan distribusi durasi sesi yang sudah berakhir (rata-rata, median, p90, kelompok durasi, alasan berakhir).
+// Query: filter aktivitas (tanggal membatasi waktu login; satker, eselon, user berlaku).
+func GetSessionDurations(c *gin.Context) {
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	stats, err := repository.NewUserSessionRepository(database.GetDB()).DurationStats(filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// GetSessionConcurrency mengembalikan jumlah sesi bersamaan per bucket. Query: granularity (hour|day|week, default hour),
+// start_date dan end_date (wajib, atau date_range), plus filter satker/eselon/user.
+func GetSessionConcurrency(c *gin.Context) {
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	granularity := c.DefaultQuery("granularity", repository.GranularityHour)
+	data, err := repository.NewUserSessionRepository(database.GetDB()).Concurrency(granularity, filter, time.Now())
+	if errors.Is(err, repository.ErrInvalidSessionGranularity) || errors.Is(err, repository.ErrSessionRangeRequired) ||
+		errors.Is(err, repository.ErrTooManyBuckets) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// GetUserSessionTimeline mengembalikan sesi satu user (path :id = user_profiles.id) terbaru dulu. Query: start_date, end_date, page, page_size.
+func GetUserSessionTimeline(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeAdmin)))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > config.MaxPageSizeAdmin {
+		pageSize = config.DefaultPageSizeAdmin
+	}
+
+	sessions, total, err := repository.NewUserSessionRepository(database.GetDB()).Timeline(int64(id), filter, page, pageSize)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        sessions,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// GetUserCohorts mengembalikan matriks kohort retensi user: user dikelompokkan per minggu/bulan aktivitas pertama, lalu porsi yang aktif
+// di setiap periode sesudahnya. Query: granularity (week|month, default month), periods (periode lanjutan, default 12), start_date/end_date
+// (memilih kohort), root_satker_id/satker_ids/eselon (anggota kohort), cluster (aktivitas yang dihitung).
+func GetUserCohorts(c *gin.Context) {
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	q := repository.CohortQuery{Granularity: c.DefaultQuery("granularity", repository.GranularityMonth), Periods: config.DefaultCohortPeriods}
+	if v := c.Query("periods"); v != "" {
+		periods, err := strconv.Atoi(v)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "periods harus bilangan bulat")
+			return
+		}
+		q.Periods = periods
+	}
+	if err := q.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := getActivityLogRepo().GetUserCohorts(q, filter)
+	if errors.Is(err, repository.ErrTooManyCohorts) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	response.CachedJSON(c, gin.H{"data": data})
+}
+
+// GetActivityFunnel mengembalikan jumlah user dan konversi per langkah funnel. Query: steps (nama/kategori jenis aktivitas berurutan, dipisah koma),
+// step_by (name|category, default name), window (durasi mis. 30m/24h, atau session; default 24h), split_by (cluster|eselon), plus filter standar
+// (jenis aktivitas diabaikan). Tanpa start_date dipakai 30 hari terakhir sampai end_date.
+func GetActivityFunnel(c *gin.Context) {
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	q := repository.FunnelQuery{
+		Steps:   queryValues(c, "steps"),
+		StepBy:  c.DefaultQuery("step_by", repository.FunnelStepByName),
+		Window:  config.DefaultFunnelWindow,
+		SplitBy: c.Query("split_by"),
+	}
+	switch window := c.Query("window"); window {
+	case "":
+	case repository.FunnelWindowSession:
+		q.Window = 0
+	default:
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			response.Error(c, http.StatusBadRequest, repository.ErrInvalidFunnelWindow.Error())
+			return
+		}
+		q.Window = d
+	}
+	if err := q.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := getActivityLogRepo().GetFunnel(q, filter)
+	if errors.Is(err, repository.ErrFunnelRangeTooLong) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	response.CachedJSON(c, gin.H{"data": data})
+}
@@ -1,13 +1,17 @@
 // File profile_handler.go: handler untuk profil user dan permintaan akses laporan.
 //
-// Endpoint: get profil (user login), update foto profil, ajukan akses laporan, daftar permintaan pending (admin), setujui/tolak akses (admin), get user by ID (testing/admin).
+// Endpoint: get profil (user login), update foto profil, ajukan akses laporan, riwayat + statistik aktivitas sendiri (my-activity), daftar permintaan pending dan setujui/tolak akses (penyetuju tahap aktif, lewat service.AccessWorkflowService), get user by ID (testing/admin).
 package handler
 
 import (
 	"net/http"
 	"strconv"
 
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/dto"
 	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -85,14 +89,29 @@ func UpdateProfilePhoto(c *gin.Context) {
 	})
 }
 
-// RequestReportAccess mengajukan permintaan akses laporan untuk user yang login. Admin tidak perlu; status pending/approved ditolak.
-func RequestReportAccess(c *gin.Context) {
+// GetMyActivity mengembalikan riwayat aktivitas (activity_logs_normalized) dan statistik milik user login, lewat profil aktivitas yang tertaut ke akunnya.
+// Jika akun belum tertaut, dicoba penautan otomatis by email (hanya jika cocok unik); jika tetap belum tertaut → linked=false tanpa data.
+// Query: filter aktivitas (parseActivityFilter; user_id diabaikan), page, page_size.
+func GetMyActivity(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeActivities)))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > config.MaxPageSizeUnits {
+		pageSize = config.DefaultPageSizeActivities
+	}
+
 	db := database.GetDB()
 	var user entity.User
 	if err := db.First(&user, userID).Error; err != nil {
@@ -100,111 +119,123 @@ func RequestReportAccess(c *gin.Context) {
 		return
 	}
 
-	if user.Role == "admin" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Admin users already have full access"})
+	linked, err := service.NewProfileLinkService(db).EnsureLinked(&user)
+	if err != nil {
+		response.Internal(c, err)
 		return
 	}
-
-	if user.ReportAccessStatus == "pending" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You already have a pending request"})
+	if linked.ProfileID == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"linked":  false,
+			"message": "Akun Anda belum tertaut ke profil aktivitas. Hubungi admin untuk penautan.",
+			"data":    []dto.ActivityLogDTO{},
+		})
 		return
 	}
 
-	if user.ReportAccessStatus == "approved" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You already have report access"})
+	var profile entity.UserProfile
+	if err := db.First(&profile, *linked.ProfileID).Error; err != nil {
+		response.Internal(c, err)
 		return
 	}
 
-	// Set status user ke pending agar admin bisa menyetujui/tolak.
-	result := db.Model(&entity.User{}).
-		Where("id = ?", userID).
-		Update("report_access_status", "pending")
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit request"})
+	repo := getUserActivityRepo()
+	stats, err := repo.GetStats(profile.ID, filter)
+	if err != nil {
+		response.Internal(c, err)
 		return
 	}
+	activities, total, err := repo.GetActivities(profile.ID, page, pageSize, filter)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	dtos := make([]dto.ActivityLogDTO, len(activities))
+	for i, a := range activities {
+		dtos[i] = dto.ToDTO(a)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Report access request submitted successfully",
-		"status":  "pending",
+		"linked":      true,
+		"profile":     profile,
+		"stats":       stats,
+		"data":        dtos,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
 	})
 }
 
-// GetPendingAccessRequests mengembalikan daftar user dengan report_access_status = pending. Hanya admin.
-func GetPendingAccessRequests(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
+// RequestReportAccess mengajukan permintaan akses laporan untuk user yang login lewat workflow persetujuan (body: reason wajib, templates dan satker_ids opsional).
+func RequestReportAccess(c *gin.Context) {
+	var body accessSubmitRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
 	}
 
-	db := database.GetDB()
-
-	var user entity.User
-	if err := db.First(&user, userID).Error; err != nil || user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+	accessRequest, err := service.NewAccessWorkflowService(database.GetDB()).Submit(auditActor(c), c.GetInt("user_id"), body.Reason, body.scope())
+	if err != nil {
+		respondAccessWorkflowError(c, err)
 		return
 	}
 
-	var users []entity.User
-	if err := db.Where("report_access_status = ?", "pending").Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve access requests"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Report access request submitted successfully",
+		"status":     accessRequest.Status,
+		"request_id": accessRequest.ID,
+	})
+}
+
+// GetPendingAccessRequests mengembalikan permintaan akses pending yang tahap aktifnya boleh diputuskan user login (admin: semua yang pending).
+func GetPendingAccessRequests(c *gin.Context) {
+	requests, err := service.NewAccessWorkflowService(database.GetDB()).ListActionable(c.GetInt("user_id"))
+	if err != nil {
+		respondAccessWorkflowError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"requests": users,
-		"count":    len(users),
+		"requests": requests,
+		"count":    len(requests),
 	})
 }
 
-// ApproveReportAccess menyetujui atau menolak permintaan akses user (path :id, query action=approve|reject). Hanya admin.
+// ApproveReportAccess memutuskan tahap aktif permintaan pending milik user (path :id = id user pemohon, query action=approve|reject, body opsional reason; wajib untuk reject).
 func ApproveReportAccess(c *gin.Context) {
-	requestUserID := c.Param("id")
+	requestUserID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
 	action := c.Query("action") // "approve" atau "reject"
-
 	if action != "approve" && action != "reject" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action. Use 'approve' or 'reject'"})
 		return
 	}
 
-	adminID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	db := database.GetDB()
-	var admin entity.User
-	if err := db.First(&admin, adminID).Error; err != nil || admin.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-		return
-	}
-
-	newStatus := "rejected"
-	if action == "approve" {
-		newStatus = "approved"
-	}
-
-	// Hanya update jika user memang status pending (hindari ubah yang sudah approved/rejected).
-	result := db.Model(&entity.User{}).
-		Where("id = ? AND report_access_status = ?", requestUserID, "pending").
-		Update("report_access_status", newStatus)
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update access status"})
-		return
+	var body accessDecisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No pending request found for this user"})
+	accessRequest, err := service.NewAccessWorkflowService(database.GetDB()).
+		DecideForUser(auditActor(c), requestUserID, action == "approve", body.Reason)
+	if err != nil {
+		respondAccessWorkflowError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Access request " + action + "d successfully",
-		"status":  newStatus,
+		"message":    "Access request " + action + "d successfully",
+		"status":     accessRequest.Status,
+		"request_id": accessRequest.ID,
 	})
 }
 
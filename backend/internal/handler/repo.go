@@ -1,7 +1,7 @@
 // File repo.go: helper untuk mendapatkan instance repository yang dipakai handler.
 //
-// Handler dashboard, chart, dll. memakai getActivityLogRepo(); handler search memakai getSearchRepo().
-// Keduanya memakai koneksi DB dari database.GetDB().
+// Handler dashboard, chart, dll. memakai getActivityLogRepo(); handler search memakai getSearchRepo(); my-activity memakai getUserActivityRepo().
+// Semuanya memakai koneksi DB dari database.GetDB().
 package handler
 
 import (
@@ -9,12 +9,17 @@ import (
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 )
 
-// getActivityLogRepo mengembalikan repository aktivitas (query activity_logs_normalized, satker, filter regional).
+// getActivityLogRepo mengembalikan repository aktivitas (query activity_logs_normalized, satker, filter regional); hasil agregat melewati cache (cached_repository.go).
 func getActivityLogRepo() repository.ActivityLogRepository {
-	return repository.NewActivityLogRepository(database.GetDB())
+	return repository.NewCachedActivityLogRepository(database.GetDB())
 }
 
 // getSearchRepo mengembalikan repository pencarian (autocomplete, hasil search).
 func getSearchRepo() *repository.SearchRepository {
 	return repository.NewSearchRepository(database.GetDB())
 }
+
+// getUserActivityRepo mengembalikan repository aktivitas per profil (riwayat dan statistik /api/profile/my-activity).
+func getUserActivityRepo() *repository.UserActivityRepository {
+	return repository.NewUserActivityRepository(database.GetDB())
+}
@@ -1,9 +1,12 @@
 // File report_handler.go: handler untuk laporan (template, generate, unduh, riwayat) dan permintaan akses laporan (report_access_requests).
 //
-// Endpoint: daftar template, generate report (CSV/Excel/PDF), download file, riwayat unduhan, daftar permintaan akses (admin), ajukan akses, update status permintaan (admin).
+// Endpoint: daftar template, generate report (CSV/Excel/PDF), download file, riwayat unduhan, daftar permintaan akses (admin), ajukan akses, putuskan tahap permintaan.
+// Unduh dan riwayat butuh JWT: file hanya untuk pembuatnya atau admin (dicocokkan lewat report_downloads.filename), riwayat non-admin hanya miliknya sendiri.
+// Ajukan dan putuskan diteruskan ke service.AccessWorkflowService (workflow bertahap, lihat access_workflow.go).
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -19,14 +22,16 @@ import (
 	"github.com/bpk-ri/dashboard-monitoring/internal/service"
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// ReportTemplate dipakai untuk response daftar template laporan (id, judul, deskripsi, format yang didukung).
+// ReportTemplate dipakai untuk response daftar template laporan (id, judul, deskripsi, format yang didukung, khusus admin).
 type ReportTemplate struct {
 	ID          string   `json:"id"`
 	Title       string   `json:"title"`
 	Description string   `json:"description"`
 	Formats     []string `json:"formats"`
+	AdminOnly   bool     `json:"admin_only,omitempty"`
 }
 
 // DownloadHistory dipakai untuk response riwayat unduhan (id, nama laporan, format, ukuran, waktu, status).
@@ -48,39 +53,55 @@ type AccessRequest struct {
 	Status      string    `json:"status"`
 }
 
-// GetReportTemplates mengembalikan daftar template laporan yang tersedia (hardcoded: org-performance, user-activity, feature-usage).
+// GetReportTemplates mengembalikan daftar template laporan yang tersedia (hardcoded: org-performance, user-activity, feature-usage, user-retention, account-hygiene).
 func GetReportTemplates(c *gin.Context) {
 	templates := []ReportTemplate{
 		{
-			ID:          "org-performance",
+			ID:          entity.ReportTemplateOrgPerformance,
 			Title:       "Laporan Kinerja Organisasi",
 			Description: "Analisis aktivitas berdasarkan Unit Kerja (Satker) dan sebaran geografis",
 			Formats:     []string{"CSV", "Excel", "PDF"},
 		},
 		{
-			ID:          "user-activity",
+			ID:          entity.ReportTemplateUserActivity,
 			Title:       "Laporan Aktivitas Pengguna",
 			Description: "Tren login harian, waktu akses puncak, dan daftar pengguna teraktif",
 			Formats:     []string{"CSV", "Excel", "PDF"},
 		},
 		{
-			ID:          "feature-usage",
+			ID:          entity.ReportTemplateFeatureUsage,
 			Title:       "Laporan Pemanfaatan Fitur",
 			Description: "Statistik penggunaan menu, kata kunci pencarian, dan unduhan file",
 			Formats:     []string{"CSV", "Excel", "PDF"},
 		},
+		{
+			ID:          entity.ReportTemplateUserRetention,
+			Title:       "Laporan Retensi Pengguna",
+			Description: "Kohort bulanan pengguna baru berdasarkan aktivitas pertama dan porsi yang tetap aktif di bulan-bulan berikutnya",
+			Formats:     []string{"CSV", "Excel", "PDF"},
+		},
+		{
+			ID:          entity.ReportTemplateAccountHygiene,
+			Title:       "Laporan Akun Dorman dan Yatim",
+			Description: "Akun yang lama tidak login, akun dengan satker yang sudah tidak ada, dan profil tanpa aktivitas",
+			Formats:     []string{"CSV", "Excel", "PDF"},
+			AdminOnly:   true,
+		},
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": templates})
 }
 
-// GenerateReport membuat laporan berdasarkan template_id, format (CSV/Excel/PDF), dan rentang tanggal. Menyimpan file di generated_reports, mencatat di report_downloads, mengembalikan URL unduh.
+// GenerateReport membuat laporan berdasarkan template_id, format (CSV/Excel/PDF), rentang tanggal, dan satker_id opsional (root pohon satker). Butuh JWT.
+// Non-admin wajib punya grant akses aktif yang mencakup template dan satker (lihat service.AuthorizeReport); data dibatasi ke satker dalam grant.
+// Menyimpan file di generated_reports, mencatat di report_downloads, mengembalikan URL unduh.
 func GenerateReport(c *gin.Context) {
 	var req struct {
 		TemplateID string `json:"template_id"`
 		Format     string `json:"format"`
 		StartDate  string `json:"start_date"`
 		EndDate    string `json:"end_date"`
+		SatkerID   *int64 `json:"satker_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -88,41 +109,39 @@ func GenerateReport(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-
-	var userIDInt int = 1
-	generatedBy := "System"
-	username := "anonymous"
-	email := "system@bpk.go.id"
+	filter := repository.ActivityFilter{StartDate: req.StartDate, EndDate: req.EndDate}.Normalize()
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if exists {
-		userIDInt = userID.(int)
-	} else {
-		// Fallback: baca X-User-ID dari header (rute tanpa middleware auth).
-		userIDStr := c.GetHeader("X-User-ID")
-		if userIDStr != "" {
-			if _, err := fmt.Sscanf(userIDStr, "%d", &userIDInt); err == nil && userIDInt > 0 {
-				// OK
-			} else {
-				userIDInt = 1
-			}
-		}
+	userIDInt := c.GetInt("user_id")
+	db := database.GetDB()
+	var user entity.User
+	if err := db.First(&user, userIDInt).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	generatedBy := user.FullName
+	if generatedBy == "" {
+		generatedBy = user.Username
 	}
+	username := user.Username
+	email := user.Email
 
-	if userIDInt > 0 {
-		db := database.GetDB()
-		var user entity.User
-		if err := db.First(&user, userIDInt).Error; err == nil {
-			generatedBy = user.FullName
-			if generatedBy == "" {
-				generatedBy = user.Username
-			}
-			username = user.Username
-			email = user.Email
-		}
+	authz, err := service.NewAccessWorkflowService(db).AuthorizeReport(userIDInt, req.TemplateID, req.SatkerID)
+	if err != nil {
+		respondReportAccessError(c, err, req.TemplateID)
+		return
 	}
 
-	reportData, err := repository.GenerateReportData(req.TemplateID, req.StartDate, req.EndDate)
+	// Satker dari otorisasi dipasang setelah validasi: pohon satker grant boleh melebihi batas jumlah nilai filter dari query.
+	filter.SatkerIDs = authz.SatkerIDs
+	reportData, err := repository.GenerateReportData(req.TemplateID, filter)
+	if errors.Is(err, repository.ErrTooManyCohorts) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		response.Internal(c, err)
 		return
@@ -174,21 +193,34 @@ func GenerateReport(c *gin.Context) {
 		endDate = &req.EndDate
 	}
 
+	baseFilename := filepath.Base(filename)
 	download := &entity.ReportDownload{
 		UserID:     userIDInt,
 		ReportName: reportData.Title,
 		TemplateID: req.TemplateID,
 		Format:     formatUpper,
 		FileSize:   fileSize,
+		Filename:   baseFilename,
+		SatkerID:   req.SatkerID,
 		StartDate:  startDate,
 		EndDate:    endDate,
 	}
 
+	// Tanpa catatan ini file tidak bisa diunduh (otorisasi DownloadFile), jadi gagal simpan = gagal generate.
 	if err := repository.CreateReportDownload(download); err != nil {
-		c.Error(err) // Catat error tapi jangan gagalkan response
+		os.Remove(filename)
+		response.Internal(c, err)
+		return
 	}
 
-	baseFilename := filepath.Base(filename)
+	actor := auditActor(c)
+	actor.UserID = &userIDInt
+	recordAudit(actor, service.AuditEntry{
+		Action:     service.AuditActionReportGenerate,
+		TargetType: service.AuditTargetReport,
+		TargetID:   baseFilename,
+		After:      gin.H{"template_id": req.TemplateID, "format": formatUpper, "start_date": req.StartDate, "end_date": req.EndDate, "satker_id": req.SatkerID},
+	})
 	downloadURL := fmt.Sprintf("/api/reports/download/%s", baseFilename)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -202,7 +234,27 @@ func GenerateReport(c *gin.Context) {
 	})
 }
 
-// DownloadFile mengirim file laporan yang sudah di-generate (path :filename). Cegah path traversal; set Content-Type dan Content-Disposition.
+// respondReportAccessError memetakan error AuthorizeReport: template/satker tidak valid → 400, tanpa grant atau di luar cakupan → 403 (dicatat ke audit sebagai auth.access_denied).
+func respondReportAccessError(c *gin.Context, err error, templateID string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReportTemplate), errors.Is(err, service.ErrInvalidSatkerScope):
+		response.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrReportAccessDenied), errors.Is(err, service.ErrReportTemplateDenied), errors.Is(err, service.ErrReportSatkerDenied):
+		recordAudit(auditActor(c), service.AuditEntry{
+			Action:     service.AuditActionAccessDenied,
+			TargetType: service.AuditTargetReport,
+			TargetID:   templateID,
+			After:      gin.H{"reason": err.Error()},
+		})
+		response.Error(c, http.StatusForbidden, err.Error())
+	default:
+		response.Internal(c, err)
+	}
+}
+
+// DownloadFile mengirim file laporan yang sudah di-generate (path :filename). Butuh JWT. Cegah path traversal; set Content-Type dan Content-Disposition.
+// File hanya dikirim ke user yang membuatnya (baris report_downloads dengan filename ini) atau admin, lalu grant akses laporan diperiksa ulang
+// untuk template dan satker laporan (AuthorizeReport, termasuk template khusus admin): grant yang dicabut atau kedaluwarsa menutup unduhan file lama.
 func DownloadFile(c *gin.Context) {
 	filename := c.Param("filename")
 
@@ -212,6 +264,33 @@ func DownloadFile(c *gin.Context) {
 		return
 	}
 
+	record, err := repository.GetReportDownloadByFilename(filename)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	userID := c.GetInt("user_id")
+	actor := auditActor(c)
+	actor.UserID = &userID
+	if c.GetString("user_role") != entity.RoleAdmin && record.UserID != userID {
+		recordAudit(actor, service.AuditEntry{
+			Action:     service.AuditActionAccessDenied,
+			TargetType: service.AuditTargetReport,
+			TargetID:   filename,
+			After:      gin.H{"reason": "bukan pemilik laporan"},
+		})
+		response.Error(c, http.StatusForbidden, "Tidak berhak mengunduh laporan ini")
+		return
+	}
+	if _, err := service.NewAccessWorkflowService(database.GetDB()).AuthorizeReport(userID, record.TemplateID, record.SatkerID); err != nil {
+		respondReportAccessError(c, err, record.TemplateID)
+		return
+	}
+
 	filePath := filepath.Join("generated_reports", filename)
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -232,6 +311,13 @@ func DownloadFile(c *gin.Context) {
 		contentType = "application/octet-stream"
 	}
 
+	recordAudit(actor, service.AuditEntry{
+		Action:     service.AuditActionReportDownload,
+		TargetType: service.AuditTargetReport,
+		TargetID:   filename,
+		After:      gin.H{"template_id": record.TemplateID, "format": record.Format, "owner_user_id": record.UserID, "satker_id": record.SatkerID},
+	})
+
 	c.Header("Content-Type", contentType)
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	c.File(filePath)
@@ -251,7 +337,7 @@ func formatFileSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// GetRecentDownloads mengembalikan riwayat unduhan terbaru. Query: limit, start_date, end_date (opsional). Jika tabel report_downloads belum ada, kembalikan array kosong agar UI tidak error.
+// GetRecentDownloads mengembalikan riwayat unduhan terbaru (butuh JWT; admin melihat semua user, selain admin hanya miliknya). Query: limit, start_date, end_date (opsional). Jika tabel report_downloads belum ada, kembalikan array kosong agar UI tidak error.
 func GetRecentDownloads(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", strconv.Itoa(config.DefaultLimit))
 	limit, err := strconv.Atoi(limitStr)
@@ -262,7 +348,11 @@ func GetRecentDownloads(c *gin.Context) {
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 
-	downloads, err := repository.GetRecentDownloadsWithFilter(limit, startDate, endDate)
+	scopeUserID := c.GetInt("user_id")
+	if c.GetString("user_role") == entity.RoleAdmin {
+		scopeUserID = 0
+	}
+	downloads, err := repository.GetRecentDownloadsWithFilter(limit, startDate, endDate, scopeUserID)
 	if err != nil {
 		if strings.Contains(err.Error(), "report_downloads") && strings.Contains(err.Error(), "does not exist") {
 			c.JSON(http.StatusOK, gin.H{"data": []interface{}{}})
@@ -315,13 +405,16 @@ func GetAccessRequests(c *gin.Context) {
 	}
 
 	type AccessRequestResponse struct {
-		ID          int       `json:"id"`
-		UserID      int       `json:"user_id"`
-		UserName    string    `json:"user_name"`
-		Unit        string    `json:"unit"`
-		RequestedAt time.Time `json:"requested_at"`
-		Status      string    `json:"status"`
-		Reason      string    `json:"reason,omitempty"`
+		ID          int        `json:"id"`
+		UserID      int        `json:"user_id"`
+		UserName    string     `json:"user_name"`
+		Unit        string     `json:"unit"`
+		RequestedAt time.Time  `json:"requested_at"`
+		Status      string     `json:"status"`
+		Reason      string     `json:"reason,omitempty"`
+		CurrentStep int        `json:"current_step"`
+		StepRole    string     `json:"step_role,omitempty"`
+		ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	}
 
 	responseData := make([]AccessRequestResponse, 0, len(requests))
@@ -345,9 +438,12 @@ func GetAccessRequests(c *gin.Context) {
 			RequestedAt: r.RequestedAt,
 			Status:      r.Status,
 			Reason:      r.Reason,
+			CurrentStep: r.CurrentStep,
+			ExpiresAt:   r.ExpiresAt,
 		})
 
-		if r.Status == "pending" {
+		if r.Status == entity.AccessRequestPending {
+			responseData[len(responseData)-1].StepRole = r.CurrentStepRole()
 			pendingCount++
 		}
 	}
@@ -358,55 +454,24 @@ func GetAccessRequests(c *gin.Context) {
 	})
 }
 
-// RequestAccess membuat permintaan akses laporan (body: user_id, reason). Buat record di report_access_requests dan set user.report_access_status = pending.
+// RequestAccess membuat permintaan akses laporan lewat workflow persetujuan (body: reason wajib, templates dan satker_ids opsional).
+// Butuh JWT; pemohon selalu user login (user_id di body, jika dikirim klien lama, diabaikan) sehingga audit tidak bisa diatasnamakan user lain.
 func RequestAccess(c *gin.Context) {
-	var req struct {
-		UserID int    `json:"user_id"`
-		Reason string `json:"reason"`
-	}
-
+	var req accessSubmitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
-
-	if req.UserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID is required"})
-		return
-	}
-
-	db := database.GetDB()
-
-	var user entity.User
-	if err := db.First(&user, req.UserID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	if user.ReportAccessStatus == "pending" {
-		c.JSON(http.StatusConflict, gin.H{"error": "Permintaan akses sedang diproses"})
-		return
-	}
-	if user.ReportAccessStatus == "approved" {
-		c.JSON(http.StatusConflict, gin.H{"error": "Anda sudah memiliki akses laporan"})
-		return
-	}
-
-	accessRequest := entity.ReportAccessRequest{
-		UserID:      req.UserID,
-		Reason:      req.Reason,
-		Status:      "pending",
-		RequestedAt: time.Now(),
-	}
-
-	if err := db.Create(&accessRequest).Error; err != nil {
-		response.Internal(c, err)
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	// Sinkronkan status user ke pending agar konsisten dengan permintaan.
-	if err := db.Model(&user).Update("report_access_status", "pending").Error; err != nil {
-		response.Internal(c, err)
+	actor := auditActor(c)
+	accessRequest, err := service.NewAccessWorkflowService(database.GetDB()).Submit(actor, userID, req.Reason, req.scope())
+	if err != nil {
+		respondAccessWorkflowError(c, err)
 		return
 	}
 
@@ -414,15 +479,15 @@ func RequestAccess(c *gin.Context) {
 		"success":    true,
 		"message":    "Permintaan akses berhasil dikirim",
 		"request_id": accessRequest.ID,
+		"status":     accessRequest.Status,
 	})
 }
 
-// UpdateAccessRequest mengubah status permintaan akses (path :id, body: status approved/rejected, admin_notes). Hanya admin. Update report_access_requests dan users.report_access_status; buat notifikasi untuk user.
+// UpdateAccessRequest memutuskan tahap aktif permintaan akses (path :id, body: status approved/rejected, admin_notes sebagai alasan; wajib untuk rejected).
+// Butuh JWT; hanya penyetuju tahap aktif atau admin. Status yang dikembalikan tetap pending jika masih ada tahap berikutnya.
 func UpdateAccessRequest(c *gin.Context) {
-	id := c.Param("id")
-	requestID, err := strconv.Atoi(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+	requestID, ok := parseIDParam(c, "id")
+	if !ok {
 		return
 	}
 
@@ -436,64 +501,23 @@ func UpdateAccessRequest(c *gin.Context) {
 		return
 	}
 
-	if req.Status != "approved" && req.Status != "rejected" {
+	if req.Status != entity.AccessRequestApproved && req.Status != entity.AccessRequestRejected {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be 'approved' or 'rejected'"})
 		return
 	}
 
-	db := database.GetDB()
-
-	var accessRequest entity.ReportAccessRequest
-	if err := db.First(&accessRequest, requestID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
-		return
-	}
-
-	now := time.Now()
-	accessRequest.Status = req.Status
-	accessRequest.ProcessedAt = &now
-	accessRequest.AdminNotes = req.AdminNotes
-
-	if err := db.Save(&accessRequest).Error; err != nil {
-		response.Internal(c, err)
-		return
-	}
-
-	// Jika ditolak, set user ke "none" agar bisa ajukan lagi.
-	newStatus := req.Status
-	if req.Status == "rejected" {
-		newStatus = "none"
-	}
-	if err := db.Model(&entity.User{}).Where("id = ?", accessRequest.UserID).Update("report_access_status", newStatus).Error; err != nil {
-		response.Internal(c, err)
+	accessRequest, err := service.NewAccessWorkflowService(database.GetDB()).
+		Decide(auditActor(c), requestID, req.Status == entity.AccessRequestApproved, req.AdminNotes, nil)
+	if err != nil {
+		respondAccessWorkflowError(c, err)
 		return
 	}
 
-	notifTitle := "Akses Laporan"
-	notifMessage := "Permintaan akses laporan Anda telah disetujui"
-	notifType := "success"
-	if req.Status == "rejected" {
-		notifMessage = "Permintaan akses laporan Anda ditolak"
-		notifType = "error"
-	}
-
-	notification := entity.Notification{
-		UserID:        accessRequest.UserID,
-		Title:         notifTitle,
-		Message:       notifMessage,
-		Type:          notifType,
-		IsRead:        false,
-		RelatedEntity: "report_access",
-		RelatedID:     &requestID,
-		CreatedAt:     time.Now(),
-	}
-
-	db.Create(&notification)
-
 	c.JSON(http.StatusOK, gin.H{
-		"success":    true,
-		"request_id": requestID,
-		"status":     req.Status,
-		"message":    "Status permintaan berhasil diperbarui",
+		"success":      true,
+		"request_id":   requestID,
+		"status":       accessRequest.Status,
+		"current_step": accessRequest.CurrentStep,
+		"message":      "Status permintaan berhasil diperbarui",
 	})
 }
@@ -0,0 +1,64 @@
+// File session_handler.go: HTTP handler sesi login milik user sendiri (prefix /api/account/sessions, butuh JWT).
+//
+// Endpoint: ListMySessions (sesi aktif: perangkat, IP, user agent, waktu dibuat/terakhir aktif/kedaluwarsa; current = sesi token ini),
+// RevokeMySession (cabut satu sesi; token yang terikat ke sesi itu langsung ditolak AuthMiddleware).
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListMySessions mengembalikan sesi login aktif milik user yang login (terakhir aktif dulu).
+func ListMySessions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	sessions, err := service.NewSessionService(database.GetDB()).ListActive(userID)
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	current := c.GetString("session_id")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions, "count": len(sessions)})
+}
+
+// RevokeMySession mencabut satu sesi login milik user (path :id). Mencabut sesi saat ini sama dengan logout.
+func RevokeMySession(c *gin.Context) {
+	sessionID := c.Param("id")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	session, err := service.NewSessionService(database.GetDB()).Revoke(userID, sessionID, service.SessionRevokedByUser)
+	if err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			response.Error(c, http.StatusNotFound, "Sesi tidak ditemukan")
+			return
+		}
+		response.Internal(c, err)
+		return
+	}
+
+	recordAudit(auditActor(c), service.AuditEntry{
+		Action:     service.AuditActionSessionRevoke,
+		TargetType: service.AuditTargetSession,
+		TargetID:   session.ID,
+		After:      gin.H{"device": session.Device, "ip": session.IP},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sesi berhasil dicabut",
+		"current": session.ID == c.GetString("session_id"),
+	})
+}
@@ -0,0 +1,92 @@
+// File user_engagement_handler.go: HTTP handler status engagement profil aktivitas (active, dormant, never_active) di bawah /api/users/engagement.
+//
+// Endpoint: GetUserEngagement (jumlah per status, total dan per satker), ListEngagementProfiles (daftar profil per status).
+// Hanya admin dan unit_head; cakupan satker ditentukan service.UserEngagementService.Scope.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/response"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// GetUserEngagement mengembalikan jumlah profil active, dormant, dan never_active (total dan per satker, dorman terbanyak dulu).
+// Query: root_satker_id, satker_ids, eselon (satker profil). unit_head dibatasi ke pohon satker-nya.
+func GetUserEngagement(c *gin.Context) {
+	svc := service.NewUserEngagementService(database.GetDB())
+	scope, ok := engagementScope(c, svc)
+	if !ok {
+		return
+	}
+	summary, err := svc.Summary(scope, time.Now())
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+// ListEngagementProfiles mengembalikan profil beserta status engagement, aktivitas terakhir paling lama dulu.
+// Query: status (active|dormant|never_active), root_satker_id, satker_ids, eselon, page, page_size.
+func ListEngagementProfiles(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(config.DefaultPageSizeAdmin)))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > config.MaxPageSizeAdmin {
+		pageSize = config.DefaultPageSizeAdmin
+	}
+	status := c.Query("status")
+	if !oneOf(status, entity.UserEngagementActive, entity.UserEngagementDormant, entity.UserEngagementNeverActive) {
+		response.Error(c, http.StatusBadRequest, "status harus salah satu dari: active, dormant, never_active")
+		return
+	}
+
+	svc := service.NewUserEngagementService(database.GetDB())
+	scope, ok := engagementScope(c, svc)
+	if !ok {
+		return
+	}
+	profiles, total, err := svc.Profiles(repository.EngagementFilter{Satker: scope, Status: status, Page: page, PageSize: pageSize}, time.Now())
+	if err != nil {
+		response.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        profiles,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// engagementScope mengurai filter satker dari query lalu membatasinya sesuai role user login. Jika gagal, response sudah ditulis dan ok=false.
+func engagementScope(c *gin.Context, svc *service.UserEngagementService) (repository.ActivityFilter, bool) {
+	filter, ok := parseActivityFilter(c)
+	if !ok {
+		return filter, false
+	}
+	scope, err := svc.Scope(c.GetInt("user_id"), c.GetString("user_role"), filter)
+	switch {
+	case errors.Is(err, service.ErrEngagementForbidden), errors.Is(err, service.ErrEngagementScope):
+		response.Error(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrEngagementNoSatker):
+		response.Error(c, http.StatusConflict, err.Error())
+	case err != nil:
+		response.Internal(c, err)
+	default:
+		return scope, true
+	}
+	return scope, false
+}
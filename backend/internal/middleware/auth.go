@@ -1,22 +1,27 @@
 // Package middleware berisi middleware HTTP untuk autentikasi dan otorisasi.
 //
-// File auth.go: AuthMiddleware (validasi JWT dari header Authorization, set user_id dan user_role di context),
-// AdminMiddleware (pastikan user punya role admin; harus dipasang setelah AuthMiddleware).
+// File auth.go: AuthMiddleware (validasi JWT dari header Authorization + sesi login di auth_sessions, set user_id, user_role, dan session_id di context; token wajib-ganti-password hanya boleh ke endpoint ganti password),
+// AdminMiddleware (pastikan user punya role admin; harus dipasang setelah AuthMiddleware; penolakan dicatat ke audit_events).
 package middleware
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/bpk-ri/dashboard-monitoring/internal/auth"
 	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/service"
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware memvalidasi JWT dari header Authorization (format "Bearer <token>") dan menyimpan user_id serta user_role di context.
-// Jika token tidak ada, format salah, atau invalid/kedaluwarsa, request di-abort dengan 401. Handler berikutnya bisa membaca c.Get("user_id") dan c.Get("user_role").
+// PasswordChangePath adalah route (c.FullPath) yang tetap boleh diakses dengan token wajib-ganti-password.
+const PasswordChangePath = "/api/account/change-password"
+
+// AuthMiddleware memvalidasi JWT dari header Authorization (format "Bearer <token>") beserta sesi login-nya, lalu menyimpan user_id, user_role, dan session_id di context.
+// Jika token tidak ada, format salah, invalid/kedaluwarsa, atau sesinya sudah dicabut, request di-abort dengan 401. Handler berikutnya bisa membaca c.Get("user_id") dan c.Get("user_role").
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -41,7 +46,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		userID, role, err := auth.ValidateToken(tokenString)
+		claims, err := auth.ParseToken(tokenString)
 		if err != nil {
 			if errors.Is(err, auth.ErrJWTSecretNotSet) {
 				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server misconfiguration"})
@@ -53,9 +58,29 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Token harus terikat ke sesi yang masih aktif (belum logout/dicabut); token lama tanpa jti ikut ditolak sehingga user login ulang.
+		if _, err := service.NewSessionService(database.GetDB()).Validate(claims.SessionID(), claims.UserID, c.ClientIP()); err != nil {
+			if errors.Is(err, service.ErrSessionInvalid) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Sesi tidak valid atau sudah berakhir, silakan login kembali", "code": "session_invalid"})
+			} else {
+				log.Printf("[ERROR] %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Terjadi kesalahan"})
+			}
+			c.Abort()
+			return
+		}
+
+		// Token dari login dengan password kedaluwarsa/direset admin hanya boleh dipakai untuk ganti password.
+		if claims.PasswordChangeRequired && c.FullPath() != PasswordChangePath {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Password harus diganti sebelum melanjutkan", "code": "password_change_required"})
+			c.Abort()
+			return
+		}
+
 		// Simpan di context agar handler bisa pakai c.Get("user_id") dan c.Get("user_role").
-		c.Set("user_id", userID)
-		c.Set("user_role", role)
+		c.Set("user_id", claims.UserID)
+		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID())
 
 		c.Next()
 	}
@@ -82,6 +107,15 @@ func AdminMiddleware() gin.HandlerFunc {
 		}
 
 		if user.Role != "admin" {
+			// Percobaan akses route admin oleh non-admin dicatat ke audit trail (gagal mencatat tidak mengubah response).
+			actor := service.AuditActor{UserID: &user.ID, Username: user.Username, IP: c.ClientIP(), UserAgent: c.Request.UserAgent(), RequestID: c.GetString("request_id")}
+			if err := service.NewAuditService(db).Record(actor, service.AuditEntry{
+				Action:     service.AuditActionAccessDenied,
+				TargetType: service.AuditTargetRoute,
+				TargetID:   c.Request.Method + " " + c.FullPath(),
+			}); err != nil {
+				log.Printf("[ERROR] audit %s: %v", service.AuditActionAccessDenied, err)
+			}
 			c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak: hanya admin yang diizinkan"})
 			c.Abort()
 			return
@@ -0,0 +1,28 @@
+// File request_id.go: RequestID middleware — setiap request mendapat ID unik (header X-Request-ID) untuk korelasi log dan audit trail.
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader nama header request/response yang membawa request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID membatasi request ID dari client: alfanumerik, '-', '_', '.', maksimal 100 karakter (mencegah log/header injection).
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// RequestID memakai X-Request-ID dari client jika formatnya valid, selain itu membuat UUID baru. ID disimpan di context ("request_id") dan dikirim balik di header response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Set("request_id", id)
+		c.Writer.Header().Set(RequestIDHeader, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,105 @@
+// File account_hygiene_repository.go: deteksi akun dorman dan yatim untuk template laporan account-hygiene dan GET /api/admin/users/flagged.
+//
+// FlaggedAccounts mengembalikan akun users aktif yang tidak login sejak batas dorman atau satker-nya sudah tidak ada di ref_satker_units
+// (users.satker_id tidak punya foreign key). InactiveProfiles mengembalikan user_profiles yang belum pernah punya aktivitas (last_activity NULL).
+package repository
+
+import (
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+)
+
+// AccountHygieneRepository menyimpan koneksi DB untuk deteksi akun dorman/yatim.
+type AccountHygieneRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountHygieneRepository membuat instance AccountHygieneRepository.
+func NewAccountHygieneRepository(db *gorm.DB) *AccountHygieneRepository {
+	return &AccountHygieneRepository{db: db}
+}
+
+// FlaggedAccount satu akun users aktif yang ditandai beserta alasannya (entity.AccountFlag*).
+type FlaggedAccount struct {
+	ID             int        `json:"id"`
+	Username       string     `json:"username"`
+	FullName       string     `json:"full_name"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	SatkerID       *int64     `json:"satker_id"`
+	LastLogin      *time.Time `json:"last_login"`
+	CreatedAt      time.Time  `json:"created_at"`
+	OrphanedSatker bool       `json:"-"`
+	Reasons        []string   `json:"reasons" gorm:"-"`
+}
+
+// InactiveProfile satu profil aktivitas tanpa aktivitas sama sekali.
+type InactiveProfile struct {
+	ID         int64  `json:"id"`
+	Nama       string `json:"nama"`
+	Email      string `json:"email"`
+	SatkerID   *int64 `json:"satker_id"`
+	SatkerName string `json:"satker_name"`
+}
+
+// FlaggedAccounts mengembalikan akun aktif yang terakhir login (atau dibuat, jika belum pernah login) sebelum dormantSince
+// atau yang satker-nya tidak ada lagi, login terlama dulu. satkerIDs tidak kosong = hanya akun dengan satker tersebut
+// (akun yatim otomatis tidak ikut karena satker-nya sudah tidak ada).
+func (r *AccountHygieneRepository) FlaggedAccounts(dormantSince time.Time, satkerIDs []int64) ([]FlaggedAccount, error) {
+	sql, args := sqlbuilder.Select(
+		"u.id", "u.username", "COALESCE(u.full_name, '') AS full_name", "COALESCE(u.email, '') AS email", "u.role",
+		"u.satker_id", "u.last_login", "u.created_at",
+		"(u.satker_id IS NOT NULL AND s.id IS NULL) AS orphaned_satker",
+	).
+		From("users u").
+		LeftJoin("ref_satker_units s ON s.id = u.satker_id").
+		WhereExpr("u.is_active = TRUE").
+		WhereExpr("(COALESCE(u.last_login, u.created_at) < ? OR (u.satker_id IS NOT NULL AND s.id IS NULL))", dormantSince).
+		Where(ActivityFilter{SatkerIDs: satkerIDs}.where("u")).
+		OrderBy("u.last_login ASC NULLS FIRST", "u.id").
+		Build()
+
+	var accounts []FlaggedAccount
+	if err := r.db.Raw(sql, args...).Scan(&accounts).Error; err != nil {
+		return nil, err
+	}
+	for i := range accounts {
+		lastSeen := accounts[i].CreatedAt
+		if accounts[i].LastLogin != nil {
+			lastSeen = *accounts[i].LastLogin
+		}
+		if lastSeen.Before(dormantSince) {
+			accounts[i].Reasons = append(accounts[i].Reasons, entity.AccountFlagDormantLogin)
+		}
+		if accounts[i].OrphanedSatker {
+			accounts[i].Reasons = append(accounts[i].Reasons, entity.AccountFlagOrphanedSatker)
+		}
+	}
+	if accounts == nil {
+		accounts = []FlaggedAccount{}
+	}
+	return accounts, nil
+}
+
+// InactiveProfiles mengembalikan profil tanpa aktivitas (first_activity/last_activity NULL), urut nama. satkerIDs tidak kosong = hanya profil di satker tersebut.
+func (r *AccountHygieneRepository) InactiveProfiles(satkerIDs []int64) ([]InactiveProfile, error) {
+	sql, args := sqlbuilder.Select("up.id", "up.nama", "COALESCE(up.email, '') AS email", "up.satker_id", "COALESCE(s.satker_name, '') AS satker_name").
+		From("user_profiles up").
+		LeftJoin("ref_satker_units s ON s.id = up.satker_id").
+		WhereExpr("up.last_activity IS NULL").
+		Where(ActivityFilter{SatkerIDs: satkerIDs}.where("up")).
+		OrderBy("up.nama", "up.id").
+		Build()
+
+	var profiles []InactiveProfile
+	if err := r.db.Raw(sql, args...).Scan(&profiles).Error; err != nil {
+		return nil, err
+	}
+	if profiles == nil {
+		profiles = []InactiveProfile{}
+	}
+	return profiles, nil
+}
@@ -0,0 +1,212 @@
+// File activity_cohort_repository.go: matriks kohort retensi user untuk GET /api/insights/cohorts dan template laporan user-retention.
+//
+// Kohort = minggu ISO atau bulan dari user_profiles.first_activity. Untuk setiap kohort dihitung jumlah user (size) dan jumlah user yang punya
+// aktivitas pada periode ke-0 (periode kohort itu sendiri) sampai ke-N sesudahnya; retensi = aktif / size. Periode yang belum berjalan tidak diisi
+// sehingga baris kohort terbaru lebih pendek (matriks segitiga). Filter satker/eselon membatasi anggota kohort (satker profil); filter cluster,
+// status, jenis aktivitas, dan provinsi membatasi aktivitas yang dihitung sebagai aktif. Tanggal filter memilih kohort (rentang first_activity).
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+)
+
+var (
+	ErrInvalidCohortGranularity = errors.New("granularity harus week atau month")
+	ErrInvalidCohortPeriods     = errors.New("periods harus antara 1 dan batas periode kohort maksimum")
+	ErrTooManyCohorts           = errors.New("rentang tanggal mencakup terlalu banyak kohort; perkecil rentang atau pilih granularity month")
+)
+
+// CohortQuery opsi matriks kohort: granularitas kohort dan periode (week|month) serta jumlah periode lanjutan per kohort.
+type CohortQuery struct {
+	Granularity string `json:"granularity"`
+	Periods     int    `json:"periods"`
+}
+
+// Validate memeriksa granularitas dan jumlah periode (1..config.MaxCohortPeriods).
+func (q CohortQuery) Validate() error {
+	if q.Granularity != GranularityWeek && q.Granularity != GranularityMonth {
+		return ErrInvalidCohortGranularity
+	}
+	if q.Periods < 1 || q.Periods > config.MaxCohortPeriods {
+		return ErrInvalidCohortPeriods
+	}
+	return nil
+}
+
+// CohortRow satu baris matriks: kohort (awal minggu/bulan, YYYY-MM-DD), jumlah user, lalu user aktif dan retensi (0–1) per periode ke-0..N
+// yang sudah berjalan.
+type CohortRow struct {
+	Cohort    string    `json:"cohort"`
+	Size      int64     `json:"size"`
+	Active    []int64   `json:"active"`
+	Retention []float64 `json:"retention"`
+}
+
+// CohortMatrix hasil analisis kohort. Average = retensi gabungan per periode (total aktif / total user kohort yang periode itu sudah berjalan).
+type CohortMatrix struct {
+	Granularity string      `json:"granularity"`
+	Periods     int         `json:"periods"`
+	StartDate   string      `json:"start_date"`
+	EndDate     string      `json:"end_date"`
+	TotalUsers  int64       `json:"total_users"`
+	Cohorts     []CohortRow `json:"cohorts"`
+	Average     []float64   `json:"average"`
+}
+
+// cohortCell hasil query: kohort dan periode sebagai YYYY-MM-DD menurut zona waktu database.
+type cohortCell struct {
+	Cohort string
+	Period string
+	Count  int64
+}
+
+// GetUserCohorts menyusun matriks kohort retensi sesuai opsi dan filter. Tanpa start_date, kohort dimulai q.Periods-1 periode sebelum end_date
+// (default hari ini); kedua batas dibulatkan ke awal/akhir minggu atau bulan.
+func (r *activityLogRepository) GetUserCohorts(q CohortQuery, filter ActivityFilter) (*CohortMatrix, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	start, end, err := filter.dates()
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end, _ = sqlbuilder.ParseDate(time.Now().Format(sqlbuilder.DateLayout))
+	}
+	last := truncateBucket(q.Granularity, end.Time())
+	first := cohortShift(q.Granularity, last, -(q.Periods - 1))
+	if !start.IsZero() {
+		first = truncateBucket(q.Granularity, start.Time())
+	}
+	var cohorts []time.Time
+	for b := first; !b.After(last); b = nextBucket(q.Granularity, b) {
+		if len(cohorts) == config.MaxCohorts {
+			return nil, ErrTooManyCohorts
+		}
+		cohorts = append(cohorts, b)
+	}
+	lower := first.Format(sqlbuilder.DateLayout)
+	upper := nextBucket(q.Granularity, last).Format(sqlbuilder.DateLayout)
+	activityUpper := cohortShift(q.Granularity, last, q.Periods+1).Format(sqlbuilder.DateLayout)
+
+	// Anggota kohort dibatasi atribut profil (satker, eselon); atribut aktivitas dipakai di query aktif.
+	members := ActivityFilter{RootSatkerIDs: filter.RootSatkerIDs, SatkerIDs: filter.SatkerIDs, Eselon: filter.Eselon}
+	activity := ActivityFilter{Clusters: filter.Clusters, Statuses: filter.Statuses, ActivityTypes: filter.ActivityTypes, Provinces: filter.Provinces}
+
+	// q.Granularity sudah divalidasi terhadap daftar konstanta sehingga aman menjadi literal date_trunc.
+	cohortExpr := "to_char(date_trunc('" + q.Granularity + "', up.first_activity), 'YYYY-MM-DD')"
+	memberQuery := func(columns ...string) *sqlbuilder.SelectBuilder {
+		return sqlbuilder.Select(columns...).
+			From("user_profiles up").
+			WhereExpr("up.first_activity >= ? AND up.first_activity < ?", lower, upper).
+			Where(members.where("up"))
+	}
+
+	var sizes []cohortCell
+	query, args := memberQuery(cohortExpr+" AS cohort", "COUNT(*) AS count").GroupBy("cohort").Build()
+	if err := r.db.Raw(query, args...).Scan(&sizes).Error; err != nil {
+		return nil, err
+	}
+	var active []cohortCell
+	query, args = sqlbuilder.Select("c.cohort", "to_char(date_trunc('"+q.Granularity+"', a.tanggal), 'YYYY-MM-DD') AS period", "COUNT(DISTINCT a.user_id) AS count").
+		FromSubquery(memberQuery("up.id AS user_id", cohortExpr+" AS cohort"), "c").
+		Join("activity_logs_normalized a ON a.user_id = c.user_id").
+		WhereExpr("a.tanggal >= ? AND a.tanggal < ?", lower, activityUpper).
+		Where(activity.where("a")).
+		GroupBy("c.cohort", "period").
+		Build()
+	if err := r.db.Raw(query, args...).Scan(&active).Error; err != nil {
+		return nil, err
+	}
+	var current string
+	if err := r.db.Raw("SELECT to_char(date_trunc('" + q.Granularity + "', now()), 'YYYY-MM-DD')").Scan(&current).Error; err != nil {
+		return nil, err
+	}
+	return buildCohortMatrix(q, cohorts, sizes, active, current), nil
+}
+
+// buildCohortMatrix menyusun baris per kohort (termasuk kohort tanpa user) dan retensi gabungan dari hasil query.
+func buildCohortMatrix(q CohortQuery, cohorts []time.Time, sizes, active []cohortCell, current string) *CohortMatrix {
+	result := &CohortMatrix{
+		Granularity: q.Granularity,
+		Periods:     q.Periods,
+		StartDate:   cohorts[0].Format(sqlbuilder.DateLayout),
+		EndDate:     nextBucket(q.Granularity, cohorts[len(cohorts)-1]).AddDate(0, 0, -1).Format(sqlbuilder.DateLayout),
+		Cohorts:     make([]CohortRow, 0, len(cohorts)),
+	}
+	sizeOf := map[string]int64{}
+	for _, s := range sizes {
+		sizeOf[s.Cohort] = s.Count
+	}
+	activeOf := map[string]map[int]int64{}
+	for _, a := range active {
+		offset, ok := cohortOffset(q.Granularity, a.Cohort, a.Period)
+		if !ok || offset < 0 || offset > q.Periods {
+			continue
+		}
+		if activeOf[a.Cohort] == nil {
+			activeOf[a.Cohort] = map[int]int64{}
+		}
+		activeOf[a.Cohort][offset] = a.Count
+	}
+
+	activeTotal := make([]int64, q.Periods+1)
+	sizeTotal := make([]int64, q.Periods+1)
+	for _, c := range cohorts {
+		key := c.Format(sqlbuilder.DateLayout)
+		row := CohortRow{Cohort: key, Size: sizeOf[key], Active: []int64{}, Retention: []float64{}}
+		elapsed, ok := cohortOffset(q.Granularity, key, current)
+		if !ok || elapsed > q.Periods {
+			elapsed = q.Periods
+		}
+		for offset := 0; offset <= elapsed; offset++ {
+			n := activeOf[key][offset]
+			row.Active = append(row.Active, n)
+			row.Retention = append(row.Retention, ratio(n, row.Size))
+			activeTotal[offset] += n
+			sizeTotal[offset] += row.Size
+		}
+		result.TotalUsers += row.Size
+		result.Cohorts = append(result.Cohorts, row)
+	}
+	for offset := 0; offset <= q.Periods && sizeTotal[offset] > 0; offset++ {
+		result.Average = append(result.Average, ratio(activeTotal[offset], sizeTotal[offset]))
+	}
+	if result.Average == nil {
+		result.Average = []float64{}
+	}
+	return result
+}
+
+// cohortOffset mengembalikan selisih periode (minggu atau bulan) dari cohort ke period (keduanya YYYY-MM-DD awal periode).
+func cohortOffset(granularity, cohort, period string) (int, bool) {
+	c, err1 := time.Parse(sqlbuilder.DateLayout, cohort)
+	p, err2 := time.Parse(sqlbuilder.DateLayout, period)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	if granularity == GranularityWeek {
+		return int(p.Sub(c).Hours()/24) / 7, true
+	}
+	return (p.Year()-c.Year())*12 + int(p.Month()) - int(c.Month()), true
+}
+
+// cohortShift menggeser awal periode b sebanyak n minggu atau bulan (n boleh negatif).
+func cohortShift(granularity string, b time.Time, n int) time.Time {
+	if granularity == GranularityWeek {
+		return b.AddDate(0, 0, 7*n)
+	}
+	return b.AddDate(0, n, 0)
+}
+
+// ratio mengembalikan part/whole (0 jika whole 0).
+func ratio(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,67 @@
+// File activity_feed_repository.go: pembacaan aktivitas baru untuk live feed GET /api/dashboard/stream.
+//
+// LatestID mengembalikan id aktivitas terbesar (watermark awal feed). After membaca aktivitas dengan id > watermark berurutan id
+// beserta relasinya (untuk dto.ToDTO), opsional dengan filter; dipakai poller feed dan replay Last-Event-ID.
+// MatchIDs menyaring id aktivitas yang lolos filter dengan kondisi SQL yang sama seperti endpoint dashboard (ActivityFilter.where).
+package repository
+
+import (
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+)
+
+// ActivityFeedRepository menyimpan koneksi DB untuk live feed aktivitas.
+type ActivityFeedRepository struct {
+	db *gorm.DB
+}
+
+// NewActivityFeedRepository membuat instance ActivityFeedRepository.
+func NewActivityFeedRepository(db *gorm.DB) *ActivityFeedRepository {
+	return &ActivityFeedRepository{db: db}
+}
+
+// LatestID mengembalikan id terbesar di activity_logs_normalized (0 jika tabel kosong).
+func (r *ActivityFeedRepository) LatestID() (int64, error) {
+	var id int64
+	err := r.db.Raw("SELECT COALESCE(MAX(id), 0) FROM activity_logs_normalized").Scan(&id).Error
+	return id, err
+}
+
+// After mengembalikan paling banyak limit aktivitas dengan id > afterID yang lolos filter, urut id naik, dengan relasi di-preload.
+func (r *ActivityFeedRepository) After(afterID int64, limit int, filter ActivityFilter) ([]entity.ActivityLog, error) {
+	var activities []entity.ActivityLog
+	query := r.db.Model(&entity.ActivityLog{}).
+		Preload("User").
+		Preload("Satker").
+		Preload("ActivityType").
+		Preload("Cluster").
+		Preload("Location").
+		Where("activity_logs_normalized.id > ?", afterID)
+	query = filter.apply(query, "activity_logs_normalized")
+
+	err := query.Order("activity_logs_normalized.id").
+		Limit(limit).
+		Find(&activities).Error
+	return activities, err
+}
+
+// MatchIDs mengembalikan id dari ids yang lolos filter, urut id naik. Tanpa kondisi filter, ids dikembalikan apa adanya tanpa query.
+func (r *ActivityFeedRepository) MatchIDs(ids []int64, filter ActivityFilter) ([]int64, error) {
+	cond := filter.where("a")
+	if len(ids) == 0 || cond.IsEmpty() {
+		return ids, nil
+	}
+	sql, args := sqlbuilder.Select("a.id").
+		From("activity_logs_normalized a").
+		WhereExpr("a.id IN ?", ids).
+		Where(cond).
+		OrderBy("a.id").
+		Build()
+
+	var matched []int64
+	if err := r.db.Raw(sql, args...).Scan(&matched).Error; err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
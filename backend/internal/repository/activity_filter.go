@@ -0,0 +1,214 @@
+// File activity_filter.go: ActivityFilter — satu tipe filter aktivitas untuk semua query dashboard, regional, konten, pencarian, laporan, dan my-activity.
+//
+// Filter: rentang tanggal (inklusif, YYYY-MM-DD), jendela waktu tepat (Since/Until, dipakai aturan peringatan), cluster, eselon, pohon satker (RootSatkerIDs) dan satker eksak (SatkerIDs), status, jenis aktivitas, provinsi, user (user_profiles.id).
+// Filter multi-nilai digabung OR di dalam satu field dan AND antar field. Eselon diabaikan jika filter satker diisi (perilaku lama root_satker_id).
+// Kondisi disusun dengan sqlbuilder (nilai selalu lewat placeholder) dan memakai subquery ke tabel referensi sehingga bisa dipasang di query apa pun tanpa bentrok alias JOIN, termasuk tabel rollup (kolom ID sama).
+package repository
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidFilterDate   = errors.New("start_date dan end_date harus berformat YYYY-MM-DD")
+	ErrInvalidFilterRange  = errors.New("start_date tidak boleh setelah end_date")
+	ErrInvalidFilterID     = errors.New("ID pada filter harus bilangan bulat positif")
+	ErrTooManyFilterValues = errors.New("jumlah nilai filter melebihi batas")
+)
+
+// ActivityFilter filter aktivitas tervalidasi. Nilai kosong = tanpa batasan untuk field itu.
+type ActivityFilter struct {
+	StartDate     string     `json:"start_date,omitempty"`      // Tanggal awal (inklusif).
+	EndDate       string     `json:"end_date,omitempty"`        // Tanggal akhir (inklusif).
+	Clusters      []string   `json:"clusters,omitempty"`        // ref_clusters.name.
+	Eselon        string     `json:"eselon,omitempty"`          // ref_satker_units.eselon_level.
+	RootSatkerIDs []int64    `json:"root_satker_ids,omitempty"` // Root pohon satker (root + semua turunan).
+	SatkerIDs     []int64    `json:"satker_ids,omitempty"`      // satker_id eksak.
+	Statuses      []string   `json:"statuses,omitempty"`        // activity_logs_normalized.status.
+	ActivityTypes []string   `json:"activity_types,omitempty"`  // ref_activity_types.name.
+	Provinces     []string   `json:"provinces,omitempty"`       // ref_locations.province (tanpa beda huruf besar/kecil).
+	UserIDs       []int64    `json:"user_ids,omitempty"`        // user_profiles.id.
+	Since         *time.Time `json:"since,omitempty"`           // Waktu awal tepat (inklusif, konvensi kolom tanggal: jam dinding sebagai UTC); tidak diisi dari query string.
+	Until         *time.Time `json:"until,omitempty"`           // Waktu akhir tepat (eksklusif, konvensi yang sama); tidak diisi dari query string.
+}
+
+// Normalize mengembalikan salinan filter dengan string di-trim, nilai duplikat/kosong dibuang, slice diurutkan, provinsi huruf besar,
+// dan eselon dikosongkan jika filter satker diisi. Dua filter yang ekuivalen menghasilkan nilai yang sama (dipakai untuk kunci cache).
+func (f ActivityFilter) Normalize() ActivityFilter {
+	out := ActivityFilter{
+		StartDate:     strings.TrimSpace(f.StartDate),
+		EndDate:       strings.TrimSpace(f.EndDate),
+		Clusters:      normalizeStrings(f.Clusters, false),
+		Eselon:        strings.TrimSpace(f.Eselon),
+		RootSatkerIDs: normalizeIDs(f.RootSatkerIDs),
+		SatkerIDs:     normalizeIDs(f.SatkerIDs),
+		Statuses:      normalizeStrings(f.Statuses, false),
+		ActivityTypes: normalizeStrings(f.ActivityTypes, false),
+		Provinces:     normalizeStrings(f.Provinces, true),
+		UserIDs:       normalizeIDs(f.UserIDs),
+		Since:         f.Since,
+		Until:         f.Until,
+	}
+	if out.RootSatkerIDs != nil || out.SatkerIDs != nil {
+		out.Eselon = ""
+	}
+	return out
+}
+
+// Validate memeriksa format dan urutan tanggal, ID positif, dan jumlah nilai per field (config.MaxFilterValues).
+func (f ActivityFilter) Validate() error {
+	start, end, err := f.dates()
+	if err != nil {
+		return err
+	}
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		return ErrInvalidFilterRange
+	}
+	if f.Since != nil && f.Until != nil && !f.Since.Before(*f.Until) {
+		return ErrInvalidFilterRange
+	}
+	for _, ids := range [][]int64{f.RootSatkerIDs, f.SatkerIDs, f.UserIDs} {
+		if len(ids) > config.MaxFilterValues {
+			return ErrTooManyFilterValues
+		}
+		for _, id := range ids {
+			if id <= 0 {
+				return ErrInvalidFilterID
+			}
+		}
+	}
+	for _, values := range [][]string{f.Clusters, f.Statuses, f.ActivityTypes, f.Provinces} {
+		if len(values) > config.MaxFilterValues {
+			return ErrTooManyFilterValues
+		}
+	}
+	return nil
+}
+
+// dates mengurai StartDate dan EndDate menjadi sqlbuilder.Date (nol jika kosong).
+func (f ActivityFilter) dates() (start, end sqlbuilder.Date, err error) {
+	if start, err = sqlbuilder.ParseDate(f.StartDate); err != nil {
+		return start, end, ErrInvalidFilterDate
+	}
+	if end, err = sqlbuilder.ParseDate(f.EndDate); err != nil {
+		return start, end, ErrInvalidFilterDate
+	}
+	return start, end, nil
+}
+
+// rollupCompatible mengembalikan true jika filter bisa dijawab dari activity_rollup_hourly (rollup tidak menyimpan user dan hanya berbutir jam).
+func (f ActivityFilter) rollupCompatible() bool {
+	return len(f.UserIDs) == 0 && f.Since == nil && f.Until == nil
+}
+
+// where menyusun kondisi filter untuk tabel aktivitas dengan alias (mis. "activity_logs_normalized", "a"); fragment kosong jika tanpa filter.
+func (f ActivityFilter) where(alias string) sqlbuilder.Fragment {
+	return f.conditions(alias, false)
+}
+
+// rollupWhere menyusun kondisi filter untuk activity_rollup_hourly dengan alias (tanggal dari kolom day).
+func (f ActivityFilter) rollupWhere(alias string) sqlbuilder.Fragment {
+	return f.conditions(alias, true)
+}
+
+// apply menambahkan kondisi filter ke query tabel aktivitas dengan alias.
+func (f ActivityFilter) apply(db *gorm.DB, alias string) *gorm.DB {
+	if cond := f.where(alias); !cond.IsEmpty() {
+		return db.Where(cond.SQL, cond.Args...)
+	}
+	return db
+}
+
+// conditions membangun kondisi untuk semua field. Tanggal pada tabel mentah dibandingkan sebagai rentang timestamp (sqlbuilder.DateRange)
+// agar indeks tanggal terpakai; pada rollup dibandingkan dengan kolom day (sqlbuilder.DayRange). Tanggal tidak valid diabaikan (Validate menolaknya lebih dulu).
+func (f ActivityFilter) conditions(alias string, rollup bool) sqlbuilder.Fragment {
+	col := func(name string) string { return alias + "." + name }
+	var conds []sqlbuilder.Fragment
+
+	if start, end, err := f.dates(); err == nil {
+		if rollup {
+			conds = append(conds, sqlbuilder.DayRange(col("day"), start, end))
+		} else {
+			conds = append(conds, sqlbuilder.DateRange(col("tanggal"), start, end))
+		}
+	}
+	if f.Since != nil && !rollup {
+		conds = append(conds, sqlbuilder.Expr(col("tanggal")+" >= ?", *f.Since))
+	}
+	if f.Until != nil && !rollup {
+		conds = append(conds, sqlbuilder.Expr(col("tanggal")+" < ?", *f.Until))
+	}
+	if len(f.Clusters) > 0 {
+		conds = append(conds, sqlbuilder.Expr(col("cluster_id")+" IN (SELECT id FROM ref_clusters WHERE name IN ?)", f.Clusters))
+	}
+	if len(f.RootSatkerIDs) > 0 {
+		conds = append(conds, sqlbuilder.Expr(col("satker_id")+` IN (
+			WITH RECURSIVE satker_tree AS (
+				SELECT id FROM ref_satker_units WHERE id IN ?
+				UNION
+				SELECT s.id FROM ref_satker_units s INNER JOIN satker_tree t ON s.parent_id = t.id
+			)
+			SELECT id FROM satker_tree
+		)`, f.RootSatkerIDs))
+	}
+	if len(f.SatkerIDs) > 0 {
+		conds = append(conds, sqlbuilder.Expr(col("satker_id")+" IN ?", f.SatkerIDs))
+	}
+	if f.Eselon != "" && len(f.RootSatkerIDs) == 0 && len(f.SatkerIDs) == 0 {
+		conds = append(conds, sqlbuilder.Expr(col("satker_id")+" IN (SELECT id FROM ref_satker_units WHERE eselon_level = ?)", f.Eselon))
+	}
+	if len(f.Statuses) > 0 {
+		conds = append(conds, sqlbuilder.Expr(col("status")+" IN ?", f.Statuses))
+	}
+	if len(f.ActivityTypes) > 0 {
+		conds = append(conds, sqlbuilder.Expr(col("activity_type_id")+" IN (SELECT id FROM ref_activity_types WHERE name IN ?)", f.ActivityTypes))
+	}
+	if len(f.Provinces) > 0 {
+		conds = append(conds, sqlbuilder.Expr(col("location_id")+" IN (SELECT id FROM ref_locations WHERE UPPER(province) IN ?)", f.Provinces))
+	}
+	if len(f.UserIDs) > 0 && !rollup {
+		conds = append(conds, sqlbuilder.Expr(col("user_id")+" IN ?", f.UserIDs))
+	}
+	return sqlbuilder.And(conds...)
+}
+
+// normalizeStrings trim, buang kosong/duplikat, urutkan; upper = ubah ke huruf besar. Hasil nil jika tidak ada nilai.
+func normalizeStrings(values []string, upper bool) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if upper {
+			v = strings.ToUpper(v)
+		}
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// normalizeIDs buang duplikat lalu urutkan; ID <= 0 dipertahankan agar Validate bisa menolaknya. Hasil nil jika kosong.
+func normalizeIDs(ids []int64) []int64 {
+	seen := map[int64]bool{}
+	var out []int64
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,299 @@
+// File activity_funnel_repository.go: funnel urutan jenis aktivitas (mis. LOGIN → search → view → download) untuk GET /api/insights/funnel.
+//
+// Langkah dicocokkan ke nama atau kategori ref_activity_types (tanpa membedakan huruf besar/kecil). Untuk setiap aktivitas langkah pertama
+// dalam rentang tanggal dicari langkah berikutnya secara berurutan (aktivitas paling awal sesudah langkah sebelumnya) sampai batas window
+// dari langkah pertama, atau di dalam sesi yang sama (user_sessions) jika window = sesi. User dihitung mencapai langkah ke-k jika ada satu
+// percobaan yang mencapainya; langkah lanjutan boleh terjadi sesudah end_date selama masih di dalam window/sesi. Filter standar berlaku
+// untuk semua langkah kecuali filter jenis aktivitas (diganti daftar langkah). Opsional dipecah per cluster atau eselon aktivitas langkah pertama.
+package repository
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+)
+
+// Cara mencocokkan langkah funnel ke ref_activity_types.
+const (
+	FunnelStepByName     = "name"
+	FunnelStepByCategory = "category"
+)
+
+// FunnelWindowSession label window jika langkah harus berada di dalam satu sesi.
+const FunnelWindowSession = "session"
+
+var (
+	ErrInvalidFunnelSteps  = errors.New("steps harus berisi 2 sampai batas langkah funnel maksimum, tanpa nilai kosong")
+	ErrInvalidFunnelStepBy = errors.New("step_by harus name atau category")
+	ErrInvalidFunnelWindow = errors.New("window harus session atau durasi antara 1m dan batas window maksimum (mis. 30m, 24h)")
+	ErrInvalidFunnelSplit  = errors.New("split_by harus kosong, cluster, atau eselon")
+	ErrFunnelRangeTooLong  = errors.New("rentang tanggal funnel terlalu panjang; perkecil rentang")
+)
+
+// FunnelQuery opsi funnel: langkah berurutan, pencocokan (name|category), window dari langkah pertama (0 = satu sesi), dan dimensi pemecah.
+type FunnelQuery struct {
+	Steps   []string      `json:"steps"`
+	StepBy  string        `json:"step_by"`
+	Window  time.Duration `json:"window"`
+	SplitBy string        `json:"split_by,omitempty"`
+}
+
+// Validate memeriksa jumlah langkah, pencocokan, window, dan dimensi pemecah.
+func (q FunnelQuery) Validate() error {
+	if len(q.Steps) < 2 || len(q.Steps) > config.MaxFunnelSteps {
+		return ErrInvalidFunnelSteps
+	}
+	for _, step := range q.Steps {
+		if strings.TrimSpace(step) == "" {
+			return ErrInvalidFunnelSteps
+		}
+	}
+	if q.StepBy != FunnelStepByName && q.StepBy != FunnelStepByCategory {
+		return ErrInvalidFunnelStepBy
+	}
+	if q.Window != 0 && (q.Window < time.Minute || q.Window > config.MaxFunnelWindow) {
+		return ErrInvalidFunnelWindow
+	}
+	if q.SplitBy != "" && q.SplitBy != TimeSeriesByCluster && q.SplitBy != TimeSeriesByEselon {
+		return ErrInvalidFunnelSplit
+	}
+	return nil
+}
+
+// FunnelStep satu langkah: jumlah user yang mencapainya, konversi dari langkah pertama dan dari langkah sebelumnya (0–1).
+type FunnelStep struct {
+	Step           int     `json:"step"`
+	Name           string  `json:"name"`
+	Users          int64   `json:"users"`
+	Conversion     float64 `json:"conversion"`
+	StepConversion float64 `json:"step_conversion"`
+}
+
+// FunnelGroup funnel untuk satu nilai dimensi pemecah (cluster atau eselon aktivitas langkah pertama).
+type FunnelGroup struct {
+	Key   string       `json:"key"`
+	Steps []FunnelStep `json:"steps"`
+}
+
+// FunnelResult hasil funnel. Steps = semua user; Groups hanya jika split_by diisi (user bisa masuk lebih dari satu grup).
+type FunnelResult struct {
+	StepBy    string        `json:"step_by"`
+	Window    string        `json:"window"`
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Steps     []FunnelStep  `json:"steps"`
+	SplitBy   string        `json:"split_by,omitempty"`
+	Groups    []FunnelGroup `json:"groups,omitempty"`
+}
+
+// funnelEvent satu aktivitas yang cocok dengan salah satu langkah, urut per user lalu waktu.
+type funnelEvent struct {
+	UserID    int64
+	ID        int64
+	Tanggal   time.Time
+	Step      string
+	Grp       string
+	SessionID *int64
+	InRange   bool
+}
+
+// GetFunnel menghitung funnel sesuai opsi dan filter. Tanpa start_date, langkah pertama diambil dari config.DefaultFunnelRangeDays hari
+// sampai end_date (default hari ini).
+func (r *activityLogRepository) GetFunnel(q FunnelQuery, filter ActivityFilter) (*FunnelResult, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	start, end, err := filter.dates()
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end, _ = sqlbuilder.ParseDate(time.Now().Format(sqlbuilder.DateLayout))
+	}
+	if start.IsZero() {
+		start = end.AddDays(-(config.DefaultFunnelRangeDays - 1))
+	}
+	if end.Time().Sub(start.Time()) >= time.Duration(config.MaxFunnelRangeDays)*24*time.Hour {
+		return nil, ErrFunnelRangeTooLong
+	}
+
+	steps := make([]string, len(q.Steps))
+	for i, step := range q.Steps {
+		steps[i] = strings.ToLower(strings.TrimSpace(step))
+	}
+	// Langkah lanjutan boleh melewati end_date: batas atas = akhir end_date + window (sesi: + batas window maksimum).
+	extend := q.Window
+	if extend == 0 {
+		extend = config.MaxFunnelWindow
+	}
+	scope := filter
+	scope.StartDate, scope.EndDate, scope.ActivityTypes = "", "", nil
+	upper := end.AddDays(1).String()
+
+	// q.StepBy sudah divalidasi terhadap daftar konstanta sehingga aman menjadi nama kolom.
+	stepExpr := "LOWER(at." + q.StepBy + ")"
+	grpExpr, grpJoin := "''", ""
+	switch q.SplitBy {
+	case TimeSeriesByCluster:
+		grpExpr, grpJoin = "COALESCE(c.name, 'Tidak Terkategori')", "ref_clusters c ON c.id = a.cluster_id"
+	case TimeSeriesByEselon:
+		grpExpr, grpJoin = "COALESCE(NULLIF(s.eselon_level, ''), 'Tidak Diketahui')", "ref_satker_units s ON s.id = a.satker_id"
+	}
+	sessionExpr := "NULL::bigint"
+	if q.Window == 0 {
+		sessionExpr = "us.id"
+	}
+
+	b := sqlbuilder.Select("a.user_id", "a.id", "a.tanggal", stepExpr+" AS step", grpExpr+" AS grp", sessionExpr+" AS session_id",
+		"a.tanggal < CAST(? AS timestamp) AS in_range").
+		From("activity_logs_normalized a").
+		Join("ref_activity_types at ON at.id = a.activity_type_id")
+	if grpJoin != "" {
+		b.LeftJoin(grpJoin)
+	}
+	if q.Window == 0 {
+		// Sesi yang memuat aktivitas: sesi terakhir yang dimulai sebelum aktivitas dan belum berakhir saat itu (indeks user_id, started_at).
+		b.LeftJoin(`LATERAL (
+			SELECT us.id FROM user_sessions us
+			WHERE us.user_id = a.user_id AND us.started_at <= a.tanggal AND COALESCE(us.ended_at, us.last_activity_at) >= a.tanggal
+			ORDER BY us.started_at DESC LIMIT 1
+		) us ON TRUE`)
+	}
+	query, args := b.
+		WhereExpr("a.tanggal >= ? AND a.tanggal < CAST(? AS timestamp) + make_interval(secs => ?)", start.String(), upper, extend.Seconds()).
+		WhereExpr(stepExpr+" IN ?", steps).
+		Where(scope.where("a")).
+		OrderBy("a.user_id", "a.tanggal", "a.id").
+		Build()
+	// Placeholder in_range ada di daftar kolom, sebelum argumen kondisi WHERE.
+	args = append([]interface{}{upper}, args...)
+
+	rows, err := r.db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counter := newFunnelCounter(steps, q.Window)
+	var events []funnelEvent
+	for rows.Next() {
+		var ev funnelEvent
+		if err := rows.Scan(&ev.UserID, &ev.ID, &ev.Tanggal, &ev.Step, &ev.Grp, &ev.SessionID, &ev.InRange); err != nil {
+			return nil, err
+		}
+		if len(events) > 0 && events[0].UserID != ev.UserID {
+			counter.add(events)
+			events = events[:0]
+		}
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	counter.add(events)
+
+	result := &FunnelResult{
+		StepBy:    q.StepBy,
+		Window:    FunnelWindowSession,
+		StartDate: start.String(),
+		EndDate:   end.String(),
+		Steps:     funnelSteps(q.Steps, counter.total),
+		SplitBy:   q.SplitBy,
+	}
+	if q.Window != 0 {
+		result.Window = q.Window.String()
+	}
+	if q.SplitBy != "" {
+		result.Groups = make([]FunnelGroup, 0, len(counter.groups))
+		for _, key := range counter.groupOrder {
+			result.Groups = append(result.Groups, FunnelGroup{Key: key, Steps: funnelSteps(q.Steps, counter.groups[key])})
+		}
+		// Grup dengan user langkah pertama terbanyak dulu.
+		sort.SliceStable(result.Groups, func(i, j int) bool {
+			return result.Groups[i].Steps[0].Users > result.Groups[j].Steps[0].Users
+		})
+	}
+	return result, nil
+}
+
+// funnelCounter menghitung jumlah user per langkah (total dan per grup) dari aktivitas satu user sekaligus.
+type funnelCounter struct {
+	steps      []string
+	window     time.Duration
+	total      []int64
+	groups     map[string][]int64
+	groupOrder []string
+}
+
+// newFunnelCounter membuat funnelCounter untuk langkah (huruf kecil) dan window (0 = satu sesi).
+func newFunnelCounter(steps []string, window time.Duration) *funnelCounter {
+	return &funnelCounter{steps: steps, window: window, total: make([]int64, len(steps)), groups: map[string][]int64{}}
+}
+
+// add memproses aktivitas satu user (urut waktu): setiap langkah pertama dalam rentang dicoba sebagai awal funnel, langkah berikutnya diambil
+// dari aktivitas paling awal yang cocok sesudahnya (greedy, optimal untuk urutan tetap). Kedalaman terbaik dihitung per user dan per grup.
+func (fc *funnelCounter) add(events []funnelEvent) {
+	if len(events) == 0 {
+		return
+	}
+	best := 0
+	bestByGroup := map[string]int{}
+	for i, first := range events {
+		if !first.InRange || first.Step != fc.steps[0] {
+			continue
+		}
+		if fc.window == 0 && first.SessionID == nil {
+			continue
+		}
+		depth := 1
+		deadline := first.Tanggal.Add(fc.window)
+		for _, ev := range events[i+1:] {
+			if depth == len(fc.steps) {
+				break
+			}
+			if fc.window != 0 && ev.Tanggal.After(deadline) {
+				break
+			}
+			if fc.window == 0 && (ev.SessionID == nil || *ev.SessionID != *first.SessionID) {
+				continue
+			}
+			if ev.Step == fc.steps[depth] {
+				depth++
+			}
+		}
+		best = max(best, depth)
+		bestByGroup[first.Grp] = max(bestByGroup[first.Grp], depth)
+	}
+	for k := 0; k < best; k++ {
+		fc.total[k]++
+	}
+	for grp, depth := range bestByGroup {
+		counts, ok := fc.groups[grp]
+		if !ok {
+			counts = make([]int64, len(fc.steps))
+			fc.groups[grp] = counts
+			fc.groupOrder = append(fc.groupOrder, grp)
+		}
+		for k := 0; k < depth; k++ {
+			counts[k]++
+		}
+	}
+}
+
+// funnelSteps menyusun langkah funnel dengan konversi dari jumlah user per langkah.
+func funnelSteps(names []string, users []int64) []FunnelStep {
+	steps := make([]FunnelStep, len(names))
+	for i, name := range names {
+		steps[i] = FunnelStep{Step: i + 1, Name: name, Users: users[i], Conversion: ratio(users[i], users[0])}
+		if i == 0 {
+			steps[i].StepConversion = ratio(users[0], users[0])
+		} else {
+			steps[i].StepConversion = ratio(users[i], users[i-1])
+		}
+	}
+	return steps
+}
@@ -0,0 +1,137 @@
+// File activity_heatmap_repository.go: heatmap aktivitas hari-dalam-minggu × jam (7×24) untuk GET /api/regional/heatmap.
+//
+// Setiap sel berisi jumlah aktivitas dan jumlah user unik. Hari mengikuti ISO (indeks 0 = Senin … 6 = Minggu), jam 0–23 dari kolom tanggal.
+// Opsional: kecualikan hari libur (ref_holidays) dan normalisasi per satker (setiap satker berbobot sama: rata-rata porsi aktivitas satker di sel itu).
+// Selalu membaca tabel mentah karena user unik tidak tersedia di rollup.
+package repository
+
+import (
+	"errors"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+)
+
+// Mode normalisasi heatmap.
+const (
+	HeatmapNormalizeNone   = ""
+	HeatmapNormalizeSatker = "satker"
+)
+
+// HeatmapDays label hari heatmap sesuai urutan baris (ISO: Senin dulu).
+var HeatmapDays = []string{"Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu", "Minggu"}
+
+// ErrInvalidHeatmapNormalize dikembalikan jika mode normalisasi tidak dikenal.
+var ErrInvalidHeatmapNormalize = errors.New("normalize harus kosong atau satker")
+
+// HeatmapQuery opsi heatmap: normalisasi (kosong atau satker) dan pengecualian hari libur.
+type HeatmapQuery struct {
+	Normalize       string `json:"normalize,omitempty"`
+	ExcludeHolidays bool   `json:"exclude_holidays,omitempty"`
+}
+
+// Validate memeriksa mode normalisasi.
+func (q HeatmapQuery) Validate() error {
+	if q.Normalize != HeatmapNormalizeNone && q.Normalize != HeatmapNormalizeSatker {
+		return ErrInvalidHeatmapNormalize
+	}
+	return nil
+}
+
+// Heatmap matriks 7×24: Counts dan UniqueUsers per sel; Normalized (porsi 0–1, total 1) dan SatkerCount hanya jika normalize=satker.
+type Heatmap struct {
+	Days            []string        `json:"days"`
+	Counts          [7][24]int64    `json:"counts"`
+	UniqueUsers     [7][24]int64    `json:"unique_users"`
+	Normalized      *[7][24]float64 `json:"normalized,omitempty"`
+	SatkerCount     int64           `json:"satker_count,omitempty"`
+	Total           int64           `json:"total"`
+	Normalize       string          `json:"normalize,omitempty"`
+	ExcludeHolidays bool            `json:"exclude_holidays"`
+}
+
+// heatmapCell satu sel hasil query (dow 1–7 ISO, hour 0–23).
+type heatmapCell struct {
+	Dow         int
+	Hour        int
+	Count       int64
+	UniqueUsers int64
+	Share       float64
+}
+
+// GetActivityHeatmap mengembalikan heatmap hari × jam sesuai opsi dan filter.
+func (r *activityLogRepository) GetActivityHeatmap(q HeatmapQuery, filter ActivityFilter) (*Heatmap, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	result := &Heatmap{Days: HeatmapDays, Normalize: q.Normalize, ExcludeHolidays: q.ExcludeHolidays}
+
+	var cells []heatmapCell
+	query, args := heatmapBase(q, filter, heatmapDow, heatmapHour, "COUNT(*) AS count", "COUNT(DISTINCT a.user_id) AS unique_users").
+		GroupBy("dow", "hour").
+		Build()
+	if err := r.db.Raw(query, args...).Scan(&cells).Error; err != nil {
+		return nil, err
+	}
+	for _, cell := range cells {
+		if d, h, ok := heatmapIndex(cell); ok {
+			result.Counts[d][h] = cell.Count
+			result.UniqueUsers[d][h] = cell.UniqueUsers
+			result.Total += cell.Count
+		}
+	}
+
+	if q.Normalize == HeatmapNormalizeSatker {
+		// Porsi per satker: cnt sel / total satker, dijumlah lalu dibagi jumlah satker → setiap satker berbobot sama.
+		perSatker := heatmapBase(q, filter, "a.satker_id", heatmapDow, heatmapHour, "COUNT(*) AS cnt").
+			WhereExpr("a.satker_id IS NOT NULL").
+			GroupBy("a.satker_id", "dow", "hour").
+			Fragment()
+		var shares []heatmapCell
+		err := r.db.Raw(`
+			WITH cells AS (`+perSatker.SQL+`),
+			totals AS (SELECT satker_id, SUM(cnt) AS total FROM cells GROUP BY satker_id)
+			SELECT c.dow, c.hour, SUM(c.cnt::float8 / t.total) / (SELECT COUNT(*) FROM totals) AS share
+			FROM cells c JOIN totals t ON t.satker_id = c.satker_id
+			GROUP BY c.dow, c.hour
+		`, perSatker.Args...).Scan(&shares).Error
+		if err != nil {
+			return nil, err
+		}
+		var normalized [7][24]float64
+		for _, cell := range shares {
+			if d, h, ok := heatmapIndex(cell); ok {
+				normalized[d][h] = cell.Share
+			}
+		}
+		result.Normalized = &normalized
+
+		countQuery, countArgs := heatmapBase(q, filter, "COUNT(DISTINCT a.satker_id)").WhereExpr("a.satker_id IS NOT NULL").Build()
+		if err := r.db.Raw(countQuery, countArgs...).Scan(&result.SatkerCount).Error; err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Kolom hari (ISO 1–7) dan jam (0–23) heatmap.
+const (
+	heatmapDow  = "EXTRACT(ISODOW FROM a.tanggal)::int AS dow"
+	heatmapHour = "EXTRACT(HOUR FROM a.tanggal)::int AS hour"
+)
+
+// heatmapBase query dasar heatmap atas tabel aktivitas (alias a): kolom, filter aktivitas, dan pengecualian hari libur jika diminta.
+func heatmapBase(q HeatmapQuery, filter ActivityFilter, columns ...string) *sqlbuilder.SelectBuilder {
+	b := sqlbuilder.Select(columns...).
+		From("activity_logs_normalized a").
+		Where(filter.where("a"))
+	if q.ExcludeHolidays {
+		b.WhereExpr("NOT EXISTS (SELECT 1 FROM ref_holidays h WHERE h.holiday_date = a.tanggal::date)")
+	}
+	return b
+}
+
+// heatmapIndex memetakan sel (dow ISO 1–7, hour 0–23) ke indeks matriks; ok=false jika di luar rentang.
+func heatmapIndex(cell heatmapCell) (int, int, bool) {
+	d, h := cell.Dow-1, cell.Hour
+	return d, h, d >= 0 && d < 7 && h >= 0 && h < 24
+}
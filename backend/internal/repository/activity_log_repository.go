@@ -1,36 +1,40 @@
 // Package repository berisi akses data ke database (query, agregasi).
 //
 // File activity_log_repository.go: repository untuk tabel activity_logs_normalized dan tabel referensi (ref_clusters, ref_satker_units, ref_activity_types, ref_locations, user_profiles).
-// Menyediakan: filter regional (tanggal, cluster, eselon, satkerIds), hitung total, hitung per status, aktivitas terbaru, chart per scope/jam/provinsi/lokasi/satker, jam tersibuk, tingkat sukses akses, user unik, cluster unik, top kontributor, error logout.
+// Menyediakan: hitung total, hitung per status, aktivitas terbaru, chart per scope/jam/provinsi/lokasi/satker, jam tersibuk, tingkat sukses akses, time-series (activity_timeseries_repository.go), heatmap hari × jam (activity_heatmap_repository.go), user unik, cluster unik, top kontributor, error logout.
+// Semua query menerima ActivityFilter (activity_filter.go) yang dipasang lewat filter.apply. Hitungan dan chart agregat membaca activity_rollup_hourly jika rollup siap (lihat activity_rollup_repository.go); selain itu tabel mentah.
 package repository
 
 import (
-	"strings"
-
 	"github.com/bpk-ri/dashboard-monitoring/internal/config"
 	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
 	"gorm.io/gorm"
 )
 
 // ActivityLogRepository interface untuk semua query aktivitas (dashboard, chart, filter regional).
 type ActivityLogRepository interface {
 	GetSatkerIdsUnderRoot(rootId int64) ([]int64, error)
-	GetTotalCount(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (int64, error)
-	GetCountByStatus(status string, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (int64, error)
-	GetRecentActivities(page, pageSize int, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]entity.ActivityLog, error)
-	GetActivityCountByScope(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (map[string]int64, error)
-	GetActivityCountByHour(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
-	GetActivityCountByHourForSatker(satker string, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
-	GetActivityCountByProvince(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
-	GetActivityCountByLokasi(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
-	GetActivityCountBySatkerProvince(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
-	GetActivityCountBySatker(page, pageSize int, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
-	GetBusiestHour(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (int, int64, error)
-	GetAccessSuccessRateByDate(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
-	GetUniqueUsersCount(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (int64, error)
+	GetTotalCount(filter ActivityFilter) (int64, error)
+	GetCountByStatus(status string, filter ActivityFilter) (int64, error)
+	GetRecentActivities(page, pageSize int, filter ActivityFilter) ([]entity.ActivityLog, error)
+	GetActivityCountByScope(filter ActivityFilter) (map[string]int64, error)
+	GetActivityCountByHour(filter ActivityFilter) ([]map[string]interface{}, error)
+	GetActivityCountByHourForSatker(satker string, filter ActivityFilter) ([]map[string]interface{}, error)
+	GetActivityCountByProvince(filter ActivityFilter) ([]map[string]interface{}, error)
+	GetActivityCountByLokasi(filter ActivityFilter) ([]map[string]interface{}, error)
+	GetActivityCountBySatkerProvince(filter ActivityFilter) ([]map[string]interface{}, error)
+	GetActivityCountBySatker(page, pageSize int, filter ActivityFilter) ([]map[string]interface{}, error)
+	GetBusiestHour(filter ActivityFilter) (int, int64, error)
+	GetAccessSuccessRateByDate(filter ActivityFilter) ([]map[string]interface{}, error)
+	GetActivityTimeSeries(q TimeSeriesQuery, filter ActivityFilter) (*TimeSeriesResult, error)
+	GetActivityHeatmap(q HeatmapQuery, filter ActivityFilter) (*Heatmap, error)
+	GetUserCohorts(q CohortQuery, filter ActivityFilter) (*CohortMatrix, error)
+	GetFunnel(q FunnelQuery, filter ActivityFilter) (*FunnelResult, error)
+	GetUniqueUsersCount(filter ActivityFilter) (int64, error)
 	GetUniqueClusters() ([]string, error)
-	GetTopContributors(limit int, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
-	GetLogoutErrors(limit int, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error)
+	GetTopContributors(limit int, filter ActivityFilter) ([]map[string]interface{}, error)
+	GetLogoutErrors(limit int, filter ActivityFilter) ([]map[string]interface{}, error)
 }
 
 // activityLogRepository implementasi ActivityLogRepository; menyimpan koneksi DB.
@@ -43,44 +47,6 @@ func NewActivityLogRepository(db *gorm.DB) ActivityLogRepository {
 	return &activityLogRepository{db: db}
 }
 
-// applyDateFilter menambah kondisi WHERE untuk kolom tanggal: BETWEEN, >= start, atau <= end. Jika keduanya nil, query tidak diubah.
-func (r *activityLogRepository) applyDateFilter(db *gorm.DB, startDate, endDate *string) *gorm.DB {
-	if startDate != nil && endDate != nil {
-		return db.Where("DATE(tanggal) BETWEEN ? AND ?", *startDate, *endDate)
-	} else if startDate != nil {
-		return db.Where("DATE(tanggal) >= ?", *startDate)
-	} else if endDate != nil {
-		return db.Where("DATE(tanggal) <= ?", *endDate)
-	}
-	return db
-}
-
-// applyClusterFilter menambah JOIN ref_clusters dan WHERE c.name = cluster jika cluster tidak kosong.
-func (r *activityLogRepository) applyClusterFilter(db *gorm.DB, cluster *string) *gorm.DB {
-	if cluster != nil && *cluster != "" {
-		return db.Joins("LEFT JOIN ref_clusters c ON c.id = activity_logs_normalized.cluster_id").
-			Where("c.name = ?", *cluster)
-	}
-	return db
-}
-
-// applyEselonFilter menambah JOIN ref_satker_units dan WHERE s.eselon_level = eselon jika eselon tidak kosong.
-func (r *activityLogRepository) applyEselonFilter(db *gorm.DB, eselon *string) *gorm.DB {
-	if eselon != nil && *eselon != "" {
-		return db.Joins("LEFT JOIN ref_satker_units s ON s.id = activity_logs_normalized.satker_id").
-			Where("s.eselon_level = ?", *eselon)
-	}
-	return db
-}
-
-// applyEselonOrSatkerIdsFilter: jika satkerIds berisi, filter satker_id IN (satkerIds); jika tidak, pakai filter eselon.
-func (r *activityLogRepository) applyEselonOrSatkerIdsFilter(db *gorm.DB, eselon *string, satkerIds []int64) *gorm.DB {
-	if len(satkerIds) > 0 {
-		return db.Where("activity_logs_normalized.satker_id IN ?", satkerIds)
-	}
-	return r.applyEselonFilter(db, eselon)
-}
-
 // GetSatkerIdsUnderRoot mengembalikan rootId dan semua ID satker turunan (recursive: parent_id → id). Dipakai untuk filter per unit Eselon I.
 func (r *activityLogRepository) GetSatkerIdsUnderRoot(rootId int64) ([]int64, error) {
 	var ids []int64
@@ -96,26 +62,28 @@ func (r *activityLogRepository) GetSatkerIdsUnderRoot(rootId int64) ([]int64, er
 	return ids, err
 }
 
-// GetTotalCount menghitung total baris aktivitas setelah filter tanggal, cluster, eselon/satkerIds.
-func (r *activityLogRepository) GetTotalCount(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (int64, error) {
+// GetTotalCount menghitung total baris aktivitas setelah filter.
+func (r *activityLogRepository) GetTotalCount(filter ActivityFilter) (int64, error) {
+	if r.rollupReady(filter) {
+		return r.rollupTotalCount(filter)
+	}
 	var count int64
 	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 	err := query.Count(&count).Error
 	return count, err
 }
 
 // GetCountByStatus menghitung jumlah aktivitas per status: SUCCESS = LOGIN dengan scope success/NULL, FAILED = LOGOUT dengan scope error; selain itu filter by at.name = status.
-func (r *activityLogRepository) GetCountByStatus(status string, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (int64, error) {
+func (r *activityLogRepository) GetCountByStatus(status string, filter ActivityFilter) (int64, error) {
+	if r.rollupReady(filter) {
+		return r.rollupCountByStatus(status, filter)
+	}
 	var count int64
 	query := r.db.Model(&entity.ActivityLog{}).
 		Joins("LEFT JOIN ref_activity_types at ON at.id = activity_logs_normalized.activity_type_id")
 
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 
 	if status == "SUCCESS" {
 		err := query.Where("at.name = ? AND (scope ILIKE ? OR scope IS NULL OR scope = '')", "LOGIN", "%success%").Count(&count).Error
@@ -129,7 +97,7 @@ func (r *activityLogRepository) GetCountByStatus(status string, startDate, endDa
 }
 
 // GetRecentActivities mengembalikan aktivitas terbaru dengan paginasi; relasi User, Satker, ActivityType, Cluster, Location di-preload untuk DTO.
-func (r *activityLogRepository) GetRecentActivities(page, pageSize int, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]entity.ActivityLog, error) {
+func (r *activityLogRepository) GetRecentActivities(page, pageSize int, filter ActivityFilter) ([]entity.ActivityLog, error) {
 	var activities []entity.ActivityLog
 
 	query := r.db.Model(&entity.ActivityLog{}).
@@ -137,24 +105,8 @@ func (r *activityLogRepository) GetRecentActivities(page, pageSize int, startDat
 		Preload("Satker").
 		Preload("ActivityType").
 		Preload("Cluster").
-		Preload("Location").
-		Joins("LEFT JOIN user_profiles u ON u.id = activity_logs_normalized.user_id").
-		Joins("LEFT JOIN ref_satker_units s ON s.id = activity_logs_normalized.satker_id").
-		Joins("LEFT JOIN ref_activity_types at ON at.id = activity_logs_normalized.activity_type_id").
-		Joins("LEFT JOIN ref_clusters c ON c.id = activity_logs_normalized.cluster_id").
-		Joins("LEFT JOIN ref_locations l ON l.id = activity_logs_normalized.location_id")
-
-	query = r.applyDateFilter(query, startDate, endDate)
-
-	if cluster != nil && *cluster != "" {
-		query = query.Where("c.name = ?", *cluster)
-	}
-
-	if len(satkerIds) > 0 {
-		query = query.Where("activity_logs_normalized.satker_id IN ?", satkerIds)
-	} else if eselon != nil && *eselon != "" {
-		query = query.Where("s.eselon_level = ?", *eselon)
-	}
+		Preload("Location")
+	query = filter.apply(query, "activity_logs_normalized")
 
 	offset := (page - 1) * pageSize
 	err := query.Order("activity_logs_normalized.tanggal DESC").
@@ -165,20 +117,11 @@ func (r *activityLogRepository) GetRecentActivities(page, pageSize int, startDat
 	return activities, err
 }
 
-// buildDateFilter mengembalikan string kondisi WHERE untuk tanggal (dipakai di raw query). Tanpa filter mengembalikan "1=1".
-func (r *activityLogRepository) buildDateFilter(startDate, endDate *string) string {
-	if startDate != nil && endDate != nil {
-		return "DATE(tanggal) BETWEEN '" + *startDate + "' AND '" + *endDate + "'"
-	} else if startDate != nil {
-		return "DATE(tanggal) >= '" + *startDate + "'"
-	} else if endDate != nil {
-		return "DATE(tanggal) <= '" + *endDate + "'"
-	}
-	return "1=1"
-}
-
 // GetActivityCountByScope mengelompokkan aktivitas menurut kategori (at.category) lalu memetakan ke label: data_access→Monitoring & View, authentication→System Auth, search→Discovery, download→Data Extraction, lain→Other.
-func (r *activityLogRepository) GetActivityCountByScope(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (map[string]int64, error) {
+func (r *activityLogRepository) GetActivityCountByScope(filter ActivityFilter) (map[string]int64, error) {
+	if r.rollupReady(filter) {
+		return r.rollupCountByScope(filter)
+	}
 	type Result struct {
 		Category string
 		Count    int64
@@ -188,9 +131,7 @@ func (r *activityLogRepository) GetActivityCountByScope(startDate, endDate *stri
 	query := r.db.Model(&entity.ActivityLog{}).
 		Joins("LEFT JOIN ref_activity_types at ON activity_logs_normalized.activity_type_id = at.id")
 
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 
 	err := query.
 		Select(`
@@ -219,23 +160,21 @@ func (r *activityLogRepository) GetActivityCountByScope(startDate, endDate *stri
 }
 
 // GetActivityCountByHour mengembalikan jumlah aktivitas per jam (0–23); hasil slice map hour/count, urut jam naik.
-func (r *activityLogRepository) GetActivityCountByHour(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
-	type Result struct {
-		Hour  int
-		Count int64
+func (r *activityLogRepository) GetActivityCountByHour(filter ActivityFilter) ([]map[string]interface{}, error) {
+	var results []hourCount
+	var err error
+	if r.rollupReady(filter) {
+		results, err = r.rollupHourCounts("", filter)
+	} else {
+		query := r.db.Model(&entity.ActivityLog{})
+		query = filter.apply(query, "activity_logs_normalized")
+		err = query.
+			Select("EXTRACT(HOUR FROM tanggal)::int as hour, COUNT(*) as count").
+			Group("hour").
+			Order("hour ASC").
+			Scan(&results).Error
 	}
 
-	var results []Result
-	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
-	err := query.
-		Select("EXTRACT(HOUR FROM tanggal)::int as hour, COUNT(*) as count").
-		Group("hour").
-		Order("hour ASC").
-		Scan(&results).Error
-
 	if err != nil {
 		return nil, err
 	}
@@ -251,26 +190,24 @@ func (r *activityLogRepository) GetActivityCountByHour(startDate, endDate *strin
 }
 
 // GetActivityCountByHourForSatker sama seperti GetActivityCountByHour tetapi difilter oleh nama satker; mengembalikan 24 jam (jam tanpa data diisi 0).
-func (r *activityLogRepository) GetActivityCountByHourForSatker(satker string, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
-	type Result struct {
-		Hour  int
-		Count int64
-	}
-
-	var results []Result
-	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+func (r *activityLogRepository) GetActivityCountByHourForSatker(satker string, filter ActivityFilter) ([]map[string]interface{}, error) {
+	var results []hourCount
+	var err error
+	if r.rollupReady(filter) {
+		results, err = r.rollupHourCounts(satker, filter)
+	} else {
+		query := r.db.Model(&entity.ActivityLog{})
+		query = filter.apply(query, "activity_logs_normalized")
 
-	query = query.Joins("LEFT JOIN ref_satker_units s ON s.id = activity_logs_normalized.satker_id").
-		Where("s.satker_name = ?", satker)
+		query = query.Joins("LEFT JOIN ref_satker_units s ON s.id = activity_logs_normalized.satker_id").
+			Where("s.satker_name = ?", satker)
 
-	err := query.
-		Select("EXTRACT(HOUR FROM tanggal)::int as hour, COUNT(*) as count").
-		Group("hour").
-		Order("hour ASC").
-		Scan(&results).Error
+		err = query.
+			Select("EXTRACT(HOUR FROM tanggal)::int as hour, COUNT(*) as count").
+			Group("hour").
+			Order("hour ASC").
+			Scan(&results).Error
+	}
 
 	if err != nil {
 		return nil, err
@@ -297,7 +234,10 @@ func (r *activityLogRepository) GetActivityCountByHourForSatker(satker string, s
 }
 
 // GetActivityCountByProvince mengelompokkan aktivitas per provinsi (ref_locations.province); mengabaikan provinsi kosong/NULL; urut count menurun.
-func (r *activityLogRepository) GetActivityCountByProvince(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
+func (r *activityLogRepository) GetActivityCountByProvince(filter ActivityFilter) ([]map[string]interface{}, error) {
+	if r.rollupReady(filter) {
+		return r.rollupCountByProvince(filter)
+	}
 	type Result struct {
 		Province string
 		Count    int64
@@ -305,9 +245,7 @@ func (r *activityLogRepository) GetActivityCountByProvince(startDate, endDate *s
 
 	var results []Result
 	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 
 	err := query.
 		Joins("LEFT JOIN ref_locations l ON l.id = activity_logs_normalized.location_id").
@@ -332,7 +270,10 @@ func (r *activityLogRepository) GetActivityCountByProvince(startDate, endDate *s
 }
 
 // GetActivityCountByLokasi mengelompokkan aktivitas per location_name; batas hasil TopLokasiLimit; urut count menurun.
-func (r *activityLogRepository) GetActivityCountByLokasi(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
+func (r *activityLogRepository) GetActivityCountByLokasi(filter ActivityFilter) ([]map[string]interface{}, error) {
+	if r.rollupReady(filter) {
+		return r.rollupCountByLokasi(filter)
+	}
 	type Result struct {
 		Lokasi string
 		Count  int64
@@ -340,9 +281,7 @@ func (r *activityLogRepository) GetActivityCountByLokasi(startDate, endDate *str
 
 	var results []Result
 	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 
 	err := query.
 		Joins("LEFT JOIN ref_locations l ON l.id = activity_logs_normalized.location_id").
@@ -367,68 +306,17 @@ func (r *activityLogRepository) GetActivityCountByLokasi(startDate, endDate *str
 	return data, nil
 }
 
-// GetActivityCountBySatkerProvince mengagregasi aktivitas per provinsi (dari ref_locations); normalisasi nama provinsi (DKI→DKI JAKARTA, DAERAH ISTIMEWA YOGYAKARTA→DI YOGYAKARTA); exclude provinsi generik (UNKNOWN, KALIMANTAN, dll.). Raw SQL dengan subquery.
-func (r *activityLogRepository) GetActivityCountBySatkerProvince(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
+// GetActivityCountBySatkerProvince mengagregasi aktivitas per provinsi (dari ref_locations); normalisasi nama provinsi (DKI→DKI JAKARTA, DAERAH ISTIMEWA YOGYAKARTA→DI YOGYAKARTA); exclude provinsi generik (UNKNOWN, KALIMANTAN, dll.). Raw SQL (sqlbuilder) dengan subquery.
+func (r *activityLogRepository) GetActivityCountBySatkerProvince(filter ActivityFilter) ([]map[string]interface{}, error) {
+	if r.rollupReady(filter) {
+		return r.rollupCountBySatkerProvince(filter)
+	}
 	type Result struct {
 		Province string
 		Count    int64
 	}
 
-	var conditions []string
-	var args []interface{}
-
-	if startDate != nil && endDate != nil {
-		conditions = append(conditions, "DATE(al.tanggal) BETWEEN ? AND ?")
-		args = append(args, *startDate, *endDate)
-	} else if startDate != nil {
-		conditions = append(conditions, "DATE(al.tanggal) >= ?")
-		args = append(args, *startDate)
-	} else if endDate != nil {
-		conditions = append(conditions, "DATE(al.tanggal) <= ?")
-		args = append(args, *endDate)
-	}
-
-	if cluster != nil && *cluster != "" {
-		conditions = append(conditions, "c.name = ?")
-		args = append(args, *cluster)
-	}
-
-	if len(satkerIds) > 0 {
-		conditions = append(conditions, "al.satker_id IN ?")
-		args = append(args, satkerIds)
-	} else if eselon != nil && *eselon != "" {
-		conditions = append(conditions, "s.eselon_level = ?")
-		args = append(args, *eselon)
-	}
-
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + strings.Join(conditions, " AND ") + " AND "
-	} else {
-		whereClause = "WHERE "
-	}
-	whereClause += "l.province != '' AND l.province IS NOT NULL AND UPPER(l.province) NOT IN ('UNKNOWN', 'KALIMANTAN', 'SULAWESI', 'PAPUA', 'JAWA', 'KEPULAUAN')"
-
-	sqlQuery := `
-		SELECT normalized_province as province, SUM(cnt) as count
-		FROM (
-			SELECT 
-				CASE
-					WHEN UPPER(l.province) = 'DKI' THEN 'DKI JAKARTA'
-					WHEN UPPER(l.province) = 'DAERAH ISTIMEWA YOGYAKARTA' THEN 'DI YOGYAKARTA'
-					ELSE UPPER(l.province)
-				END as normalized_province,
-				COUNT(*) as cnt
-			FROM activity_logs_normalized al
-			LEFT JOIN ref_locations l ON l.id = al.location_id
-			LEFT JOIN ref_clusters c ON c.id = al.cluster_id
-			LEFT JOIN ref_satker_units s ON s.id = al.satker_id
-			` + whereClause + `
-			GROUP BY normalized_province
-		) subquery
-		GROUP BY normalized_province
-		ORDER BY count DESC
-	`
+	sqlQuery, args := satkerProvinceQuery(filter)
 
 	var results []Result
 	err := r.db.Raw(sqlQuery, args...).Scan(&results).Error
@@ -446,8 +334,30 @@ func (r *activityLogRepository) GetActivityCountBySatkerProvince(startDate, endD
 	return data, nil
 }
 
+// satkerProvinceQuery menyusun SQL GetActivityCountBySatkerProvince: subquery hitung per provinsi ternormalisasi lalu dijumlah per provinsi.
+func satkerProvinceQuery(filter ActivityFilter) (string, []interface{}) {
+	sub := sqlbuilder.Select(`CASE
+				WHEN UPPER(l.province) = 'DKI' THEN 'DKI JAKARTA'
+				WHEN UPPER(l.province) = 'DAERAH ISTIMEWA YOGYAKARTA' THEN 'DI YOGYAKARTA'
+				ELSE UPPER(l.province)
+			END AS normalized_province`, "COUNT(*) AS cnt").
+		From("activity_logs_normalized al").
+		LeftJoin("ref_locations l ON l.id = al.location_id").
+		Where(filter.where("al")).
+		WhereExpr("l.province != '' AND l.province IS NOT NULL AND UPPER(l.province) NOT IN ('UNKNOWN', 'KALIMANTAN', 'SULAWESI', 'PAPUA', 'JAWA', 'KEPULAUAN')").
+		GroupBy("normalized_province")
+	return sqlbuilder.Select("normalized_province AS province", "SUM(cnt) AS count").
+		FromSubquery(sub, "subquery").
+		GroupBy("normalized_province").
+		OrderBy("count DESC").
+		Build()
+}
+
 // GetActivityCountBySatker mengembalikan jumlah aktivitas per satker (satker_name) dengan paginasi; field rank = offset + urutan dalam halaman.
-func (r *activityLogRepository) GetActivityCountBySatker(page, pageSize int, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
+func (r *activityLogRepository) GetActivityCountBySatker(page, pageSize int, filter ActivityFilter) ([]map[string]interface{}, error) {
+	if r.rollupReady(filter) {
+		return r.rollupCountBySatker(page, pageSize, filter)
+	}
 	type Result struct {
 		Satker string
 		Count  int64
@@ -456,9 +366,7 @@ func (r *activityLogRepository) GetActivityCountBySatker(page, pageSize int, sta
 	var results []Result
 	offset := (page - 1) * pageSize
 	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 	err := query.
 		Joins("LEFT JOIN ref_satker_units s ON s.id = activity_logs_normalized.satker_id").
 		Select("s.satker_name as satker, COUNT(*) as count").
@@ -485,17 +393,24 @@ func (r *activityLogRepository) GetActivityCountBySatker(page, pageSize int, sta
 }
 
 // GetBusiestHour mengembalikan jam (0–23) dengan jumlah aktivitas terbanyak dan jumlahnya; LIMIT 1 setelah ORDER count DESC.
-func (r *activityLogRepository) GetBusiestHour(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (int, int64, error) {
-	type Result struct {
-		Hour  int
-		Count int64
+func (r *activityLogRepository) GetBusiestHour(filter ActivityFilter) (int, int64, error) {
+	if r.rollupReady(filter) {
+		results, err := r.rollupHourCounts("", filter)
+		if err != nil {
+			return 0, 0, err
+		}
+		var busiest hourCount
+		for _, h := range results {
+			if h.Count > busiest.Count {
+				busiest = h
+			}
+		}
+		return busiest.Hour, busiest.Count, nil
 	}
 
-	var result Result
+	var result hourCount
 	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 	err := query.
 		Select("EXTRACT(HOUR FROM tanggal)::int as hour, COUNT(*) as count").
 		Group("hour").
@@ -511,29 +426,26 @@ func (r *activityLogRepository) GetBusiestHour(startDate, endDate *string, clust
 }
 
 // GetAccessSuccessRateByDate mengembalikan per tanggal: jumlah login sukses (LOGIN + scope success/NULL), jumlah logout error (LOGOUT + scope error), dan success_rate (persen). Urut tanggal naik.
-func (r *activityLogRepository) GetAccessSuccessRateByDate(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
-	type Result struct {
-		Date    string
-		Success int64
-		Failed  int64
-	}
-
-	var results []Result
-	query := r.db.Model(&entity.ActivityLog{}).
-		Joins("LEFT JOIN ref_activity_types at ON activity_logs_normalized.activity_type_id = at.id")
+func (r *activityLogRepository) GetAccessSuccessRateByDate(filter ActivityFilter) ([]map[string]interface{}, error) {
+	var results []successRateRow
+	var err error
+	if r.rollupReady(filter) {
+		results, err = r.rollupAccessSuccessRateRows(filter)
+	} else {
+		query := r.db.Model(&entity.ActivityLog{}).
+			Joins("LEFT JOIN ref_activity_types at ON activity_logs_normalized.activity_type_id = at.id")
 
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
-	err := query.
-		Select(`
-			DATE(tanggal) as date,
-			COUNT(CASE WHEN at.name = 'LOGIN' AND (scope ILIKE '%success%' OR scope IS NULL OR scope = '') THEN 1 END) as success,
-			COUNT(CASE WHEN at.name = 'LOGOUT' AND scope ILIKE '%error%' THEN 1 END) as failed
-		`).
-		Group("DATE(tanggal)").
-		Order("date ASC").
-		Scan(&results).Error
+		query = filter.apply(query, "activity_logs_normalized")
+		err = query.
+			Select(`
+				DATE(tanggal) as date,
+				COUNT(CASE WHEN at.name = 'LOGIN' AND (scope ILIKE '%success%' OR scope IS NULL OR scope = '') THEN 1 END) as success,
+				COUNT(CASE WHEN at.name = 'LOGOUT' AND scope ILIKE '%error%' THEN 1 END) as failed
+			`).
+			Group("DATE(tanggal)").
+			Order("date ASC").
+			Scan(&results).Error
+	}
 
 	if err != nil {
 		return nil, err
@@ -556,13 +468,11 @@ func (r *activityLogRepository) GetAccessSuccessRateByDate(startDate, endDate *s
 	return data, nil
 }
 
-// GetUniqueUsersCount menghitung jumlah user_id unik (DISTINCT user_id) setelah filter tanggal, cluster, eselon/satkerIds.
-func (r *activityLogRepository) GetUniqueUsersCount(startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) (int64, error) {
+// GetUniqueUsersCount menghitung jumlah user_id unik (DISTINCT user_id) setelah filter.
+func (r *activityLogRepository) GetUniqueUsersCount(filter ActivityFilter) (int64, error) {
 	var count int64
 	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 	err := query.
 		Distinct("user_id").
 		Count(&count).Error
@@ -581,7 +491,7 @@ func (r *activityLogRepository) GetUniqueClusters() ([]string, error) {
 }
 
 // GetTopContributors mengembalikan top N user (nama + satker) dengan jumlah aktivitas terbanyak; rank dari ROW_NUMBER(), group by nama dan satker_name.
-func (r *activityLogRepository) GetTopContributors(limit int, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
+func (r *activityLogRepository) GetTopContributors(limit int, filter ActivityFilter) ([]map[string]interface{}, error) {
 	type Result struct {
 		Rank     int
 		Nama     string
@@ -591,9 +501,7 @@ func (r *activityLogRepository) GetTopContributors(limit int, startDate, endDate
 
 	var results []Result
 	query := r.db.Model(&entity.ActivityLog{})
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 
 	err := query.
 		Joins("LEFT JOIN user_profiles u ON u.id = activity_logs_normalized.user_id").
@@ -622,7 +530,7 @@ func (r *activityLogRepository) GetTopContributors(limit int, startDate, endDate
 }
 
 // GetLogoutErrors mengembalikan top N user dengan error logout terbanyak (at.name = LOGOUT, scope ILIKE '%error%'); urut berdasarkan waktu error terbaru (latest_error DESC).
-func (r *activityLogRepository) GetLogoutErrors(limit int, startDate, endDate *string, cluster *string, eselon *string, satkerIds []int64) ([]map[string]interface{}, error) {
+func (r *activityLogRepository) GetLogoutErrors(limit int, filter ActivityFilter) ([]map[string]interface{}, error) {
 	type Result struct {
 		Nama        string
 		ErrorCount  int64
@@ -634,9 +542,7 @@ func (r *activityLogRepository) GetLogoutErrors(limit int, startDate, endDate *s
 		Joins("LEFT JOIN user_profiles u ON u.id = activity_logs_normalized.user_id").
 		Joins("LEFT JOIN ref_activity_types at ON at.id = activity_logs_normalized.activity_type_id")
 
-	query = r.applyDateFilter(query, startDate, endDate)
-	query = r.applyClusterFilter(query, cluster)
-	query = r.applyEselonOrSatkerIdsFilter(query, eselon, satkerIds)
+	query = filter.apply(query, "activity_logs_normalized")
 
 	err := query.
 		Select("u.nama, COUNT(*) as error_count, MAX(tanggal) as latest_error").
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+// satkerProvinceSelect bagian SELECT subquery GetActivityCountBySatkerProvince (normalisasi nama provinsi).
+const satkerProvinceSelect = "SELECT normalized_province AS province, SUM(cnt) AS count FROM (SELECT CASE\n" +
+	"\t\t\t\tWHEN UPPER(l.province) = 'DKI' THEN 'DKI JAKARTA'\n" +
+	"\t\t\t\tWHEN UPPER(l.province) = 'DAERAH ISTIMEWA YOGYAKARTA' THEN 'DI YOGYAKARTA'\n" +
+	"\t\t\t\tELSE UPPER(l.province)\n" +
+	"\t\t\tEND AS normalized_province, COUNT(*) AS cnt FROM activity_logs_normalized al LEFT JOIN ref_locations l ON l.id = al.location_id WHERE "
+
+// satkerProvinceExclusion kondisi pengecualian provinsi generik di subquery.
+const satkerProvinceExclusion = "l.province != '' AND l.province IS NOT NULL AND UPPER(l.province) NOT IN ('UNKNOWN', 'KALIMANTAN', 'SULAWESI', 'PAPUA', 'JAWA', 'KEPULAUAN')"
+
+// satkerProvinceTail bagian agregasi luar GetActivityCountBySatkerProvince.
+const satkerProvinceTail = " GROUP BY normalized_province) subquery GROUP BY normalized_province ORDER BY count DESC"
+
+func TestSatkerProvinceQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   ActivityFilter
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "tanpa filter",
+			filter:  ActivityFilter{},
+			wantSQL: satkerProvinceSelect + satkerProvinceExclusion + satkerProvinceTail,
+		},
+		{
+			name: "filter tanggal, satker, status, provinsi",
+			filter: ActivityFilter{
+				StartDate: "2026-01-01",
+				EndDate:   "2026-01-31",
+				Statuses:  []string{"SUCCESS"},
+				SatkerIDs: []int64{4, 9},
+				Provinces: []string{"ACEH"},
+			},
+			wantSQL: satkerProvinceSelect +
+				"(((al.tanggal >= ?) AND (al.tanggal < ?)) AND (al.satker_id IN ?) AND (al.status IN ?)" +
+				" AND (al.location_id IN (SELECT id FROM ref_locations WHERE UPPER(province) IN ?)))" +
+				" AND (" + satkerProvinceExclusion + ")" + satkerProvinceTail,
+			wantArgs: []interface{}{"2026-01-01", "2026-02-01", []int64{4, 9}, []string{"SUCCESS"}, []string{"ACEH"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := satkerProvinceQuery(tt.filter)
+			if sql != tt.wantSQL {
+				t.Errorf("SQL:\n got  %q\n want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args: got %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,524 @@
+// File activity_rollup_repository.go: tabel rollup activity_rollup_hourly (agregat per hari, jam, satker, cluster, jenis aktivitas, lokasi, status, kelas scope).
+//
+// ActivityRollupRepository memelihara rollup: Refresh (hitung ulang hari terdampak: hari baris dengan id > watermark − ROLLUP_RESCAN_IDS dan
+// ROLLUP_RESCAN_DAYS hari terakhir), Rebuild (ulang dari nol), Check (bandingkan rollup dengan agregat data mentah). Watermark disimpan di activity_rollup_state.
+// Id sequence dibagikan saat insert, bukan saat commit: impor yang commit terlambat bisa menambah id di bawah watermark, jadi Refresh memproses ulang
+// jendela id di bawah watermark dan menghitung ulang hari secara penuh (bukan menambahkan jumlah) sehingga hasilnya idempoten.
+// Bagian bawah file berisi varian query ActivityLogRepository yang membaca rollup; dipakai jika ROLLUP_ENABLED dan watermark sudah mencakup semua baris (rollupReady).
+// Metode yang butuh data per user (user unik, top kontributor, error logout) atau baris mentah (aktivitas terbaru), dan filter user, tetap membaca tabel mentah.
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"gorm.io/gorm"
+)
+
+// rollupLockKey kunci pg_advisory_xact_lock agar Refresh/Rebuild tidak berjalan bersamaan.
+const rollupLockKey = 7_301_018
+
+// rollupKeyColumns kolom kunci rollup (urutan sama dengan primary key).
+const rollupKeyColumns = "day, hour, satker_id, cluster_id, activity_type_id, location_id, status, scope_class"
+
+// rollupSelectFromRaw ekspresi kolom kunci dari activity_logs_normalized; ID referensi NULL disimpan sebagai 0.
+const rollupSelectFromRaw = `
+	DATE(tanggal) AS day,
+	EXTRACT(HOUR FROM tanggal)::smallint AS hour,
+	COALESCE(satker_id, 0) AS satker_id,
+	COALESCE(cluster_id, 0) AS cluster_id,
+	COALESCE(activity_type_id, 0) AS activity_type_id,
+	COALESCE(location_id, 0) AS location_id,
+	COALESCE(status, '') AS status,
+	CASE
+		WHEN COALESCE(scope, '') = '' THEN 'empty'
+		WHEN scope ILIKE '%success%' AND scope ILIKE '%error%' THEN 'success_error'
+		WHEN scope ILIKE '%success%' THEN 'success'
+		WHEN scope ILIKE '%error%' THEN 'error'
+		ELSE 'other'
+	END AS scope_class`
+
+// Kelas scope yang setara dengan kondisi scope pada query mentah.
+var (
+	// rollupSuccessScopes = scope ILIKE '%success%' OR scope IS NULL OR scope = ''.
+	rollupSuccessScopes = []string{"empty", "success", "success_error"}
+	// rollupErrorScopes = scope ILIKE '%error%'.
+	rollupErrorScopes = []string{"error", "success_error"}
+)
+
+// RollupRefreshResult hasil Refresh: rentang id yang diproses (FromLogID eksklusif, sudah termasuk jendela proses ulang), jumlah hari yang dihitung ulang,
+// dan jumlah grup rollup yang ditambah, diubah, atau dihapus.
+type RollupRefreshResult struct {
+	FromLogID int64 `json:"from_log_id"`
+	ToLogID   int64 `json:"to_log_id"`
+	Days      int64 `json:"days"`
+	Groups    int64 `json:"groups"`
+}
+
+// RollupMismatch satu grup rollup yang jumlahnya berbeda dengan data mentah.
+type RollupMismatch struct {
+	Day            time.Time `json:"day"`
+	Hour           int       `json:"hour"`
+	SatkerID       int64     `json:"satker_id"`
+	ClusterID      int64     `json:"cluster_id"`
+	ActivityTypeID int64     `json:"activity_type_id"`
+	LocationID     int64     `json:"location_id"`
+	Status         string    `json:"status"`
+	ScopeClass     string    `json:"scope_class"`
+	RawCount       int64     `json:"raw_count"`
+	RollupCount    int64     `json:"rollup_count"`
+}
+
+// RollupCheckReport hasil Check: watermark, baris mentah yang belum masuk rollup, total kedua sisi, jumlah grup berbeda dan contohnya.
+type RollupCheckReport struct {
+	LastLogID      int64            `json:"last_log_id"`
+	MaxLogID       int64            `json:"max_log_id"`
+	PendingRows    int64            `json:"pending_rows"`
+	RawTotal       int64            `json:"raw_total"`
+	RollupTotal    int64            `json:"rollup_total"`
+	MismatchGroups int64            `json:"mismatch_groups"`
+	Samples        []RollupMismatch `json:"samples"`
+}
+
+// Consistent mengembalikan true jika rollup sama persis dengan data mentah sampai watermark.
+func (r *RollupCheckReport) Consistent() bool {
+	return r.MismatchGroups == 0 && r.RawTotal == r.RollupTotal
+}
+
+// ActivityRollupRepository memelihara tabel rollup aktivitas.
+type ActivityRollupRepository struct {
+	db *gorm.DB
+}
+
+// NewActivityRollupRepository membuat instance ActivityRollupRepository.
+func NewActivityRollupRepository(db *gorm.DB) *ActivityRollupRepository {
+	return &ActivityRollupRepository{db: db}
+}
+
+// Refresh menghitung ulang hari rollup yang terdampak baris baru, baris yang commit terlambat di bawah watermark (jendela ROLLUP_RESCAN_IDS),
+// dan perubahan/penghapusan baris pada ROLLUP_RESCAN_DAYS hari terakhir, lalu memajukan watermark ke id maksimum saat ini.
+// Perubahan data mentah di luar kedua jendela tetap butuh Rebuild.
+func (r *ActivityRollupRepository) Refresh() (*RollupRefreshResult, error) {
+	var result *RollupRefreshResult
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = refreshRollup(tx)
+		return err
+	})
+	return result, err
+}
+
+// Rebuild mengosongkan rollup dan membangunnya ulang dari seluruh data mentah (dipakai setelah restore dump atau jika Check menemukan selisih).
+func (r *ActivityRollupRepository) Rebuild() (*RollupRefreshResult, error) {
+	var result *RollupRefreshResult
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rollupLockKey).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM activity_rollup_hourly").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM activity_rollup_state").Error; err != nil {
+			return err
+		}
+		var err error
+		result, err = refreshRollup(tx)
+		return err
+	})
+	return result, err
+}
+
+// rollupRescanFrom batas bawah (eksklusif) id yang diproses Refresh: watermark dikurangi jendela proses ulang, minimal 0.
+// Baris yang id-nya dibagikan sebelum refresh sebelumnya tetapi baru commit sesudahnya (id <= watermark) ikut diproses selama masih di dalam jendela.
+func rollupRescanFrom(lastLogID, rescanIDs int64) int64 {
+	return max(lastLogID-rescanIDs, 0)
+}
+
+// refreshRollup menjalankan langkah Refresh di dalam transaksi tx: kumpulkan hari terdampak ke rollup_dirty_days, hitung ulang grup hari itu dari data mentah
+// (id <= id maksimum) ke rollup_fresh, hapus grup rollup yang tidak ada lagi, lalu upsert grup yang baru atau jumlahnya berubah.
+func refreshRollup(tx *gorm.DB) (*RollupRefreshResult, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rollupLockKey).Error; err != nil {
+		return nil, err
+	}
+	cfg := config.GetRollupConfig()
+	var result RollupRefreshResult
+	var lastLogID int64
+	if err := tx.Raw("SELECT COALESCE((SELECT last_log_id FROM activity_rollup_state WHERE id = 1), 0)").Scan(&lastLogID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Raw("SELECT COALESCE(MAX(id), 0) FROM activity_logs_normalized").Scan(&result.ToLogID).Error; err != nil {
+		return nil, err
+	}
+	result.FromLogID = rollupRescanFrom(lastLogID, cfg.RescanIDs)
+
+	res := tx.Exec(`
+		CREATE TEMP TABLE rollup_dirty_days ON COMMIT DROP AS
+		SELECT DISTINCT DATE(tanggal) AS day FROM activity_logs_normalized WHERE id > ? AND id <= ?
+		UNION
+		SELECT DISTINCT day FROM activity_rollup_hourly WHERE day > (SELECT MAX(day) FROM activity_rollup_hourly) - ?::int
+	`, result.FromLogID, result.ToLogID, cfg.RescanDays)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	result.Days = res.RowsAffected
+
+	if result.Days > 0 {
+		err := tx.Exec(`
+			CREATE TEMP TABLE rollup_fresh ON COMMIT DROP AS
+			SELECT `+rollupSelectFromRaw+`, COUNT(*) AS activity_count
+			FROM activity_logs_normalized
+			JOIN rollup_dirty_days d ON tanggal >= d.day AND tanggal < d.day + 1
+			WHERE id <= ?
+			GROUP BY 1, 2, 3, 4, 5, 6, 7, 8
+		`, result.ToLogID).Error
+		if err != nil {
+			return nil, err
+		}
+		res = tx.Exec(`
+			DELETE FROM activity_rollup_hourly
+			WHERE day IN (SELECT day FROM rollup_dirty_days)
+			AND (` + rollupKeyColumns + `) NOT IN (SELECT ` + rollupKeyColumns + ` FROM rollup_fresh)
+		`)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		result.Groups = res.RowsAffected
+		res = tx.Exec(`
+			INSERT INTO activity_rollup_hourly (` + rollupKeyColumns + `, activity_count)
+			SELECT ` + rollupKeyColumns + `, activity_count FROM rollup_fresh
+			ON CONFLICT (` + rollupKeyColumns + `)
+			DO UPDATE SET activity_count = EXCLUDED.activity_count
+			WHERE activity_rollup_hourly.activity_count <> EXCLUDED.activity_count
+		`)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		result.Groups += res.RowsAffected
+	}
+
+	if result.Groups > 0 {
+		// Rollup berubah (data baru, commit terlambat, atau baris diubah/dihapus): hasil analitik yang di-cache harus dimuat ulang.
+		if _, err := BumpDataVersion(tx, DataVersionActivity); err != nil {
+			return nil, err
+		}
+	}
+
+	err := tx.Exec(`
+		INSERT INTO activity_rollup_state (id, last_log_id, refreshed_at) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET last_log_id = EXCLUDED.last_log_id, refreshed_at = EXCLUDED.refreshed_at
+	`, result.ToLogID, time.Now()).Error
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Check membandingkan rollup dengan agregat data mentah (baris dengan id <= watermark) per grup kunci; sampleLimit = jumlah contoh selisih yang dikembalikan.
+func (r *ActivityRollupRepository) Check(sampleLimit int) (*RollupCheckReport, error) {
+	var report RollupCheckReport
+	if err := r.db.Raw("SELECT COALESCE((SELECT last_log_id FROM activity_rollup_state WHERE id = 1), 0)").Scan(&report.LastLogID).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Raw("SELECT COALESCE(MAX(id), 0) FROM activity_logs_normalized").Scan(&report.MaxLogID).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Raw("SELECT COUNT(*) FROM activity_logs_normalized WHERE id > ?", report.LastLogID).Scan(&report.PendingRows).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Raw("SELECT COUNT(*) FROM activity_logs_normalized WHERE id <= ?", report.LastLogID).Scan(&report.RawTotal).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Raw("SELECT COALESCE(SUM(activity_count), 0) FROM activity_rollup_hourly").Scan(&report.RollupTotal).Error; err != nil {
+		return nil, err
+	}
+
+	diff := `
+		WITH raw AS (
+			SELECT ` + rollupSelectFromRaw + `, COUNT(*) AS raw_count
+			FROM activity_logs_normalized
+			WHERE id <= ?
+			GROUP BY 1, 2, 3, 4, 5, 6, 7, 8
+		)
+		SELECT ` + rollupKeyColumns + `, COALESCE(raw.raw_count, 0) AS raw_count, COALESCE(ar.activity_count, 0) AS rollup_count
+		FROM raw
+		FULL OUTER JOIN activity_rollup_hourly ar USING (` + rollupKeyColumns + `)
+		WHERE COALESCE(raw.raw_count, 0) <> COALESCE(ar.activity_count, 0)`
+	if err := r.db.Raw("SELECT COUNT(*) FROM ("+diff+") d", report.LastLogID).Scan(&report.MismatchGroups).Error; err != nil {
+		return nil, err
+	}
+	if report.MismatchGroups > 0 && sampleLimit > 0 {
+		if err := r.db.Raw(diff+" ORDER BY day, hour LIMIT ?", report.LastLogID, sampleLimit).Scan(&report.Samples).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &report, nil
+}
+
+// rollupReady mengembalikan true jika rollup boleh dipakai: ROLLUP_ENABLED, filter tidak memakai field yang tidak ada di rollup (user),
+// dan watermark sudah mencakup id maksimum activity_logs_normalized. Gagal query (mis. migrasi 018 belum dijalankan) dianggap belum siap sehingga query jatuh ke tabel mentah.
+func (r *activityLogRepository) rollupReady(filter ActivityFilter) bool {
+	if !config.RollupsEnabled() || !filter.rollupCompatible() {
+		return false
+	}
+	ready, err := rollupCurrent(r.db)
+	return err == nil && ready
+}
+
+// Current mengembalikan true jika watermark rollup sudah mencakup semua baris activity_logs_normalized (tanpa memperhatikan ROLLUP_ENABLED).
+func (r *ActivityRollupRepository) Current() (bool, error) {
+	return rollupCurrent(r.db)
+}
+
+// rollupCurrent memeriksa watermark activity_rollup_state terhadap id maksimum activity_logs_normalized.
+func rollupCurrent(db *gorm.DB) (bool, error) {
+	var ready bool
+	err := db.Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM activity_rollup_state
+			WHERE id = 1 AND last_log_id >= (SELECT COALESCE(MAX(id), 0) FROM activity_logs_normalized)
+		)
+	`).Scan(&ready).Error
+	return ready, err
+}
+
+// rollupQuery mengembalikan query dasar atas activity_rollup_hourly (alias ar) dengan ActivityFilter yang setara dengan filter tabel mentah.
+func (r *activityLogRepository) rollupQuery(filter ActivityFilter) *gorm.DB {
+	query := r.db.Table("activity_rollup_hourly ar")
+	if cond := filter.rollupWhere("ar"); !cond.IsEmpty() {
+		query = query.Where(cond.SQL, cond.Args...)
+	}
+	return query
+}
+
+// rollupTotalCount: versi rollup GetTotalCount.
+func (r *activityLogRepository) rollupTotalCount(filter ActivityFilter) (int64, error) {
+	var count int64
+	err := r.rollupQuery(filter).
+		Select("COALESCE(SUM(ar.activity_count), 0)").
+		Scan(&count).Error
+	return count, err
+}
+
+// rollupCountByStatus: versi rollup GetCountByStatus (SUCCESS/FAILED memakai kelas scope).
+func (r *activityLogRepository) rollupCountByStatus(status string, filter ActivityFilter) (int64, error) {
+	query := r.rollupQuery(filter).
+		Joins("LEFT JOIN ref_activity_types at ON at.id = ar.activity_type_id")
+	switch status {
+	case "SUCCESS":
+		query = query.Where("at.name = ? AND ar.scope_class IN ?", "LOGIN", rollupSuccessScopes)
+	case "FAILED":
+		query = query.Where("at.name = ? AND ar.scope_class IN ?", "LOGOUT", rollupErrorScopes)
+	default:
+		query = query.Where("at.name = ?", status)
+	}
+	var count int64
+	err := query.Select("COALESCE(SUM(ar.activity_count), 0)").Scan(&count).Error
+	return count, err
+}
+
+// rollupCountByScope: versi rollup GetActivityCountByScope.
+func (r *activityLogRepository) rollupCountByScope(filter ActivityFilter) (map[string]int64, error) {
+	type Result struct {
+		Category string
+		Count    int64
+	}
+
+	var results []Result
+	err := r.rollupQuery(filter).
+		Joins("LEFT JOIN ref_activity_types at ON ar.activity_type_id = at.id").
+		Select(`
+			CASE COALESCE(NULLIF(at.category, ''), 'other')
+				WHEN 'data_access'     THEN 'Monitoring & View'
+				WHEN 'authentication'  THEN 'System Auth'
+				WHEN 'search'          THEN 'Discovery'
+				WHEN 'download'        THEN 'Data Extraction'
+				ELSE 'Other'
+			END as category,
+			SUM(ar.activity_count) as count
+		`).
+		Group("category").
+		Order("count DESC").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, r := range results {
+		counts[r.Category] = r.Count
+	}
+	return counts, nil
+}
+
+// rollupHourCounts mengembalikan jumlah per jam dari rollup (urut jam naik); satker != "" membatasi ke satu nama satker.
+func (r *activityLogRepository) rollupHourCounts(satker string, filter ActivityFilter) ([]hourCount, error) {
+	query := r.rollupQuery(filter)
+	if satker != "" {
+		query = query.Joins("LEFT JOIN ref_satker_units s ON s.id = ar.satker_id").Where("s.satker_name = ?", satker)
+	}
+	var results []hourCount
+	err := query.
+		Select("ar.hour::int as hour, SUM(ar.activity_count) as count").
+		Group("ar.hour").
+		Order("hour ASC").
+		Scan(&results).Error
+	return results, err
+}
+
+// hourCount satu baris jumlah aktivitas per jam.
+type hourCount struct {
+	Hour  int
+	Count int64
+}
+
+// rollupCountByProvince: versi rollup GetActivityCountByProvince.
+func (r *activityLogRepository) rollupCountByProvince(filter ActivityFilter) ([]map[string]interface{}, error) {
+	type Result struct {
+		Province string
+		Count    int64
+	}
+
+	var results []Result
+	err := r.rollupQuery(filter).
+		Joins("LEFT JOIN ref_locations l ON l.id = ar.location_id").
+		Select("l.province, SUM(ar.activity_count) as count").
+		Where("l.province != '' AND l.province IS NOT NULL").
+		Group("l.province").
+		Order("count DESC").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var data []map[string]interface{}
+	for _, r := range results {
+		data = append(data, map[string]interface{}{
+			"province": r.Province,
+			"count":    r.Count,
+		})
+	}
+	return data, nil
+}
+
+// rollupCountByLokasi: versi rollup GetActivityCountByLokasi.
+func (r *activityLogRepository) rollupCountByLokasi(filter ActivityFilter) ([]map[string]interface{}, error) {
+	type Result struct {
+		Lokasi string
+		Count  int64
+	}
+
+	var results []Result
+	err := r.rollupQuery(filter).
+		Joins("LEFT JOIN ref_locations l ON l.id = ar.location_id").
+		Select("l.location_name as lokasi, SUM(ar.activity_count) as count").
+		Where("l.location_name != '' AND l.location_name IS NOT NULL").
+		Group("l.location_name").
+		Order("count DESC").
+		Limit(config.TopLokasiLimit).
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var data []map[string]interface{}
+	for _, r := range results {
+		data = append(data, map[string]interface{}{
+			"location": r.Lokasi,
+			"count":    r.Count,
+		})
+	}
+	return data, nil
+}
+
+// rollupCountBySatkerProvince: versi rollup GetActivityCountBySatkerProvince (normalisasi dan pengecualian provinsi sama).
+func (r *activityLogRepository) rollupCountBySatkerProvince(filter ActivityFilter) ([]map[string]interface{}, error) {
+	type Result struct {
+		Province string
+		Count    int64
+	}
+
+	var results []Result
+	err := r.rollupQuery(filter).
+		Joins("LEFT JOIN ref_locations l ON l.id = ar.location_id").
+		Select(`
+			CASE
+				WHEN UPPER(l.province) = 'DKI' THEN 'DKI JAKARTA'
+				WHEN UPPER(l.province) = 'DAERAH ISTIMEWA YOGYAKARTA' THEN 'DI YOGYAKARTA'
+				ELSE UPPER(l.province)
+			END as province,
+			SUM(ar.activity_count) as count
+		`).
+		Where("l.province != '' AND l.province IS NOT NULL AND UPPER(l.province) NOT IN ('UNKNOWN', 'KALIMANTAN', 'SULAWESI', 'PAPUA', 'JAWA', 'KEPULAUAN')").
+		Group("1").
+		Order("count DESC").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var data []map[string]interface{}
+	for _, r := range results {
+		data = append(data, map[string]interface{}{
+			"lokasi": r.Province,
+			"count":  r.Count,
+		})
+	}
+	return data, nil
+}
+
+// rollupCountBySatker: versi rollup GetActivityCountBySatker.
+func (r *activityLogRepository) rollupCountBySatker(page, pageSize int, filter ActivityFilter) ([]map[string]interface{}, error) {
+	type Result struct {
+		Satker string
+		Count  int64
+	}
+
+	var results []Result
+	offset := (page - 1) * pageSize
+	err := r.rollupQuery(filter).
+		Joins("LEFT JOIN ref_satker_units s ON s.id = ar.satker_id").
+		Select("s.satker_name as satker, SUM(ar.activity_count) as count").
+		Where("s.satker_name != '' AND s.satker_name IS NOT NULL").
+		Group("s.satker_name").
+		Order("count DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var data []map[string]interface{}
+	for i, r := range results {
+		data = append(data, map[string]interface{}{
+			"rank":   offset + i + 1,
+			"satker": r.Satker,
+			"count":  r.Count,
+		})
+	}
+	return data, nil
+}
+
+// rollupAccessSuccessRateRows: versi rollup agregat harian GetAccessSuccessRateByDate (sukses login, error logout).
+func (r *activityLogRepository) rollupAccessSuccessRateRows(filter ActivityFilter) ([]successRateRow, error) {
+	var results []successRateRow
+	err := r.rollupQuery(filter).
+		Joins("LEFT JOIN ref_activity_types at ON ar.activity_type_id = at.id").
+		Select(`
+			ar.day as date,
+			COALESCE(SUM(CASE WHEN at.name = 'LOGIN' AND ar.scope_class IN ('` + strings.Join(rollupSuccessScopes, "','") + `') THEN ar.activity_count END), 0) as success,
+			COALESCE(SUM(CASE WHEN at.name = 'LOGOUT' AND ar.scope_class IN ('` + strings.Join(rollupErrorScopes, "','") + `') THEN ar.activity_count END), 0) as failed
+		`).
+		Group("ar.day").
+		Order("date ASC").
+		Scan(&results).Error
+	return results, err
+}
+
+// successRateRow satu baris agregat harian untuk tingkat sukses akses.
+type successRateRow struct {
+	Date    string
+	Success int64
+	Failed  int64
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+)
+
+func TestRollupRescanFromCommitTerlambat(t *testing.T) {
+	// Refresh sebelumnya melihat MAX(id) = 1000 saat id 995 belum commit; refresh berikutnya melihat MAX(id) = 1200 dan id 995 sudah commit.
+	const watermark, upTo, lateID = 1000, 1200, 995
+
+	tests := []struct {
+		name      string
+		lastLogID int64
+		rescanIDs int64
+		wantFrom  int64
+		wantLate  bool
+	}{
+		{"jendela default mencakup id terlambat", watermark, config.DefaultRollupRescanIDs, 0, true},
+		{"jendela 10 id mencakup id terlambat", watermark, 10, 990, true},
+		{"jendela 5 id tepat di batas", watermark, 5, 995, false},
+		{"tanpa jendela (perilaku watermark murni) melewatkan id terlambat", watermark, 0, 1000, false},
+		{"refresh pertama mulai dari 0", 0, 10, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := rollupRescanFrom(tt.lastLogID, tt.rescanIDs)
+			if from != tt.wantFrom {
+				t.Errorf("rollupRescanFrom(%d, %d) = %d, ingin %d", tt.lastLogID, tt.rescanIDs, from, tt.wantFrom)
+			}
+			if got := lateID > from && lateID <= upTo; got != tt.wantLate {
+				t.Errorf("id %d diproses = %v, ingin %v (rentang (%d, %d])", lateID, got, tt.wantLate, from, upTo)
+			}
+		})
+	}
+}
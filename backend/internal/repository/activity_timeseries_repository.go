@@ -0,0 +1,291 @@
+// File activity_timeseries_repository.go: deret waktu jumlah aktivitas per bucket (menit, jam, hari, minggu ISO, bulan, kuartal) untuk GET /api/dashboard/timeseries.
+//
+// Bucket dihitung dengan date_trunc di database lalu diisi nol di Go untuk bucket tanpa aktivitas, sepanjang rentang filter (atau rentang data jika tanggal filter kosong).
+// Opsional dipecah per dimensi (cluster, status, kategori = jenis aktivitas, eselon) dan diberi rata-rata bergulir (trailing) sepanjang N bucket.
+// Granularitas jam ke atas membaca activity_rollup_hourly jika rollup siap; menit selalu dari tabel mentah.
+package repository
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+)
+
+// Granularitas bucket time-series.
+const (
+	GranularityMinute  = "minute"
+	GranularityHour    = "hour"
+	GranularityDay     = "day"
+	GranularityWeek    = "week"
+	GranularityMonth   = "month"
+	GranularityQuarter = "quarter"
+)
+
+// Dimensi pemecah series.
+const (
+	TimeSeriesByCluster  = "cluster"
+	TimeSeriesByStatus   = "status"
+	TimeSeriesByCategory = "category"
+	TimeSeriesByEselon   = "eselon"
+)
+
+// timeSeriesTotalKey kunci series tunggal jika tidak dipecah per dimensi.
+const timeSeriesTotalKey = "total"
+
+var (
+	ErrInvalidGranularity   = errors.New("granularity harus salah satu dari minute, hour, day, week, month, quarter")
+	ErrInvalidDimension     = errors.New("split_by harus salah satu dari cluster, status, category, eselon")
+	ErrInvalidRollingWindow = errors.New("rolling harus antara 0 dan batas jendela maksimum")
+	ErrTooManyBuckets       = errors.New("rentang terlalu panjang untuk granularitas ini; perkecil rentang atau pilih granularitas lebih kasar")
+)
+
+// TimeSeriesQuery parameter time-series: granularitas, dimensi pemecah (kosong = satu series total), jendela rata-rata bergulir (0 = tanpa).
+type TimeSeriesQuery struct {
+	Granularity string `json:"granularity"`
+	SplitBy     string `json:"split_by,omitempty"`
+	Rolling     int    `json:"rolling,omitempty"`
+}
+
+// TimeSeriesPoint satu bucket: awal bucket, jumlah aktivitas, dan rata-rata bergulir (nil sampai jendela penuh atau jika rolling tidak diminta).
+type TimeSeriesPoint struct {
+	Bucket     time.Time `json:"bucket"`
+	Count      int64     `json:"count"`
+	RollingAvg *float64  `json:"rolling_avg,omitempty"`
+}
+
+// TimeSeries satu series: kunci (nilai dimensi atau "total"), jumlah keseluruhan, dan titik per bucket.
+type TimeSeries struct {
+	Key    string            `json:"key"`
+	Total  int64             `json:"total"`
+	Points []TimeSeriesPoint `json:"points"`
+}
+
+// TimeSeriesResult hasil time-series: parameter yang dipakai, rentang bucket (awal bucket pertama–terakhir), dan daftar series (urut total DESC).
+type TimeSeriesResult struct {
+	Granularity string       `json:"granularity"`
+	SplitBy     string       `json:"split_by,omitempty"`
+	Rolling     int          `json:"rolling,omitempty"`
+	Start       *time.Time   `json:"start"`
+	End         *time.Time   `json:"end"`
+	Series      []TimeSeries `json:"series"`
+}
+
+// Validate memeriksa granularitas, dimensi, dan jendela rolling.
+func (q TimeSeriesQuery) Validate() error {
+	switch q.Granularity {
+	case GranularityMinute, GranularityHour, GranularityDay, GranularityWeek, GranularityMonth, GranularityQuarter:
+	default:
+		return ErrInvalidGranularity
+	}
+	switch q.SplitBy {
+	case "", TimeSeriesByCluster, TimeSeriesByStatus, TimeSeriesByCategory, TimeSeriesByEselon:
+	default:
+		return ErrInvalidDimension
+	}
+	if q.Rolling < 0 || q.Rolling > config.MaxRollingWindow {
+		return ErrInvalidRollingWindow
+	}
+	return nil
+}
+
+// timeSeriesRow satu baris hasil agregasi: awal bucket, kunci series, jumlah.
+type timeSeriesRow struct {
+	Bucket time.Time
+	Series string
+	Count  int64
+}
+
+// GetActivityTimeSeries mengembalikan deret waktu aktivitas sesuai query dan filter. Rentang bucket = StartDate–EndDate filter;
+// batas yang kosong diambil dari aktivitas pertama/terakhir yang lolos filter. ErrTooManyBuckets jika jumlah bucket melebihi config.MaxTimeSeriesBuckets.
+func (r *activityLogRepository) GetActivityTimeSeries(q TimeSeriesQuery, filter ActivityFilter) (*TimeSeriesResult, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	result := &TimeSeriesResult{Granularity: q.Granularity, SplitBy: q.SplitBy, Rolling: q.Rolling, Series: []TimeSeries{}}
+
+	start, end, err := r.timeSeriesBounds(filter)
+	if err != nil || start.IsZero() || end.IsZero() {
+		return result, err
+	}
+	buckets := timeSeriesBuckets(q.Granularity, start, end)
+	if buckets == nil {
+		return nil, ErrTooManyBuckets
+	}
+	result.Start, result.End = &buckets[0], &buckets[len(buckets)-1]
+
+	var rows []timeSeriesRow
+	useRollup := q.Granularity != GranularityMinute && r.rollupReady(filter)
+	query, args := timeSeriesQuery(q, filter, useRollup).Build()
+	if err := r.db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// Kelompokkan per series lalu isi semua bucket (nol jika tidak ada baris).
+	counts := map[string]map[time.Time]int64{}
+	if q.SplitBy == "" {
+		counts[timeSeriesTotalKey] = map[time.Time]int64{}
+	}
+	for _, row := range rows {
+		if counts[row.Series] == nil {
+			counts[row.Series] = map[time.Time]int64{}
+		}
+		counts[row.Series][row.Bucket.UTC()] += row.Count
+	}
+	for key, byBucket := range counts {
+		series := TimeSeries{Key: key, Points: make([]TimeSeriesPoint, len(buckets))}
+		for i, b := range buckets {
+			series.Points[i] = TimeSeriesPoint{Bucket: b, Count: byBucket[b]}
+			series.Total += byBucket[b]
+		}
+		applyRollingAverage(series.Points, q.Rolling)
+		result.Series = append(result.Series, series)
+	}
+	sort.Slice(result.Series, func(i, j int) bool {
+		if result.Series[i].Total != result.Series[j].Total {
+			return result.Series[i].Total > result.Series[j].Total
+		}
+		return result.Series[i].Key < result.Series[j].Key
+	})
+	return result, nil
+}
+
+// timeSeriesBounds mengembalikan awal hari StartDate dan akhir hari EndDate (eksklusif dikurangi 1 ns) dalam UTC;
+// batas kosong diisi MIN/MAX tanggal aktivitas yang lolos filter. Nol jika tidak ada aktivitas.
+func (r *activityLogRepository) timeSeriesBounds(filter ActivityFilter) (time.Time, time.Time, error) {
+	startDate, endDate, err := filter.dates()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	var start, end time.Time
+	if !startDate.IsZero() {
+		start = startDate.Time()
+	}
+	if !endDate.IsZero() {
+		end = endDate.AddDays(1).Time().Add(-time.Nanosecond)
+	}
+	if start.IsZero() || end.IsZero() {
+		var bounds struct {
+			MinTanggal *time.Time
+			MaxTanggal *time.Time
+		}
+		query, args := sqlbuilder.Select("MIN(a.tanggal) AS min_tanggal", "MAX(a.tanggal) AS max_tanggal").
+			From("activity_logs_normalized a").
+			Where(filter.where("a")).
+			Build()
+		if err := r.db.Raw(query, args...).Scan(&bounds).Error; err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if bounds.MinTanggal == nil || bounds.MaxTanggal == nil {
+			return time.Time{}, time.Time{}, nil
+		}
+		if start.IsZero() {
+			start = bounds.MinTanggal.UTC()
+		}
+		if end.IsZero() {
+			end = bounds.MaxTanggal.UTC()
+		}
+	}
+	return start, end, nil
+}
+
+// timeSeriesQuery menyusun agregasi per (bucket, series) dari tabel mentah (alias a) atau rollup (alias ar, waktu = day + hour).
+func timeSeriesQuery(q TimeSeriesQuery, filter ActivityFilter, rollup bool) *sqlbuilder.SelectBuilder {
+	alias, timeExpr, countExpr, cond := "a", "a.tanggal", "COUNT(*)", filter.where("a")
+	from := "activity_logs_normalized a"
+	if rollup {
+		alias, timeExpr, countExpr, cond = "ar", "(ar.day + make_interval(hours => ar.hour))", "SUM(ar.activity_count)", filter.rollupWhere("ar")
+		from = "activity_rollup_hourly ar"
+	}
+
+	seriesExpr, join := "'"+timeSeriesTotalKey+"'", ""
+	switch q.SplitBy {
+	case TimeSeriesByCluster:
+		seriesExpr, join = "COALESCE(c.name, 'Tidak Terkategori')", "ref_clusters c ON c.id = "+alias+".cluster_id"
+	case TimeSeriesByStatus:
+		seriesExpr = "COALESCE(NULLIF(" + alias + ".status, ''), 'UNKNOWN')"
+	case TimeSeriesByCategory:
+		seriesExpr, join = "COALESCE(at.name, 'Tidak Terkategori')", "ref_activity_types at ON at.id = "+alias+".activity_type_id"
+	case TimeSeriesByEselon:
+		seriesExpr, join = "COALESCE(NULLIF(s.eselon_level, ''), 'Tidak Diketahui')", "ref_satker_units s ON s.id = "+alias+".satker_id"
+	}
+
+	// q.Granularity sudah divalidasi terhadap daftar konstanta sehingga aman menjadi literal date_trunc.
+	b := sqlbuilder.Select("date_trunc('"+q.Granularity+"', "+timeExpr+") AS bucket", seriesExpr+" AS series", countExpr+" AS count").
+		From(from)
+	if join != "" {
+		b.LeftJoin(join)
+	}
+	return b.Where(cond).GroupBy("bucket", "series")
+}
+
+// timeSeriesBuckets mengembalikan awal setiap bucket dari bucket yang memuat start sampai bucket yang memuat end (UTC).
+// nil jika jumlah bucket melebihi config.MaxTimeSeriesBuckets.
+func timeSeriesBuckets(granularity string, start, end time.Time) []time.Time {
+	var buckets []time.Time
+	for b := truncateBucket(granularity, start.UTC()); !b.After(end.UTC()); b = nextBucket(granularity, b) {
+		if len(buckets) == config.MaxTimeSeriesBuckets {
+			return nil
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+// truncateBucket memotong t ke awal bucket, setara dengan date_trunc PostgreSQL (minggu dimulai Senin, sesuai ISO).
+func truncateBucket(granularity string, t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch granularity {
+	case GranularityMinute:
+		return t.Truncate(time.Minute)
+	case GranularityHour:
+		return t.Truncate(time.Hour)
+	case GranularityWeek:
+		offset := (int(t.Weekday()) + 6) % 7 // Senin = 0.
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, time.UTC)
+	case GranularityMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case GranularityQuarter:
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nextBucket mengembalikan awal bucket berikutnya setelah b.
+func nextBucket(granularity string, b time.Time) time.Time {
+	switch granularity {
+	case GranularityMinute:
+		return b.Add(time.Minute)
+	case GranularityHour:
+		return b.Add(time.Hour)
+	case GranularityWeek:
+		return b.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return b.AddDate(0, 1, 0)
+	case GranularityQuarter:
+		return b.AddDate(0, 3, 0)
+	default:
+		return b.AddDate(0, 0, 1)
+	}
+}
+
+// applyRollingAverage mengisi RollingAvg dengan rata-rata window bucket terakhir (termasuk bucket itu sendiri) mulai dari bucket ke-window.
+func applyRollingAverage(points []TimeSeriesPoint, window int) {
+	if window <= 0 {
+		return
+	}
+	var sum int64
+	for i := range points {
+		sum += points[i].Count
+		if i >= window {
+			sum -= points[i-window].Count
+		}
+		if i >= window-1 {
+			avg := float64(sum) / float64(window)
+			points[i].RollingAvg = &avg
+		}
+	}
+}
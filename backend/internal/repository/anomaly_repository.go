@@ -0,0 +1,206 @@
+// File anomaly_repository.go: data untuk detektor anomali dan penyimpanan temuannya (tabel anomalies).
+//
+// Deret harian per subjek: volume satker dan error logout dari rollup activity_rollup_hourly; unduhan per user dari tabel mentah (rollup tidak menyimpan user).
+// Save menyimpan hasil satu detektor untuk satu hari (upsert; status tindak lanjut dipertahankan) dan menghapus temuan open yang tidak lagi terdeteksi.
+package repository
+
+import (
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DailyCount jumlah harian satu subjek (SubjectID 0 untuk deret global). Day berformat YYYY-MM-DD.
+type DailyCount struct {
+	SubjectID   int64
+	SubjectName string
+	Day         string
+	Count       int64
+}
+
+// AnomalyListFilter filter daftar anomali; field kosong diabaikan. Tanggal (YYYY-MM-DD) membatasi kolom day.
+type AnomalyListFilter struct {
+	Status      string
+	Detector    string
+	Severity    string
+	SubjectType string
+	StartDate   string
+	EndDate     string
+	Page        int
+	PageSize    int
+}
+
+// AnomalyRepository akses data detektor anomali.
+type AnomalyRepository struct {
+	db *gorm.DB
+}
+
+// NewAnomalyRepository membuat instance AnomalyRepository.
+func NewAnomalyRepository(db *gorm.DB) *AnomalyRepository {
+	return &AnomalyRepository{db: db}
+}
+
+// DayTotals mengembalikan total aktivitas di rollup per hari untuk hari-hari di days; hari tanpa data (belum diimpor) tidak ada di map.
+func (r *AnomalyRepository) DayTotals(days []string) (map[string]int64, error) {
+	var rows []DailyCount
+	err := r.db.Raw(`
+		SELECT to_char(day, 'YYYY-MM-DD') AS day, SUM(activity_count) AS count
+		FROM activity_rollup_hourly WHERE day IN ? GROUP BY day
+	`, days).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		out[row.Day] = row.Count
+	}
+	return out, nil
+}
+
+// Holidays mengembalikan tanggal di days yang terdaftar di ref_holidays.
+func (r *AnomalyRepository) Holidays(days []string) (map[string]bool, error) {
+	var found []string
+	err := r.db.Raw("SELECT to_char(holiday_date, 'YYYY-MM-DD') FROM ref_holidays WHERE holiday_date IN ?", days).Scan(&found).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(found))
+	for _, d := range found {
+		out[d] = true
+	}
+	return out, nil
+}
+
+// SatkerDailyCounts jumlah aktivitas per satker per hari untuk hari-hari di days (dari rollup; aktivitas tanpa satker diabaikan).
+func (r *AnomalyRepository) SatkerDailyCounts(days []string) ([]DailyCount, error) {
+	query, args := sqlbuilder.Select(
+		"ar.satker_id AS subject_id",
+		"COALESCE(s.satker_name, '') AS subject_name",
+		"to_char(ar.day, 'YYYY-MM-DD') AS day",
+		"SUM(ar.activity_count) AS count",
+	).
+		From("activity_rollup_hourly ar").
+		LeftJoin("ref_satker_units s ON s.id = ar.satker_id").
+		WhereExpr("ar.satker_id <> 0").
+		WhereExpr("ar.day IN ?", days).
+		GroupBy("ar.satker_id", "s.satker_name", "ar.day").
+		Build()
+	var rows []DailyCount
+	err := r.db.Raw(query, args...).Scan(&rows).Error
+	return rows, err
+}
+
+// FailedLogoutDailyCounts jumlah error logout (LOGOUT dengan scope error, sama dengan GetCountByStatus FAILED) per hari untuk hari-hari di days (dari rollup).
+func (r *AnomalyRepository) FailedLogoutDailyCounts(days []string) ([]DailyCount, error) {
+	query, args := sqlbuilder.Select(
+		"to_char(ar.day, 'YYYY-MM-DD') AS day",
+		"SUM(ar.activity_count) AS count",
+	).
+		From("activity_rollup_hourly ar").
+		Join("ref_activity_types at ON at.id = ar.activity_type_id").
+		WhereExpr("at.name = ?", "LOGOUT").
+		WhereExpr("ar.scope_class IN ?", rollupErrorScopes).
+		WhereExpr("ar.day IN ?", days).
+		GroupBy("ar.day").
+		Build()
+	var rows []DailyCount
+	err := r.db.Raw(query, args...).Scan(&rows).Error
+	return rows, err
+}
+
+// UserDownloadDailyCounts jumlah unduhan (jenis aktivitas ILIKE '%download%', sama dengan ExportStats) per user per hari untuk hari-hari di days.
+// Membaca tabel mentah dengan rentang tanggal min–max days agar indeks tanggal terpakai. days harus terurut naik.
+func (r *AnomalyRepository) UserDownloadDailyCounts(days []string) ([]DailyCount, error) {
+	if len(days) == 0 {
+		return nil, nil
+	}
+	start, err := sqlbuilder.ParseDate(days[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := sqlbuilder.ParseDate(days[len(days)-1])
+	if err != nil {
+		return nil, err
+	}
+	query, args := sqlbuilder.Select(
+		"a.user_id AS subject_id",
+		"COALESCE(up.nama, '') AS subject_name",
+		"to_char(DATE(a.tanggal), 'YYYY-MM-DD') AS day",
+		"COUNT(*) AS count",
+	).
+		From("activity_logs_normalized a").
+		Join("ref_activity_types at ON at.id = a.activity_type_id").
+		LeftJoin("user_profiles up ON up.id = a.user_id").
+		WhereExpr("at.name ILIKE '%download%'").
+		Where(sqlbuilder.DateRange("a.tanggal", start, end)).
+		WhereExpr("DATE(a.tanggal) IN ?", days).
+		GroupBy("a.user_id", "up.nama", "DATE(a.tanggal)").
+		Build()
+	var rows []DailyCount
+	err = r.db.Raw(query, args...).Scan(&rows).Error
+	return rows, err
+}
+
+// Save menyimpan temuan satu detektor untuk satu hari dalam satu transaksi: upsert per (detector, subject_id, day) tanpa mengubah status,
+// lalu menghapus temuan berstatus open pada hari itu yang tidak lagi terdeteksi (mis. data terlambat sudah melengkapi hari tersebut).
+func (r *AnomalyRepository) Save(detector, day string, anomalies []entity.Anomaly) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		keep := []int64{-1}
+		for i := range anomalies {
+			a := &anomalies[i]
+			a.UpdatedAt = time.Now()
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "detector"}, {Name: "subject_id"}, {Name: "day"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"subject_name", "observed", "baseline_median", "baseline_mad", "score", "direction", "severity", "explanation", "updated_at",
+				}),
+			}).Omit("status_note", "status_changed_by", "status_changed_at").Create(a).Error
+			if err != nil {
+				return err
+			}
+			keep = append(keep, a.SubjectID)
+		}
+		return tx.Where("detector = ? AND day = ? AND status = ? AND subject_id NOT IN ?", detector, day, entity.AnomalyStatusOpen, keep).
+			Delete(&entity.Anomaly{}).Error
+	})
+}
+
+// List mengembalikan anomali sesuai filter (hari terbaru dulu, lalu skor absolut terbesar) beserta total untuk paginasi.
+func (r *AnomalyRepository) List(f AnomalyListFilter) ([]entity.Anomaly, int64, error) {
+	query := r.db.Model(&entity.Anomaly{})
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Detector != "" {
+		query = query.Where("detector = ?", f.Detector)
+	}
+	if f.Severity != "" {
+		query = query.Where("severity = ?", f.Severity)
+	}
+	if f.SubjectType != "" {
+		query = query.Where("subject_type = ?", f.SubjectType)
+	}
+	start, errStart := sqlbuilder.ParseDate(f.StartDate)
+	end, errEnd := sqlbuilder.ParseDate(f.EndDate)
+	if errStart != nil || errEnd != nil {
+		return nil, 0, sqlbuilder.ErrInvalidDate
+	}
+	if cond := sqlbuilder.DayRange("day", start, end); !cond.IsEmpty() {
+		query = query.Where(cond.SQL, cond.Args...)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var anomalies []entity.Anomaly
+	offset := (f.Page - 1) * f.PageSize
+	err := query.Order("day DESC").Order("ABS(score) DESC").Order("id").Offset(offset).Limit(f.PageSize).Find(&anomalies).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return anomalies, total, nil
+}
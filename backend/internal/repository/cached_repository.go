@@ -0,0 +1,209 @@
+// File cached_repository.go: cache di depan query analitik — dekorator ActivityLogRepository dan fungsi content_repository.
+//
+// Kunci cache = namespace metode + versi data aktivitas + parameter filter ternormalisasi (cache.Key). Impor menaikkan versi data sehingga hasil lama tidak dipakai lagi.
+// Tidak di-cache: GetRecentActivities (baris mentah berpaginasi) dan GetSatkerIdsUnderRoot (data referensi, dipakai untuk otorisasi).
+// Jika versi data tidak bisa dibaca (mis. migrasi 019 belum dijalankan), query dijalankan langsung tanpa cache.
+package repository
+
+import (
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/cache"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
+	"gorm.io/gorm"
+)
+
+// cachedQuery mengembalikan hasil load dari cache dengan kunci namespace + versi data aktivitas + parts.
+func cachedQuery[T any](db *gorm.DB, namespace string, load func() (T, error), parts ...interface{}) (T, error) {
+	version, err := GetDataVersion(db, DataVersionActivity)
+	if err != nil {
+		return load()
+	}
+	return cache.Fetch(cache.Key(namespace, append([]interface{}{version}, parts...)...), load)
+}
+
+// cachedActivityLogRepository membungkus ActivityLogRepository; metode yang tidak di-override diteruskan tanpa cache.
+type cachedActivityLogRepository struct {
+	ActivityLogRepository
+	db *gorm.DB
+}
+
+// NewCachedActivityLogRepository membuat repository aktivitas dengan cache hasil agregat (dipakai handler dashboard/regional).
+func NewCachedActivityLogRepository(db *gorm.DB) ActivityLogRepository {
+	return &cachedActivityLogRepository{ActivityLogRepository: NewActivityLogRepository(db), db: db}
+}
+
+// GetTotalCount versi cache dari ActivityLogRepository.GetTotalCount.
+func (r *cachedActivityLogRepository) GetTotalCount(filter ActivityFilter) (int64, error) {
+	return cachedQuery(r.db, "activity.total", func() (int64, error) {
+		return r.ActivityLogRepository.GetTotalCount(filter)
+	}, filter.Normalize())
+}
+
+// GetCountByStatus versi cache dari ActivityLogRepository.GetCountByStatus.
+func (r *cachedActivityLogRepository) GetCountByStatus(status string, filter ActivityFilter) (int64, error) {
+	return cachedQuery(r.db, "activity.status", func() (int64, error) {
+		return r.ActivityLogRepository.GetCountByStatus(status, filter)
+	}, status, filter.Normalize())
+}
+
+// GetActivityCountByScope versi cache dari ActivityLogRepository.GetActivityCountByScope.
+func (r *cachedActivityLogRepository) GetActivityCountByScope(filter ActivityFilter) (map[string]int64, error) {
+	return cachedQuery(r.db, "activity.scope", func() (map[string]int64, error) {
+		return r.ActivityLogRepository.GetActivityCountByScope(filter)
+	}, filter.Normalize())
+}
+
+// GetActivityCountByHour versi cache dari ActivityLogRepository.GetActivityCountByHour.
+func (r *cachedActivityLogRepository) GetActivityCountByHour(filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.hour", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetActivityCountByHour(filter)
+	}, filter.Normalize())
+}
+
+// GetActivityCountByHourForSatker versi cache dari ActivityLogRepository.GetActivityCountByHourForSatker.
+func (r *cachedActivityLogRepository) GetActivityCountByHourForSatker(satker string, filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.hour_satker", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetActivityCountByHourForSatker(satker, filter)
+	}, satker, filter.Normalize())
+}
+
+// GetActivityCountByProvince versi cache dari ActivityLogRepository.GetActivityCountByProvince.
+func (r *cachedActivityLogRepository) GetActivityCountByProvince(filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.province", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetActivityCountByProvince(filter)
+	}, filter.Normalize())
+}
+
+// GetActivityCountByLokasi versi cache dari ActivityLogRepository.GetActivityCountByLokasi.
+func (r *cachedActivityLogRepository) GetActivityCountByLokasi(filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.lokasi", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetActivityCountByLokasi(filter)
+	}, filter.Normalize())
+}
+
+// GetActivityCountBySatkerProvince versi cache dari ActivityLogRepository.GetActivityCountBySatkerProvince.
+func (r *cachedActivityLogRepository) GetActivityCountBySatkerProvince(filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.satker_province", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetActivityCountBySatkerProvince(filter)
+	}, filter.Normalize())
+}
+
+// GetActivityCountBySatker versi cache dari ActivityLogRepository.GetActivityCountBySatker.
+func (r *cachedActivityLogRepository) GetActivityCountBySatker(page, pageSize int, filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.satker", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetActivityCountBySatker(page, pageSize, filter)
+	}, page, pageSize, filter.Normalize())
+}
+
+// busiestHour hasil GetBusiestHour dalam bentuk yang bisa disimpan di cache.
+type busiestHour struct {
+	Hour  int   `json:"hour"`
+	Count int64 `json:"count"`
+}
+
+// GetBusiestHour versi cache dari ActivityLogRepository.GetBusiestHour.
+func (r *cachedActivityLogRepository) GetBusiestHour(filter ActivityFilter) (int, int64, error) {
+	result, err := cachedQuery(r.db, "activity.busiest_hour", func() (busiestHour, error) {
+		hour, count, err := r.ActivityLogRepository.GetBusiestHour(filter)
+		return busiestHour{Hour: hour, Count: count}, err
+	}, filter.Normalize())
+	return result.Hour, result.Count, err
+}
+
+// GetAccessSuccessRateByDate versi cache dari ActivityLogRepository.GetAccessSuccessRateByDate.
+func (r *cachedActivityLogRepository) GetAccessSuccessRateByDate(filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.success_rate", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetAccessSuccessRateByDate(filter)
+	}, filter.Normalize())
+}
+
+// GetActivityTimeSeries versi cache dari ActivityLogRepository.GetActivityTimeSeries.
+func (r *cachedActivityLogRepository) GetActivityTimeSeries(q TimeSeriesQuery, filter ActivityFilter) (*TimeSeriesResult, error) {
+	return cachedQuery(r.db, "activity.timeseries", func() (*TimeSeriesResult, error) {
+		return r.ActivityLogRepository.GetActivityTimeSeries(q, filter)
+	}, q, filter.Normalize())
+}
+
+// GetActivityHeatmap versi cache dari ActivityLogRepository.GetActivityHeatmap.
+func (r *cachedActivityLogRepository) GetActivityHeatmap(q HeatmapQuery, filter ActivityFilter) (*Heatmap, error) {
+	return cachedQuery(r.db, "activity.heatmap", func() (*Heatmap, error) {
+		return r.ActivityLogRepository.GetActivityHeatmap(q, filter)
+	}, q, filter.Normalize())
+}
+
+// GetUserCohorts versi cache dari ActivityLogRepository.GetUserCohorts. Tanggal hari ini ikut kunci karena rentang default dan periode berjalan bergantung padanya.
+func (r *cachedActivityLogRepository) GetUserCohorts(q CohortQuery, filter ActivityFilter) (*CohortMatrix, error) {
+	return cachedQuery(r.db, "activity.cohorts", func() (*CohortMatrix, error) {
+		return r.ActivityLogRepository.GetUserCohorts(q, filter)
+	}, q, filter.Normalize(), time.Now().Format(sqlbuilder.DateLayout))
+}
+
+// GetFunnel versi cache dari ActivityLogRepository.GetFunnel. Tanggal hari ini ikut kunci karena rentang default bergantung padanya.
+func (r *cachedActivityLogRepository) GetFunnel(q FunnelQuery, filter ActivityFilter) (*FunnelResult, error) {
+	return cachedQuery(r.db, "activity.funnel", func() (*FunnelResult, error) {
+		return r.ActivityLogRepository.GetFunnel(q, filter)
+	}, q, filter.Normalize(), time.Now().Format(sqlbuilder.DateLayout))
+}
+
+// GetUniqueUsersCount versi cache dari ActivityLogRepository.GetUniqueUsersCount.
+func (r *cachedActivityLogRepository) GetUniqueUsersCount(filter ActivityFilter) (int64, error) {
+	return cachedQuery(r.db, "activity.unique_users", func() (int64, error) {
+		return r.ActivityLogRepository.GetUniqueUsersCount(filter)
+	}, filter.Normalize())
+}
+
+// GetUniqueClusters versi cache dari ActivityLogRepository.GetUniqueClusters.
+func (r *cachedActivityLogRepository) GetUniqueClusters() ([]string, error) {
+	return cachedQuery(r.db, "activity.clusters", r.ActivityLogRepository.GetUniqueClusters)
+}
+
+// GetTopContributors versi cache dari ActivityLogRepository.GetTopContributors.
+func (r *cachedActivityLogRepository) GetTopContributors(limit int, filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.top_contributors", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetTopContributors(limit, filter)
+	}, limit, filter.Normalize())
+}
+
+// GetLogoutErrors versi cache dari ActivityLogRepository.GetLogoutErrors.
+func (r *cachedActivityLogRepository) GetLogoutErrors(limit int, filter ActivityFilter) ([]map[string]interface{}, error) {
+	return cachedQuery(r.db, "activity.logout_errors", func() ([]map[string]interface{}, error) {
+		return r.ActivityLogRepository.GetLogoutErrors(limit, filter)
+	}, limit, filter.Normalize())
+}
+
+// GetDashboardRankings versi cache dari loadDashboardRankings.
+func GetDashboardRankings(filter ActivityFilter) ([]DashboardRanking, error) {
+	return cachedQuery(database.GetDB(), "content.rankings", func() ([]DashboardRanking, error) {
+		return loadDashboardRankings(filter)
+	}, filter.Normalize())
+}
+
+// GetSearchModuleUsage versi cache dari loadSearchModuleUsage.
+func GetSearchModuleUsage(filter ActivityFilter) ([]SearchModule, error) {
+	return cachedQuery(database.GetDB(), "content.search_modules", func() ([]SearchModule, error) {
+		return loadSearchModuleUsage(filter)
+	}, filter.Normalize())
+}
+
+// GetExportStats versi cache dari loadExportStats.
+func GetExportStats(filter ActivityFilter) (*ExportStats, error) {
+	return cachedQuery(database.GetDB(), "content.export_stats", func() (*ExportStats, error) {
+		return loadExportStats(filter)
+	}, filter.Normalize())
+}
+
+// GetOperationalIntents versi cache dari loadOperationalIntents.
+func GetOperationalIntents(filter ActivityFilter, limit int) ([]OperationalIntent, error) {
+	return cachedQuery(database.GetDB(), "content.intents", func() ([]OperationalIntent, error) {
+		return loadOperationalIntents(filter, limit)
+	}, limit, filter.Normalize())
+}
+
+// GetGlobalEconomicsChart versi cache dari loadGlobalEconomicsChart.
+func GetGlobalEconomicsChart(filter ActivityFilter) ([]GlobalEconomicsData, error) {
+	return cachedQuery(database.GetDB(), "content.global_economics", func() ([]GlobalEconomicsData, error) {
+		return loadGlobalEconomicsChart(filter)
+	}, filter.Normalize())
+}
@@ -1,12 +1,11 @@
 // File content_repository.go: query untuk data analitik/konten dashboard (peringkat kluster, penggunaan modul pencarian, statistik ekspor, intensi operasional, chart Global Economics).
 //
-// Semua fungsi memakai raw SQL dengan parameter posisi ($1, $2, ...) untuk filter tanggal dan cluster. Data sumber: activity_logs_normalized dan tabel referensi (ref_clusters, ref_activity_types).
+// Semua query disusun dengan sqlbuilder plus kondisi ActivityFilter (alias tabel aktivitas = a); nilai request hanya lewat placeholder. Data sumber: activity_logs_normalized dan tabel referensi (ref_clusters, ref_activity_types).
+// Fungsi load* menjalankan query langsung; versi exported (GetDashboardRankings, dst.) ada di cached_repository.go dan melewati cache.
 package repository
 
 import (
-	"strconv"
-
-	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 )
 
@@ -50,37 +49,16 @@ type GlobalEconomicsData struct {
 	Count    int    `json:"count"`
 }
 
-// GetDashboardRankings mengembalikan peringkat penggunaan dashboard per cluster (nama cluster = COALESCE(c.name, 'Tidak Terkategori')). Filter opsional: startDate, endDate. Persentase dihitung dari total semua count.
-func GetDashboardRankings(startDate, endDate string) ([]DashboardRanking, error) {
+// loadDashboardRankings mengembalikan peringkat penggunaan dashboard per cluster (nama cluster = COALESCE(c.name, 'Tidak Terkategori')). Persentase dihitung dari total semua count.
+func loadDashboardRankings(filter ActivityFilter) ([]DashboardRanking, error) {
 	db := database.GetDB()
-
-	query := `
-		SELECT 
-			COALESCE(c.name, 'Tidak Terkategori') as name,
-			COUNT(a.id) as count
-		FROM activity_logs_normalized a
-		LEFT JOIN ref_clusters c ON a.cluster_id = c.id
-		WHERE 1=1
-	`
-
-	args := []interface{}{}
-	argCount := 0
-
-	if startDate != "" {
-		argCount++
-		query += " AND a.tanggal >= $" + strconv.Itoa(argCount)
-		args = append(args, startDate)
-	}
-	if endDate != "" {
-		argCount++
-		query += " AND a.tanggal <= $" + strconv.Itoa(argCount)
-		args = append(args, endDate)
-	}
-
-	query += `
-		GROUP BY c.name
-		ORDER BY count DESC
-	`
+	query, args := sqlbuilder.Select("COALESCE(c.name, 'Tidak Terkategori') AS name", "COUNT(a.id) AS count").
+		From("activity_logs_normalized a").
+		LeftJoin("ref_clusters c ON a.cluster_id = c.id").
+		Where(filter.where("a")).
+		GroupBy("c.name").
+		OrderBy("count DESC").
+		Build()
 
 	rows, err := db.Raw(query, args...).Rows()
 	if err != nil {
@@ -127,59 +105,24 @@ func GetDashboardRankings(startDate, endDate string) ([]DashboardRanking, error)
 	return rankings, nil
 }
 
-// GetSearchModuleUsage mengembalikan statistik penggunaan modul pencarian: hanya aktivitas yang namanya/scope/detail mengandung search atau pencarian; nama modul = scope jika ada kata search/pencarian, else detail_aktifitas. Filter: cluster, startDate, endDate. Hasil dibatasi 5 baris, urut count DESC.
-func GetSearchModuleUsage(startDate, endDate, cluster string) ([]SearchModule, error) {
+// loadSearchModuleUsage mengembalikan statistik penggunaan modul pencarian: hanya aktivitas yang namanya/scope/detail mengandung search atau pencarian; nama modul = scope jika ada kata search/pencarian, else detail_aktifitas. Hasil dibatasi 5 baris, urut count DESC.
+func loadSearchModuleUsage(filter ActivityFilter) ([]SearchModule, error) {
 	db := database.GetDB()
-
-	query := `
-		SELECT 
-			module_name,
-			count
-		FROM (
-			SELECT 
-				CASE 
+	sub := sqlbuilder.Select(`CASE
 					WHEN a.scope ILIKE '%search%' OR a.scope ILIKE '%pencarian%' THEN 
 						COALESCE(NULLIF(a.scope, ''), 'Lainnya')
 					ELSE COALESCE(NULLIF(a.detail_aktifitas, ''), 'Lainnya')
-				END as module_name,
-				COUNT(*) as count
-			FROM activity_logs_normalized a
-			LEFT JOIN ref_activity_types at ON a.activity_type_id = at.id
-			LEFT JOIN ref_clusters c ON a.cluster_id = c.id
-			WHERE (
-				at.name ILIKE '%search%' 
-				OR at.name ILIKE '%pencarian%'
-				OR a.scope ILIKE '%search%'
-				OR a.detail_aktifitas ILIKE '%pencarian%'
-			)
-	`
-
-	args := []interface{}{}
-	argCount := 0
-
-	if cluster != "" {
-		argCount++
-		query += " AND c.name = $" + strconv.Itoa(argCount)
-		args = append(args, cluster)
-	}
-
-	if startDate != "" {
-		argCount++
-		query += " AND a.tanggal >= $" + strconv.Itoa(argCount)
-		args = append(args, startDate)
-	}
-	if endDate != "" {
-		argCount++
-		query += " AND a.tanggal <= $" + strconv.Itoa(argCount)
-		args = append(args, endDate)
-	}
-
-	query += `
-			GROUP BY module_name
-		) sub
-		ORDER BY count DESC
-		LIMIT 5
-	`
+				END AS module_name`, "COUNT(*) AS count").
+		From("activity_logs_normalized a").
+		LeftJoin("ref_activity_types at ON a.activity_type_id = at.id").
+		WhereExpr("at.name ILIKE '%search%' OR at.name ILIKE '%pencarian%' OR a.scope ILIKE '%search%' OR a.detail_aktifitas ILIKE '%pencarian%'").
+		Where(filter.where("a")).
+		GroupBy("module_name")
+	query, args := sqlbuilder.Select("module_name", "count").
+		FromSubquery(sub, "sub").
+		OrderBy("count DESC").
+		Limit(5).
+		Build()
 
 	rows, err := db.Raw(query, args...).Rows()
 	if err != nil {
@@ -199,85 +142,46 @@ func GetSearchModuleUsage(startDate, endDate, cluster string) ([]SearchModule, e
 	return modules, nil
 }
 
-// GetExportStats mengembalikan statistik view vs download: view_data = aktivitas at.name ILIKE '%view%' dan BUKAN download/export; download_data = at.name ILIKE '%download%'. Detail per aktivitas (detail = detail_aktifitas atau scope atau at.name) dibatasi 10 baris masing-masing. Filter: cluster, startDate, endDate dipakai untuk semua subquery.
-func GetExportStats(startDate, endDate, cluster string) (*ExportStats, error) {
+// loadExportStats mengembalikan statistik view vs download: view_data = aktivitas at.name ILIKE '%view%' dan BUKAN download/export; download_data = at.name ILIKE '%download%'. Detail per aktivitas (detail = detail_aktifitas atau scope atau at.name) dibatasi 10 baris masing-masing. Filter dipakai untuk semua subquery.
+func loadExportStats(filter ActivityFilter) (*ExportStats, error) {
 	db := database.GetDB()
 
-	args := []interface{}{}
-	argCount := 0
-
-	// Bangun klausa filter yang sama untuk semua query (cluster + tanggal).
-	dateFilter := ""
-	if cluster != "" {
-		argCount++
-		dateFilter += " AND c.name = $" + strconv.Itoa(argCount)
-		args = append(args, cluster)
-	}
-	if startDate != "" {
-		argCount++
-		dateFilter += " AND a.tanggal >= $" + strconv.Itoa(argCount)
-		args = append(args, startDate)
+	// Basis query yang sama (join jenis aktivitas + filter) untuk semua hitungan; kategori view/download ditambahkan per query.
+	cond := filter.where("a")
+	viewCond := sqlbuilder.Expr("at.name ILIKE '%view%' AND at.name NOT ILIKE '%download%' AND at.name NOT ILIKE '%export%'")
+	downloadCond := sqlbuilder.Expr("at.name ILIKE '%download%'")
+	count := func(category sqlbuilder.Fragment) *sqlbuilder.SelectBuilder {
+		return sqlbuilder.Select("COUNT(*)").
+			From("activity_logs_normalized a").
+			Join("ref_activity_types at ON a.activity_type_id = at.id").
+			Where(category).
+			Where(cond)
 	}
-	if endDate != "" {
-		argCount++
-		dateFilter += " AND a.tanggal <= $" + strconv.Itoa(argCount)
-		args = append(args, endDate)
+	details := func(category sqlbuilder.Fragment) *sqlbuilder.SelectBuilder {
+		return sqlbuilder.Select("COALESCE(NULLIF(a.detail_aktifitas, ''), a.scope, at.name) AS detail", "COUNT(*) AS count").
+			From("activity_logs_normalized a").
+			Join("ref_activity_types at ON a.activity_type_id = at.id").
+			Where(category).
+			Where(cond).
+			GroupBy("detail").
+			OrderBy("count DESC").
+			Limit(10)
 	}
 
 	var viewCount int
-	viewQuery := `
-		SELECT COUNT(*) 
-		FROM activity_logs_normalized a
-		JOIN ref_activity_types at ON a.activity_type_id = at.id
-		LEFT JOIN ref_clusters c ON a.cluster_id = c.id
-		WHERE at.name ILIKE '%view%' 
-		AND at.name NOT ILIKE '%download%' 
-		AND at.name NOT ILIKE '%export%'
-	` + dateFilter
+	viewQuery, args := count(viewCond).Build()
 	db.Raw(viewQuery, args...).Scan(&viewCount)
 
 	var downloadCount int
-	downloadQuery := `
-		SELECT COUNT(*) 
-		FROM activity_logs_normalized a
-		JOIN ref_activity_types at ON a.activity_type_id = at.id
-		LEFT JOIN ref_clusters c ON a.cluster_id = c.id
-		WHERE at.name ILIKE '%download%'
-	` + dateFilter
+	downloadQuery, args := count(downloadCond).Build()
 	db.Raw(downloadQuery, args...).Scan(&downloadCount)
 
 	var viewDetails []DetailActivityCount
-	viewDetailQuery := `
-		SELECT 
-			COALESCE(NULLIF(a.detail_aktifitas, ''), a.scope, at.name) as detail,
-			COUNT(*) as count
-		FROM activity_logs_normalized a
-		JOIN ref_activity_types at ON a.activity_type_id = at.id
-		LEFT JOIN ref_clusters c ON a.cluster_id = c.id
-		WHERE at.name ILIKE '%view%' 
-		AND at.name NOT ILIKE '%download%' 
-		AND at.name NOT ILIKE '%export%'
-	` + dateFilter + `
-		GROUP BY detail
-		ORDER BY count DESC
-		LIMIT 10
-	`
+	viewDetailQuery, args := details(viewCond).Build()
 	db.Raw(viewDetailQuery, args...).Scan(&viewDetails)
 
 	var downloadDetails []DetailActivityCount
-	downloadDetailQuery := `
-		SELECT 
-			COALESCE(NULLIF(a.detail_aktifitas, ''), a.scope, at.name) as detail,
-			COUNT(*) as count
-		FROM activity_logs_normalized a
-		JOIN ref_activity_types at ON a.activity_type_id = at.id
-		LEFT JOIN ref_clusters c ON a.cluster_id = c.id
-		WHERE at.name ILIKE '%download%'
-	` + dateFilter + `
-		GROUP BY detail
-		ORDER BY count DESC
-		LIMIT 10
-	`
+	downloadDetailQuery, args := details(downloadCond).Build()
 	db.Raw(downloadDetailQuery, args...).Scan(&downloadDetails)
 
 	return &ExportStats{
@@ -288,55 +192,21 @@ func GetExportStats(startDate, endDate, cluster string) (*ExportStats, error) {
 	}, nil
 }
 
-// GetOperationalIntents mengembalikan statistik intensi operasional: aktivitas yang bukan LOGIN/LOGOUT; intent_name = scope atau detail_aktifitas atau at.name. Filter: cluster, startDate, endDate. Limit dari limitStr (parse gagal pakai config.DefaultLimit). Exclude intent_name null/kosong.
-func GetOperationalIntents(startDate, endDate, cluster, limitStr string) ([]OperationalIntent, error) {
+// loadOperationalIntents mengembalikan statistik intensi operasional: aktivitas yang bukan LOGIN/LOGOUT; intent_name = scope atau detail_aktifitas atau at.name. Dibatasi limit baris. Exclude intent_name null/kosong.
+func loadOperationalIntents(filter ActivityFilter, limit int) ([]OperationalIntent, error) {
 	db := database.GetDB()
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		limit = config.DefaultLimit
-	}
-
-	query := `
-		SELECT intent_name, count FROM (
-			SELECT 
-				COALESCE(NULLIF(a.scope, ''), a.detail_aktifitas, at.name) as intent_name,
-				COUNT(*) as count
-			FROM activity_logs_normalized a
-			JOIN ref_activity_types at ON a.activity_type_id = at.id
-			LEFT JOIN ref_clusters c ON a.cluster_id = c.id
-			WHERE at.name IS NOT NULL
-			AND at.name NOT IN ('LOGIN', 'LOGOUT')
-	`
-
-	args := []interface{}{}
-	argCount := 0
-
-	if cluster != "" {
-		argCount++
-		query += " AND c.name = $" + strconv.Itoa(argCount)
-		args = append(args, cluster)
-	}
-
-	if startDate != "" {
-		argCount++
-		query += " AND a.tanggal >= $" + strconv.Itoa(argCount)
-		args = append(args, startDate)
-	}
-	if endDate != "" {
-		argCount++
-		query += " AND a.tanggal <= $" + strconv.Itoa(argCount)
-		args = append(args, endDate)
-	}
-
-	argCount++
-	query += `
-			GROUP BY intent_name
-		) sub
-		WHERE intent_name IS NOT NULL AND intent_name != ''
-		ORDER BY count DESC
-		LIMIT $` + strconv.Itoa(argCount)
-	args = append(args, limit)
+	sub := sqlbuilder.Select("COALESCE(NULLIF(a.scope, ''), a.detail_aktifitas, at.name) AS intent_name", "COUNT(*) AS count").
+		From("activity_logs_normalized a").
+		Join("ref_activity_types at ON a.activity_type_id = at.id").
+		WhereExpr("at.name IS NOT NULL AND at.name NOT IN ('LOGIN', 'LOGOUT')").
+		Where(filter.where("a")).
+		GroupBy("intent_name")
+	query, args := sqlbuilder.Select("intent_name", "count").
+		FromSubquery(sub, "sub").
+		WhereExpr("intent_name IS NOT NULL AND intent_name != ''").
+		OrderBy("count DESC").
+		Limit(limit).
+		Build()
 
 	rows, err := db.Raw(query, args...).Rows()
 	if err != nil {
@@ -356,46 +226,25 @@ func GetOperationalIntents(startDate, endDate, cluster, limitStr string) ([]Oper
 	return intents, nil
 }
 
-// GetGlobalEconomicsChart mengembalikan data chart Global Economics: kategori dari scope (ntpn→NTPN, komdlng/eri→KOMDLNG, ink/garuda→INK, trust/bkn→Trust, lain→Other). Hanya aktivitas dengan c.name='pencarian' atau scope ILIKE '%search%'. Filter: startDate, endDate.
-func GetGlobalEconomicsChart(startDate, endDate string) ([]GlobalEconomicsData, error) {
+// loadGlobalEconomicsChart mengembalikan data chart Global Economics: kategori dari scope (ntpn→NTPN, komdlng/eri→KOMDLNG, ink/garuda→INK, trust/bkn→Trust, lain→Other). Hanya aktivitas dengan c.name='pencarian' atau scope ILIKE '%search%'.
+func loadGlobalEconomicsChart(filter ActivityFilter) ([]GlobalEconomicsData, error) {
 	db := database.GetDB()
-
-	query := `
-		SELECT category, count FROM (
-			SELECT 
-				CASE 
+	sub := sqlbuilder.Select(`CASE
 					WHEN a.scope ILIKE '%ntpn%' THEN 'NTPN'
 					WHEN a.scope ILIKE '%komdlng%' OR a.scope ILIKE '%eri%' THEN 'KOMDLNG'
 					WHEN a.scope ILIKE '%ink%' OR a.scope ILIKE '%garuda%' THEN 'INK'
 					WHEN a.scope ILIKE '%trust%' OR a.scope ILIKE '%bkn%' THEN 'Trust'
 					ELSE 'Other'
-				END as category,
-				COUNT(*) as count
-			FROM activity_logs_normalized a
-			LEFT JOIN ref_clusters c ON a.cluster_id = c.id
-			WHERE c.name = 'pencarian'
-			OR a.scope ILIKE '%search%'
-	`
-
-	args := []interface{}{}
-	argCount := 0
-
-	if startDate != "" {
-		argCount++
-		query += " AND a.tanggal >= $" + strconv.Itoa(argCount)
-		args = append(args, startDate)
-	}
-	if endDate != "" {
-		argCount++
-		query += " AND a.tanggal <= $" + strconv.Itoa(argCount)
-		args = append(args, endDate)
-	}
-
-	query += `
-			GROUP BY category
-		) sub
-		ORDER BY count DESC
-	`
+				END AS category`, "COUNT(*) AS count").
+		From("activity_logs_normalized a").
+		LeftJoin("ref_clusters c ON a.cluster_id = c.id").
+		WhereExpr("c.name = 'pencarian' OR a.scope ILIKE '%search%'").
+		Where(filter.where("a")).
+		GroupBy("category")
+	query, args := sqlbuilder.Select("category", "count").
+		FromSubquery(sub, "sub").
+		OrderBy("count DESC").
+		Build()
 
 	rows, err := db.Raw(query, args...).Rows()
 	if err != nil {
@@ -0,0 +1,65 @@
+// File data_version_repository.go: penghitung versi data (tabel data_versions) untuk invalidasi cache hasil query analitik.
+//
+// Setiap impor aktivitas (cmd/import) dan refresh/rebuild rollup yang mengubah data menaikkan versi DataVersionActivity. Versi menjadi bagian kunci cache,
+// sehingga entri lama tidak terpakai lagi dan tersingkir sendiri oleh LRU/TTL. Versi dibaca ulang dari DB paling sering setiap CACHE_VERSION_CHECK
+// agar impor dari proses lain (CLI) terlihat oleh API server.
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"gorm.io/gorm"
+)
+
+// DataVersionActivity nama versi data aktivitas (activity_logs_normalized dan turunannya).
+const DataVersionActivity = "activity"
+
+// dataVersionMemo versi terakhir yang dibaca per nama beserta waktu bacanya.
+type dataVersionMemo struct {
+	version   int64
+	checkedAt time.Time
+}
+
+var (
+	dataVersionMu    sync.Mutex
+	dataVersionMemos = map[string]dataVersionMemo{}
+)
+
+// GetDataVersion mengembalikan versi data name (0 jika belum pernah dinaikkan). Hasil disimpan di memori selama CACHE_VERSION_CHECK.
+func GetDataVersion(db *gorm.DB, name string) (int64, error) {
+	interval := config.GetCacheConfig().VersionCheck
+	dataVersionMu.Lock()
+	memo, ok := dataVersionMemos[name]
+	dataVersionMu.Unlock()
+	if ok && time.Since(memo.checkedAt) < interval {
+		return memo.version, nil
+	}
+
+	var version int64
+	if err := db.Raw("SELECT COALESCE((SELECT version FROM data_versions WHERE name = ?), 0)", name).Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	dataVersionMu.Lock()
+	dataVersionMemos[name] = dataVersionMemo{version: version, checkedAt: time.Now()}
+	dataVersionMu.Unlock()
+	return version, nil
+}
+
+// BumpDataVersion menaikkan versi data name dan mengembalikan versi baru. Memo proses ini ikut diperbarui agar perubahan langsung terlihat.
+func BumpDataVersion(db *gorm.DB, name string) (int64, error) {
+	var version int64
+	err := db.Raw(`
+		INSERT INTO data_versions (name, version, updated_at) VALUES (?, 1, ?)
+		ON CONFLICT (name) DO UPDATE SET version = data_versions.version + 1, updated_at = EXCLUDED.updated_at
+		RETURNING version
+	`, name, time.Now()).Scan(&version).Error
+	if err != nil {
+		return 0, err
+	}
+	dataVersionMu.Lock()
+	dataVersionMemos[name] = dataVersionMemo{version: version, checkedAt: time.Now()}
+	dataVersionMu.Unlock()
+	return version, nil
+}
@@ -1,13 +1,15 @@
 // File report_repository.go: query dan pembuatan data untuk laporan (generate data per template, catat unduhan, riwayat unduhan).
 //
-// GenerateReportData mengisi data sesuai template (org-performance, user-activity, feature-usage). CreateReportDownload mencatat satu unduhan. GetRecentDownloads / GetRecentDownloadsWithFilter / GetDownloadsByUser mengambil riwayat unduhan.
+// GenerateReportData mengisi data sesuai template (org-performance, user-activity, feature-usage, user-retention, account-hygiene). CreateReportDownload mencatat satu unduhan, GetReportDownloadByFilename mencari catatan file untuk otorisasi unduhan.
+// GetRecentDownloads / GetRecentDownloadsWithFilter / GetDownloadsByUser mengambil riwayat unduhan.
 package repository
 
 import (
-	"strconv"
 	"time"
 
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
 	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
 	"github.com/bpk-ri/dashboard-monitoring/pkg/database"
 )
 
@@ -20,13 +22,22 @@ type ReportData struct {
 	Details     []map[string]interface{} `json:"details"`
 }
 
-// GenerateReportData membangun data laporan berdasarkan templateID dan rentang startDate–endDate. Template: org-performance (total aktivitas/user, top 10 satker), user-activity (login total/sukses/gagal, top 10 user), feature-usage (view/download/search, top 10 fitur).
-func GenerateReportData(templateID, startDate, endDate string) (*ReportData, error) {
+// GenerateReportData membangun data laporan berdasarkan templateID dan filter aktivitas. Template: org-performance (total aktivitas/user, top 10 satker), user-activity (login total/sukses/gagal, top 10 user), feature-usage (view/download/search, top 10 fitur),
+// user-retention (matriks kohort bulanan dari GetUserCohorts; tanggal memilih kohort, satker membatasi anggota kohort),
+// account-hygiene (akun dorman/yatim dan profil tanpa aktivitas per saat ini; tanggal diabaikan, satker membatasi akun dan profil).
+// filter.SatkerIDs dipakai untuk menegakkan cakupan grant akses laporan; periode laporan diambil dari filter.StartDate–EndDate.
+func GenerateReportData(templateID string, filter ActivityFilter) (*ReportData, error) {
 	db := database.GetDB()
+	cond := filter.where("a")
 
 	var report ReportData
 	report.GeneratedAt = time.Now()
-	report.Period = startDate + " - " + endDate
+	report.Period = filter.StartDate + " - " + filter.EndDate
+
+	// activities: basis query aktivitas dalam filter; kolom, join, dan kondisi tambahan dipasang per query.
+	activities := func(columns ...string) *sqlbuilder.SelectBuilder {
+		return sqlbuilder.Select(columns...).From("activity_logs_normalized a").Where(cond)
+	}
 
 	switch templateID {
 	case "org-performance":
@@ -34,63 +45,30 @@ func GenerateReportData(templateID, startDate, endDate string) (*ReportData, err
 
 		var totalActivities, totalUsers int
 
-		// Hitung total aktivitas: query dengan parameter posisi $1, $2 untuk filter tanggal.
-		query := "SELECT COUNT(*) FROM activity_logs_normalized a"
-		args := []interface{}{}
-		argCount := 0
-
-		if startDate != "" {
-			argCount++
-			query += " WHERE a.tanggal >= $" + strconv.Itoa(argCount)
-			args = append(args, startDate)
-		}
-		if endDate != "" {
-			if argCount == 0 {
-				query += " WHERE"
-			} else {
-				query += " AND"
-			}
-			argCount++
-			query += " a.tanggal <= $" + strconv.Itoa(argCount)
-			args = append(args, endDate)
-		}
-
+		query, args := activities("COUNT(*)").Build()
 		db.Raw(query, args...).Scan(&totalActivities)
 
-		// Hitung user unik (COUNT DISTINCT u.nama) dalam rentang tanggal; string tanggal digabung ke query.
-		userQuery := "SELECT COUNT(DISTINCT u.nama) FROM activity_logs_normalized a JOIN user_profiles u ON a.user_id = u.id"
-		if startDate != "" || endDate != "" {
-			userQuery += " WHERE 1=1"
-			if startDate != "" {
-				userQuery += " AND a.tanggal >= '" + startDate + "'"
-			}
-			if endDate != "" {
-				userQuery += " AND a.tanggal <= '" + endDate + "'"
-			}
-		}
-		db.Raw(userQuery).Scan(&totalUsers)
+		// Hitung user unik (COUNT DISTINCT u.nama) dalam filter.
+		query, args = activities("COUNT(DISTINCT u.nama)").
+			Join("user_profiles u ON a.user_id = u.id").
+			Build()
+		db.Raw(query, args...).Scan(&totalUsers)
 
 		report.Summary = map[string]interface{}{
 			"total_activities": totalActivities,
 			"total_users":      totalUsers,
 		}
 
-		// Top 10 satker by jumlah aktivitas; filter tanggal digabung ke SQL.
-		satkerQuery := `
-			SELECT s.satker_name, COUNT(*) as count 
-			FROM activity_logs_normalized a
-			JOIN ref_satker_units s ON a.satker_id = s.id
-			WHERE s.satker_name IS NOT NULL AND s.satker_name != ''
-		`
-		if startDate != "" {
-			satkerQuery += " AND a.tanggal >= '" + startDate + "'"
-		}
-		if endDate != "" {
-			satkerQuery += " AND a.tanggal <= '" + endDate + "'"
-		}
-		satkerQuery += " GROUP BY s.satker_name ORDER BY count DESC LIMIT 10"
+		// Top 10 satker by jumlah aktivitas.
+		query, args = activities("s.satker_name", "COUNT(*) AS count").
+			Join("ref_satker_units s ON a.satker_id = s.id").
+			WhereExpr("s.satker_name IS NOT NULL AND s.satker_name != ''").
+			GroupBy("s.satker_name").
+			OrderBy("count DESC").
+			Limit(10).
+			Build()
 
-		rows, err := db.Raw(satkerQuery).Rows()
+		rows, err := db.Raw(query, args...).Rows()
 		if err != nil {
 			return nil, err
 		}
@@ -111,26 +89,24 @@ func GenerateReportData(templateID, startDate, endDate string) (*ReportData, err
 
 		var totalLogins, successLogins, failedLogins int
 
-		// Basis query: jumlah aktivitas LOGIN; lalu tambah kondisi tanggal.
-		loginQuery := `
-			SELECT COUNT(*) 
-			FROM activity_logs_normalized a
-			JOIN ref_activity_types at ON a.activity_type_id = at.id
-			WHERE at.name = 'LOGIN'
-		`
-		if startDate != "" {
-			loginQuery += " AND a.tanggal >= '" + startDate + "'"
-		}
-		if endDate != "" {
-			loginQuery += " AND a.tanggal <= '" + endDate + "'"
+		// Jumlah aktivitas LOGIN dalam filter; status nil = semua status.
+		logins := func(status interface{}) *sqlbuilder.SelectBuilder {
+			q := activities("COUNT(*)").
+				Join("ref_activity_types at ON a.activity_type_id = at.id").
+				WhereExpr("at.name = ?", "LOGIN")
+			if status != nil {
+				q.WhereExpr("a.status = ?", status)
+			}
+			return q
 		}
-		db.Raw(loginQuery).Scan(&totalLogins)
+		query, args := logins(nil).Build()
+		db.Raw(query, args...).Scan(&totalLogins)
 
-		successQuery := loginQuery + " AND a.status = 'SUCCESS'"
-		db.Raw(successQuery).Scan(&successLogins)
+		query, args = logins("SUCCESS").Build()
+		db.Raw(query, args...).Scan(&successLogins)
 
-		failedQuery := loginQuery + " AND a.status = 'FAILED'"
-		db.Raw(failedQuery).Scan(&failedLogins)
+		query, args = logins("FAILED").Build()
+		db.Raw(query, args...).Scan(&failedLogins)
 
 		report.Summary = map[string]interface{}{
 			"total_logins":   totalLogins,
@@ -139,21 +115,15 @@ func GenerateReportData(templateID, startDate, endDate string) (*ReportData, err
 		}
 
 		// Top 10 user aktif (nama + count aktivitas).
-		userQuery := `
-			SELECT u.nama, COUNT(*) as count 
-			FROM activity_logs_normalized a
-			JOIN user_profiles u ON a.user_id = u.id
-			WHERE u.nama IS NOT NULL AND u.nama != ''
-		`
-		if startDate != "" {
-			userQuery += " AND a.tanggal >= '" + startDate + "'"
-		}
-		if endDate != "" {
-			userQuery += " AND a.tanggal <= '" + endDate + "'"
-		}
-		userQuery += " GROUP BY u.nama ORDER BY count DESC LIMIT 10"
-
-		rows, err := db.Raw(userQuery).Rows()
+		query, args = activities("u.nama", "COUNT(*) AS count").
+			Join("user_profiles u ON a.user_id = u.id").
+			WhereExpr("u.nama IS NOT NULL AND u.nama != ''").
+			GroupBy("u.nama").
+			OrderBy("count DESC").
+			Limit(10).
+			Build()
+
+		rows, err := db.Raw(query, args...).Rows()
 		if err != nil {
 			return nil, err
 		}
@@ -174,28 +144,20 @@ func GenerateReportData(templateID, startDate, endDate string) (*ReportData, err
 
 		var totalViews, totalDownloads, totalSearches int
 
-		// Basis query COUNT + join activity_types; dateCondition dipakai di tiga query (view, download, search).
-		baseQuery := `
-			SELECT COUNT(*) 
-			FROM activity_logs_normalized a
-			JOIN ref_activity_types at ON a.activity_type_id = at.id
-		`
-		dateCondition := ""
-		if startDate != "" {
-			dateCondition += " AND a.tanggal >= '" + startDate + "'"
-		}
-		if endDate != "" {
-			dateCondition += " AND a.tanggal <= '" + endDate + "'"
+		// Jumlah aktivitas per kategori fitur (view, download, search) dalam filter.
+		features := func(category string) *sqlbuilder.SelectBuilder {
+			return activities("COUNT(*)").
+				Join("ref_activity_types at ON a.activity_type_id = at.id").
+				WhereExpr(category)
 		}
+		query, args := features("at.name = 'View'").Build()
+		db.Raw(query, args...).Scan(&totalViews)
 
-		viewQuery := baseQuery + " WHERE at.name = 'View'" + dateCondition
-		db.Raw(viewQuery).Scan(&totalViews)
+		query, args = features("at.name ILIKE '%download%'").Build()
+		db.Raw(query, args...).Scan(&totalDownloads)
 
-		downloadQuery := baseQuery + " WHERE at.name ILIKE '%download%'" + dateCondition
-		db.Raw(downloadQuery).Scan(&totalDownloads)
-
-		searchQuery := baseQuery + " WHERE (at.name ILIKE '%search%' OR at.name ILIKE '%pencarian%')" + dateCondition
-		db.Raw(searchQuery).Scan(&totalSearches)
+		query, args = features("at.name ILIKE '%search%' OR at.name ILIKE '%pencarian%'").Build()
+		db.Raw(query, args...).Scan(&totalSearches)
 
 		report.Summary = map[string]interface{}{
 			"total_views":     totalViews,
@@ -204,21 +166,15 @@ func GenerateReportData(templateID, startDate, endDate string) (*ReportData, err
 		}
 
 		// Rincian per jenis fitur (at.name), top 10.
-		featureQuery := `
-			SELECT at.name, COUNT(*) as count 
-			FROM activity_logs_normalized a
-			JOIN ref_activity_types at ON a.activity_type_id = at.id
-			WHERE at.name IS NOT NULL
-		`
-		if startDate != "" {
-			featureQuery += " AND a.tanggal >= '" + startDate + "'"
-		}
-		if endDate != "" {
-			featureQuery += " AND a.tanggal <= '" + endDate + "'"
-		}
-		featureQuery += " GROUP BY at.name ORDER BY count DESC LIMIT 10"
-
-		rows, err := db.Raw(featureQuery).Rows()
+		query, args = activities("at.name", "COUNT(*) AS count").
+			Join("ref_activity_types at ON a.activity_type_id = at.id").
+			WhereExpr("at.name IS NOT NULL").
+			GroupBy("at.name").
+			OrderBy("count DESC").
+			Limit(10).
+			Build()
+
+		rows, err := db.Raw(query, args...).Rows()
 		if err != nil {
 			return nil, err
 		}
@@ -233,6 +189,90 @@ func GenerateReportData(templateID, startDate, endDate string) (*ReportData, err
 				"count":   count,
 			})
 		}
+
+	case "user-retention":
+		report.Title = "Laporan Retensi Pengguna"
+
+		matrix, err := NewActivityLogRepository(db).GetUserCohorts(CohortQuery{Granularity: GranularityMonth, Periods: config.DefaultCohortPeriods}, filter)
+		if err != nil {
+			return nil, err
+		}
+		report.Period = matrix.StartDate + " - " + matrix.EndDate
+		report.Summary = map[string]interface{}{
+			"granularity":       matrix.Granularity,
+			"periods":           matrix.Periods,
+			"total_users":       int(matrix.TotalUsers),
+			"cohort_count":      len(matrix.Cohorts),
+			"average_retention": matrix.Average,
+		}
+
+		// Satu baris per kohort: ukuran kohort dan retensi per bulan ke-0..N yang sudah berjalan.
+		for _, row := range matrix.Cohorts {
+			report.Details = append(report.Details, map[string]interface{}{
+				"cohort":    row.Cohort,
+				"size":      int(row.Size),
+				"retention": row.Retention,
+			})
+		}
+
+	case "account-hygiene":
+		report.Title = "Laporan Akun Dorman dan Yatim"
+
+		// Kondisi akun per saat laporan dibuat; rentang tanggal filter tidak dipakai.
+		dormantDays := config.AccountDormantDays()
+		report.Period = "Per " + report.GeneratedAt.Format(sqlbuilder.DateLayout)
+		repo := NewAccountHygieneRepository(db)
+		accounts, err := repo.FlaggedAccounts(report.GeneratedAt.AddDate(0, 0, -dormantDays), filter.SatkerIDs)
+		if err != nil {
+			return nil, err
+		}
+		profiles, err := repo.InactiveProfiles(filter.SatkerIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		var dormant, orphaned int
+		for _, account := range accounts {
+			lastLogin := ""
+			if account.LastLogin != nil {
+				lastLogin = account.LastLogin.Format("2006-01-02 15:04")
+			}
+			for _, reason := range account.Reasons {
+				switch reason {
+				case entity.AccountFlagDormantLogin:
+					dormant++
+				case entity.AccountFlagOrphanedSatker:
+					orphaned++
+				}
+			}
+			report.Details = append(report.Details, map[string]interface{}{
+				"category":   "account",
+				"id":         account.ID,
+				"username":   account.Username,
+				"full_name":  account.FullName,
+				"email":      account.Email,
+				"role":       account.Role,
+				"last_login": lastLogin,
+				"reasons":    account.Reasons,
+			})
+		}
+		// Profil tanpa aktivitas dicatat setelah akun; profil tidak punya login sehingga tidak ikut dinonaktifkan massal.
+		for _, profile := range profiles {
+			report.Details = append(report.Details, map[string]interface{}{
+				"category":    "profile",
+				"id":          int(profile.ID),
+				"nama":        profile.Nama,
+				"email":       profile.Email,
+				"satker_name": profile.SatkerName,
+			})
+		}
+		report.Summary = map[string]interface{}{
+			"dormant_days":      dormantDays,
+			"flagged_accounts":  len(accounts),
+			"dormant_accounts":  dormant,
+			"orphaned_accounts": orphaned,
+			"inactive_profiles": len(profiles),
+		}
 	}
 
 	return &report, nil
@@ -244,6 +284,15 @@ func CreateReportDownload(download *entity.ReportDownload) error {
 	return db.Create(download).Error
 }
 
+// GetReportDownloadByFilename mengembalikan catatan laporan untuk nama file hasil generate (gorm.ErrRecordNotFound jika tidak ada).
+func GetReportDownloadByFilename(filename string) (*entity.ReportDownload, error) {
+	var download entity.ReportDownload
+	if err := database.GetDB().Where("filename = ?", filename).First(&download).Error; err != nil {
+		return nil, err
+	}
+	return &download, nil
+}
+
 // GetRecentDownloads mengembalikan N unduhan terbaru (urut generated_at DESC) dengan relasi User di-preload.
 func GetRecentDownloads(limit int) ([]entity.ReportDownload, error) {
 	db := database.GetDB()
@@ -258,11 +307,15 @@ func GetRecentDownloads(limit int) ([]entity.ReportDownload, error) {
 }
 
 // GetRecentDownloadsWithFilter sama seperti GetRecentDownloads dengan filter tanggal opsional: BETWEEN, >= startDate, atau <= endDate.
-func GetRecentDownloadsWithFilter(limit int, startDate, endDate string) ([]entity.ReportDownload, error) {
+// userID > 0 membatasi ke laporan milik user tersebut; 0 = semua user.
+func GetRecentDownloadsWithFilter(limit int, startDate, endDate string, userID int) ([]entity.ReportDownload, error) {
 	db := database.GetDB()
 	var downloads []entity.ReportDownload
 
 	query := db.Preload("User")
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
 
 	if startDate != "" && endDate != "" {
 		query = query.Where("DATE(generated_at) BETWEEN ? AND ?", startDate, endDate)
@@ -1,11 +1,9 @@
 // File search_repository.go: repository untuk pencarian aktivitas dan autocomplete.
 //
-// Search: pencarian aktivitas dengan filter (query teks, satker, satkerIds, cluster, status, activityTypes, tanggal), paginasi, preload relasi. GetSuggestions: saran dari user_profiles, ref_satker_units, ref_locations. SearchUsers / SearchSatker: cari user by nama/email, cari satker by nama.
+// Search: pencarian aktivitas dengan query teks, nama satker, dan ActivityFilter, paginasi, preload relasi. GetSuggestions: saran dari user_profiles, ref_satker_units, ref_locations. SearchUsers / SearchSatker: cari user by nama/email, cari satker by nama.
 package repository
 
 import (
-	"time"
-
 	"github.com/bpk-ri/dashboard-monitoring/internal/config"
 	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
 	"gorm.io/gorm"
@@ -21,18 +19,13 @@ func NewSearchRepository(db *gorm.DB) *SearchRepository {
 	return &SearchRepository{db: db}
 }
 
-// SearchParams parameter untuk Search: teks query, filter satker/cluster/status/jenis aktivitas/tanggal, paginasi.
+// SearchParams parameter untuk Search: teks query, nama satker, ActivityFilter, paginasi.
 type SearchParams struct {
-	Query         string    // Teks pencarian (nama, satker, email, nama aktivitas)
-	Satker        string    // Filter nama satker (ILIKE)
-	SatkerIds     []int64   // Filter satker_id IN (...)
-	Cluster       string    // Filter nama cluster (exact)
-	Status        string    // Filter status aktivitas
-	ActivityTypes []string  // Filter at.name IN (...)
-	StartDate     time.Time // Filter tanggal >=
-	EndDate       time.Time // Filter tanggal <=
-	Page          int       // Halaman (1-based)
-	PageSize      int       // Jumlah per halaman
+	Query    string         // Teks pencarian (nama, satker, email, nama aktivitas)
+	Satker   string         // Filter nama satker (ILIKE)
+	Filter   ActivityFilter // Filter aktivitas (tanggal, cluster, satker, status, jenis aktivitas, provinsi, user)
+	Page     int            // Halaman (1-based)
+	PageSize int            // Jumlah per halaman
 }
 
 // Suggestion satu item saran autocomplete: type (user, satker, lokasi), value, label.
@@ -52,15 +45,13 @@ func (r *SearchRepository) Search(params SearchParams) ([]entity.ActivityLog, in
 		Preload("Location").
 		Joins("LEFT JOIN user_profiles u ON u.id = activity_logs_normalized.user_id").
 		Joins("LEFT JOIN ref_satker_units s ON s.id = activity_logs_normalized.satker_id").
-		Joins("LEFT JOIN ref_activity_types at ON at.id = activity_logs_normalized.activity_type_id").
-		Joins("LEFT JOIN ref_clusters c ON c.id = activity_logs_normalized.cluster_id").
-		Joins("LEFT JOIN ref_locations l ON l.id = activity_logs_normalized.location_id")
+		Joins("LEFT JOIN ref_activity_types at ON at.id = activity_logs_normalized.activity_type_id")
 
 	// Filter teks: cari di nama user, nama satker, email, atau nama jenis aktivitas (ILIKE %query%).
 	if params.Query != "" {
 		likeQuery := "%" + params.Query + "%"
 		query = query.Where(
-			"u.nama ILIKE ? OR s.satker_name ILIKE ? OR u.email ILIKE ? OR at.name ILIKE ?",
+			"(u.nama ILIKE ? OR s.satker_name ILIKE ? OR u.email ILIKE ? OR at.name ILIKE ?)",
 			likeQuery, likeQuery, likeQuery, likeQuery,
 		)
 	}
@@ -69,28 +60,7 @@ func (r *SearchRepository) Search(params SearchParams) ([]entity.ActivityLog, in
 		query = query.Where("s.satker_name ILIKE ?", "%"+params.Satker+"%")
 	}
 
-	if len(params.SatkerIds) > 0 {
-		query = query.Where("activity_logs_normalized.satker_id IN ?", params.SatkerIds)
-	}
-
-	if params.Cluster != "" {
-		query = query.Where("c.name = ?", params.Cluster)
-	}
-
-	if params.Status != "" {
-		query = query.Where("status = ?", params.Status)
-	}
-
-	if len(params.ActivityTypes) > 0 {
-		query = query.Where("at.name IN ?", params.ActivityTypes)
-	}
-
-	if !params.StartDate.IsZero() {
-		query = query.Where("tanggal >= ?", params.StartDate)
-	}
-	if !params.EndDate.IsZero() {
-		query = query.Where("tanggal <= ?", params.EndDate)
-	}
+	query = params.Filter.apply(query, "activity_logs_normalized")
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -99,7 +69,7 @@ func (r *SearchRepository) Search(params SearchParams) ([]entity.ActivityLog, in
 
 	var results []entity.ActivityLog
 	offset := (params.Page - 1) * params.PageSize
-	err := query.Order("tanggal DESC").
+	err := query.Order("activity_logs_normalized.tanggal DESC").
 		Limit(params.PageSize).
 		Offset(offset).
 		Find(&results).Error
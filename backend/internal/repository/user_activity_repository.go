@@ -0,0 +1,97 @@
+// File user_activity_repository.go: repository riwayat dan statistik aktivitas satu profil (activity_logs_normalized.user_id = user_profiles.id).
+//
+// Dipakai endpoint /api/profile/my-activity untuk menampilkan aktivitas milik akun yang sudah tertaut ke profil aktivitas.
+package repository
+
+import (
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"gorm.io/gorm"
+)
+
+// UserActivityRepository menyimpan koneksi DB untuk query aktivitas per profil.
+type UserActivityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserActivityRepository membuat instance UserActivityRepository.
+func NewUserActivityRepository(db *gorm.DB) *UserActivityRepository {
+	return &UserActivityRepository{db: db}
+}
+
+// UserActivityStats ringkasan aktivitas satu profil dalam filter.
+type UserActivityStats struct {
+	Total          int64               `json:"total"`
+	SuccessLogins  int64               `json:"success_logins"`
+	LogoutErrors   int64               `json:"logout_errors"`
+	ActiveDays     int64               `json:"active_days"`
+	FirstActivity  *time.Time          `json:"first_activity"`
+	LastActivity   *time.Time          `json:"last_activity"`
+	ByActivityType []ActivityTypeCount `json:"by_activity_type"`
+}
+
+// ActivityTypeCount jumlah aktivitas per jenis aktivitas.
+type ActivityTypeCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// scoped mengembalikan query activity_logs_normalized untuk profileID dengan ActivityFilter (filter user di dalam filter diabaikan; profil selalu dari pemanggil).
+func (r *UserActivityRepository) scoped(profileID int64, filter ActivityFilter) *gorm.DB {
+	filter.UserIDs = nil
+	query := r.db.Model(&entity.ActivityLog{}).Where("activity_logs_normalized.user_id = ?", profileID)
+	return filter.apply(query, "activity_logs_normalized")
+}
+
+// GetActivities mengembalikan aktivitas profil terbaru dulu dengan paginasi (relasi di-preload untuk DTO) beserta total baris.
+func (r *UserActivityRepository) GetActivities(profileID int64, page, pageSize int, filter ActivityFilter) ([]entity.ActivityLog, int64, error) {
+	var total int64
+	if err := r.scoped(profileID, filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var activities []entity.ActivityLog
+	err := r.scoped(profileID, filter).
+		Preload("User").
+		Preload("Satker").
+		Preload("ActivityType").
+		Preload("Cluster").
+		Preload("Location").
+		Order("activity_logs_normalized.tanggal DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&activities).Error
+	return activities, total, err
+}
+
+// GetStats menghitung total, login sukses, logout error, jumlah hari aktif, aktivitas pertama/terakhir, dan jumlah per jenis aktivitas.
+func (r *UserActivityRepository) GetStats(profileID int64, filter ActivityFilter) (*UserActivityStats, error) {
+	var stats UserActivityStats
+	err := r.scoped(profileID, filter).
+		Joins("LEFT JOIN ref_activity_types at ON at.id = activity_logs_normalized.activity_type_id").
+		Select(`
+			COUNT(*) AS total,
+			COUNT(CASE WHEN at.name = 'LOGIN' AND (scope ILIKE '%success%' OR scope IS NULL OR scope = '') THEN 1 END) AS success_logins,
+			COUNT(CASE WHEN at.name = 'LOGOUT' AND scope ILIKE '%error%' THEN 1 END) AS logout_errors,
+			COUNT(DISTINCT DATE(tanggal)) AS active_days,
+			MIN(tanggal) AS first_activity,
+			MAX(tanggal) AS last_activity
+		`).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats.ByActivityType = []ActivityTypeCount{}
+	err = r.scoped(profileID, filter).
+		Joins("JOIN ref_activity_types at ON at.id = activity_logs_normalized.activity_type_id").
+		Select("at.name AS name, COUNT(*) AS count").
+		Group("at.name").
+		Order("count DESC").
+		Scan(&stats.ByActivityType).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,189 @@
+// File user_engagement_repository.go: pemeliharaan aktivitas pertama/terakhir profil (user_profiles.first_activity, last_activity, is_active)
+// dan status engagement turunan (active, dormant, never_active) untuk GET /api/users/engagement.
+//
+// RecordActivity dipanggil cmd/import di transaksi yang sama dengan insert aktivitas; Backfill (cmd/backfill) menghitung ulang semuanya dari
+// activity_logs_normalized; RefreshStatus (job user-engagement) menyelaraskan is_active dengan batas dorman. Status dihitung saat query dari
+// last_activity, sehingga tidak bergantung pada kapan is_active terakhir diperbarui.
+package repository
+
+import (
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+)
+
+// UserEngagementRepository menyimpan koneksi DB untuk pemeliharaan dan agregat engagement profil.
+type UserEngagementRepository struct {
+	db *gorm.DB
+}
+
+// NewUserEngagementRepository membuat instance UserEngagementRepository.
+func NewUserEngagementRepository(db *gorm.DB) *UserEngagementRepository {
+	return &UserEngagementRepository{db: db}
+}
+
+// EngagementCounts jumlah profil per status engagement.
+type EngagementCounts struct {
+	Active      int64 `json:"active"`
+	Dormant     int64 `json:"dormant"`
+	NeverActive int64 `json:"never_active"`
+	Total       int64 `json:"total"`
+}
+
+// SatkerEngagement jumlah profil per status untuk satu satker (satker_id nil = profil tanpa satker).
+type SatkerEngagement struct {
+	SatkerID   *int64 `json:"satker_id"`
+	SatkerName string `json:"satker_name"`
+	EngagementCounts
+}
+
+// EngagementProfile satu profil beserta status engagement-nya.
+type EngagementProfile struct {
+	ID            int64      `json:"id"`
+	Nama          string     `json:"nama"`
+	Email         string     `json:"email"`
+	SatkerID      *int64     `json:"satker_id"`
+	SatkerName    string     `json:"satker_name"`
+	FirstActivity *time.Time `json:"first_activity"`
+	LastActivity  *time.Time `json:"last_activity"`
+	Status        string     `json:"status"`
+}
+
+// EngagementFilter filter daftar profil: satker (RootSatkerIDs/SatkerIDs/Eselon dari ActivityFilter, diterapkan ke satker profil), status, paginasi.
+type EngagementFilter struct {
+	Satker   ActivityFilter
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// BackfillResult hasil Backfill: profil yang rentang aktivitasnya dikoreksi dan profil yang is_active-nya berubah.
+type BackfillResult struct {
+	Spans    int64
+	Statuses int64
+}
+
+// RecordActivity memperluas rentang aktivitas profil dengan aktivitas pada at dan menyetel is_active (aktivitas terakhir sejak activeSince).
+// Dipanggil di transaksi insert aktivitas agar profil dan activity_logs_normalized selalu konsisten.
+func (r *UserEngagementRepository) RecordActivity(profileID int64, at, activeSince time.Time) error {
+	return r.db.Exec(`
+		UPDATE user_profiles
+		SET first_activity = LEAST(COALESCE(first_activity, ?), ?),
+		    last_activity  = GREATEST(COALESCE(last_activity, ?), ?),
+		    is_active      = GREATEST(COALESCE(last_activity, ?), ?) >= ?
+		WHERE id = ?
+	`, at, at, at, at, at, at, activeSince, profileID).Error
+}
+
+// Backfill menghitung ulang first_activity dan last_activity semua profil dari activity_logs_normalized (NULL jika tanpa aktivitas),
+// lalu menyelaraskan is_active dengan activeSince. Hanya baris yang berubah yang ditulis.
+func (r *UserEngagementRepository) Backfill(activeSince time.Time) (*BackfillResult, error) {
+	result := &BackfillResult{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			UPDATE user_profiles up
+			SET first_activity = span.first_activity, last_activity = span.last_activity
+			FROM (
+				SELECT p.id, MIN(a.tanggal) AS first_activity, MAX(a.tanggal) AS last_activity
+				FROM user_profiles p
+				LEFT JOIN activity_logs_normalized a ON a.user_id = p.id
+				GROUP BY p.id
+			) span
+			WHERE span.id = up.id
+			  AND (up.first_activity IS DISTINCT FROM span.first_activity OR up.last_activity IS DISTINCT FROM span.last_activity)
+		`)
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Spans = res.RowsAffected
+
+		statuses, err := NewUserEngagementRepository(tx).RefreshStatus(activeSince)
+		result.Statuses = statuses
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RefreshStatus menyetel is_active = aktivitas terakhir sejak activeSince untuk profil yang nilainya berbeda; mengembalikan jumlah baris diubah.
+func (r *UserEngagementRepository) RefreshStatus(activeSince time.Time) (int64, error) {
+	res := r.db.Exec(`
+		UPDATE user_profiles
+		SET is_active = COALESCE(last_activity >= ?, FALSE)
+		WHERE is_active IS DISTINCT FROM COALESCE(last_activity >= ?, FALSE)
+	`, activeSince, activeSince)
+	return res.RowsAffected, res.Error
+}
+
+// Summary menghitung jumlah profil per status engagement, total dan per satker, dalam cakupan satker (kosong = semua profil).
+func (r *UserEngagementRepository) Summary(satker ActivityFilter, activeSince time.Time) ([]SatkerEngagement, EngagementCounts, error) {
+	profiles := engagementProfiles(satker, activeSince, "up.satker_id", "COALESCE(s.satker_name, '') AS satker_name")
+
+	var rows []SatkerEngagement
+	err := r.db.Raw(`SELECT satker_id, satker_name,
+			COUNT(*) FILTER (WHERE status = ?) AS active,
+			COUNT(*) FILTER (WHERE status = ?) AS dormant,
+			COUNT(*) FILTER (WHERE status = ?) AS never_active,
+			COUNT(*) AS total
+		FROM (`+profiles.SQL+`) e
+		GROUP BY satker_id, satker_name
+		ORDER BY dormant DESC, total DESC, satker_name`,
+		append([]interface{}{entity.UserEngagementActive, entity.UserEngagementDormant, entity.UserEngagementNeverActive}, profiles.Args...)...).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, EngagementCounts{}, err
+	}
+	var totals EngagementCounts
+	for _, row := range rows {
+		totals.Active += row.Active
+		totals.Dormant += row.Dormant
+		totals.NeverActive += row.NeverActive
+		totals.Total += row.Total
+	}
+	if rows == nil {
+		rows = []SatkerEngagement{}
+	}
+	return rows, totals, nil
+}
+
+// Profiles mengembalikan profil dalam cakupan satker dengan status engagement, aktivitas terakhir paling lama dulu, beserta total baris.
+func (r *UserEngagementRepository) Profiles(filter EngagementFilter, activeSince time.Time) ([]EngagementProfile, int64, error) {
+	profiles := engagementProfiles(filter.Satker, activeSince,
+		"up.id", "up.nama", "COALESCE(up.email, '') AS email", "up.satker_id", "COALESCE(s.satker_name, '') AS satker_name",
+		"up.first_activity", "up.last_activity")
+	query := sqlbuilder.Fragment{SQL: "(" + profiles.SQL + ") e", Args: profiles.Args}
+	if filter.Status != "" {
+		query = sqlbuilder.Fragment{SQL: query.SQL + " WHERE status = ?", Args: append(append([]interface{}{}, query.Args...), filter.Status)}
+	}
+
+	var total int64
+	if err := r.db.Raw("SELECT COUNT(*) FROM "+query.SQL, query.Args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var result []EngagementProfile
+	err := r.db.Raw("SELECT * FROM "+query.SQL+" ORDER BY last_activity ASC NULLS FIRST, id LIMIT ? OFFSET ?",
+		append(query.Args, filter.PageSize, (filter.Page-1)*filter.PageSize)...).Scan(&result).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if result == nil {
+		result = []EngagementProfile{}
+	}
+	return result, total, nil
+}
+
+// engagementProfiles subquery profil (alias up, satker alias s) dalam cakupan satker profil dengan kolom tambahan dan kolom status engagement.
+func engagementProfiles(satker ActivityFilter, activeSince time.Time, columns ...string) sqlbuilder.Fragment {
+	scope := ActivityFilter{RootSatkerIDs: satker.RootSatkerIDs, SatkerIDs: satker.SatkerIDs, Eselon: satker.Eselon}
+	base := sqlbuilder.Select(append(columns, "up.last_activity AS status_at")...).
+		From("user_profiles up").
+		LeftJoin("ref_satker_units s ON s.id = up.satker_id").
+		Where(scope.where("up")).
+		Fragment()
+	return sqlbuilder.Expr(`SELECT p.*, CASE WHEN p.status_at IS NULL THEN ? WHEN p.status_at >= ? THEN ? ELSE ? END AS status FROM (`+base.SQL+`) p`,
+		append([]interface{}{entity.UserEngagementNeverActive, activeSince, entity.UserEngagementActive, entity.UserEngagementDormant}, base.Args...)...)
+}
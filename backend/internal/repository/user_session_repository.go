@@ -0,0 +1,332 @@
+// File user_session_repository.go: data sesi aktivitas user (tabel user_sessions) hasil rekonstruksi event LOGIN/LOGOUT.
+//
+// Bagian atas: bahan job sessionization (watermark user_session_state, user yang terdampak data baru, event per user, penggantian sesi).
+// Bagian bawah: analitik sesi untuk /api/insights/sessions — distribusi durasi, jumlah sesi bersamaan per bucket waktu, dan timeline sesi per user.
+// Filter analitik memakai ActivityFilter: tanggal membatasi started_at; satker (pohon/eksak), eselon, dan user berlaku; field lain tidak ada di sesi dan diabaikan.
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSessionGranularity = errors.New("granularity harus salah satu dari hour, day, week")
+	ErrSessionRangeRequired      = errors.New("start_date dan end_date wajib diisi")
+)
+
+// sessionIntervals interval PostgreSQL per granularitas deret sesi bersamaan.
+var sessionIntervals = map[string]string{
+	GranularityHour: "1 hour",
+	GranularityDay:  "1 day",
+	GranularityWeek: "7 days",
+}
+
+// SessionDurationBuckets batas atas (detik, eksklusif) kelompok distribusi durasi; kelompok terakhir tanpa batas atas.
+var SessionDurationBuckets = []struct {
+	Label      string
+	MaxSeconds int64
+}{
+	{"< 1 menit", 60},
+	{"1–5 menit", 5 * 60},
+	{"5–15 menit", 15 * 60},
+	{"15–30 menit", 30 * 60},
+	{"30–60 menit", 60 * 60},
+	{"1–2 jam", 2 * 60 * 60},
+	{"2–4 jam", 4 * 60 * 60},
+	{"≥ 4 jam", 0},
+}
+
+// SessionEvent satu event aktivitas user untuk rekonstruksi sesi.
+type SessionEvent struct {
+	ID         int64
+	Tanggal    time.Time
+	SatkerID   *int64
+	LocationID *int64
+	Type       string // ref_activity_types.name.
+	Scope      string
+}
+
+// SessionUser user yang sesinya perlu dibangun ulang mulai Since (event baru paling awal atau awal sesi yang masih terbuka).
+type SessionUser struct {
+	UserID int64
+	Since  time.Time
+}
+
+// SessionDurationBucket satu kelompok distribusi durasi; MaxSeconds 0 = tanpa batas atas.
+type SessionDurationBucket struct {
+	Label      string `json:"label"`
+	MinSeconds int64  `json:"min_seconds"`
+	MaxSeconds int64  `json:"max_seconds,omitempty"`
+	Count      int64  `json:"count"`
+}
+
+// SessionDurationStats distribusi durasi sesi yang sudah berakhir, plus jumlah sesi yang masih terbuka.
+type SessionDurationStats struct {
+	Total          int64                   `json:"total"`
+	AverageSeconds float64                 `json:"average_seconds"`
+	MedianSeconds  float64                 `json:"median_seconds"`
+	P90Seconds     float64                 `json:"p90_seconds"`
+	ErrorLogouts   int64                   `json:"error_logouts"`
+	OpenSessions   int64                   `json:"open_sessions"`
+	ByEndReason    map[string]int64        `json:"by_end_reason"`
+	Buckets        []SessionDurationBucket `json:"buckets"`
+}
+
+// SessionConcurrencyPoint sesi pada satu bucket: Concurrent = sesi aktif tepat di awal bucket, Overlapping = sesi yang aktif kapan pun di dalam bucket.
+type SessionConcurrencyPoint struct {
+	Bucket      time.Time `json:"bucket"`
+	Concurrent  int64     `json:"concurrent"`
+	Overlapping int64     `json:"overlapping"`
+}
+
+// SessionConcurrency deret jumlah sesi bersamaan beserta puncaknya.
+type SessionConcurrency struct {
+	Granularity    string                    `json:"granularity"`
+	Points         []SessionConcurrencyPoint `json:"points"`
+	PeakConcurrent int64                     `json:"peak_concurrent"`
+	PeakAt         *time.Time                `json:"peak_at,omitempty"`
+}
+
+// UserSessionRepository akses data sesi aktivitas user.
+type UserSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUserSessionRepository membuat instance UserSessionRepository.
+func NewUserSessionRepository(db *gorm.DB) *UserSessionRepository {
+	return &UserSessionRepository{db: db}
+}
+
+// Watermark mengembalikan last_log_id user_session_state dan id maksimum activity_logs_normalized saat ini.
+func (r *UserSessionRepository) Watermark() (last, upTo int64, err error) {
+	if err = r.db.Raw("SELECT last_log_id FROM user_session_state WHERE id = 1").Scan(&last).Error; err != nil {
+		return 0, 0, err
+	}
+	err = r.db.Raw("SELECT COALESCE(MAX(id), 0) FROM activity_logs_normalized").Scan(&upTo).Error
+	return last, upTo, err
+}
+
+// SetWatermark menyimpan last_log_id setelah semua sesi terdampak dibangun ulang.
+func (r *UserSessionRepository) SetWatermark(lastLogID int64) error {
+	return r.db.Exec("UPDATE user_session_state SET last_log_id = ?, refreshed_at = ? WHERE id = 1", lastLogID, time.Now()).Error
+}
+
+// AffectedUsers mengembalikan user dengan event baru (after < id <= upTo) atau sesi yang masih terbuka, beserta waktu mulai pembangunan ulang.
+func (r *UserSessionRepository) AffectedUsers(after, upTo int64) ([]SessionUser, error) {
+	var users []SessionUser
+	err := r.db.Raw(`
+		SELECT user_id, MIN(since) AS since FROM (
+			SELECT user_id, MIN(tanggal) AS since FROM activity_logs_normalized WHERE id > ? AND id <= ? GROUP BY user_id
+			UNION ALL
+			SELECT user_id, MIN(started_at) AS since FROM user_sessions WHERE ended_at IS NULL GROUP BY user_id
+		) affected
+		GROUP BY user_id ORDER BY user_id
+	`, after, upTo).Scan(&users).Error
+	return users, err
+}
+
+// RebuildStart mengembalikan waktu mulai pembangunan ulang sesi user: since, atau awal sesi paling awal yang bisa terpengaruh event pada since
+// (sesi terbuka atau sesi yang berakhir kurang dari idleTimeout sebelum since) jika lebih awal.
+func (r *UserSessionRepository) RebuildStart(userID int64, since time.Time, idleTimeout time.Duration) (time.Time, error) {
+	var start *time.Time
+	err := r.db.Raw(`
+		SELECT MIN(started_at) FROM user_sessions
+		WHERE user_id = ? AND (ended_at IS NULL OR ended_at >= ?)
+	`, userID, since.Add(-idleTimeout)).Scan(&start).Error
+	if err != nil {
+		return since, err
+	}
+	if start != nil && start.Before(since) {
+		return *start, nil
+	}
+	return since, nil
+}
+
+// Events mengembalikan event user mulai from (id <= upTo) terurut waktu lalu id.
+func (r *UserSessionRepository) Events(userID int64, from time.Time, upTo int64) ([]SessionEvent, error) {
+	var events []SessionEvent
+	err := r.db.Raw(`
+		SELECT a.id, a.tanggal, a.satker_id, a.location_id, COALESCE(at.name, '') AS type, COALESCE(a.scope, '') AS scope
+		FROM activity_logs_normalized a
+		LEFT JOIN ref_activity_types at ON at.id = a.activity_type_id
+		WHERE a.user_id = ? AND a.tanggal >= ? AND a.id <= ?
+		ORDER BY a.tanggal, a.id
+	`, userID, from, upTo).Scan(&events).Error
+	return events, err
+}
+
+// Replace menghapus sesi user yang dimulai sejak from lalu menyimpan sessions (hasil pembangunan ulang).
+func (r *UserSessionRepository) Replace(userID int64, from time.Time, sessions []entity.UserSession) error {
+	if err := r.db.Where("user_id = ? AND started_at >= ?", userID, from).Delete(&entity.UserSession{}).Error; err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(sessions, 500).Error
+}
+
+// DurationStats mengembalikan distribusi durasi sesi yang sudah berakhir sesuai filter.
+func (r *UserSessionRepository) DurationStats(filter ActivityFilter) (*SessionDurationStats, error) {
+	stats := &SessionDurationStats{ByEndReason: map[string]int64{}}
+	query, args := sessionSelect(filter,
+		"COUNT(*) FILTER (WHERE s.ended_at IS NOT NULL) AS total",
+		"COALESCE(AVG(s.duration_seconds), 0) AS average_seconds",
+		"COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY s.duration_seconds), 0) AS median_seconds",
+		"COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY s.duration_seconds), 0) AS p90_seconds",
+		"COUNT(*) FILTER (WHERE s.error_logout) AS error_logouts",
+		"COUNT(*) FILTER (WHERE s.ended_at IS NULL) AS open_sessions",
+	).Build()
+	if err := r.db.Raw(query, args...).Scan(stats).Error; err != nil {
+		return nil, err
+	}
+
+	var reasons []struct {
+		EndReason string
+		Count     int64
+	}
+	query, args = sessionSelect(filter, "s.end_reason", "COUNT(*) AS count").
+		WhereExpr("s.ended_at IS NOT NULL").
+		GroupBy("s.end_reason").
+		Build()
+	if err := r.db.Raw(query, args...).Scan(&reasons).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range reasons {
+		stats.ByEndReason[row.EndReason] = row.Count
+	}
+
+	var counts []struct {
+		Bucket int
+		Count  int64
+	}
+	query, args = sessionSelect(filter, sessionBucketExpr()+" AS bucket", "COUNT(*) AS count").
+		WhereExpr("s.ended_at IS NOT NULL").
+		GroupBy("bucket").
+		Build()
+	if err := r.db.Raw(query, args...).Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	byBucket := map[int]int64{}
+	for _, row := range counts {
+		byBucket[row.Bucket] = row.Count
+	}
+	var lower int64
+	for i, b := range SessionDurationBuckets {
+		stats.Buckets = append(stats.Buckets, SessionDurationBucket{Label: b.Label, MinSeconds: lower, MaxSeconds: b.MaxSeconds, Count: byBucket[i]})
+		lower = b.MaxSeconds
+	}
+	return stats, nil
+}
+
+// Concurrency mengembalikan jumlah sesi bersamaan per bucket (hour, day, week; UTC) untuk rentang StartDate–EndDate filter (wajib).
+// Sesi yang masih terbuka dianggap aktif sampai now (diterjemahkan ke konvensi tanggal lewat config.ActivityNow). ErrTooManyBuckets jika jumlah bucket melebihi config.MaxTimeSeriesBuckets.
+func (r *UserSessionRepository) Concurrency(granularity string, filter ActivityFilter, now time.Time) (*SessionConcurrency, error) {
+	interval, ok := sessionIntervals[granularity]
+	if !ok {
+		return nil, ErrInvalidSessionGranularity
+	}
+	startDate, endDate, err := filter.dates()
+	if err != nil {
+		return nil, err
+	}
+	if startDate.IsZero() || endDate.IsZero() {
+		return nil, ErrSessionRangeRequired
+	}
+	buckets := timeSeriesBuckets(granularity, startDate.Time(), endDate.AddDays(1).Time().Add(-time.Nanosecond))
+	if buckets == nil {
+		return nil, ErrTooManyBuckets
+	}
+
+	cond := sessionScope(filter).where("s")
+	join := "user_sessions s ON s.started_at < b.bucket + ?::interval AND COALESCE(s.ended_at, ?) > b.bucket"
+	joinArgs := []interface{}{interval, config.ActivityNow(now)}
+	if !cond.IsEmpty() {
+		join += " AND " + cond.SQL
+		joinArgs = append(joinArgs, cond.Args...)
+	}
+	var rows []SessionConcurrencyPoint
+	err = r.db.Raw(`
+		SELECT b.bucket, COUNT(s.id) FILTER (WHERE s.started_at <= b.bucket) AS concurrent, COUNT(s.id) AS overlapping
+		FROM generate_series(?::timestamptz, ?::timestamptz, ?::interval) AS b(bucket)
+		LEFT JOIN `+join+`
+		GROUP BY b.bucket ORDER BY b.bucket
+	`, append([]interface{}{buckets[0], buckets[len(buckets)-1], interval}, joinArgs...)...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SessionConcurrency{Granularity: granularity, Points: make([]SessionConcurrencyPoint, 0, len(rows))}
+	for _, row := range rows {
+		row.Bucket = row.Bucket.UTC()
+		result.Points = append(result.Points, row)
+		if row.Concurrent > result.PeakConcurrent {
+			at := row.Bucket
+			result.PeakConcurrent, result.PeakAt = row.Concurrent, &at
+		}
+	}
+	return result, nil
+}
+
+// Timeline mengembalikan sesi satu user (user_profiles.id) terbaru dulu sesuai rentang tanggal filter beserta total.
+func (r *UserSessionRepository) Timeline(userID int64, filter ActivityFilter, page, pageSize int) ([]entity.UserSession, int64, error) {
+	start, end, err := filter.dates()
+	if err != nil {
+		return nil, 0, err
+	}
+	query := r.db.Model(&entity.UserSession{}).Where("user_id = ?", userID)
+	if cond := sqlbuilder.DateRange("started_at", start, end); !cond.IsEmpty() {
+		query = query.Where(cond.SQL, cond.Args...)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var sessions []entity.UserSession
+	err = query.Order("started_at DESC").Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&sessions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+// sessionScope menyisakan field filter yang berlaku untuk sesi (satker, eselon, user); tanggal ditangani terpisah karena kolomnya started_at.
+func sessionScope(filter ActivityFilter) ActivityFilter {
+	return ActivityFilter{
+		Eselon:        filter.Eselon,
+		RootSatkerIDs: filter.RootSatkerIDs,
+		SatkerIDs:     filter.SatkerIDs,
+		UserIDs:       filter.UserIDs,
+	}
+}
+
+// sessionSelect query dasar analitik sesi (alias s): kolom, rentang tanggal pada started_at, dan filter satker/eselon/user.
+func sessionSelect(filter ActivityFilter, columns ...string) *sqlbuilder.SelectBuilder {
+	b := sqlbuilder.Select(columns...).
+		From("user_sessions s").
+		Where(sessionScope(filter).where("s"))
+	if start, end, err := filter.dates(); err == nil {
+		b.Where(sqlbuilder.DateRange("s.started_at", start, end))
+	}
+	return b
+}
+
+// sessionBucketExpr ekspresi CASE indeks SessionDurationBuckets dari duration_seconds (batas berupa konstanta, bukan input user).
+func sessionBucketExpr() string {
+	var sb strings.Builder
+	sb.WriteString("CASE")
+	last := len(SessionDurationBuckets) - 1
+	for i, b := range SessionDurationBuckets[:last] {
+		fmt.Fprintf(&sb, " WHEN s.duration_seconds < %d THEN %d", b.MaxSeconds, i)
+	}
+	fmt.Fprintf(&sb, " ELSE %d END", last)
+	return sb.String()
+}
@@ -1,11 +1,16 @@
 // Package response berisi helper untuk mengirim response HTTP seragam dari handler.
 //
-// File response.go: Internal (log error + 500 + pesan umum), Error (response error dengan status code dan pesan kustom).
+// File response.go: Internal (log error + 500 + pesan umum), Error (response error dengan status code dan pesan kustom),
+// CachedJSON (200 + ETag dari isi body; 304 tanpa body jika If-None-Match cocok).
 package response
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,3 +27,34 @@ func Internal(c *gin.Context, err error) {
 func Error(c *gin.Context, code int, message string) {
 	c.JSON(code, gin.H{"error": message})
 }
+
+// CachedJSON mengirim payload sebagai JSON 200 dengan header ETag (hash SHA-256 body) dan Cache-Control "private, no-cache" (client wajib revalidasi).
+// Jika header If-None-Match request memuat ETag yang sama (atau "*"), kirim 304 Not Modified tanpa body. Dipakai endpoint analitik yang hasilnya di-cache.
+func CachedJSON(c *gin.Context, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		Internal(c, err)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches mengembalikan true jika header If-None-Match (daftar dipisah koma, boleh weak W/) memuat etag atau "*".
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
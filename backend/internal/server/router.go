@@ -1,6 +1,6 @@
 // Package server berisi inisialisasi HTTP server (Gin engine) dan pendaftaran route + middleware.
 //
-// File router.go: SetupRouter membuat engine Gin, pasang CORS, health check, dan semua route API (auth, account, dashboard, regional, content, reports, notifications, users, profile, search, metadata, org-tree).
+// File router.go: SetupRouter membuat engine Gin, pasang request ID, CORS, health check, dan semua route API (auth, account, admin, dashboard, regional, content, reports, report-access, notifications, users, profile, search, metadata, org-tree).
 package server
 
 import (
@@ -18,13 +18,17 @@ func SetupRouter() *gin.Engine {
 	r.RedirectTrailingSlash = false
 	r.RedirectFixedPath = false
 
+	// Request ID: setiap request punya X-Request-ID (dari client jika valid, selain itu dibuat baru) untuk korelasi log dan audit trail.
+	r.Use(middleware.RequestID())
+
 	// Middleware CORS: baca Origin request, set Access-Control-Allow-Origin dari config.CORSOrigin (ALLOWED_ORIGINS env; kosong = "*").
 	r.Use(func(c *gin.Context) {
 		if origin := config.CORSOrigin(c.Request.Header.Get("Origin")); origin != "" {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 		}
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-ID, X-Request-ID, If-None-Match, Last-Event-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Content-Disposition, ETag")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -41,44 +45,89 @@ func SetupRouter() *gin.Engine {
 		})
 	})
 
-	// Grup auth (publik): login, register, lupa password, logout.
+	// Grup auth (publik): login, register, lupa password, logout, aktivasi akun.
 	auth := r.Group("/api/auth")
 	{
 		auth.POST("/login", handler.Login)
 		auth.POST("/register", handler.Register)
 		auth.POST("/forgot-password", handler.ForgotPassword)
 		auth.POST("/logout", handler.Logout)
+		auth.POST("/activate", handler.ActivateAccount)
 	}
 
 	// Semua route di bawah prefix /api (kecuali auth sudah di atas).
 	api := r.Group("/api")
 	{
-		// Akun: ganti password (butuh JWT).
+		// Akun: ganti password, daftar dan cabut sesi login sendiri (butuh JWT).
 		account := api.Group("/account")
 		account.Use(middleware.AuthMiddleware())
 		{
 			account.POST("/change-password", handler.ChangePassword)
+			account.GET("/sessions", handler.ListMySessions)
+			account.DELETE("/sessions/:id", handler.RevokeMySession)
 		}
 
-		// Dashboard: statistik, aktivitas, chart, sukses akses, date-range, clusters, logout errors.
+		// Admin: manajemen user (list/detail/buat/ubah/nonaktifkan/reset password, impor massal CSV/XLSX, akun dorman/yatim + nonaktifkan massal), rekonsiliasi akun ↔ profil aktivitas, audit trail (list/detail/ekspor CSV/verifikasi hash chain), kalender hari libur, peringatan keamanan, aturan peringatan (CRUD + riwayat firing). Butuh JWT + role admin; setiap perubahan dicatat ke audit_events.
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			admin.GET("/users", handler.ListAdminUsers)
+			admin.POST("/users", handler.CreateAdminUser)
+			admin.POST("/users/import", handler.ImportAdminUsers)
+			admin.GET("/users/flagged", handler.ListFlaggedAccounts)
+			admin.POST("/users/flagged/deactivate", handler.DeactivateFlaggedAccounts)
+			admin.GET("/users/:id", handler.GetAdminUser)
+			admin.PUT("/users/:id", handler.UpdateAdminUser)
+			admin.DELETE("/users/:id", handler.DeactivateAdminUser)
+			admin.POST("/users/:id/reset-password", handler.ResetAdminUserPassword)
+			admin.POST("/users/:id/resend-activation", handler.ResendAdminUserActivation)
+			admin.PUT("/users/:id/profile", handler.LinkUserProfile)
+			admin.DELETE("/users/:id/profile", handler.UnlinkUserProfile)
+
+			admin.GET("/profile-links", handler.GetProfileLinkReconciliation)
+			admin.POST("/profile-links/auto", handler.AutoLinkProfiles)
+
+			admin.GET("/audit-events", handler.ListAuditEvents)
+			admin.GET("/audit-events/export", handler.ExportAuditEvents)
+			admin.GET("/audit-events/verify", handler.VerifyAuditChain)
+			admin.GET("/audit-events/:id", handler.GetAuditEvent)
+
+			admin.GET("/holidays", handler.ListHolidays)
+			admin.POST("/holidays", handler.CreateHoliday)
+			admin.DELETE("/holidays/:id", handler.DeleteHoliday)
+
+			admin.GET("/security-alerts", handler.ListSecurityAlerts)
+
+			admin.GET("/alert-rules", handler.ListAlertRules)
+			admin.POST("/alert-rules", handler.CreateAlertRule)
+			admin.GET("/alert-rules/:id", handler.GetAlertRule)
+			admin.PUT("/alert-rules/:id", handler.UpdateAlertRule)
+			admin.DELETE("/alert-rules/:id", handler.DeleteAlertRule)
+			admin.GET("/alert-firings", handler.ListAlertFirings)
+		}
+
+		// Dashboard: statistik, aktivitas, live feed SSE, chart, sukses akses, time-series, date-range, clusters, logout errors.
 		dashboard := api.Group("/dashboard")
 		{
 			dashboard.GET("/stats", handler.GetDashboardStats)
 			dashboard.GET("/activities", handler.GetActivities)
+			dashboard.GET("/stream", handler.StreamActivities)
 			dashboard.GET("/charts/:type", handler.GetChartData)
 			dashboard.GET("/access-success", handler.GetAccessSuccessRate)
+			dashboard.GET("/timeseries", handler.GetActivityTimeSeries)
 			dashboard.GET("/date-range", handler.GetDateRange)
 			dashboard.GET("/clusters", handler.GetClusters)
 			dashboard.GET("/logout-errors", handler.GetLogoutErrors)
 		}
 
-		// Regional: provinsi, lokasi, unit, jam per satker, top kontributor.
+		// Regional: provinsi, lokasi, unit, jam per satker, heatmap hari × jam, top kontributor.
 		regional := api.Group("/regional")
 		{
 			regional.GET("/provinces", handler.GetProvinces)
 			regional.GET("/locations", handler.GetLokasi)
 			regional.GET("/units", handler.GetUnits)
 			regional.GET("/units/hourly", handler.GetHourlyDataForSatker)
+			regional.GET("/heatmap", handler.GetActivityHeatmap)
 			regional.GET("/top-contributors", handler.GetTopContributors)
 		}
 
@@ -92,16 +141,43 @@ func SetupRouter() *gin.Engine {
 			content.GET("/global-economics", handler.GetGlobalEconomicsChart)
 		}
 
-		// Laporan: template, generate, download file, riwayat unduhan, permintaan akses, request/update akses.
+		// Insights: temuan detektor anomali, analitik sesi aktivitas, kohort retensi user, dan funnel jenis aktivitas (butuh JWT); acknowledge/dismiss dan timeline sesi per user butuh role admin.
+		insights := api.Group("/insights")
+		insights.Use(middleware.AuthMiddleware())
+		{
+			insights.GET("/anomalies", handler.ListAnomalies)
+			insights.POST("/anomalies/:id/acknowledge", middleware.AdminMiddleware(), handler.AcknowledgeAnomaly)
+			insights.POST("/anomalies/:id/dismiss", middleware.AdminMiddleware(), handler.DismissAnomaly)
+			insights.GET("/sessions/durations", handler.GetSessionDurations)
+			insights.GET("/sessions/concurrency", handler.GetSessionConcurrency)
+			insights.GET("/sessions/users/:id", middleware.AdminMiddleware(), handler.GetUserSessionTimeline)
+			insights.GET("/cohorts", handler.GetUserCohorts)
+			insights.GET("/funnel", handler.GetActivityFunnel)
+		}
+
+		// Laporan: template, generate (butuh JWT + grant akses), download file (butuh JWT; pembuat atau admin), riwayat unduhan (butuh JWT; milik sendiri kecuali admin), permintaan akses, request akses (butuh JWT pemohon), update akses (butuh JWT penyetuju).
 		reports := api.Group("/reports")
 		{
 			reports.GET("/templates", handler.GetReportTemplates)
-			reports.POST("/generate", handler.GenerateReport)
-			reports.GET("/download/:filename", handler.DownloadFile)
-			reports.GET("/downloads", handler.GetRecentDownloads)
+			reports.POST("/generate", middleware.AuthMiddleware(), handler.GenerateReport)
+			reports.GET("/download/:filename", middleware.AuthMiddleware(), handler.DownloadFile)
+			reports.GET("/downloads", middleware.AuthMiddleware(), handler.GetRecentDownloads)
 			reports.GET("/access-requests", handler.GetAccessRequests)
-			reports.POST("/request-access", handler.RequestAccess)
-			reports.PUT("/access-requests/:id", handler.UpdateAccessRequest)
+			reports.POST("/request-access", middleware.AuthMiddleware(), handler.RequestAccess)
+			reports.PUT("/access-requests/:id", middleware.AuthMiddleware(), handler.UpdateAccessRequest)
+		}
+
+		// Workflow akses laporan: antrian penyetuju, permintaan sendiri, detail + riwayat, approve/reject tahap aktif, revoke/review (admin). Semua butuh JWT.
+		reportAccess := api.Group("/report-access")
+		reportAccess.Use(middleware.AuthMiddleware())
+		{
+			reportAccess.GET("/requests", handler.ListAccessApprovals)
+			reportAccess.GET("/my-requests", handler.ListMyAccessRequests)
+			reportAccess.GET("/requests/:id", handler.GetAccessRequestDetail)
+			reportAccess.POST("/requests/:id/approve", handler.ApproveAccessRequest)
+			reportAccess.POST("/requests/:id/reject", handler.RejectAccessRequest)
+			reportAccess.POST("/requests/:id/revoke", handler.RevokeAccessRequest)
+			reportAccess.POST("/requests/:id/review", handler.ReviewAccessRequest)
 		}
 
 		// Notifikasi: daftar, tandai baca, tandai semua baca (semua butuh JWT).
@@ -113,19 +189,24 @@ func SetupRouter() *gin.Engine {
 			notifications.POST("/read-all", handler.MarkAllNotificationsRead)
 		}
 
-		// Users: profil user (query user_id).
+		// Users: profil user (query user_id); engagement profil aktivitas (butuh JWT, admin atau unit_head).
 		users := api.Group("/users")
 		{
 			users.GET("/profile", handler.GetUserProfile)
+			users.GET("/engagement", middleware.AuthMiddleware(), handler.GetUserEngagement)
+			users.GET("/engagement/profiles", middleware.AuthMiddleware(), handler.ListEngagementProfiles)
 		}
 
-		// Profil user login: get profil, update foto, ajukan akses laporan (semua butuh JWT).
+		// Profil user login: get profil, update foto, ajukan akses laporan, riwayat aktivitas sendiri, antrian + keputusan akses per user (semua butuh JWT).
 		profile := api.Group("/profile")
 		profile.Use(middleware.AuthMiddleware())
 		{
 			profile.GET("", handler.GetProfile)
 			profile.PUT("/photo", handler.UpdateProfilePhoto)
 			profile.POST("/request-access", handler.RequestReportAccess)
+			profile.GET("/my-activity", handler.GetMyActivity)
+			profile.GET("/access-requests", handler.GetPendingAccessRequests)
+			profile.PUT("/access-requests/:id", handler.ApproveReportAccess)
 		}
 
 		// Pencarian global, saran, cari user, cari satker.
@@ -0,0 +1,699 @@
+// File access_workflow.go: workflow persetujuan permintaan akses laporan (report_access_requests) — satu-satunya jalur yang mengubah status akses laporan user.
+//
+// Alur: Submit (alasan wajib) → tahap persetujuan berurutan sesuai ACCESS_APPROVAL_STEPS (mis. unit_head satker pemohon, lalu admin) → approved (dengan masa berlaku
+// dan jadwal review) atau rejected (alasan wajib). Akses yang disetujui bisa di-review (diperpanjang) atau dicabut (alasan wajib), dan kedaluwarsa otomatis lewat job.
+// Tahap yang tidak punya penyetuju (mis. satker tanpa unit_head) dilewati otomatis, kecuali tahap terakhir. Admin boleh memutuskan di tahap mana pun.
+// Setiap langkah dicatat ke report_access_request_events (riwayat) dan audit_events, users.report_access_status disinkronkan, dan pemohon serta penyetuju diberi notifikasi.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAccessRequestNotFound = errors.New("permintaan akses tidak ditemukan")
+	ErrAccessReasonRequired  = errors.New("alasan wajib diisi")
+	ErrAccessAlreadyPending  = errors.New("permintaan akses sedang diproses")
+	ErrAccessAlreadyGranted  = errors.New("user sudah memiliki akses laporan")
+	ErrAccessNotNeeded       = errors.New("admin sudah memiliki akses penuh")
+	ErrAccessNotPending      = errors.New("permintaan akses tidak sedang menunggu persetujuan")
+	ErrAccessNotApproved     = errors.New("akses laporan tidak dalam status disetujui")
+	ErrAccessNotApprover     = errors.New("tidak berwenang memutuskan tahap persetujuan ini")
+	ErrAccessSelfApproval    = errors.New("tidak dapat memutuskan permintaan akses sendiri")
+)
+
+// Nilai users.report_access_status yang disinkronkan workflow.
+const (
+	userAccessNone     = "none"
+	userAccessPending  = "pending"
+	userAccessApproved = "approved"
+	userAccessRejected = "rejected"
+)
+
+// notificationRelatedAccess nilai notifications.related_entity untuk notifikasi workflow akses.
+const notificationRelatedAccess = "report_access"
+
+// AccessRequestFilter filter dan paginasi daftar permintaan akses.
+type AccessRequestFilter struct {
+	Status   string
+	UserID   int // 0 = semua user.
+	Page     int
+	PageSize int
+}
+
+// AccessWorkflowService menyimpan koneksi DB dan konfigurasi workflow akses laporan.
+type AccessWorkflowService struct {
+	db    *gorm.DB
+	steps []string
+	cfg   config.AccessWorkflowConfig
+}
+
+// NewAccessWorkflowService membuat instance AccessWorkflowService dari config ACCESS_*. Role tahap yang tidak dikenal diabaikan; jika tidak tersisa, dipakai satu tahap admin.
+func NewAccessWorkflowService(db *gorm.DB) *AccessWorkflowService {
+	cfg := config.GetAccessWorkflowConfig()
+	steps := make([]string, 0, len(cfg.ApprovalSteps))
+	for _, role := range cfg.ApprovalSteps {
+		if entity.IsValidRole(role) && role != entity.RoleUser {
+			steps = append(steps, role)
+		}
+	}
+	if len(steps) == 0 {
+		steps = []string{entity.RoleAdmin}
+	}
+	return &AccessWorkflowService{db: db, steps: steps, cfg: cfg}
+}
+
+// Submit mengajukan akses laporan untuk userID dengan alasan wajib dan cakupan scope (template + root satker). Tanpa satker, cakupan default = pohon satker pemohon (jika ada).
+// Tahap awal tanpa penyetuju langsung dilewati, lalu penyetuju tahap aktif dan pemohon diberi notifikasi.
+func (s *AccessWorkflowService) Submit(actor AuditActor, userID int, reason string, scope AccessScope) (*entity.ReportAccessRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) < config.MinAccessReasonLength {
+		return nil, ErrAccessReasonRequired
+	}
+
+	var req *entity.ReportAccessRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := findUserByID(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.Role == entity.RoleAdmin {
+			return ErrAccessNotNeeded
+		}
+		var open []entity.ReportAccessRequest
+		if err := tx.Where("user_id = ? AND status IN ?", userID, []string{entity.AccessRequestPending, entity.AccessRequestApproved}).Find(&open).Error; err != nil {
+			return err
+		}
+		for _, r := range open {
+			if r.Status == entity.AccessRequestPending {
+				return ErrAccessAlreadyPending
+			}
+			return ErrAccessAlreadyGranted
+		}
+		if len(scope.SatkerIDs) == 0 && user.SatkerID != nil {
+			scope.SatkerIDs = []int64{*user.SatkerID}
+		}
+		templates, satkerIDs, err := normalizeScope(tx, scope)
+		if err != nil {
+			return err
+		}
+
+		req = &entity.ReportAccessRequest{
+			UserID:        userID,
+			Reason:        reason,
+			Status:        entity.AccessRequestPending,
+			ApprovalSteps: strings.Join(s.steps, ","),
+			Templates:     templates,
+			SatkerIDs:     satkerIDs,
+			RequestedAt:   time.Now(),
+		}
+		if err := tx.Create(req).Error; err != nil {
+			return err
+		}
+		if err := s.addEvent(tx, req, nil, entity.AccessEventSubmitted, actor.UserID, "", entity.AccessRequestPending, reason); err != nil {
+			return err
+		}
+		if err := s.enterStep(tx, req, user); err != nil {
+			return err
+		}
+		if err := setUserAccessStatus(tx, userID, userAccessPending); err != nil {
+			return err
+		}
+		if err := notify(tx, userID, "Akses Laporan", "Permintaan akses laporan Anda telah dikirim dan menunggu persetujuan", "info", req.ID); err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionReportAccessRequest,
+			TargetType: AuditTargetAccessRequest,
+			TargetID:   strconv.Itoa(req.ID),
+			After:      req,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Decide menyetujui atau menolak tahap aktif permintaan requestID. Menolak wajib beralasan. Persetujuan di tahap terakhir membuat akses berlaku (approved).
+// override (opsional, hanya admin, hanya dipakai di persetujuan tahap terakhir) mengganti cakupan dan/atau tanggal kedaluwarsa grant.
+func (s *AccessWorkflowService) Decide(actor AuditActor, requestID int, approve bool, reason string, override *GrantOverride) (*entity.ReportAccessRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if !approve && len([]rune(reason)) < config.MinAccessReasonLength {
+		return nil, ErrAccessReasonRequired
+	}
+
+	var req *entity.ReportAccessRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		req, err = findAccessRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if req.Status != entity.AccessRequestPending {
+			return ErrAccessNotPending
+		}
+		approver, err := s.loadActor(tx, actor)
+		if err != nil {
+			return err
+		}
+		if approver.ID == req.UserID {
+			return ErrAccessSelfApproval
+		}
+		requester, err := findUserByID(tx, req.UserID)
+		if err != nil {
+			return err
+		}
+		steps := req.Steps()
+		role := req.CurrentStepRole()
+		if !canApproveStep(approver, requester, role) {
+			return ErrAccessNotApprover
+		}
+
+		before := *req
+		step := req.CurrentStep
+		now := time.Now()
+		if !approve {
+			req.Status = entity.AccessRequestRejected
+			req.ProcessedAt = &now
+			req.ProcessedBy = &approver.ID
+			req.AdminNotes = reason
+			if err := tx.Save(req).Error; err != nil {
+				return err
+			}
+			if err := s.addEvent(tx, req, &step, entity.AccessEventRejected, &approver.ID, entity.AccessRequestPending, entity.AccessRequestRejected, reason); err != nil {
+				return err
+			}
+			if err := setUserAccessStatus(tx, req.UserID, userAccessRejected); err != nil {
+				return err
+			}
+			if err := notify(tx, req.UserID, "Akses Laporan", "Permintaan akses laporan Anda ditolak: "+reason, "error", req.ID); err != nil {
+				return err
+			}
+		} else if step < len(steps)-1 {
+			if err := s.addEvent(tx, req, &step, entity.AccessEventStepApproved, &approver.ID, entity.AccessRequestPending, entity.AccessRequestPending, reason); err != nil {
+				return err
+			}
+			req.CurrentStep++
+			if err := tx.Save(req).Error; err != nil {
+				return err
+			}
+			if err := s.enterStep(tx, req, requester); err != nil {
+				return err
+			}
+			message := fmt.Sprintf("Permintaan akses laporan Anda disetujui di tahap %s dan diteruskan ke tahap %s", role, steps[req.CurrentStep])
+			if err := notify(tx, req.UserID, "Akses Laporan", message, "info", req.ID); err != nil {
+				return err
+			}
+		} else {
+			req.Status = entity.AccessRequestApproved
+			req.ProcessedAt = &now
+			req.ProcessedBy = &approver.ID
+			req.AdminNotes = reason
+			s.setGrantPeriod(req, now)
+			if override != nil {
+				if err := applyGrantOverride(tx, req, approver, override, now); err != nil {
+					return err
+				}
+			}
+			if err := tx.Save(req).Error; err != nil {
+				return err
+			}
+			if err := s.addEvent(tx, req, &step, entity.AccessEventApproved, &approver.ID, entity.AccessRequestPending, entity.AccessRequestApproved, reason); err != nil {
+				return err
+			}
+			if err := setUserAccessStatus(tx, req.UserID, userAccessApproved); err != nil {
+				return err
+			}
+			message := "Permintaan akses laporan Anda telah disetujui"
+			if req.ExpiresAt != nil {
+				message += " dan berlaku sampai " + req.ExpiresAt.Format("02-01-2006")
+			}
+			if err := notify(tx, req.UserID, "Akses Laporan", message, "success", req.ID); err != nil {
+				return err
+			}
+		}
+
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionReportAccessDecide,
+			TargetType: AuditTargetAccessRequest,
+			TargetID:   strconv.Itoa(req.ID),
+			Before:     before,
+			After:      req,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// DecideForUser sama seperti Decide untuk permintaan pending milik userID (dipakai endpoint lama yang memakai id user, bukan id permintaan).
+func (s *AccessWorkflowService) DecideForUser(actor AuditActor, userID int, approve bool, reason string) (*entity.ReportAccessRequest, error) {
+	var req entity.ReportAccessRequest
+	err := s.db.Where("user_id = ? AND status = ?", userID, entity.AccessRequestPending).Order("id DESC").First(&req).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccessRequestNotFound
+		}
+		return nil, err
+	}
+	return s.Decide(actor, req.ID, approve, reason, nil)
+}
+
+// Revoke mencabut akses yang sudah disetujui (hanya admin; alasan wajib). users.report_access_status kembali ke none sehingga user bisa mengajukan ulang.
+func (s *AccessWorkflowService) Revoke(actor AuditActor, requestID int, reason string) (*entity.ReportAccessRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) < config.MinAccessReasonLength {
+		return nil, ErrAccessReasonRequired
+	}
+	return s.closeApproved(actor, requestID, entity.AccessRequestRevoked, entity.AccessEventRevoked, AuditActionReportAccessRevoke, reason,
+		"Akses laporan Anda dicabut: "+reason)
+}
+
+// Review menandai akses yang disetujui sudah ditinjau ulang (hanya admin): jadwal review berikutnya dan masa berlaku dihitung ulang dari sekarang.
+func (s *AccessWorkflowService) Review(actor AuditActor, requestID int, note string) (*entity.ReportAccessRequest, error) {
+	var req *entity.ReportAccessRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		req, err = findAccessRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if req.Status != entity.AccessRequestApproved {
+			return ErrAccessNotApproved
+		}
+		reviewer, err := s.loadActor(tx, actor)
+		if err != nil {
+			return err
+		}
+		if reviewer.Role != entity.RoleAdmin {
+			return ErrAccessNotApprover
+		}
+
+		before := *req
+		s.setGrantPeriod(req, time.Now())
+		req.ReviewNotifiedAt = nil
+		req.ExpiryNotifiedAt = nil
+		if err := tx.Save(req).Error; err != nil {
+			return err
+		}
+		if err := s.addEvent(tx, req, nil, entity.AccessEventReviewed, &reviewer.ID, entity.AccessRequestApproved, entity.AccessRequestApproved, strings.TrimSpace(note)); err != nil {
+			return err
+		}
+		message := "Akses laporan Anda telah ditinjau dan diperpanjang"
+		if req.ExpiresAt != nil {
+			message += " sampai " + req.ExpiresAt.Format("02-01-2006")
+		}
+		if err := notify(tx, req.UserID, "Akses Laporan", message, "info", req.ID); err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionReportAccessReview,
+			TargetType: AuditTargetAccessRequest,
+			TargetID:   strconv.Itoa(req.ID),
+			Before:     before,
+			After:      req,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Get mengembalikan permintaan akses beserta user pemohon dan riwayat lengkap (urut waktu).
+func (s *AccessWorkflowService) Get(requestID int) (*entity.ReportAccessRequest, error) {
+	var req entity.ReportAccessRequest
+	err := s.db.Preload("User").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Preload("Events.Actor").
+		First(&req, requestID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccessRequestNotFound
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// List mengembalikan permintaan akses (terbaru dulu) dengan user pemohon, sesuai filter status/user, beserta total.
+func (s *AccessWorkflowService) List(f AccessRequestFilter) ([]entity.ReportAccessRequest, int64, error) {
+	query := s.db.Model(&entity.ReportAccessRequest{})
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.UserID != 0 {
+		query = query.Where("user_id = ?", f.UserID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var requests []entity.ReportAccessRequest
+	err := query.Preload("User").Order("requested_at DESC, id DESC").Offset((f.Page - 1) * f.PageSize).Limit(f.PageSize).Find(&requests).Error
+	return requests, total, err
+}
+
+// ListActionable mengembalikan permintaan pending yang tahap aktifnya boleh diputuskan approverID (admin: semua yang pending).
+func (s *AccessWorkflowService) ListActionable(approverID int) ([]entity.ReportAccessRequest, error) {
+	approver, err := findUserByID(s.db, approverID)
+	if err != nil {
+		return nil, err
+	}
+	var pending []entity.ReportAccessRequest
+	if err := s.db.Preload("User").Where("status = ?", entity.AccessRequestPending).Order("requested_at").Find(&pending).Error; err != nil {
+		return nil, err
+	}
+	actionable := make([]entity.ReportAccessRequest, 0, len(pending))
+	for _, r := range pending {
+		if r.User == nil || r.UserID == approver.ID {
+			continue
+		}
+		if canApproveStep(approver, r.User, r.CurrentStepRole()) {
+			actionable = append(actionable, r)
+		}
+	}
+	return actionable, nil
+}
+
+// CanView mengembalikan true jika viewerID boleh melihat detail permintaan: pemohon, admin, atau penyetuju salah satu tahap permintaan tersebut.
+func (s *AccessWorkflowService) CanView(viewerID int, req *entity.ReportAccessRequest) (bool, error) {
+	if viewerID == req.UserID {
+		return true, nil
+	}
+	viewer, err := findUserByID(s.db, viewerID)
+	if err != nil {
+		return false, err
+	}
+	requester := req.User
+	if requester == nil {
+		if requester, err = findUserByID(s.db, req.UserID); err != nil {
+			return false, err
+		}
+	}
+	for _, role := range req.Steps() {
+		if canApproveStep(viewer, requester, role) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ExpireLapsed mengubah akses disetujui yang expires_at-nya sudah lewat menjadi expired, mengembalikan status user ke none, dan memberi notifikasi. Dipanggil job berkala.
+func (s *AccessWorkflowService) ExpireLapsed(actor AuditActor) (int, error) {
+	var lapsed []entity.ReportAccessRequest
+	if err := s.db.Where("status = ? AND expires_at IS NOT NULL AND expires_at <= ?", entity.AccessRequestApproved, time.Now()).Find(&lapsed).Error; err != nil {
+		return 0, err
+	}
+	expired := 0
+	for _, r := range lapsed {
+		_, err := s.closeApproved(actor, r.ID, entity.AccessRequestExpired, entity.AccessEventExpired, AuditActionReportAccessExpire, "",
+			"Masa berlaku akses laporan Anda telah berakhir. Ajukan ulang jika masih diperlukan.")
+		if err != nil && !errors.Is(err, ErrAccessNotApproved) {
+			return expired, err
+		}
+		if err == nil {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// SendReviewReminders memberi notifikasi ke admin (dan pemegang akses) untuk akses disetujui yang sudah jatuh tempo review dan belum diingatkan. Dipanggil job berkala.
+func (s *AccessWorkflowService) SendReviewReminders() (int, error) {
+	var due []entity.ReportAccessRequest
+	err := s.db.Preload("User").
+		Where("status = ? AND review_due_at IS NOT NULL AND review_due_at <= ?", entity.AccessRequestApproved, time.Now()).
+		Where("review_notified_at IS NULL OR review_notified_at < review_due_at").
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range due {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			admins, err := activeUsersWithRole(tx, entity.RoleAdmin, nil)
+			if err != nil {
+				return err
+			}
+			name := "user #" + strconv.Itoa(r.UserID)
+			if r.User != nil {
+				name = displayName(r.User)
+			}
+			for _, a := range admins {
+				if err := notify(tx, a.ID, "Review Akses Laporan", "Akses laporan "+name+" jatuh tempo review: perpanjang atau cabut", "warning", r.ID); err != nil {
+					return err
+				}
+			}
+			if err := notify(tx, r.UserID, "Akses Laporan", "Akses laporan Anda sedang ditinjau ulang oleh admin", "info", r.ID); err != nil {
+				return err
+			}
+			return tx.Model(&entity.ReportAccessRequest{}).Where("id = ?", r.ID).Update("review_notified_at", time.Now()).Error
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(due), nil
+}
+
+// closeApproved mengakhiri akses yang disetujui (dicabut/kedaluwarsa): status permintaan, riwayat, status user none, notifikasi, audit.
+func (s *AccessWorkflowService) closeApproved(actor AuditActor, requestID int, status, eventAction, auditAction, reason, message string) (*entity.ReportAccessRequest, error) {
+	var req *entity.ReportAccessRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		req, err = findAccessRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if req.Status != entity.AccessRequestApproved {
+			return ErrAccessNotApproved
+		}
+		var actorID *int
+		if status == entity.AccessRequestRevoked {
+			admin, err := s.loadActor(tx, actor)
+			if err != nil {
+				return err
+			}
+			if admin.Role != entity.RoleAdmin {
+				return ErrAccessNotApprover
+			}
+			actorID = &admin.ID
+		}
+
+		before := *req
+		now := time.Now()
+		req.Status = status
+		req.ProcessedAt = &now
+		req.ProcessedBy = actorID
+		if reason != "" {
+			req.AdminNotes = reason
+		}
+		if err := tx.Save(req).Error; err != nil {
+			return err
+		}
+		if err := s.addEvent(tx, req, nil, eventAction, actorID, entity.AccessRequestApproved, status, reason); err != nil {
+			return err
+		}
+		if err := setUserAccessStatus(tx, req.UserID, userAccessNone); err != nil {
+			return err
+		}
+		if err := notify(tx, req.UserID, "Akses Laporan", message, "warning", req.ID); err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     auditAction,
+			TargetType: AuditTargetAccessRequest,
+			TargetID:   strconv.Itoa(req.ID),
+			Before:     before,
+			After:      req,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// enterStep melewati tahap aktif yang tidak punya penyetuju (kecuali tahap terakhir), lalu memberi notifikasi ke penyetuju tahap aktif.
+func (s *AccessWorkflowService) enterStep(tx *gorm.DB, req *entity.ReportAccessRequest, requester *entity.User) error {
+	steps := req.Steps()
+	for {
+		role := steps[req.CurrentStep]
+		approvers, err := approversFor(tx, requester, role)
+		if err != nil {
+			return err
+		}
+		if len(approvers) > 0 || req.CurrentStep == len(steps)-1 {
+			for _, a := range approvers {
+				message := fmt.Sprintf("Permintaan akses laporan dari %s menunggu persetujuan Anda (tahap %s)", displayName(requester), role)
+				if err := notify(tx, a.ID, "Persetujuan Akses Laporan", message, "info", req.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		step := req.CurrentStep
+		if err := s.addEvent(tx, req, &step, entity.AccessEventStepSkipped, nil, entity.AccessRequestPending, entity.AccessRequestPending, "tidak ada penyetuju untuk tahap "+role); err != nil {
+			return err
+		}
+		req.CurrentStep++
+		if err := tx.Model(req).Update("current_step", req.CurrentStep).Error; err != nil {
+			return err
+		}
+	}
+}
+
+// setGrantPeriod mengisi ExpiresAt dan ReviewDueAt dari waktu from sesuai konfigurasi (0 = tidak diisi).
+func (s *AccessWorkflowService) setGrantPeriod(req *entity.ReportAccessRequest, from time.Time) {
+	req.ExpiresAt, req.ReviewDueAt = nil, nil
+	if s.cfg.GrantDuration > 0 {
+		t := from.Add(s.cfg.GrantDuration)
+		req.ExpiresAt = &t
+	}
+	if s.cfg.ReviewInterval > 0 {
+		t := from.Add(s.cfg.ReviewInterval)
+		if req.ExpiresAt == nil || t.Before(*req.ExpiresAt) {
+			req.ReviewDueAt = &t
+		}
+	}
+}
+
+// applyGrantOverride menerapkan perubahan cakupan/kedaluwarsa grant dari admin saat persetujuan akhir. ReviewDueAt tidak boleh melewati ExpiresAt baru.
+func applyGrantOverride(tx *gorm.DB, req *entity.ReportAccessRequest, approver *entity.User, override *GrantOverride, now time.Time) error {
+	if approver.Role != entity.RoleAdmin {
+		return ErrAccessNotApprover
+	}
+	if override.Scope != nil {
+		templates, satkerIDs, err := normalizeScope(tx, *override.Scope)
+		if err != nil {
+			return err
+		}
+		req.Templates, req.SatkerIDs = templates, satkerIDs
+	}
+	if override.ExpiresAt != nil {
+		if !override.ExpiresAt.After(now) {
+			return ErrInvalidGrantExpiry
+		}
+		expiresAt := *override.ExpiresAt
+		req.ExpiresAt = &expiresAt
+		if req.ReviewDueAt != nil && !req.ReviewDueAt.Before(expiresAt) {
+			req.ReviewDueAt = nil
+		}
+	}
+	return nil
+}
+
+// addEvent menambah satu baris riwayat permintaan.
+func (s *AccessWorkflowService) addEvent(tx *gorm.DB, req *entity.ReportAccessRequest, step *int, action string, actorID *int, from, to, reason string) error {
+	event := entity.ReportAccessRequestEvent{
+		RequestID:  req.ID,
+		Step:       step,
+		Action:     action,
+		ActorID:    actorID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}
+	if step != nil {
+		event.StepRole = req.Steps()[*step]
+	}
+	return tx.Create(&event).Error
+}
+
+// loadActor mengambil user pelaku dari actor.UserID; tanpa user login → ErrAccessNotApprover.
+func (s *AccessWorkflowService) loadActor(tx *gorm.DB, actor AuditActor) (*entity.User, error) {
+	if actor.UserID == nil {
+		return nil, ErrAccessNotApprover
+	}
+	return findUserByID(tx, *actor.UserID)
+}
+
+// canApproveStep: admin boleh di tahap mana pun; unit_head hanya untuk pemohon dari satker yang sama; role lain cukup sama dengan role tahap.
+func canApproveStep(approver, requester *entity.User, role string) bool {
+	if !approver.IsActive {
+		return false
+	}
+	if approver.Role == entity.RoleAdmin {
+		return true
+	}
+	if approver.Role != role {
+		return false
+	}
+	if role == entity.RoleUnitHead {
+		return requester.SatkerID != nil && approver.SatkerID != nil && *requester.SatkerID == *approver.SatkerID
+	}
+	return true
+}
+
+// approversFor mengembalikan user aktif yang menjadi penyetuju tahap role untuk pemohon (unit_head: satker yang sama dengan pemohon).
+func approversFor(tx *gorm.DB, requester *entity.User, role string) ([]entity.User, error) {
+	if role == entity.RoleUnitHead {
+		if requester.SatkerID == nil {
+			return nil, nil
+		}
+		return activeUsersWithRole(tx, role, requester.SatkerID)
+	}
+	return activeUsersWithRole(tx, role, nil)
+}
+
+// activeUsersWithRole mengembalikan user aktif dengan role tertentu, opsional dibatasi satker.
+func activeUsersWithRole(tx *gorm.DB, role string, satkerID *int64) ([]entity.User, error) {
+	query := tx.Where("role = ? AND is_active = ?", role, true)
+	if satkerID != nil {
+		query = query.Where("satker_id = ?", *satkerID)
+	}
+	var users []entity.User
+	err := query.Order("id").Find(&users).Error
+	return users, err
+}
+
+// findAccessRequest mengambil permintaan by id dengan kunci baris (FOR UPDATE) agar keputusan paralel tidak saling menimpa.
+func findAccessRequest(tx *gorm.DB, id int) (*entity.ReportAccessRequest, error) {
+	var req entity.ReportAccessRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccessRequestNotFound
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// setUserAccessStatus menyinkronkan users.report_access_status.
+func setUserAccessStatus(tx *gorm.DB, userID int, status string) error {
+	return tx.Model(&entity.User{}).Where("id = ?", userID).Update("report_access_status", status).Error
+}
+
+// notify membuat notifikasi in-app untuk user terkait permintaan akses requestID.
+func notify(tx *gorm.DB, userID int, title, message, notifType string, requestID int) error {
+	id := requestID
+	return tx.Create(&entity.Notification{
+		UserID:        userID,
+		Title:         title,
+		Message:       message,
+		Type:          notifType,
+		RelatedEntity: notificationRelatedAccess,
+		RelatedID:     &id,
+		CreatedAt:     time.Now(),
+	}).Error
+}
+
+// displayName mengembalikan nama lengkap user, atau username jika nama kosong.
+func displayName(u *entity.User) string {
+	if u.FullName != "" {
+		return u.FullName
+	}
+	return u.Username
+}
@@ -0,0 +1,145 @@
+// File account_hygiene_service.go: deteksi akun dorman/yatim dan penonaktifan massal oleh admin (GET/POST /api/admin/users/flagged).
+//
+// Akun ditandai jika tidak login selama ACCOUNT_DORMANT_DAYS hari (atau belum pernah login sejak dibuat) atau satker-nya sudah tidak ada.
+// DeactivateFlagged menonaktifkan akun yang masih ditandai saat dijalankan; tiap akun diproses dalam transaksi sendiri (seperti Deactivate:
+// sesi dicabut, audit user.deactivate_flagged beserta alasan), sehingga akun yang gagal (admin terakhir, akun sendiri) tidak membatalkan yang lain.
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Status hasil penonaktifan massal per akun.
+const (
+	FlaggedDeactivated = "deactivated"
+	FlaggedNotFlagged  = "not_flagged" // Akun tidak (lagi) ditandai atau sudah nonaktif; dilewati.
+	FlaggedError       = "error"
+)
+
+// FlaggedAccounts daftar akun ditandai beserta batas dorman yang dipakai.
+type FlaggedAccounts struct {
+	DormantAfterDays int                         `json:"dormant_after_days"`
+	DormantSince     time.Time                   `json:"dormant_since"`
+	Accounts         []repository.FlaggedAccount `json:"accounts"`
+}
+
+// FlaggedDeactivation hasil penonaktifan satu akun.
+type FlaggedDeactivation struct {
+	UserID   int      `json:"user_id"`
+	Username string   `json:"username,omitempty"`
+	Reasons  []string `json:"reasons,omitempty"`
+	Status   string   `json:"status"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// FlaggedDeactivationSummary ringkasan penonaktifan massal.
+type FlaggedDeactivationSummary struct {
+	Total       int `json:"total"`
+	Deactivated int `json:"deactivated"`
+	Skipped     int `json:"skipped"`
+	Failed      int `json:"failed"`
+}
+
+// flaggedAuditSnapshot snapshot audit sesudah penonaktifan: data user ditambah alasan penandaan.
+type flaggedAuditSnapshot struct {
+	*entity.User
+	FlagReasons []string `json:"flag_reasons"`
+}
+
+// AccountHygieneService deteksi akun dorman/yatim dengan batas dorman dari env ACCOUNT_DORMANT_DAYS.
+type AccountHygieneService struct {
+	db          *gorm.DB
+	dormantDays int
+}
+
+// NewAccountHygieneService membuat AccountHygieneService.
+func NewAccountHygieneService(db *gorm.DB) *AccountHygieneService {
+	return &AccountHygieneService{db: db, dormantDays: config.AccountDormantDays()}
+}
+
+// Flagged mengembalikan semua akun aktif yang ditandai per now.
+func (s *AccountHygieneService) Flagged(now time.Time) (*FlaggedAccounts, error) {
+	since := now.AddDate(0, 0, -s.dormantDays)
+	accounts, err := repository.NewAccountHygieneRepository(s.db).FlaggedAccounts(since, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &FlaggedAccounts{DormantAfterDays: s.dormantDays, DormantSince: since, Accounts: accounts}, nil
+}
+
+// DeactivateFlagged menonaktifkan akun ditandai. userIDs kosong = semua akun yang ditandai; id yang tidak ditandai dilewati (not_flagged).
+func (s *AccountHygieneService) DeactivateFlagged(actor AuditActor, userIDs []int, now time.Time) ([]FlaggedDeactivation, FlaggedDeactivationSummary, error) {
+	flagged, err := s.Flagged(now)
+	if err != nil {
+		return nil, FlaggedDeactivationSummary{}, err
+	}
+	byID := make(map[int]repository.FlaggedAccount, len(flagged.Accounts))
+	for _, account := range flagged.Accounts {
+		byID[account.ID] = account
+	}
+	if len(userIDs) == 0 {
+		for _, account := range flagged.Accounts {
+			userIDs = append(userIDs, account.ID)
+		}
+	}
+
+	results := make([]FlaggedDeactivation, 0, len(userIDs))
+	summary := FlaggedDeactivationSummary{}
+	seen := map[int]bool{}
+	for _, id := range userIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		summary.Total++
+
+		res := FlaggedDeactivation{UserID: id}
+		account, ok := byID[id]
+		if !ok {
+			res.Status = FlaggedNotFlagged
+			summary.Skipped++
+			results = append(results, res)
+			continue
+		}
+		res.Username, res.Reasons = account.Username, account.Reasons
+		if err := s.deactivate(actor, id, account.Reasons); err != nil {
+			res.Status, res.Error = FlaggedError, err.Error()
+			summary.Failed++
+		} else {
+			res.Status = FlaggedDeactivated
+			summary.Deactivated++
+		}
+		results = append(results, res)
+	}
+	return results, summary, nil
+}
+
+// deactivate menonaktifkan satu akun ditandai dalam transaksi sendiri dan mencatat audit beserta alasannya.
+func (s *AccountHygieneService) deactivate(actor AuditActor, id int, reasons []string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := findUserByID(tx, id)
+		if err != nil {
+			return err
+		}
+		if !user.IsActive {
+			return nil
+		}
+		before := *user
+		if err := deactivateAccount(tx, actor, user); err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserDeactivateFlagged,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			Before:     before,
+			After:      flaggedAuditSnapshot{User: user, FlagReasons: reasons},
+		})
+	})
+}
@@ -0,0 +1,180 @@
+// File activity_feed.go: pub/sub in-process untuk live feed aktivitas (GET /api/dashboard/stream).
+//
+// Aktivitas ditulis cmd/import di proses terpisah, sehingga sisi ingest di proses API adalah job activity-feed: Poll membaca baris
+// activity_logs_normalized dengan id > watermark (in-memory, awalnya id terbesar saat start) lalu Publish ke semua subscriber sebagai dto.ActivityLogDTO.
+// Setiap subscriber punya antrean berkapasitas STREAM_BUFFER_SIZE. Publish mengirim per potongan sebesar antrean; subscriber yang antreannya penuh
+// ditunggu paling lama STREAM_LAG_GRACE per potongan (satu tenggat bersama untuk semua subscriber), sehingga koneksi yang sedang memproses batch
+// sebelumnya tetap ikut, sedangkan koneksi yang benar-benar lambat diputus (Lagged) dan menyambung ulang dengan Last-Event-ID untuk mengejar dari DB.
+// Baris yang di-commit dengan id lebih kecil dari watermark (penulis paralel) tidak ikut dipublikasikan; replay Last-Event-ID tetap membacanya dari DB.
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/dto"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+)
+
+// ActivityFeedEvent satu aktivitas baru di feed; ID = id aktivitas (dipakai sebagai id event SSE).
+type ActivityFeedEvent struct {
+	ID       int64
+	Activity dto.ActivityLogDTO
+}
+
+// FeedSubscription langganan satu koneksi: antrean event, watermark feed saat berlangganan, dan tanda tertinggal.
+type FeedSubscription struct {
+	events chan ActivityFeedEvent
+	from   int64
+	lagged bool // Diisi sebelum events ditutup; aman dibaca setelah Events() tertutup.
+}
+
+// Events mengembalikan antrean event; ditutup saat Unsubscribe atau saat subscriber tertinggal.
+func (s *FeedSubscription) Events() <-chan ActivityFeedEvent {
+	return s.events
+}
+
+// From mengembalikan watermark feed saat berlangganan: semua aktivitas dengan id > From akan dikirim lewat Events.
+func (s *FeedSubscription) From() int64 {
+	return s.from
+}
+
+// Lagged mengembalikan true jika langganan diputus karena antrean penuh. Hanya bermakna setelah Events() tertutup.
+func (s *FeedSubscription) Lagged() bool {
+	return s.lagged
+}
+
+// ActivityFeed hub pub/sub aktivitas baru: daftar subscriber, watermark id terakhir yang dipublikasikan, kapasitas antrean per subscriber,
+// dan tenggang menunggu subscriber yang antreannya penuh.
+type ActivityFeed struct {
+	mu         sync.Mutex
+	subs       map[*FeedSubscription]struct{}
+	lastID     int64
+	ready      bool // Watermark sudah diinisialisasi dari DB.
+	bufferSize int
+	lagGrace   time.Duration
+}
+
+// NewActivityFeed membuat ActivityFeed dengan kapasitas antrean bufferSize event per subscriber dan tenggang lagGrace per potongan publikasi.
+func NewActivityFeed(bufferSize int, lagGrace time.Duration) *ActivityFeed {
+	return &ActivityFeed{subs: map[*FeedSubscription]struct{}{}, bufferSize: bufferSize, lagGrace: lagGrace}
+}
+
+var (
+	liveFeed     *ActivityFeed
+	liveFeedOnce sync.Once
+)
+
+// LiveActivityFeed mengembalikan feed tingkat proses yang diisi ActivityFeedJob dan dibaca handler stream (STREAM_BUFFER_SIZE, STREAM_LAG_GRACE).
+func LiveActivityFeed() *ActivityFeed {
+	liveFeedOnce.Do(func() {
+		cfg := config.GetStreamConfig()
+		liveFeed = NewActivityFeed(cfg.BufferSize, cfg.LagGrace)
+	})
+	return liveFeed
+}
+
+// Subscribe mendaftarkan subscriber baru. Pemanggil wajib memanggil Unsubscribe setelah selesai.
+func (f *ActivityFeed) Subscribe() *FeedSubscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sub := &FeedSubscription{events: make(chan ActivityFeedEvent, f.bufferSize), from: f.lastID}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe melepas subscriber dan menutup antreannya; aman dipanggil lebih dari sekali atau setelah subscriber diputus karena tertinggal.
+func (f *ActivityFeed) Unsubscribe(sub *FeedSubscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[sub]; ok {
+		delete(f.subs, sub)
+		close(sub.events)
+	}
+}
+
+// Publish mengirim events (urut id naik) ke semua subscriber per potongan sebesar antrean. Untuk setiap potongan, subscriber yang antreannya penuh
+// ditunggu sampai tenggat bersama (lagGrace sejak potongan mulai dikirim); yang belum juga punya ruang ditandai Lagged dan diputus.
+func (f *ActivityFeed) Publish(events []ActivityFeedEvent) {
+	if len(events) == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for start := 0; start < len(events); start += f.bufferSize {
+		chunk := events[start:min(start+f.bufferSize, len(events))]
+		ctx, cancel := context.WithTimeout(context.Background(), f.lagGrace)
+		for sub := range f.subs {
+			if !sub.send(ctx, chunk) {
+				sub.lagged = true
+				delete(f.subs, sub)
+				close(sub.events)
+			}
+		}
+		cancel()
+	}
+	if last := events[len(events)-1].ID; last > f.lastID {
+		f.lastID = last
+	}
+}
+
+// send memasukkan chunk ke antrean subscriber; menunggu ruang kosong sampai ctx selesai. false jika tenggat lewat sebelum semua event masuk.
+func (s *FeedSubscription) send(ctx context.Context, chunk []ActivityFeedEvent) bool {
+	for _, ev := range chunk {
+		// Coba tanpa menunggu dulu: jika tenggat sudah lewat (habis menunggu subscriber lain) tetapi antrean masih punya ruang, event tetap dikirim.
+		select {
+		case s.events <- ev:
+			continue
+		default:
+		}
+		select {
+		case s.events <- ev:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// Poll membaca aktivitas baru sejak watermark dan mempublikasikannya, batch demi batch; mengembalikan jumlah yang dipublikasikan.
+// Run pertama hanya menginisialisasi watermark (riwayat tidak dipublikasikan); tanpa subscriber, watermark langsung dimajukan ke id terbesar.
+func (f *ActivityFeed) Poll(repo *repository.ActivityFeedRepository) (int, error) {
+	f.mu.Lock()
+	ready, idle, lastID := f.ready, len(f.subs) == 0, f.lastID
+	f.mu.Unlock()
+
+	if !ready || idle {
+		latest, err := repo.LatestID()
+		if err != nil {
+			return 0, err
+		}
+		f.mu.Lock()
+		// Subscriber yang baru masuk sejak pengecekan di atas sudah memegang watermark lama; jangan lompati aktivitas untuknya.
+		if latest > f.lastID && (!f.ready || len(f.subs) == 0) {
+			f.lastID = latest
+		}
+		f.ready = true
+		f.mu.Unlock()
+		return 0, nil
+	}
+
+	published := 0
+	for {
+		activities, err := repo.After(lastID, config.StreamPollBatchSize, repository.ActivityFilter{})
+		if err != nil {
+			return published, err
+		}
+		events := make([]ActivityFeedEvent, len(activities))
+		for i, a := range activities {
+			events[i] = ActivityFeedEvent{ID: a.ID, Activity: dto.ToDTO(a)}
+		}
+		f.Publish(events)
+		published += len(events)
+		if len(activities) < config.StreamPollBatchSize {
+			return published, nil
+		}
+		lastID = activities[len(activities)-1].ID
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivityFeedPublishLargePoll(t *testing.T) {
+	feed := NewActivityFeed(256, 500*time.Millisecond)
+	fast := feed.Subscribe()
+	slow := feed.Subscribe()
+
+	// Konsumen cepat meniru handler stream: menguras antrean per batch lalu jeda singkat (round-trip MatchIDs).
+	received := make(chan []int64)
+	go func() {
+		var ids []int64
+		for ev := range fast.Events() {
+			ids = append(ids, ev.ID)
+			if len(fast.Events()) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		received <- ids
+	}()
+
+	events := make([]ActivityFeedEvent, 1000) // Satu poll penuh (StreamPollBatchSize).
+	for i := range events {
+		events[i] = ActivityFeedEvent{ID: int64(i + 1)}
+	}
+	feed.Publish(events)
+
+	if !slow.Lagged() {
+		t.Errorf("subscriber yang tidak membaca seharusnya Lagged")
+	}
+	if fast.Lagged() {
+		t.Fatalf("subscriber cepat tidak boleh Lagged")
+	}
+	feed.Unsubscribe(fast)
+	ids := <-received
+	if len(ids) != len(events) {
+		t.Fatalf("subscriber cepat menerima %d event, want %d", len(ids), len(events))
+	}
+	for i, id := range ids {
+		if id != int64(i+1) {
+			t.Fatalf("event ke-%d id %d, want %d", i, id, i+1)
+		}
+	}
+	if from := feed.Subscribe().From(); from != 1000 {
+		t.Errorf("watermark = %d, want 1000", from)
+	}
+}
@@ -0,0 +1,604 @@
+// File alert_rule_service.go: aturan peringatan yang didefinisikan admin atas metrik dashboard (tabel alert_rules) dan riwayat firing-nya (alert_rule_firings).
+//
+// Satu aturan = metrik (agregat ActivityLogRepository) atas aktivitas yang cocok dengan filter dalam jendela bergulir yang berakhir saat evaluasi,
+// dibandingkan dengan ambang. Evaluate (job alert-rules) membuka firing saat kondisi terpenuhi dan belum ada firing terbuka (deduplikasi),
+// memperbarui nilai selama kondisi masih terpenuhi, dan menutupnya (resolved) saat kondisi tidak lagi terpenuhi. Cooldown menahan firing baru
+// sampai cooldown sejak firing sebelumnya dimulai lewat. Penerima (role dan user eksplisit) mendapat notifikasi in-app saat firing dan resolved.
+//
+// tanggal berisi jam dinding CSV (zona ACTIVITY_TIMEZONE) yang disimpan apa adanya sebagai UTC, sehingga batas jendela dihitung dalam konvensi yang sama
+// (config.ActivityNow), bukan sebagai instan UTC sebenarnya. Jendela bergulir hanya bermakna relatif terhadap latensi impor: aktivitas baru terlihat
+// setelah cmd/import memuatnya, jadi jendela yang lebih pendek dari jeda impor bisa kosong (aturan "tanpa aktivitas" ikut firing) dan
+// lonjakan yang diimpor terlambat dinilai pada evaluasi setelah impor selama masih berada di dalam jendela.
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAlertRuleNotFound       = errors.New("aturan peringatan tidak ditemukan")
+	ErrAlertRuleNameEmpty      = errors.New("nama aturan wajib diisi")
+	ErrAlertRuleMetric         = errors.New("metric harus salah satu dari: total_activities, successful_logins, logout_errors, unique_users")
+	ErrAlertRuleComparator     = errors.New("comparator harus salah satu dari: gt, gte, lt, lte, eq")
+	ErrAlertRuleWindow         = fmt.Errorf("window_minutes harus antara %d dan %d", config.AlertRuleMinWindow, config.AlertRuleMaxWindow)
+	ErrAlertRuleCooldown       = fmt.Errorf("cooldown_minutes harus antara 0 dan %d", config.AlertRuleMaxCooldown)
+	ErrAlertRuleThreshold      = errors.New("threshold tidak boleh negatif")
+	ErrAlertRuleFilter         = errors.New("filter harus berupa objek filter aktivitas yang valid")
+	ErrAlertRuleFilterDates    = errors.New("filter tidak boleh memuat start_date, end_date, since, atau until; gunakan window_minutes")
+	ErrAlertRuleNoRecipients   = errors.New("aturan harus memiliki minimal satu penerima (recipient_roles atau recipient_user_ids)")
+	ErrAlertRuleRecipientRole  = errors.New("recipient_roles berisi role yang tidak dikenal")
+	ErrAlertRuleRecipientUser  = errors.New("recipient_user_ids berisi user yang tidak ditemukan atau tidak aktif")
+	ErrAlertRuleTooManyTargets = fmt.Errorf("recipient_user_ids maksimal %d user", config.AlertRuleMaxRecipients)
+)
+
+// notificationRelatedAlertRule nilai notifications.related_entity untuk notifikasi aturan peringatan (related_id = id firing).
+const notificationRelatedAlertRule = "alert_rule_firing"
+
+// alertMetricLabels label metrik untuk pesan notifikasi.
+var alertMetricLabels = map[string]string{
+	entity.AlertMetricTotalActivities: "jumlah aktivitas",
+	entity.AlertMetricSuccessLogins:   "login sukses",
+	entity.AlertMetricLogoutErrors:    "error logout",
+	entity.AlertMetricUniqueUsers:     "user unik",
+}
+
+// AlertRuleResult ringkasan satu Evaluate.
+type AlertRuleResult struct {
+	Evaluated  int // Aturan aktif yang dinilai.
+	Fired      int // Firing baru.
+	Resolved   int // Firing yang ditutup.
+	Suppressed int // Kondisi terpenuhi tetapi masih dalam cooldown.
+	Failed     int // Aturan yang gagal dinilai (dicatat ke log, dicoba lagi di run berikutnya).
+}
+
+// AlertFiringListFilter filter riwayat firing; field kosong diabaikan.
+type AlertFiringListFilter struct {
+	RuleID   int64
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// AlertRuleService CRUD dan evaluasi aturan peringatan.
+type AlertRuleService struct {
+	db *gorm.DB
+}
+
+// NewAlertRuleService membuat AlertRuleService.
+func NewAlertRuleService(db *gorm.DB) *AlertRuleService {
+	return &AlertRuleService{db: db}
+}
+
+// List mengembalikan semua aturan terurut nama.
+func (s *AlertRuleService) List() ([]entity.AlertRule, error) {
+	var rules []entity.AlertRule
+	if err := s.db.Order("name").Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Get mengembalikan satu aturan beserta firing yang sedang terbuka (nil jika tidak ada).
+func (s *AlertRuleService) Get(id int64) (*entity.AlertRule, *entity.AlertRuleFiring, error) {
+	var rule entity.AlertRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrAlertRuleNotFound
+		}
+		return nil, nil, err
+	}
+	firing, err := openFiring(s.db, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &rule, firing, nil
+}
+
+// Create memvalidasi dan menyimpan aturan baru.
+func (s *AlertRuleService) Create(actor AuditActor, req entity.AlertRuleRequest) (*entity.AlertRule, error) {
+	rule := &entity.AlertRule{CreatedBy: actor.UserID}
+	if err := s.applyRequest(rule, req); err != nil {
+		return nil, err
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rule).Error; err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionAlertRuleCreate,
+			TargetType: AuditTargetAlertRule,
+			TargetID:   strconv.FormatInt(rule.ID, 10),
+			After:      rule,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Update mengganti definisi aturan. Firing yang sedang terbuka ditutup (resolved, tanpa resolved_value) jika aturan dinonaktifkan atau kondisinya
+// berubah (alertRuleRedefined): Evaluate hanya menilai aturan aktif, dan firing lama tidak lagi mewakili definisi baru. Perubahan lain
+// (nama, cooldown, penerima) membiarkan firing terbuka dinilai pada evaluasi berikutnya.
+func (s *AlertRuleService) Update(actor AuditActor, id int64, req entity.AlertRuleRequest) (*entity.AlertRule, error) {
+	var rule entity.AlertRule
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rule, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAlertRuleNotFound
+			}
+			return err
+		}
+		before := rule
+		if err := s.applyRequest(&rule, req); err != nil {
+			return err
+		}
+		rule.UpdatedAt = time.Now()
+		if err := tx.Save(&rule).Error; err != nil {
+			return err
+		}
+		if alertRuleRedefined(&before, &rule) {
+			if err := s.closeOpenFiring(tx, &rule, rule.UpdatedAt); err != nil {
+				return err
+			}
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionAlertRuleUpdate,
+			TargetType: AuditTargetAlertRule,
+			TargetID:   strconv.FormatInt(rule.ID, 10),
+			Before:     before,
+			After:      rule,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Delete menghapus aturan beserta riwayat firing-nya.
+func (s *AlertRuleService) Delete(actor AuditActor, id int64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var rule entity.AlertRule
+		if err := tx.First(&rule, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAlertRuleNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&rule).Error; err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionAlertRuleDelete,
+			TargetType: AuditTargetAlertRule,
+			TargetID:   strconv.FormatInt(rule.ID, 10),
+			Before:     rule,
+		})
+	})
+}
+
+// Firings mengembalikan riwayat firing terbaru dulu sesuai filter beserta total.
+func (s *AlertRuleService) Firings(f AlertFiringListFilter) ([]entity.AlertRuleFiring, int64, error) {
+	query := s.db.Model(&entity.AlertRuleFiring{})
+	if f.RuleID > 0 {
+		query = query.Where("alert_rule_firings.rule_id = ?", f.RuleID)
+	}
+	if f.Status != "" {
+		query = query.Where("alert_rule_firings.status = ?", f.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var firings []entity.AlertRuleFiring
+	offset := (f.Page - 1) * f.PageSize
+	err := query.Select("alert_rule_firings.*, r.name AS rule_name").
+		Joins("JOIN alert_rules r ON r.id = alert_rule_firings.rule_id").
+		Order("alert_rule_firings.fired_at DESC").Order("alert_rule_firings.id DESC").
+		Offset(offset).Limit(f.PageSize).Find(&firings).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return firings, total, nil
+}
+
+// applyRequest memvalidasi req lalu menyalinnya ke rule. Filter dinormalisasi dan disimpan ulang sebagai JSON ActivityFilter.
+func (s *AlertRuleService) applyRequest(rule *entity.AlertRule, req entity.AlertRuleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return ErrAlertRuleNameEmpty
+	}
+	if !slices.Contains(entity.AlertMetrics, req.Metric) {
+		return ErrAlertRuleMetric
+	}
+	if _, ok := entity.AlertComparators[req.Comparator]; !ok {
+		return ErrAlertRuleComparator
+	}
+	if req.WindowMinutes < config.AlertRuleMinWindow || req.WindowMinutes > config.AlertRuleMaxWindow {
+		return ErrAlertRuleWindow
+	}
+	if req.CooldownMinutes < 0 || req.CooldownMinutes > config.AlertRuleMaxCooldown {
+		return ErrAlertRuleCooldown
+	}
+	if req.Threshold < 0 {
+		return ErrAlertRuleThreshold
+	}
+	filter, err := parseAlertFilter(req.Filter)
+	if err != nil {
+		return err
+	}
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return err
+	}
+	roles, userIDs, err := s.validateRecipients(req.RecipientRoles, req.RecipientUserIDs)
+	if err != nil {
+		return err
+	}
+
+	rule.Name = name
+	rule.Description = strings.TrimSpace(req.Description)
+	rule.Metric = req.Metric
+	rule.Filter = string(filterJSON)
+	rule.WindowMinutes = req.WindowMinutes
+	rule.Comparator = req.Comparator
+	rule.Threshold = req.Threshold
+	rule.CooldownMinutes = req.CooldownMinutes
+	rule.RecipientRoles = strings.Join(roles, ",")
+	rule.RecipientUserIDs = joinInts(userIDs)
+	rule.IsActive = req.IsActive == nil || *req.IsActive
+	return nil
+}
+
+// parseAlertFilter mengurai filter aturan (kosong/null = tanpa filter) menjadi ActivityFilter tervalidasi tanpa tanggal.
+func parseAlertFilter(raw json.RawMessage) (repository.ActivityFilter, error) {
+	var filter repository.ActivityFilter
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&filter); err != nil {
+			return filter, ErrAlertRuleFilter
+		}
+	}
+	if filter.StartDate != "" || filter.EndDate != "" || filter.Since != nil || filter.Until != nil {
+		return filter, ErrAlertRuleFilterDates
+	}
+	filter = filter.Normalize()
+	if err := filter.Validate(); err != nil {
+		return filter, fmt.Errorf("%w: %v", ErrAlertRuleFilter, err)
+	}
+	return filter, nil
+}
+
+// validateRecipients memeriksa role dan user penerima (user harus ada dan aktif); hasil tanpa duplikat.
+func (s *AlertRuleService) validateRecipients(roles []string, userIDs []int) ([]string, []int, error) {
+	var outRoles []string
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		if !entity.IsValidRole(role) {
+			return nil, nil, ErrAlertRuleRecipientRole
+		}
+		if !slices.Contains(outRoles, role) {
+			outRoles = append(outRoles, role)
+		}
+	}
+	seen := map[int]bool{}
+	var outUsers []int
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			outUsers = append(outUsers, id)
+		}
+	}
+	if len(outUsers) > config.AlertRuleMaxRecipients {
+		return nil, nil, ErrAlertRuleTooManyTargets
+	}
+	if len(outRoles) == 0 && len(outUsers) == 0 {
+		return nil, nil, ErrAlertRuleNoRecipients
+	}
+	if len(outUsers) > 0 {
+		var count int64
+		if err := s.db.Model(&entity.User{}).Where("id IN ? AND is_active = ?", outUsers, true).Count(&count).Error; err != nil {
+			return nil, nil, err
+		}
+		if int(count) != len(outUsers) {
+			return nil, nil, ErrAlertRuleRecipientUser
+		}
+	}
+	return outRoles, outUsers, nil
+}
+
+// Evaluate menilai semua aturan aktif pada waktu now. Kegagalan satu aturan dicatat ke log dan tidak menghentikan aturan lain.
+func (s *AlertRuleService) Evaluate(now time.Time) (*AlertRuleResult, error) {
+	var rules []entity.AlertRule
+	if err := s.db.Where("is_active = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	result := &AlertRuleResult{}
+	repo := repository.NewActivityLogRepository(s.db)
+	for i := range rules {
+		result.Evaluated++
+		if err := s.evaluateRule(repo, &rules[i], now, result); err != nil {
+			result.Failed++
+			log.Printf("[ERROR] alert rule %d (%s): %v", rules[i].ID, rules[i].Name, err)
+		}
+	}
+	return result, nil
+}
+
+// evaluateRule menghitung metrik satu aturan lalu membuka, memperbarui, atau menutup firing-nya dalam satu transaksi.
+func (s *AlertRuleService) evaluateRule(repo repository.ActivityLogRepository, rule *entity.AlertRule, now time.Time, result *AlertRuleResult) error {
+	value, err := alertMetricValue(repo, rule, now)
+	if err != nil {
+		return err
+	}
+	met := compareAlert(value, rule.Comparator, rule.Threshold)
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.AlertRule{}).Where("id = ?", rule.ID).
+			Updates(map[string]interface{}{"last_value": value, "last_evaluated_at": now}).Error; err != nil {
+			return err
+		}
+		firing, err := openFiring(tx.Clauses(clause.Locking{Strength: "UPDATE"}), rule.ID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case met && firing != nil:
+			firing.Value = value
+			if moreExtreme(value, firing.PeakValue, firing.Comparator) {
+				firing.PeakValue = value
+			}
+			firing.LastEvaluatedAt = now
+			return tx.Model(firing).Select("value", "peak_value", "last_evaluated_at").Updates(firing).Error
+
+		case met:
+			var last entity.AlertRuleFiring
+			err := tx.Where("rule_id = ?", rule.ID).Order("fired_at DESC").Limit(1).Find(&last).Error
+			if err != nil {
+				return err
+			}
+			if last.ID != 0 && now.Before(last.FiredAt.Add(time.Duration(rule.CooldownMinutes)*time.Minute)) {
+				result.Suppressed++
+				return nil
+			}
+			firing = &entity.AlertRuleFiring{
+				RuleID:          rule.ID,
+				Status:          entity.AlertFiringStatusFiring,
+				Value:           value,
+				PeakValue:       value,
+				Threshold:       rule.Threshold,
+				Comparator:      rule.Comparator,
+				Message:         alertMessage(rule, value),
+				FiredAt:         now,
+				LastEvaluatedAt: now,
+			}
+			if err := tx.Create(firing).Error; err != nil {
+				return err
+			}
+			result.Fired++
+			return s.notify(tx, rule, firing, "Peringatan: "+rule.Name, firing.Message, "warning")
+
+		case firing != nil:
+			firing.Status = entity.AlertFiringStatusResolved
+			firing.LastEvaluatedAt = now
+			firing.ResolvedAt = &now
+			firing.ResolvedValue = &value
+			if err := tx.Model(firing).Select("status", "last_evaluated_at", "resolved_at", "resolved_value").Updates(firing).Error; err != nil {
+				return err
+			}
+			result.Resolved++
+			msg := fmt.Sprintf("Kondisi aturan \"%s\" tidak lagi terpenuhi: %s %s dalam %s terakhir (ambang %s %s).",
+				rule.Name, alertMetricLabels[rule.Metric], formatAlertValue(value), windowLabel(rule.WindowMinutes),
+				entity.AlertComparators[rule.Comparator], formatAlertValue(rule.Threshold))
+			return s.notify(tx, rule, firing, "Pulih: "+rule.Name, msg, "success")
+		}
+		return nil
+	})
+}
+
+// alertMetricValue menghitung metrik aturan atas aktivitas dalam jendela [end - window, end) dengan agregat ActivityLogRepository;
+// end = now dalam konvensi kolom tanggal (config.ActivityNow).
+func alertMetricValue(repo repository.ActivityLogRepository, rule *entity.AlertRule, now time.Time) (float64, error) {
+	filter, err := parseAlertFilter(json.RawMessage(rule.Filter))
+	if err != nil {
+		return 0, err
+	}
+	until := config.ActivityNow(now)
+	since := until.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+	filter.Since, filter.Until = &since, &until
+
+	var count int64
+	switch rule.Metric {
+	case entity.AlertMetricTotalActivities:
+		count, err = repo.GetTotalCount(filter)
+	case entity.AlertMetricSuccessLogins:
+		count, err = repo.GetCountByStatus("SUCCESS", filter)
+	case entity.AlertMetricLogoutErrors:
+		count, err = repo.GetCountByStatus("FAILED", filter)
+	case entity.AlertMetricUniqueUsers:
+		count, err = repo.GetUniqueUsersCount(filter)
+	default:
+		return 0, ErrAlertRuleMetric
+	}
+	return float64(count), err
+}
+
+// compareAlert mengembalikan true jika value memenuhi pembanding terhadap threshold.
+func compareAlert(value float64, comparator string, threshold float64) bool {
+	switch comparator {
+	case entity.AlertComparatorGT:
+		return value > threshold
+	case entity.AlertComparatorGTE:
+		return value >= threshold
+	case entity.AlertComparatorLT:
+		return value < threshold
+	case entity.AlertComparatorLTE:
+		return value <= threshold
+	case entity.AlertComparatorEQ:
+		return value == threshold
+	}
+	return false
+}
+
+// moreExtreme mengembalikan true jika value lebih jauh melewati ambang daripada peak (lebih besar untuk gt/gte, lebih kecil untuk lt/lte).
+func moreExtreme(value, peak float64, comparator string) bool {
+	switch comparator {
+	case entity.AlertComparatorGT, entity.AlertComparatorGTE:
+		return value > peak
+	case entity.AlertComparatorLT, entity.AlertComparatorLTE:
+		return value < peak
+	}
+	return false
+}
+
+// alertRuleRedefined true jika perubahan before → after membuat firing terbuka tidak lagi berlaku: aturan dinonaktifkan, atau metrik, filter,
+// jendela, pembanding, atau ambang berubah. Filter dibandingkan setelah Normalize sehingga penulisan ulang JSON yang ekuivalen tidak dihitung.
+func alertRuleRedefined(before, after *entity.AlertRule) bool {
+	if !after.IsActive {
+		return true
+	}
+	if before.Metric != after.Metric || before.WindowMinutes != after.WindowMinutes ||
+		before.Comparator != after.Comparator || before.Threshold != after.Threshold {
+		return true
+	}
+	prev, errPrev := parseAlertFilter(json.RawMessage(before.Filter))
+	next, errNext := parseAlertFilter(json.RawMessage(after.Filter))
+	return errPrev != nil || errNext != nil || !reflect.DeepEqual(prev, next)
+}
+
+// closeOpenFiring menutup firing terbuka aturan (jika ada) tanpa nilai evaluasi dan memberi tahu penerima bahwa firing ditutup karena perubahan aturan.
+func (s *AlertRuleService) closeOpenFiring(tx *gorm.DB, rule *entity.AlertRule, now time.Time) error {
+	firing, err := openFiring(tx.Clauses(clause.Locking{Strength: "UPDATE"}), rule.ID)
+	if err != nil || firing == nil {
+		return err
+	}
+	firing.Status = entity.AlertFiringStatusResolved
+	firing.LastEvaluatedAt = now
+	firing.ResolvedAt = &now
+	if err := tx.Model(firing).Select("status", "last_evaluated_at", "resolved_at").Updates(firing).Error; err != nil {
+		return err
+	}
+	reason := "definisi aturan diubah"
+	if !rule.IsActive {
+		reason = "aturan dinonaktifkan"
+	}
+	msg := fmt.Sprintf("Firing aturan \"%s\" ditutup karena %s.", rule.Name, reason)
+	return s.notify(tx, rule, firing, "Ditutup: "+rule.Name, msg, "info")
+}
+
+// openFiring mengembalikan firing aturan yang masih terbuka; nil jika tidak ada.
+func openFiring(db *gorm.DB, ruleID int64) (*entity.AlertRuleFiring, error) {
+	var firings []entity.AlertRuleFiring
+	if err := db.Where("rule_id = ? AND status = ?", ruleID, entity.AlertFiringStatusFiring).Limit(1).Find(&firings).Error; err != nil {
+		return nil, err
+	}
+	if len(firings) == 0 {
+		return nil, nil
+	}
+	return &firings[0], nil
+}
+
+// notify mengirim notifikasi in-app ke user aktif dengan role penerima dan user penerima eksplisit (tanpa duplikat).
+func (s *AlertRuleService) notify(tx *gorm.DB, rule *entity.AlertRule, firing *entity.AlertRuleFiring, title, message, kind string) error {
+	recipients := map[int]bool{}
+	var userIDs []int
+	add := func(id int) {
+		if !recipients[id] {
+			recipients[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+	for _, role := range rule.RoleList() {
+		users, err := activeUsersWithRole(tx, role, nil)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			add(u.ID)
+		}
+	}
+	if ids := rule.UserIDList(); len(ids) > 0 {
+		var active []int
+		if err := tx.Model(&entity.User{}).Where("id IN ? AND is_active = ?", ids, true).Order("id").Pluck("id", &active).Error; err != nil {
+			return err
+		}
+		for _, id := range active {
+			add(id)
+		}
+	}
+	if len(userIDs) == 0 {
+		log.Printf("Alert rule %d (%s): tidak ada penerima aktif", rule.ID, rule.Name)
+		return nil
+	}
+
+	id := int(firing.ID)
+	now := time.Now()
+	notifications := make([]entity.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notifications = append(notifications, entity.Notification{
+			UserID:        userID,
+			Title:         title,
+			Message:       message,
+			Type:          kind,
+			RelatedEntity: notificationRelatedAlertRule,
+			RelatedID:     &id,
+			CreatedAt:     now,
+		})
+	}
+	return tx.Create(&notifications).Error
+}
+
+// alertMessage pesan firing, mis. `error logout 63 dalam 1 jam terakhir (ambang > 50). Filter: {"clusters":["X"]}`.
+func alertMessage(rule *entity.AlertRule, value float64) string {
+	msg := fmt.Sprintf("Aturan \"%s\" terpenuhi: %s %s dalam %s terakhir (ambang %s %s).",
+		rule.Name, alertMetricLabels[rule.Metric], formatAlertValue(value), windowLabel(rule.WindowMinutes),
+		entity.AlertComparators[rule.Comparator], formatAlertValue(rule.Threshold))
+	if rule.Filter != "" && rule.Filter != "{}" {
+		msg += " Filter: " + rule.Filter
+	}
+	return msg
+}
+
+// windowLabel menulis jendela dalam satuan terbesar yang pas (hari, jam, menit).
+func windowLabel(minutes int) string {
+	switch {
+	case minutes%(24*60) == 0:
+		return fmt.Sprintf("%d hari", minutes/(24*60))
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d jam", minutes/60)
+	}
+	return fmt.Sprintf("%d menit", minutes)
+}
+
+// formatAlertValue menulis angka tanpa desimal jika bulat.
+func formatAlertValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// joinInts menggabungkan id dengan koma.
+func joinInts(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
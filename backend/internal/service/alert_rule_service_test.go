@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+)
+
+func TestAlertRuleRedefined(t *testing.T) {
+	base := entity.AlertRule{
+		Name:            "Error logout cluster X",
+		Metric:          entity.AlertMetricLogoutErrors,
+		Filter:          `{"clusters": ["X", "Y"]}`, // Bentuk jsonb dari database.
+		WindowMinutes:   60,
+		Comparator:      entity.AlertComparatorGT,
+		Threshold:       50,
+		CooldownMinutes: 30,
+		RecipientRoles:  "admin",
+		IsActive:        true,
+	}
+
+	tests := []struct {
+		name   string
+		change func(r *entity.AlertRule)
+		want   bool
+	}{
+		{"tanpa perubahan", func(r *entity.AlertRule) {}, false},
+		{"nama, cooldown, dan penerima berubah", func(r *entity.AlertRule) {
+			r.Name, r.CooldownMinutes, r.RecipientRoles = "Baru", 120, "admin,unit_head"
+		}, false},
+		{"filter ekuivalen ditulis ulang", func(r *entity.AlertRule) { r.Filter = `{"clusters":["Y","X"]}` }, false},
+		{"dinonaktifkan", func(r *entity.AlertRule) { r.IsActive = false }, true},
+		{"metrik berubah", func(r *entity.AlertRule) { r.Metric = entity.AlertMetricTotalActivities }, true},
+		{"filter berubah", func(r *entity.AlertRule) { r.Filter = `{"clusters":["X"]}` }, true},
+		{"filter dihapus", func(r *entity.AlertRule) { r.Filter = `{}` }, true},
+		{"jendela berubah", func(r *entity.AlertRule) { r.WindowMinutes = 120 }, true},
+		{"pembanding berubah", func(r *entity.AlertRule) { r.Comparator = entity.AlertComparatorGTE }, true},
+		{"ambang berubah", func(r *entity.AlertRule) { r.Threshold = 40 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := base, base
+			tt.change(&after)
+			if got := alertRuleRedefined(&before, &after); got != tt.want {
+				t.Errorf("alertRuleRedefined = %v, ingin %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+// File anomaly_service.go: detektor anomali aktivitas (dijalankan berkala oleh AnomalyDetectionJob) dan tindak lanjut temuannya.
+//
+// Detektor: volume harian per satker (lonjakan dan penurunan), unduhan harian per user (lonjakan), error logout harian (lonjakan).
+// Baseline musiman: hari yang sama pada AnomalyBaselineWeeks minggu sebelumnya, tanpa hari libur (ref_holidays) dan hari yang belum ada datanya.
+// Skor = z robust (observed − median) / (1,4826 × MAD); jika MAD 0 dipakai rata-rata deviasi absolut, lalu √median (minimal 1).
+// Penurunan satker tidak dinilai jika hari target adalah hari libur. Temuan disimpan ke tabel anomalies; Acknowledge/Dismiss dicatat ke audit_events.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAnomalyNotFound     = errors.New("anomali tidak ditemukan")
+	ErrAnomalyStatus       = errors.New("status anomali tidak dapat diubah")
+	ErrAnomalyRollupStale  = errors.New("rollup aktivitas belum mutakhir; jalankan cmd/rollup sebelum deteksi anomali")
+	ErrInvalidAnomalyQuery = errors.New("filter anomali tidak valid")
+)
+
+// anomalyDetector satu detektor: deret harian per subjek dan arah yang dinilai.
+type anomalyDetector struct {
+	name        string
+	subjectType string
+	label       string // Format subjek untuk penjelasan; %s = nama subjek (kecuali global).
+	drops       bool   // Penurunan juga dinilai (selain lonjakan).
+	load        func(days []string) ([]repository.DailyCount, error)
+}
+
+// AnomalyDetectResult ringkasan satu run deteksi.
+type AnomalyDetectResult struct {
+	Days    []string // Hari yang dinilai.
+	Skipped []string // Hari yang dilewati (belum ada data atau baseline terlalu pendek).
+	Found   int      // Jumlah anomali yang tersimpan untuk hari-hari yang dinilai.
+}
+
+// AnomalyService deteksi dan tindak lanjut anomali.
+type AnomalyService struct {
+	db *gorm.DB
+}
+
+// NewAnomalyService membuat AnomalyService.
+func NewAnomalyService(db *gorm.DB) *AnomalyService {
+	return &AnomalyService{db: db}
+}
+
+// detectors daftar detektor yang dijalankan untuk setiap hari.
+func (s *AnomalyService) detectors(repo *repository.AnomalyRepository) []anomalyDetector {
+	return []anomalyDetector{
+		{entity.AnomalyDetectorSatkerVolume, entity.AnomalySubjectSatker, "Volume aktivitas satker %s", true, repo.SatkerDailyCounts},
+		{entity.AnomalyDetectorUserDownloads, entity.AnomalySubjectUser, "Jumlah unduhan user %s", false, repo.UserDownloadDailyCounts},
+		{entity.AnomalyDetectorFailedLogouts, entity.AnomalySubjectGlobal, "Jumlah error logout", false, repo.FailedLogoutDailyCounts},
+	}
+}
+
+// Detect menilai AnomalyLookbackDays hari lengkap terakhir (sampai kemarin relatif now). ErrAnomalyRollupStale jika rollup tertinggal dari data mentah.
+func (s *AnomalyService) Detect(now time.Time) (*AnomalyDetectResult, error) {
+	current, err := repository.NewActivityRollupRepository(s.db).Current()
+	if err != nil {
+		return nil, err
+	}
+	if !current {
+		return nil, ErrAnomalyRollupStale
+	}
+
+	today, _ := sqlbuilder.ParseDate(now.Format(sqlbuilder.DateLayout))
+	result := &AnomalyDetectResult{}
+	for i := config.AnomalyLookbackDays; i >= 1; i-- {
+		day := today.AddDays(-i)
+		found, evaluated, err := s.DetectDay(day)
+		if err != nil {
+			return nil, err
+		}
+		if !evaluated {
+			result.Skipped = append(result.Skipped, day.String())
+			continue
+		}
+		result.Days = append(result.Days, day.String())
+		result.Found += found
+	}
+	return result, nil
+}
+
+// DetectDay menjalankan semua detektor untuk satu hari dan menyimpan hasilnya. evaluated=false jika hari itu belum ada datanya
+// atau baseline kurang dari AnomalyMinBaseline hari (temuan lama tidak diubah).
+func (s *AnomalyService) DetectDay(day sqlbuilder.Date) (found int, evaluated bool, err error) {
+	repo := repository.NewAnomalyRepository(s.db)
+	target := day.String()
+
+	candidates := make([]string, 0, config.AnomalyBaselineWeeks+1)
+	for k := config.AnomalyBaselineWeeks; k >= 1; k-- {
+		candidates = append(candidates, day.AddDays(-7*k).String())
+	}
+	candidates = append(candidates, target)
+
+	totals, err := repo.DayTotals(candidates)
+	if err != nil {
+		return 0, false, err
+	}
+	holidays, err := repo.Holidays(candidates)
+	if err != nil {
+		return 0, false, err
+	}
+	if totals[target] == 0 {
+		return 0, false, nil
+	}
+	var baseline []string
+	for _, d := range candidates[:len(candidates)-1] {
+		if totals[d] > 0 && !holidays[d] {
+			baseline = append(baseline, d)
+		}
+	}
+	if len(baseline) < config.AnomalyMinBaseline {
+		return 0, false, nil
+	}
+	days := append(append([]string{}, baseline...), target)
+
+	for _, det := range s.detectors(repo) {
+		rows, err := det.load(days)
+		if err != nil {
+			return 0, false, fmt.Errorf("%s: %w", det.name, err)
+		}
+		anomalies := evaluateAnomalies(det, day, baseline, rows, !holidays[target])
+		if err := repo.Save(det.name, target, anomalies); err != nil {
+			return 0, false, fmt.Errorf("%s: %w", det.name, err)
+		}
+		found += len(anomalies)
+	}
+	return found, true, nil
+}
+
+// evaluateAnomalies menilai setiap subjek pada hari target terhadap baseline-nya; hari baseline tanpa baris dianggap 0.
+// allowDrops=false menonaktifkan penilaian penurunan (hari libur).
+func evaluateAnomalies(det anomalyDetector, day sqlbuilder.Date, baseline []string, rows []repository.DailyCount, allowDrops bool) []entity.Anomaly {
+	type subject struct {
+		name   string
+		counts map[string]int64
+	}
+	subjects := map[int64]*subject{}
+	for _, row := range rows {
+		sub, ok := subjects[row.SubjectID]
+		if !ok {
+			sub = &subject{counts: map[string]int64{}}
+			subjects[row.SubjectID] = sub
+		}
+		if row.SubjectName != "" {
+			sub.name = row.SubjectName
+		}
+		sub.counts[row.Day] += row.Count
+	}
+
+	ids := make([]int64, 0, len(subjects))
+	for id := range subjects {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	target := day.String()
+	now := time.Now()
+	var out []entity.Anomaly
+	for _, id := range ids {
+		sub := subjects[id]
+		values := make([]float64, len(baseline))
+		for i, d := range baseline {
+			values[i] = float64(sub.counts[d])
+		}
+		observed := sub.counts[target]
+		median, mad, score := robustScore(float64(observed), values)
+
+		direction := entity.AnomalySpike
+		if score < 0 {
+			direction = entity.AnomalyDrop
+			if !det.drops || !allowDrops {
+				continue
+			}
+		}
+		if math.Abs(score) < config.AnomalyScoreThreshold || math.Abs(float64(observed)-median) < config.AnomalyMinDeviation {
+			continue
+		}
+
+		name := sub.name
+		if name == "" && det.subjectType != entity.AnomalySubjectGlobal {
+			name = "#" + strconv.FormatInt(id, 10)
+		}
+		out = append(out, entity.Anomaly{
+			Detector:       det.name,
+			SubjectType:    det.subjectType,
+			SubjectID:      id,
+			SubjectName:    name,
+			Day:            day.Time(),
+			Observed:       observed,
+			BaselineMedian: median,
+			BaselineMAD:    mad,
+			Score:          math.Round(score*100) / 100,
+			Direction:      direction,
+			Severity:       anomalySeverity(score),
+			Explanation:    anomalyExplanation(det, name, day, observed, len(baseline), median, mad, score),
+			Status:         entity.AnomalyStatusOpen,
+			DetectedAt:     now,
+		})
+	}
+	return out
+}
+
+// robustScore mengembalikan median, MAD, dan skor z robust observed terhadap values.
+func robustScore(observed float64, values []float64) (median, mad, score float64) {
+	median = medianOf(values)
+	deviations := make([]float64, len(values))
+	var sumDev float64
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+		sumDev += deviations[i]
+	}
+	mad = medianOf(deviations)
+
+	scale := 1.4826 * mad
+	if scale == 0 && len(values) > 0 {
+		scale = 1.253314 * sumDev / float64(len(values))
+	}
+	if scale == 0 {
+		scale = math.Max(math.Sqrt(median), 1)
+	}
+	return median, mad, (observed - median) / scale
+}
+
+// medianOf median dari values (0 jika kosong); values tidak diubah.
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// anomalySeverity memetakan skor absolut ke severity.
+func anomalySeverity(score float64) string {
+	switch abs := math.Abs(score); {
+	case abs >= config.AnomalyScoreHigh:
+		return entity.AnomalySeverityHigh
+	case abs >= config.AnomalyScoreMedium:
+		return entity.AnomalySeverityMedium
+	default:
+		return entity.AnomalySeverityLow
+	}
+}
+
+// anomalyExplanation penjelasan singkat temuan untuk ditampilkan ke pengguna.
+func anomalyExplanation(det anomalyDetector, name string, day sqlbuilder.Date, observed int64, baselineDays int, median, mad, score float64) string {
+	subject := det.label
+	if strings.Contains(subject, "%s") {
+		subject = fmt.Sprintf(subject, name)
+	}
+	dayName := repository.HeatmapDays[(int(day.Time().Weekday())+6)%7]
+	kind := "lonjakan"
+	if score < 0 {
+		kind = "penurunan tajam"
+	}
+	return fmt.Sprintf("%s pada %s (%s): %d, median %d hari %s sebelumnya %.1f (MAD %.1f); skor z robust %.1f, %s.",
+		subject, day.String(), dayName, observed, baselineDays, dayName, median, mad, score, kind)
+}
+
+// List mengembalikan anomali sesuai filter beserta total.
+func (s *AnomalyService) List(f repository.AnomalyListFilter) ([]entity.Anomaly, int64, error) {
+	anomalies, total, err := repository.NewAnomalyRepository(s.db).List(f)
+	if errors.Is(err, sqlbuilder.ErrInvalidDate) {
+		return nil, 0, ErrInvalidAnomalyQuery
+	}
+	return anomalies, total, err
+}
+
+// Acknowledge menandai anomali open sebagai sudah ditindaklanjuti.
+func (s *AnomalyService) Acknowledge(actor AuditActor, id int64, note string) (*entity.Anomaly, error) {
+	return s.setStatus(actor, id, note, entity.AnomalyStatusAcknowledged, AuditActionAnomalyAcknowledge, entity.AnomalyStatusOpen)
+}
+
+// Dismiss menandai anomali open atau acknowledged sebagai bukan masalah (false positive).
+func (s *AnomalyService) Dismiss(actor AuditActor, id int64, note string) (*entity.Anomaly, error) {
+	return s.setStatus(actor, id, note, entity.AnomalyStatusDismissed, AuditActionAnomalyDismiss, entity.AnomalyStatusOpen, entity.AnomalyStatusAcknowledged)
+}
+
+// setStatus mengubah status anomali jika status saat ini termasuk from, lalu mencatat audit dalam transaksi yang sama.
+func (s *AnomalyService) setStatus(actor AuditActor, id int64, note, status, action string, from ...string) (*entity.Anomaly, error) {
+	var anomaly entity.Anomaly
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&anomaly, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAnomalyNotFound
+			}
+			return err
+		}
+		allowed := false
+		for _, f := range from {
+			allowed = allowed || anomaly.Status == f
+		}
+		if !allowed {
+			return ErrAnomalyStatus
+		}
+
+		before := anomaly
+		now := time.Now()
+		anomaly.Status = status
+		anomaly.StatusChangedBy = actor.UserID
+		anomaly.StatusChangedAt = &now
+		anomaly.StatusNote = nil
+		if note = strings.TrimSpace(note); note != "" {
+			anomaly.StatusNote = &note
+		}
+		if err := tx.Save(&anomaly).Error; err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     action,
+			TargetType: AuditTargetAnomaly,
+			TargetID:   strconv.FormatInt(anomaly.ID, 10),
+			Before:     before,
+			After:      anomaly,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &anomaly, nil
+}
@@ -0,0 +1,305 @@
+// File audit_chain.go: hash chain anti-tamper untuk audit_events dan checkpoint harian bertanda tangan.
+//
+// Setiap baris baru menyimpan prev_hash (hash baris berantai sebelumnya) dan hash = SHA-256(prev_hash + isi baris kanonik). Penulisan diserialkan dengan
+// pg_advisory_xact_lock sehingga urutan id = urutan rantai. VerifyChain menelusuri seluruh baris dan melaporkan tautan pertama yang rusak
+// (isi diubah, baris dihapus/disisipkan). Checkpoint (id + hash terakhir, ditandatangani HMAC-SHA256) ditulis ke file di luar database
+// agar penulisan ulang seluruh rantai di DB pun tetap terdeteksi.
+package service
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey kunci pg_advisory_xact_lock untuk menyerialkan penulisan audit_events berantai.
+const auditChainLockKey = 7310030031
+
+// auditChainTimeLayout format created_at di payload hash: wall clock tanpa zona, presisi mikrodetik (sesuai kolom TIMESTAMP).
+const auditChainTimeLayout = "2006-01-02T15:04:05.000000"
+
+var (
+	ErrAuditCheckpointKeyNotSet = errors.New("AUDIT_CHECKPOINT_KEY belum diset")
+)
+
+// Alasan tautan rantai rusak.
+const (
+	AuditChainHashMismatch     = "hash_mismatch"      // Isi baris tidak cocok dengan hash tersimpan (baris diubah).
+	AuditChainPrevMismatch     = "prev_hash_mismatch" // prev_hash tidak sama dengan hash baris sebelumnya (baris dihapus/disisipkan).
+	AuditChainMissingHash      = "missing_hash"       // Baris tanpa hash muncul setelah rantai dimulai.
+	AuditChainCheckpointBroken = "checkpoint_mismatch"
+)
+
+// AuditChainBreak tautan pertama yang rusak.
+type AuditChainBreak struct {
+	EventID  int64  `json:"event_id"`
+	Reason   string `json:"reason"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// AuditChainReport hasil verifikasi rantai.
+type AuditChainReport struct {
+	Valid           bool             `json:"valid"`
+	Checked         int64            `json:"checked"`       // Jumlah baris berantai yang diperiksa.
+	Unchained       int64            `json:"unchained"`     // Baris lama sebelum hash chain aktif (tidak bisa diverifikasi).
+	LastEventID     int64            `json:"last_event_id"` // id baris berantai terakhir yang valid.
+	LastHash        string           `json:"last_hash"`     // hash baris berantai terakhir yang valid.
+	FirstBroken     *AuditChainBreak `json:"first_broken,omitempty"`
+	CheckpointCount int              `json:"checkpoint_count"` // Jumlah checkpoint yang dicocokkan (0 jika tanpa file checkpoint).
+}
+
+// AuditCheckpoint satu baris file checkpoint (JSON per baris).
+type AuditCheckpoint struct {
+	Date      string `json:"date"`
+	EventID   int64  `json:"event_id"`
+	Hash      string `json:"hash"`
+	Signature string `json:"signature"`
+}
+
+// auditChainPayload isi kanonik satu baris yang di-hash. id tidak ikut (belum diketahui sebelum INSERT); urutan dijaga lewat prev_hash.
+type auditChainPayload struct {
+	PrevHash      string          `json:"prev_hash"`
+	CreatedAt     string          `json:"created_at"`
+	ActorID       *int            `json:"actor_id"`
+	ActorUsername string          `json:"actor_username"`
+	Action        string          `json:"action"`
+	TargetType    string          `json:"target_type"`
+	TargetID      string          `json:"target_id"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+	Diff          json.RawMessage `json:"diff"`
+	IP            string          `json:"ip"`
+	UserAgent     string          `json:"user_agent"`
+	RequestID     string          `json:"request_id"`
+}
+
+// AuditChainHash menghitung hash baris audit dari prev_hash dan isinya. Kolom JSONB dinormalisasi (parse + marshal ulang) karena PostgreSQL menyimpan JSONB dalam bentuk kanonik sendiri.
+func AuditChainHash(e entity.AuditEvent) (string, error) {
+	payload := auditChainPayload{
+		CreatedAt:     e.CreatedAt.Format(auditChainTimeLayout),
+		ActorID:       e.ActorID,
+		ActorUsername: e.ActorUsername,
+		Action:        e.Action,
+		TargetType:    e.TargetType,
+		TargetID:      e.TargetID,
+		IP:            e.IP,
+		UserAgent:     e.UserAgent,
+		RequestID:     e.RequestID,
+	}
+	if e.PrevHash != nil {
+		payload.PrevHash = *e.PrevHash
+	}
+	var err error
+	if payload.Before, err = canonicalJSON(e.BeforeData); err != nil {
+		return "", err
+	}
+	if payload.After, err = canonicalJSON(e.AfterData); err != nil {
+		return "", err
+	}
+	if payload.Diff, err = canonicalJSON(e.Diff); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON mengubah teks JSON ke bentuk kanonik (key objek terurut, tanpa spasi); nil → null.
+func canonicalJSON(s *string) (json.RawMessage, error) {
+	if s == nil {
+		return json.RawMessage("null"), nil
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(*s), &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// appendChained menyimpan event sebagai baris berikutnya di rantai: kunci advisory (berlaku sampai transaksi selesai), ambil hash terakhir, hitung hash, INSERT.
+func appendChained(db *gorm.DB, event *entity.AuditEvent) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
+		var hashes []string
+		if err := tx.Model(&entity.AuditEvent{}).Where("hash IS NOT NULL").Order("id DESC").Limit(1).Pluck("hash", &hashes).Error; err != nil {
+			return err
+		}
+		prev := "" // Baris berantai pertama.
+		if len(hashes) > 0 {
+			prev = hashes[0]
+		}
+		event.PrevHash = &prev
+		// Presisi mikrodetik agar nilai yang di-hash sama dengan yang tersimpan di kolom TIMESTAMP.
+		event.CreatedAt = time.Now().Truncate(time.Microsecond)
+		hash, err := AuditChainHash(*event)
+		if err != nil {
+			return err
+		}
+		event.Hash = &hash
+		return tx.Create(event).Error
+	})
+}
+
+// VerifyChain menelusuri audit_events urut id dan memeriksa hash serta prev_hash setiap baris berantai. Baris tanpa hash sebelum rantai dimulai dihitung sebagai Unchained.
+// Berhenti di tautan rusak pertama (FirstBroken); Valid = true jika tidak ada yang rusak.
+func (s *AuditService) VerifyChain() (*AuditChainReport, error) {
+	report := &AuditChainReport{Valid: true}
+	started := false
+	var batch []entity.AuditEvent
+	res := s.db.Model(&entity.AuditEvent{}).Order("id").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for _, e := range batch {
+			if e.Hash == nil {
+				if !started {
+					report.Unchained++
+					continue
+				}
+				report.FirstBroken = &AuditChainBreak{EventID: e.ID, Reason: AuditChainMissingHash}
+				return errAuditChainStop
+			}
+			started = true
+
+			prev := ""
+			if e.PrevHash != nil {
+				prev = *e.PrevHash
+			}
+			if prev != report.LastHash {
+				report.FirstBroken = &AuditChainBreak{EventID: e.ID, Reason: AuditChainPrevMismatch, Expected: report.LastHash, Actual: prev}
+				return errAuditChainStop
+			}
+			hash, err := AuditChainHash(e)
+			if err != nil {
+				return err
+			}
+			if hash != *e.Hash {
+				report.FirstBroken = &AuditChainBreak{EventID: e.ID, Reason: AuditChainHashMismatch, Expected: hash, Actual: *e.Hash}
+				return errAuditChainStop
+			}
+			report.Checked++
+			report.LastEventID = e.ID
+			report.LastHash = hash
+		}
+		return nil
+	})
+	if res.Error != nil && !errors.Is(res.Error, errAuditChainStop) {
+		return nil, res.Error
+	}
+	report.Valid = report.FirstBroken == nil
+	return report, nil
+}
+
+// errAuditChainStop menghentikan FindInBatches setelah tautan rusak ditemukan.
+var errAuditChainStop = errors.New("audit chain: stop")
+
+// VerifyCheckpoints mencocokkan setiap checkpoint di file dengan hash baris audit_events yang sama. Tanda tangan yang salah atau hash yang berbeda ditandai di report (FirstBroken).
+// report harus hasil VerifyChain yang valid; file yang belum ada dianggap tanpa checkpoint.
+func (s *AuditService) VerifyCheckpoints(report *AuditChainReport, path string, key []byte) error {
+	checkpoints, err := readAuditCheckpoints(path)
+	if err != nil {
+		return err
+	}
+	for _, cp := range checkpoints {
+		if !hmac.Equal([]byte(cp.Signature), []byte(signAuditCheckpoint(cp, key))) {
+			report.FirstBroken = &AuditChainBreak{EventID: cp.EventID, Reason: AuditChainCheckpointBroken, Expected: "valid signature", Actual: cp.Signature}
+			break
+		}
+		var hashes []string
+		if err := s.db.Model(&entity.AuditEvent{}).Where("id = ? AND hash IS NOT NULL", cp.EventID).Pluck("hash", &hashes).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 || hashes[0] != cp.Hash {
+			actual := ""
+			if len(hashes) > 0 {
+				actual = hashes[0]
+			}
+			report.FirstBroken = &AuditChainBreak{EventID: cp.EventID, Reason: AuditChainCheckpointBroken, Expected: cp.Hash, Actual: actual}
+			break
+		}
+		report.CheckpointCount++
+	}
+	report.Valid = report.FirstBroken == nil
+	return nil
+}
+
+// WriteCheckpoint menambahkan checkpoint (tanggal hari ini, id + hash baris berantai terakhir, tanda tangan HMAC) ke file. Rantai diverifikasi dulu; rantai rusak tidak di-checkpoint.
+func (s *AuditService) WriteCheckpoint(path string, key []byte) (*AuditCheckpoint, error) {
+	if len(key) == 0 {
+		return nil, ErrAuditCheckpointKeyNotSet
+	}
+	report, err := s.VerifyChain()
+	if err != nil {
+		return nil, err
+	}
+	if !report.Valid {
+		return nil, fmt.Errorf("rantai audit rusak di event %d (%s); checkpoint tidak ditulis", report.FirstBroken.EventID, report.FirstBroken.Reason)
+	}
+
+	cp := AuditCheckpoint{Date: time.Now().Format("2006-01-02"), EventID: report.LastEventID, Hash: report.LastHash}
+	cp.Signature = signAuditCheckpoint(cp, key)
+	line, err := json.Marshal(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// signAuditCheckpoint menghitung HMAC-SHA256 (hex) dari "date|event_id|hash".
+func signAuditCheckpoint(cp AuditCheckpoint, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(cp.Date + "|" + strconv.FormatInt(cp.EventID, 10) + "|" + cp.Hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// readAuditCheckpoints membaca file checkpoint (satu JSON per baris, baris kosong dilewati); file belum ada → kosong.
+func readAuditCheckpoints(path string) ([]AuditCheckpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var checkpoints []AuditCheckpoint
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var cp AuditCheckpoint
+		if err := json.Unmarshal([]byte(line), &cp); err != nil {
+			return nil, fmt.Errorf("checkpoint baris %d tidak valid: %w", lineNo, err)
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, scanner.Err()
+}
@@ -0,0 +1,295 @@
+// File audit_service.go: pencatatan dan query audit trail (tabel audit_events, append-only) untuk tindakan administratif dan keamanan.
+//
+// Record menyimpan satu AuditEvent: pelaku (id + username), aksi, target, IP, user agent, request ID, snapshot before/after (JSON) dan diff field yang berubah.
+// Panggil dengan tx yang sama dengan perubahan data agar audit dan perubahan tersimpan atomik. Setiap baris masuk ke hash chain (audit_chain.go).
+// List/Export dipakai endpoint admin /api/admin/audit-events.
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"gorm.io/gorm"
+)
+
+// Nama aksi audit untuk manajemen user oleh admin.
+const (
+	AuditActionUserCreate            = "user.create"
+	AuditActionUserUpdate            = "user.update"
+	AuditActionUserDeactivate        = "user.deactivate"
+	AuditActionUserDeactivateFlagged = "user.deactivate_flagged"
+	AuditActionUserPasswordReset     = "user.password_reset"
+	AuditActionUserActivate          = "user.activate"
+	AuditActionUserActivationResend  = "user.activation_resend"
+	AuditActionUserProfileLink       = "user.profile_link"
+	AuditActionUserProfileUnlink     = "user.profile_unlink"
+)
+
+// Nama aksi audit untuk autentikasi dan akun sendiri.
+const (
+	AuditActionLogin          = "auth.login"
+	AuditActionLoginFailed    = "auth.login_failed"
+	AuditActionLogout         = "auth.logout"
+	AuditActionRegister       = "auth.register"
+	AuditActionPasswordForgot = "auth.password_forgot"
+	AuditActionPasswordChange = "auth.password_change"
+	AuditActionAccessDenied   = "auth.access_denied"
+	AuditActionSessionRevoke  = "auth.session_revoke"
+)
+
+// Nama aksi audit untuk laporan dan akses laporan.
+const (
+	AuditActionReportGenerate      = "report.generate"
+	AuditActionReportDownload      = "report.download"
+	AuditActionReportAccessRequest = "report_access.request"
+	AuditActionReportAccessDecide  = "report_access.decide"
+	AuditActionReportAccessRevoke  = "report_access.revoke"
+	AuditActionReportAccessReview  = "report_access.review"
+	AuditActionReportAccessExpire  = "report_access.expire"
+)
+
+// Nama aksi audit untuk data referensi.
+const (
+	AuditActionHolidayCreate = "holiday.create"
+	AuditActionHolidayDelete = "holiday.delete"
+)
+
+// Nama aksi audit untuk tindak lanjut anomali.
+const (
+	AuditActionAnomalyAcknowledge = "anomaly.acknowledge"
+	AuditActionAnomalyDismiss     = "anomaly.dismiss"
+)
+
+// Aksi audit aturan peringatan.
+const (
+	AuditActionAlertRuleCreate = "alert_rule.create"
+	AuditActionAlertRuleUpdate = "alert_rule.update"
+	AuditActionAlertRuleDelete = "alert_rule.delete"
+)
+
+// Tipe target audit.
+const (
+	AuditTargetUser          = "user"
+	AuditTargetReport        = "report"
+	AuditTargetAccessRequest = "report_access_request"
+	AuditTargetRoute         = "route"
+	AuditTargetSession       = "session"
+	AuditTargetHoliday       = "holiday"
+	AuditTargetAnomaly       = "anomaly"
+	AuditTargetAlertRule     = "alert_rule"
+)
+
+// auditDiffIgnoredFields tidak dimasukkan ke diff karena selalu berubah dan tidak bermakna bagi auditor.
+var auditDiffIgnoredFields = map[string]bool{"updated_at": true}
+
+// AuditActor berisi identitas pelaku dan asal request (diisi handler dari context Gin).
+type AuditActor struct {
+	UserID    *int
+	Username  string // Kosong = diisi otomatis dari users by UserID.
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// AuditEntry berisi data satu kejadian audit sebelum disimpan. Before/After boleh nil; selain itu di-marshal ke JSON.
+type AuditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// AuditChange satu field yang berubah di diff.
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditEventFilter filter dan paginasi untuk query audit_events. Field kosong/nil tidak dipakai.
+type AuditEventFilter struct {
+	ActorID    *int
+	Actor      string // Username pelaku (case-insensitive, exact).
+	Action     string // Exact, atau prefix jika diakhiri ".*" (mis. "user.*").
+	TargetType string
+	TargetID   string
+	RequestID  string
+	IP         string
+	From       *time.Time // created_at >= From
+	Until      *time.Time // created_at < Until
+	Page       int
+	PageSize   int
+}
+
+// AuditService menyimpan koneksi DB untuk menulis dan membaca audit_events.
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService membuat instance AuditService. db boleh berupa transaksi aktif.
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record menyimpan satu baris audit_events untuk actor dan entry yang diberikan. Diff dihitung jika Before dan After keduanya ada.
+func (s *AuditService) Record(actor AuditActor, entry AuditEntry) error {
+	if actor.UserID != nil && actor.Username == "" {
+		s.db.Model(&entity.User{}).Where("id = ?", *actor.UserID).Select("username").Scan(&actor.Username)
+	}
+
+	event := entity.AuditEvent{
+		ActorID:       actor.UserID,
+		ActorUsername: actor.Username,
+		Action:        entry.Action,
+		TargetType:    entry.TargetType,
+		TargetID:      entry.TargetID,
+		IP:            actor.IP,
+		UserAgent:     actor.UserAgent,
+		RequestID:     actor.RequestID,
+	}
+	var err error
+	if event.BeforeData, err = marshalAuditData(entry.Before); err != nil {
+		return err
+	}
+	if event.AfterData, err = marshalAuditData(entry.After); err != nil {
+		return err
+	}
+	if event.BeforeData != nil && event.AfterData != nil {
+		if event.Diff, err = auditDiff(*event.BeforeData, *event.AfterData); err != nil {
+			return err
+		}
+	}
+	return appendChained(s.db, &event)
+}
+
+// List mengembalikan satu halaman audit_events sesuai filter (terbaru dulu) beserta total baris yang cocok.
+func (s *AuditService) List(f AuditEventFilter) ([]entity.AuditEvent, int64, error) {
+	query := s.applyFilter(s.db.Model(&entity.AuditEvent{}), f)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []entity.AuditEvent
+	err := query.Order("created_at DESC, id DESC").
+		Offset((f.Page - 1) * f.PageSize).
+		Limit(f.PageSize).
+		Find(&events).Error
+	return events, total, err
+}
+
+// Get mengembalikan satu audit event by id.
+func (s *AuditService) Get(id int64) (*entity.AuditEvent, error) {
+	var event entity.AuditEvent
+	if err := s.db.First(&event, id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Export memanggil fn untuk setiap audit event yang cocok dengan filter (urut id naik), dibaca per batch agar hemat memori. limit > 0 membatasi jumlah baris.
+func (s *AuditService) Export(f AuditEventFilter, limit int, fn func(entity.AuditEvent) error) error {
+	query := s.applyFilter(s.db.Model(&entity.AuditEvent{}), f).Order("id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var batch []entity.AuditEvent
+	var fnErr error
+	res := query.FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for _, e := range batch {
+			if fnErr = fn(e); fnErr != nil {
+				return fnErr
+			}
+		}
+		return nil
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return res.Error
+}
+
+// applyFilter menambahkan kondisi WHERE dari AuditEventFilter.
+func (s *AuditService) applyFilter(query *gorm.DB, f AuditEventFilter) *gorm.DB {
+	if f.ActorID != nil {
+		query = query.Where("actor_id = ?", *f.ActorID)
+	}
+	if f.Actor != "" {
+		query = query.Where("LOWER(actor_username) = LOWER(?)", f.Actor)
+	}
+	if f.Action != "" {
+		if prefix, ok := strings.CutSuffix(f.Action, ".*"); ok {
+			query = query.Where("action LIKE ?", escapeLike(prefix)+".%")
+		} else {
+			query = query.Where("action = ?", f.Action)
+		}
+	}
+	if f.TargetType != "" {
+		query = query.Where("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		query = query.Where("target_id = ?", f.TargetID)
+	}
+	if f.RequestID != "" {
+		query = query.Where("request_id = ?", f.RequestID)
+	}
+	if f.IP != "" {
+		query = query.Where("ip = ?", f.IP)
+	}
+	if f.From != nil {
+		query = query.Where("created_at >= ?", *f.From)
+	}
+	if f.Until != nil {
+		query = query.Where("created_at < ?", *f.Until)
+	}
+	return query
+}
+
+// escapeLike meng-escape karakter wildcard LIKE (%, _, \) agar dicocokkan literal.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// marshalAuditData mengubah snapshot ke string JSON untuk kolom JSONB; nil → NULL.
+func marshalAuditData(v interface{}) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// auditDiff membandingkan dua snapshot JSON (objek) dan mengembalikan JSON {"field": {"from": x, "to": y}} untuk field yang berubah; nil jika tidak ada perubahan atau snapshot bukan objek.
+func auditDiff(beforeJSON, afterJSON string) (*string, error) {
+	var before, after map[string]interface{}
+	if json.Unmarshal([]byte(beforeJSON), &before) != nil || json.Unmarshal([]byte(afterJSON), &after) != nil {
+		return nil, nil
+	}
+
+	changes := make(map[string]AuditChange)
+	for k, a := range after {
+		if auditDiffIgnoredFields[k] {
+			continue
+		}
+		if b, ok := before[k]; !ok || !reflect.DeepEqual(a, b) {
+			changes[k] = AuditChange{From: before[k], To: a}
+		}
+	}
+	for k, b := range before {
+		if _, ok := after[k]; !ok && !auditDiffIgnoredFields[k] {
+			changes[k] = AuditChange{From: b, To: nil}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return marshalAuditData(changes)
+}
@@ -1,6 +1,6 @@
 // File auth_service.go: logika bisnis autentikasi (login, register, reset password) dan pembuatan token sesi.
 //
-// Login: cari user by username atau email, verifikasi bcrypt, update last_login, kembalikan token. Register: validasi email @bpk.go.id, konfirmasi password, cek duplikat, hash, create user. ResetPassword: validasi konfirmasi, cari user, hash baru, save.
+// Login: cari user by username atau email, verifikasi bcrypt, update last_login (dan tandai wajib ganti jika password kedaluwarsa), kembalikan token. Register: validasi email @bpk.go.id, konfirmasi password, kebijakan password, cek duplikat, hash, create user. ResetPassword: validasi konfirmasi, cari user, kebijakan password + riwayat, hash baru, save.
 package service
 
 import (
@@ -58,16 +58,17 @@ func (s *AuthService) Login(username, password string) (*entity.User, string, er
 		return nil, "", ErrInvalidCredentials
 	}
 
-	// Update waktu login terakhir lalu simpan ke DB
+	// Update waktu login terakhir (dan tanda wajib ganti password jika kedaluwarsa) lalu simpan ke DB
 	now := time.Now()
 	user.LastLogin = &now
+	user.MustChangePassword = NewPasswordPolicy(s.db).RequiresChange(&user)
 	s.db.Save(&user)
 
 	token := s.generateSessionToken(user.ID)
 	return &user, token, nil
 }
 
-// Register memvalidasi email @bpk.go.id dan konfirmasi password, cek duplikat username/email, hash password, lalu membuat user baru (role user, is_active true).
+// Register memvalidasi email @bpk.go.id, konfirmasi password, dan kebijakan password, cek duplikat username/email, hash password, lalu membuat user baru (role user, is_active true).
 func (s *AuthService) Register(req entity.RegisterRequest) (*entity.User, error) {
 	// Hanya email domain @bpk.go.id yang boleh daftar
 	if !strings.HasSuffix(req.Email, "@bpk.go.id") {
@@ -88,19 +89,17 @@ func (s *AuthService) Register(req entity.RegisterRequest) (*entity.User, error)
 		return nil, ErrEmailExists
 	}
 
-	// Hash password sebelum simpan (bcrypt.DefaultCost)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, err
+	newUser := entity.User{
+		Username: req.Username,
+		Role:     "user",
+		FullName: req.FullName,
+		Email:    req.Email,
+		IsActive: true,
 	}
 
-	newUser := entity.User{
-		Username:     req.Username,
-		PasswordHash: string(hashedPassword),
-		Role:         "user",
-		FullName:     req.FullName,
-		Email:        req.Email,
-		IsActive:     true,
+	// Validasi kebijakan password lalu hash (bcrypt.DefaultCost)
+	if err := NewPasswordPolicy(s.db).ApplyNewPassword(s.db, &newUser, req.Password); err != nil {
+		return nil, err
 	}
 
 	// Insert user baru ke DB
@@ -111,7 +110,7 @@ func (s *AuthService) Register(req entity.RegisterRequest) (*entity.User, error)
 	return &newUser, nil
 }
 
-// ResetPassword memvalidasi newPassword = confirmPassword, mencari user by username, hash password baru, lalu menyimpan ke DB. Jika user tidak ada → ErrUserNotFound.
+// ResetPassword memvalidasi newPassword = confirmPassword, mencari user by username, menerapkan kebijakan password + riwayat, lalu menyimpan ke DB dalam satu transaksi. Jika user tidak ada → ErrUserNotFound.
 func (s *AuthService) ResetPassword(username, newPassword, confirmPassword string) error {
 	if newPassword != confirmPassword {
 		return ErrPasswordMismatch
@@ -125,14 +124,14 @@ func (s *AuthService) ResetPassword(username, newPassword, confirmPassword strin
 		return err
 	}
 
-	// Hash password baru lalu update kolom PasswordHash
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-
-	user.PasswordHash = string(hashedPassword)
-	return s.db.Save(&user).Error
+	// Validasi + hash password baru, catat hash lama ke riwayat, lalu update kolom PasswordHash
+	policy := NewPasswordPolicy(s.db)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := policy.ApplyNewPassword(tx, &user, newPassword); err != nil {
+			return err
+		}
+		return tx.Save(&user).Error
+	})
 }
 
 // generateSessionToken menghasilkan string token sesi berformat session_token_{userID}_{timestamp YYYYMMDDHHmmss}.
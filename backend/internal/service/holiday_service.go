@@ -0,0 +1,109 @@
+// File holiday_service.go: pemeliharaan kalender hari libur nasional (ref_holidays) oleh admin.
+//
+// Setiap perubahan dicatat ke audit_events dan menaikkan versi data aktivitas agar cache heatmap (exclude_holidays) ikut tidak berlaku.
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrHolidayNotFound    = errors.New("hari libur tidak ditemukan")
+	ErrHolidayExists      = errors.New("tanggal tersebut sudah terdaftar sebagai hari libur")
+	ErrInvalidHolidayDate = errors.New("holiday_date harus berformat YYYY-MM-DD")
+	ErrHolidayNameEmpty   = errors.New("nama hari libur wajib diisi")
+)
+
+// HolidayService CRUD kalender hari libur.
+type HolidayService struct {
+	db *gorm.DB
+}
+
+// NewHolidayService membuat HolidayService.
+func NewHolidayService(db *gorm.DB) *HolidayService {
+	return &HolidayService{db: db}
+}
+
+// List mengembalikan hari libur terurut tanggal; year > 0 membatasi ke tahun tersebut.
+func (s *HolidayService) List(year int) ([]entity.Holiday, error) {
+	q := s.db.Order("holiday_date")
+	if year > 0 {
+		q = q.Where("EXTRACT(YEAR FROM holiday_date) = ?", year)
+	}
+	var holidays []entity.Holiday
+	if err := q.Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// Create menambahkan hari libur pada tanggal (YYYY-MM-DD). Satu tanggal hanya boleh terdaftar sekali.
+func (s *HolidayService) Create(actor AuditActor, date, name string) (*entity.Holiday, error) {
+	d, err := sqlbuilder.ParseDate(strings.TrimSpace(date))
+	if err != nil || d.IsZero() {
+		return nil, ErrInvalidHolidayDate
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrHolidayNameEmpty
+	}
+
+	holiday := &entity.Holiday{HolidayDate: d.Time(), Name: name}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&entity.Holiday{}).Where("holiday_date = ?", d.String()).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrHolidayExists
+		}
+		if err := tx.Create(holiday).Error; err != nil {
+			return err
+		}
+		if _, err := repository.BumpDataVersion(tx, repository.DataVersionActivity); err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionHolidayCreate,
+			TargetType: AuditTargetHoliday,
+			TargetID:   strconv.FormatInt(holiday.ID, 10),
+			After:      holiday,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return holiday, nil
+}
+
+// Delete menghapus hari libur berdasarkan id.
+func (s *HolidayService) Delete(actor AuditActor, id int64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var holiday entity.Holiday
+		if err := tx.First(&holiday, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrHolidayNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&holiday).Error; err != nil {
+			return err
+		}
+		if _, err := repository.BumpDataVersion(tx, repository.DataVersionActivity); err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionHolidayDelete,
+			TargetType: AuditTargetHoliday,
+			TargetID:   strconv.FormatInt(holiday.ID, 10),
+			Before:     holiday,
+		})
+	})
+}
@@ -0,0 +1,236 @@
+// File job_runner.go: penjalan background job periodik (goroutine per job) untuk tugas terjadwal seperti review dan kedaluwarsa akses laporan, deteksi anomali, peringatan keamanan, status engagement profil, dan live feed aktivitas.
+//
+// JobRunner: daftar Job (Name, Interval, Run). Start menjalankan tiap job sekali di awal lalu setiap Interval; Stop menutup stopChan agar semua goroutine berhenti.
+// Error dari Run hanya di-log; job tetap dijadwalkan di interval berikutnya.
+package service
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Job satu tugas periodik: Name untuk log, Interval jarak antar run, Run dipanggil dengan waktu run.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(now time.Time) error
+}
+
+// JobRunner mengelola sekumpulan Job: stopChan untuk sinyal stop, wg menunggu goroutine selesai, isRunning status.
+type JobRunner struct {
+	jobs      []Job
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	isRunning bool
+}
+
+// NewJobRunner membuat instance JobRunner dengan daftar job.
+func NewJobRunner(jobs ...Job) *JobRunner {
+	return &JobRunner{jobs: jobs, stopChan: make(chan struct{})}
+}
+
+// Add menambah job; hanya berlaku sebelum Start.
+func (jr *JobRunner) Add(job Job) {
+	jr.jobs = append(jr.jobs, job)
+}
+
+// Start menjalankan setiap job di goroutine sendiri: sekali di awal, lalu setiap Interval sampai Stop.
+func (jr *JobRunner) Start() {
+	if jr.isRunning {
+		log.Println("Job runner is already running")
+		return
+	}
+	jr.isRunning = true
+
+	for _, job := range jr.jobs {
+		job := job
+		log.Printf("Starting job %s: interval=%v", job.Name, job.Interval)
+		jr.wg.Add(1)
+		go func() {
+			defer jr.wg.Done()
+			jr.runJob(job)
+
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					jr.runJob(job)
+				case <-jr.stopChan:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Stop memberi sinyal semua job berhenti dan menunggu run yang sedang berjalan selesai.
+func (jr *JobRunner) Stop() {
+	if !jr.isRunning {
+		return
+	}
+	log.Println("Stopping job runner...")
+	close(jr.stopChan)
+	jr.wg.Wait()
+	jr.isRunning = false
+}
+
+// runJob menjalankan satu job dan mencatat error tanpa menghentikan jadwal.
+func (jr *JobRunner) runJob(job Job) {
+	if err := job.Run(time.Now()); err != nil {
+		log.Printf("[ERROR] job %s: %v", job.Name, err)
+	}
+}
+
+// AccessReviewJob job workflow akses laporan: kirim pengingat review untuk akses yang jatuh tempo review.
+func AccessReviewJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "access-review",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			reminded, err := NewAccessWorkflowService(db).SendReviewReminders()
+			if err != nil {
+				return err
+			}
+			if reminded > 0 {
+				log.Printf("Job access-review: review_reminders=%d", reminded)
+			}
+			return nil
+		},
+	}
+}
+
+// AccessExpiryJob job harian grant akses laporan: kedaluwarsakan grant yang lewat masa berlaku lalu beri tahu pemegang grant yang segera berakhir.
+func AccessExpiryJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "access-expiry",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			workflow := NewAccessWorkflowService(db)
+			expired, err := workflow.ExpireLapsed(AuditActor{UserAgent: "job/access-expiry"})
+			if err != nil {
+				return err
+			}
+			noticed, err := workflow.SendExpiryNotices()
+			if err != nil {
+				return err
+			}
+			if expired > 0 || noticed > 0 {
+				log.Printf("Job access-expiry: expired=%d expiry_notices=%d", expired, noticed)
+			}
+			return nil
+		},
+	}
+}
+
+// AnomalyDetectionJob job deteksi anomali: nilai ulang beberapa hari lengkap terakhir dan simpan temuan ke tabel anomalies.
+// Jika rollup belum mutakhir run dilewati (dicoba lagi di interval berikutnya) agar data yang belum lengkap tidak terbaca sebagai penurunan.
+func AnomalyDetectionJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "anomaly-detection",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			result, err := NewAnomalyService(db).Detect(now)
+			if errors.Is(err, ErrAnomalyRollupStale) {
+				log.Printf("Job anomaly-detection: skipped (%v)", err)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			log.Printf("Job anomaly-detection: days=%v skipped=%v anomalies=%d", result.Days, result.Skipped, result.Found)
+			return nil
+		},
+	}
+}
+
+// SecurityAlertJob job peringatan keamanan: nilai aktivitas yang baru diimpor (di luar jam kerja, lokasi tidak biasa) dan beri tahu tim keamanan.
+func SecurityAlertJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "security-alerts",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			result, err := NewSecurityAlertService(db).Evaluate()
+			if err != nil {
+				return err
+			}
+			if result.Processed > 0 {
+				log.Printf("Job security-alerts: processed=%d off_hours=%d unusual_location=%d last_log_id=%d",
+					result.Processed, result.OffHours, result.UnusualLocation, result.LastLogID)
+			}
+			return nil
+		},
+	}
+}
+
+// AlertRuleJob job aturan peringatan admin: nilai semua aturan aktif, buka firing baru (dengan cooldown) dan tutup firing yang sudah pulih.
+func AlertRuleJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "alert-rules",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			result, err := NewAlertRuleService(db).Evaluate(now)
+			if err != nil {
+				return err
+			}
+			if result.Fired > 0 || result.Resolved > 0 || result.Failed > 0 {
+				log.Printf("Job alert-rules: evaluated=%d fired=%d resolved=%d suppressed=%d failed=%d",
+					result.Evaluated, result.Fired, result.Resolved, result.Suppressed, result.Failed)
+			}
+			return nil
+		},
+	}
+}
+
+// SessionizationJob job rekonstruksi sesi aktivitas: bangun ulang sesi user yang punya event baru atau sesi terbuka (tutup sesi yang melewati batas jeda).
+func SessionizationJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "sessionization",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			result, err := NewUserSessionService(db).Sessionize(now)
+			if err != nil {
+				return err
+			}
+			if result.Users > 0 {
+				log.Printf("Job sessionization: users=%d sessions=%d last_log_id=%d", result.Users, result.Sessions, result.LastLogID)
+			}
+			return nil
+		},
+	}
+}
+
+// UserEngagementJob membuat Job yang menyelaraskan user_profiles.is_active dengan aktivitas terakhir (profil menjadi dorman tanpa impor baru).
+func UserEngagementJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "user-engagement",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			changed, err := NewUserEngagementService(db).RefreshStatus(now)
+			if err != nil {
+				return err
+			}
+			if changed > 0 {
+				log.Printf("Job user-engagement: is_active updated for %d profiles", changed)
+			}
+			return nil
+		},
+	}
+}
+
+// ActivityFeedJob job live feed: publikasikan aktivitas yang baru diimpor ke LiveActivityFeed untuk koneksi GET /api/dashboard/stream.
+func ActivityFeedJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "activity-feed",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			_, err := LiveActivityFeed().Poll(repository.NewActivityFeedRepository(db))
+			return err
+		},
+	}
+}
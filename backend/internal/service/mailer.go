@@ -0,0 +1,72 @@
+// File mailer.go: abstraksi pengiriman email (Mailer) dan implementasinya.
+//
+// LogMailer hanya menulis isi email ke log server (default; cocok untuk development). SMTPMailer mengirim lewat server SMTP (net/smtp).
+// NewMailer memilih implementasi dari config.GetMailConfig (env MAIL_DRIVER). Implementasi lain cukup memenuhi interface Mailer.
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+)
+
+// Mailer mengirim satu email teks biasa ke satu penerima.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer membuat Mailer sesuai MAIL_DRIVER: "smtp" → SMTPMailer, selain itu LogMailer.
+func NewMailer() Mailer {
+	cfg := config.GetMailConfig()
+	if cfg.Driver == "smtp" {
+		return &SMTPMailer{cfg: cfg}
+	}
+	return LogMailer{}
+}
+
+// LogMailer menulis email ke log server alih-alih mengirimnya.
+type LogMailer struct{}
+
+// Send mencatat penerima, subjek, dan isi email ke log.
+func (LogMailer) Send(to, subject, body string) error {
+	log.Printf("[MAIL] to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
+
+// SMTPMailer mengirim email lewat server SMTP (PLAIN auth jika SMTP_USERNAME diisi).
+type SMTPMailer struct {
+	cfg config.MailConfig
+}
+
+// Send menyusun pesan (header From/To/Subject/Date, UTF-8) lalu mengirim dengan smtp.SendMail.
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	if m.cfg.Host == "" {
+		return fmt.Errorf("SMTP_HOST tidak diset")
+	}
+	// Tolak CR/LF di header untuk mencegah header injection.
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("alamat atau subjek email tidak valid")
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.cfg.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := fmt.Sprintf("%s:%d", m.cfg.Host, m.cfg.Port)
+	return smtp.SendMail(addr, auth, m.cfg.From, []string{to}, []byte(msg))
+}
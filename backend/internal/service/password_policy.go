@@ -0,0 +1,227 @@
+// File password_policy.go: kebijakan password (panjang, kelas karakter, daftar password umum/bocor, riwayat, masa berlaku).
+//
+// PasswordPolicy dipakai oleh Register, ChangePassword, dan alur reset password (ForgotPassword / ResetPassword). Konfigurasi dibaca dari env PASSWORD_* lewat config.GetPasswordPolicy.
+// Daftar password yang ditolak di-embed dari common_passwords.txt sehingga ikut ter-build ke binary.
+package service
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords berisi daftar password umum (huruf kecil) dari common_passwords.txt; diisi sekali saat package di-load.
+var commonPasswords = parseCommonPasswords(commonPasswordsFile)
+
+// ErrPasswordReused dikembalikan jika password baru sama dengan password saat ini atau salah satu dari N password terakhir.
+var ErrPasswordReused = errors.New("password tidak boleh sama dengan password yang pernah dipakai sebelumnya")
+
+// PasswordPolicyError berisi satu atau lebih alasan password ditolak; Error() menggabungkan semua alasan agar bisa langsung ditampilkan ke user.
+type PasswordPolicyError struct {
+	Reasons []string
+}
+
+// Error menggabungkan semua alasan penolakan dengan "; ".
+func (e *PasswordPolicyError) Error() string {
+	return "password tidak memenuhi kebijakan: " + strings.Join(e.Reasons, "; ")
+}
+
+// PasswordPolicy menyimpan konfigurasi kebijakan password dan koneksi DB (untuk riwayat password).
+type PasswordPolicy struct {
+	db  *gorm.DB
+	cfg config.PasswordPolicyConfig
+}
+
+// NewPasswordPolicy membuat instance PasswordPolicy dengan konfigurasi dari environment.
+func NewPasswordPolicy(db *gorm.DB) *PasswordPolicy {
+	return &PasswordPolicy{db: db, cfg: config.GetPasswordPolicy()}
+}
+
+// Validate memeriksa password terhadap aturan panjang, kelas karakter, daftar password umum, dan tidak boleh mengandung identitas user (username / bagian lokal email).
+// Mengembalikan *PasswordPolicyError berisi semua alasan penolakan, atau nil jika lolos.
+func (p *PasswordPolicy) Validate(password string, identifiers ...string) error {
+	var reasons []string
+
+	if n := len([]rune(password)); n < p.cfg.MinLength {
+		reasons = append(reasons, fmt.Sprintf("minimal %d karakter", p.cfg.MinLength))
+	}
+	if p.cfg.MaxLength > 0 && len(password) > p.cfg.MaxLength {
+		reasons = append(reasons, fmt.Sprintf("maksimal %d byte", p.cfg.MaxLength))
+	}
+
+	// Hitung kelas karakter yang muncul: huruf kecil, huruf besar, angka, simbol.
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	if p.cfg.RequireLower && !hasLower {
+		reasons = append(reasons, "harus mengandung huruf kecil")
+	}
+	if p.cfg.RequireUpper && !hasUpper {
+		reasons = append(reasons, "harus mengandung huruf besar")
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		reasons = append(reasons, "harus mengandung angka")
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		reasons = append(reasons, "harus mengandung simbol")
+	}
+	classes := 0
+	for _, ok := range []bool{hasLower, hasUpper, hasDigit, hasSymbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < p.cfg.MinCharClasses {
+		reasons = append(reasons, fmt.Sprintf("harus memakai minimal %d dari: huruf kecil, huruf besar, angka, simbol", p.cfg.MinCharClasses))
+	}
+
+	if isCommonPassword(password) {
+		reasons = append(reasons, "terlalu umum atau pernah bocor")
+	}
+
+	// Password tidak boleh memuat username atau bagian lokal email (sebelum '@'), minimal 3 karakter agar tidak terlalu ketat.
+	lower := strings.ToLower(password)
+	for _, id := range identifiers {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if at := strings.Index(id, "@"); at >= 0 {
+			id = id[:at]
+		}
+		if len(id) >= 3 && strings.Contains(lower, id) {
+			reasons = append(reasons, "tidak boleh mengandung username atau email")
+			break
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &PasswordPolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
+// CheckReuse mengembalikan ErrPasswordReused jika password sama dengan hash saat ini (user.PasswordHash) atau salah satu dari HistorySize hash terakhir di password_history.
+func (p *PasswordPolicy) CheckReuse(user *entity.User, password string) error {
+	if user.PasswordHash != "" && bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil {
+		return ErrPasswordReused
+	}
+	if p.cfg.HistorySize <= 0 || user.ID == 0 {
+		return nil
+	}
+
+	var history []entity.PasswordHistory
+	if err := p.db.Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Limit(p.cfg.HistorySize).
+		Find(&history).Error; err != nil {
+		return err
+	}
+	for _, h := range history {
+		if bcrypt.CompareHashAndPassword([]byte(h.PasswordHash), []byte(password)) == nil {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// ApplyNewPassword memvalidasi password baru (Validate + CheckReuse), lalu mengisi user.PasswordHash, PasswordChangedAt, MustChangePassword=false.
+// Hash lama dipindah ke password_history (lewat tx) dan riwayat dipangkas ke HistorySize. Pemanggil bertanggung jawab menyimpan user (tx.Save).
+func (p *PasswordPolicy) ApplyNewPassword(tx *gorm.DB, user *entity.User, password string) error {
+	if err := p.Validate(password, user.Username, user.Email); err != nil {
+		return err
+	}
+	if err := p.CheckReuse(user, password); err != nil {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if user.ID != 0 && user.PasswordHash != "" && p.cfg.HistorySize > 0 {
+		if err := tx.Create(&entity.PasswordHistory{UserID: user.ID, PasswordHash: user.PasswordHash}).Error; err != nil {
+			return err
+		}
+		// Pangkas riwayat: hapus semua kecuali HistorySize baris terbaru milik user.
+		if err := tx.Exec(`
+			DELETE FROM password_history
+			WHERE user_id = ? AND id NOT IN (
+				SELECT id FROM password_history WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?
+			)`, user.ID, user.ID, p.cfg.HistorySize).Error; err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	user.PasswordHash = string(hashed)
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = false
+	return nil
+}
+
+// IsExpired mengembalikan true jika MaxAge aktif dan password user sudah lebih tua dari MaxAge. Jika PasswordChangedAt kosong (akun lama), dipakai CreatedAt.
+func (p *PasswordPolicy) IsExpired(user *entity.User) bool {
+	if p.cfg.MaxAge <= 0 {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > p.cfg.MaxAge
+}
+
+// RequiresChange mengembalikan true jika user wajib ganti password sebelum memakai aplikasi: ditandai admin/sistem (MustChangePassword) atau password kedaluwarsa.
+func (p *PasswordPolicy) RequiresChange(user *entity.User) bool {
+	return user.MustChangePassword || p.IsExpired(user)
+}
+
+// isCommonPassword mengecek password (huruf kecil) ada di daftar; juga cek versi tanpa angka/simbol di akhir agar variasi seperti "Password123!" ikut ditolak.
+func isCommonPassword(password string) bool {
+	lower := strings.ToLower(password)
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+	base := strings.TrimRightFunc(lower, func(r rune) bool {
+		return unicode.IsDigit(r) || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	if base != lower && len(base) >= 4 {
+		if _, ok := commonPasswords[base]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCommonPasswords mengubah isi file (satu password per baris) menjadi set; baris kosong dan komentar '#' diabaikan.
+func parseCommonPasswords(content string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[line] = struct{}{}
+	}
+	return set
+}
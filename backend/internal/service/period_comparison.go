@@ -0,0 +1,177 @@
+// File period_comparison.go: perbandingan periode (period-over-period) untuk KPI dan breakdown dashboard.
+//
+// Periode pembanding = periode sebelumnya dengan panjang sama (tepat sebelum start_date) atau rentang yang ditentukan pemanggil.
+// Setiap metrik dilaporkan sebagai nilai sekarang, nilai pembanding, selisih absolut, dan selisih persen (nil jika nilai pembanding 0).
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+)
+
+// Mode perbandingan periode.
+const (
+	CompareNone     = ""
+	ComparePrevious = "previous" // Periode sebelumnya dengan panjang sama.
+	CompareCustom   = "custom"   // Rentang compare_start_date–compare_end_date.
+)
+
+var (
+	ErrInvalidCompareMode  = errors.New("compare harus previous atau custom")
+	ErrCompareNeedsRange   = errors.New("compare=previous membutuhkan start_date dan end_date")
+	ErrCompareCustomRange  = errors.New("compare=custom membutuhkan compare_start_date dan compare_end_date (YYYY-MM-DD)")
+	ErrCompareInvalidOrder = errors.New("compare_start_date tidak boleh setelah compare_end_date")
+)
+
+// MetricDelta satu metrik dengan pembandingnya. DeltaPct nil jika Previous = 0 (persen tidak terdefinisi).
+type MetricDelta struct {
+	Current  int64    `json:"current"`
+	Previous int64    `json:"previous"`
+	Delta    int64    `json:"delta"`
+	DeltaPct *float64 `json:"delta_pct"`
+}
+
+// CompareCounts membangun MetricDelta dari nilai sekarang dan pembanding.
+func CompareCounts(current, previous int64) MetricDelta {
+	d := MetricDelta{Current: current, Previous: previous, Delta: current - previous}
+	if previous != 0 {
+		pct := float64(d.Delta) / float64(previous) * 100
+		d.DeltaPct = &pct
+	}
+	return d
+}
+
+// ComparisonFilter mengembalikan salinan filter dengan rentang tanggal periode pembanding; nil jika mode kosong.
+// previous: panjang sama dengan start_date–end_date, berakhir sehari sebelum start_date. custom: compareStart–compareEnd.
+func ComparisonFilter(filter repository.ActivityFilter, mode, compareStart, compareEnd string) (*repository.ActivityFilter, error) {
+	out := filter
+	switch mode {
+	case CompareNone:
+		return nil, nil
+	case ComparePrevious:
+		start, errStart := sqlbuilder.ParseDate(filter.StartDate)
+		end, errEnd := sqlbuilder.ParseDate(filter.EndDate)
+		if errStart != nil || errEnd != nil || start.IsZero() || end.IsZero() {
+			return nil, ErrCompareNeedsRange
+		}
+		days := int(end.Time().Sub(start.Time()).Hours()/24) + 1
+		out.StartDate = start.AddDays(-days).String()
+		out.EndDate = start.AddDays(-1).String()
+	case CompareCustom:
+		start, errStart := sqlbuilder.ParseDate(compareStart)
+		end, errEnd := sqlbuilder.ParseDate(compareEnd)
+		if errStart != nil || errEnd != nil || start.IsZero() || end.IsZero() {
+			return nil, ErrCompareCustomRange
+		}
+		if start.After(end) {
+			return nil, ErrCompareInvalidOrder
+		}
+		out.StartDate, out.EndDate = start.String(), end.String()
+	default:
+		return nil, ErrInvalidCompareMode
+	}
+	return &out, nil
+}
+
+// DashboardStats KPI ringkas dashboard untuk satu filter.
+type DashboardStats struct {
+	TotalUsers       int64
+	SuccessLogins    int64
+	TotalActivities  int64
+	LogoutErrors     int64
+	BusiestHour      int
+	BusiestHourCount int64
+}
+
+// LoadDashboardStats menghitung semua KPI ringkas dashboard untuk filter.
+func LoadDashboardStats(repo repository.ActivityLogRepository, filter repository.ActivityFilter) (*DashboardStats, error) {
+	var stats DashboardStats
+	var err error
+	if stats.TotalUsers, err = repo.GetUniqueUsersCount(filter); err != nil {
+		return nil, err
+	}
+	if stats.SuccessLogins, err = repo.GetCountByStatus("SUCCESS", filter); err != nil {
+		return nil, err
+	}
+	if stats.TotalActivities, err = repo.GetTotalCount(filter); err != nil {
+		return nil, err
+	}
+	if stats.LogoutErrors, err = repo.GetCountByStatus("FAILED", filter); err != nil {
+		return nil, err
+	}
+	if stats.BusiestHour, stats.BusiestHourCount, err = repo.GetBusiestHour(filter); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// DashboardStatsComparison selisih setiap KPI terhadap periode pembanding; jam tersibuk dibandingkan jumlahnya, jam pembanding dilaporkan terpisah.
+type DashboardStatsComparison struct {
+	TotalUsers          MetricDelta `json:"total_users"`
+	SuccessLogins       MetricDelta `json:"success_logins"`
+	TotalActivities     MetricDelta `json:"total_activities"`
+	LogoutErrors        MetricDelta `json:"logout_errors"`
+	BusiestHourCount    MetricDelta `json:"busiest_hour_count"`
+	PreviousBusiestHour int         `json:"previous_busiest_hour"`
+}
+
+// CompareDashboardStats membandingkan KPI sekarang dengan pembanding.
+func CompareDashboardStats(current, previous *DashboardStats) DashboardStatsComparison {
+	return DashboardStatsComparison{
+		TotalUsers:          CompareCounts(current.TotalUsers, previous.TotalUsers),
+		SuccessLogins:       CompareCounts(current.SuccessLogins, previous.SuccessLogins),
+		TotalActivities:     CompareCounts(current.TotalActivities, previous.TotalActivities),
+		LogoutErrors:        CompareCounts(current.LogoutErrors, previous.LogoutErrors),
+		BusiestHourCount:    CompareCounts(current.BusiestHourCount, previous.BusiestHourCount),
+		PreviousBusiestHour: previous.BusiestHour,
+	}
+}
+
+// CompareBreakdown menambahkan previous_count, delta, dan delta_pct ke setiap baris current (kolom "count"), dicocokkan lewat kolom key.
+// Baris yang hanya ada di periode pembanding tidak ditambahkan.
+func CompareBreakdown(current, previous []map[string]interface{}, key string) []map[string]interface{} {
+	prev := map[interface{}]int64{}
+	for _, row := range previous {
+		prev[row[key]] += toInt64(row["count"])
+	}
+	for _, row := range current {
+		d := CompareCounts(toInt64(row["count"]), prev[row[key]])
+		row["previous_count"] = d.Previous
+		row["delta"] = d.Delta
+		row["delta_pct"] = d.DeltaPct
+	}
+	return current
+}
+
+// CompareCountMap membandingkan breakdown berbentuk map (mis. per scope/cluster); kunci dari kedua periode ikut disertakan.
+func CompareCountMap(current, previous map[string]int64) map[string]MetricDelta {
+	out := make(map[string]MetricDelta, len(current))
+	for k, v := range current {
+		out[k] = CompareCounts(v, previous[k])
+	}
+	for k, v := range previous {
+		if _, ok := current[k]; !ok {
+			out[k] = CompareCounts(0, v)
+		}
+	}
+	return out
+}
+
+// toInt64 mengonversi nilai count dari hasil query atau cache (int, int64, float64, json.Number) ke int64.
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case json.Number:
+		i, _ := n.Int64()
+		return i
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
@@ -0,0 +1,297 @@
+// File profile_link_service.go: penautan akun dashboard (users) ke profil aktivitas (user_profiles).
+//
+// Pencocokan: email (case-insensitive) lebih dulu, lalu nama lengkap (users.full_name = user_profiles.nama, case-insensitive, trim).
+// Hanya profil yang belum tertaut ke akun lain yang menjadi kandidat. Tepat satu kandidat = cocok; lebih dari satu = ambigu (diselesaikan admin secara manual).
+// Reconcile menampilkan status semua akun yang belum tertaut; AutoLink menautkan yang cocok unik; Link/Unlink untuk penautan manual. Semua perubahan dicatat ke audit_events.
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProfileNotFound      = errors.New("profil aktivitas tidak ditemukan")
+	ErrProfileAlreadyLinked = errors.New("profil aktivitas sudah tertaut ke akun lain")
+)
+
+// Cara penautan (users.profile_link_method).
+const (
+	ProfileLinkEmail  = "email"
+	ProfileLinkName   = "name"
+	ProfileLinkManual = "manual"
+)
+
+// Status rekonsiliasi akun yang belum tertaut.
+const (
+	ProfileMatchUnique    = "matched"   // Tepat satu kandidat; bisa ditautkan otomatis.
+	ProfileMatchAmbiguous = "ambiguous" // Lebih dari satu kandidat.
+	ProfileMatchNone      = "unmatched" // Tidak ada kandidat.
+)
+
+// ProfileCandidate satu profil aktivitas yang cocok dengan akun.
+type ProfileCandidate struct {
+	ProfileID    int64      `json:"profile_id"`
+	Nama         string     `json:"nama"`
+	Email        string     `json:"email"`
+	SatkerName   string     `json:"satker_name"`
+	LastActivity *time.Time `json:"last_activity"`
+}
+
+// ProfileReconciliationItem status pencocokan satu akun yang belum tertaut.
+type ProfileReconciliationItem struct {
+	User       entity.User        `json:"user"`
+	Status     string             `json:"status"`
+	MatchedBy  string             `json:"matched_by,omitempty"`
+	Candidates []ProfileCandidate `json:"candidates"`
+}
+
+// ProfileLinkSummary ringkasan hasil AutoLink.
+type ProfileLinkSummary struct {
+	LinkedByEmail int  `json:"linked_by_email"`
+	LinkedByName  int  `json:"linked_by_name"`
+	Ambiguous     int  `json:"ambiguous"`
+	Unmatched     int  `json:"unmatched"`
+	DryRun        bool `json:"dry_run"`
+}
+
+// ProfileLinkService menyimpan koneksi DB untuk penautan users ↔ user_profiles.
+type ProfileLinkService struct {
+	db *gorm.DB
+}
+
+// NewProfileLinkService membuat instance ProfileLinkService.
+func NewProfileLinkService(db *gorm.DB) *ProfileLinkService {
+	return &ProfileLinkService{db: db}
+}
+
+// Reconcile mengembalikan status pencocokan untuk semua akun yang belum tertaut (urut id). status kosong = semua; selain itu filter matched/ambiguous/unmatched.
+func (s *ProfileLinkService) Reconcile(status string) ([]ProfileReconciliationItem, error) {
+	var users []entity.User
+	if err := s.db.Where("profile_id IS NULL").Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]ProfileReconciliationItem, 0, len(users))
+	for _, u := range users {
+		item, err := s.match(u)
+		if err != nil {
+			return nil, err
+		}
+		if status != "" && item.Status != status {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// AutoLink menautkan semua akun belum tertaut yang punya tepat satu kandidat. dryRun = hanya hitung tanpa menyimpan.
+// Mengembalikan ringkasan dan daftar akun yang masih perlu penanganan manual (ambigu / tidak cocok).
+func (s *ProfileLinkService) AutoLink(actor AuditActor, dryRun bool) (ProfileLinkSummary, []ProfileReconciliationItem, error) {
+	summary := ProfileLinkSummary{DryRun: dryRun}
+	items, err := s.Reconcile("")
+	if err != nil {
+		return summary, nil, err
+	}
+
+	unresolved := make([]ProfileReconciliationItem, 0)
+	for _, item := range items {
+		switch item.Status {
+		case ProfileMatchUnique:
+			if !dryRun {
+				if _, err := s.link(actor, item.User.ID, item.Candidates[0].ProfileID, item.MatchedBy); err != nil {
+					// Profil bisa saja baru ditautkan ke akun lain di iterasi sebelumnya; perlakukan sebagai ambigu.
+					if errors.Is(err, ErrProfileAlreadyLinked) {
+						item.Status = ProfileMatchAmbiguous
+						summary.Ambiguous++
+						unresolved = append(unresolved, item)
+						continue
+					}
+					return summary, nil, err
+				}
+			}
+			if item.MatchedBy == ProfileLinkEmail {
+				summary.LinkedByEmail++
+			} else {
+				summary.LinkedByName++
+			}
+		case ProfileMatchAmbiguous:
+			summary.Ambiguous++
+			unresolved = append(unresolved, item)
+		default:
+			summary.Unmatched++
+			unresolved = append(unresolved, item)
+		}
+	}
+	return summary, unresolved, nil
+}
+
+// Link menautkan user ke profil secara manual (metode manual). Profil yang sudah tertaut ke akun lain ditolak.
+func (s *ProfileLinkService) Link(actor AuditActor, userID int, profileID int64) (*entity.User, error) {
+	return s.link(actor, userID, profileID, ProfileLinkManual)
+}
+
+// Unlink melepas tautan profil dari user. Tidak error jika user memang belum tertaut.
+func (s *ProfileLinkService) Unlink(actor AuditActor, userID int) (*entity.User, error) {
+	var user *entity.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = findUserByID(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.ProfileID == nil {
+			return nil
+		}
+		before := *user
+		user.ProfileID = nil
+		user.ProfileLinkMethod = ""
+		user.ProfileLinkedAt = nil
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserProfileUnlink,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			Before:     before,
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// EnsureLinked menautkan user secara otomatis jika belum tertaut dan emailnya cocok dengan tepat satu profil (pencocokan nama tidak dipakai di sini karena rawan salah orang).
+// Hanya untuk akun dengan email terverifikasi (EmailVerifiedAt): registrasi mandiri tidak membuktikan kepemilikan email, sehingga akun seperti itu
+// ditautkan admin lewat rekonsiliasi (Link/AutoLink). Mengembalikan user (mungkin sudah diperbarui); user tetap tidak tertaut jika tidak ada kecocokan unik.
+func (s *ProfileLinkService) EnsureLinked(user *entity.User) (*entity.User, error) {
+	if user.ProfileID != nil || user.EmailVerifiedAt == nil || strings.TrimSpace(user.Email) == "" {
+		return user, nil
+	}
+	candidates, err := s.candidatesByEmail(user.Email)
+	if err != nil || len(candidates) != 1 {
+		return user, err
+	}
+	actor := AuditActor{UserID: &user.ID, UserAgent: "auto-link"}
+	linked, err := s.link(actor, user.ID, candidates[0].ProfileID, ProfileLinkEmail)
+	if errors.Is(err, ErrProfileAlreadyLinked) {
+		return user, nil
+	}
+	return linked, err
+}
+
+// match mencari kandidat profil untuk user: email dulu, lalu nama lengkap.
+func (s *ProfileLinkService) match(user entity.User) (ProfileReconciliationItem, error) {
+	item := ProfileReconciliationItem{User: user, Status: ProfileMatchNone, Candidates: []ProfileCandidate{}}
+
+	if strings.TrimSpace(user.Email) != "" {
+		candidates, err := s.candidatesByEmail(user.Email)
+		if err != nil {
+			return item, err
+		}
+		if len(candidates) > 0 {
+			item.Candidates = candidates
+			item.MatchedBy = ProfileLinkEmail
+			item.Status = matchStatus(len(candidates))
+			return item, nil
+		}
+	}
+
+	if strings.TrimSpace(user.FullName) != "" {
+		candidates, err := s.findCandidates("LOWER(TRIM(p.nama)) = LOWER(TRIM(?))", user.FullName)
+		if err != nil {
+			return item, err
+		}
+		if len(candidates) > 0 {
+			item.Candidates = candidates
+			item.MatchedBy = ProfileLinkName
+			item.Status = matchStatus(len(candidates))
+		}
+	}
+	return item, nil
+}
+
+// matchStatus mengubah jumlah kandidat menjadi status rekonsiliasi.
+func matchStatus(n int) string {
+	switch {
+	case n == 1:
+		return ProfileMatchUnique
+	case n > 1:
+		return ProfileMatchAmbiguous
+	}
+	return ProfileMatchNone
+}
+
+// candidatesByEmail mencari profil belum tertaut dengan email sama (case-insensitive).
+func (s *ProfileLinkService) candidatesByEmail(email string) ([]ProfileCandidate, error) {
+	return s.findCandidates("p.email <> '' AND LOWER(p.email) = LOWER(TRIM(?))", email)
+}
+
+// findCandidates menjalankan query profil belum tertaut dengan kondisi cond (memakai alias p untuk user_profiles).
+func (s *ProfileLinkService) findCandidates(cond string, arg interface{}) ([]ProfileCandidate, error) {
+	var candidates []ProfileCandidate
+	err := s.db.Table("user_profiles p").
+		Select("p.id AS profile_id, p.nama, COALESCE(p.email, '') AS email, COALESCE(s.satker_name, '') AS satker_name, p.last_activity").
+		Joins("LEFT JOIN ref_satker_units s ON s.id = p.satker_id").
+		Where(cond, arg).
+		Where("NOT EXISTS (SELECT 1 FROM users u WHERE u.profile_id = p.id)").
+		Order("p.last_activity DESC NULLS LAST, p.id").
+		Scan(&candidates).Error
+	return candidates, err
+}
+
+// link menautkan user ke profil dengan metode tertentu dalam satu transaksi + audit.
+func (s *ProfileLinkService) link(actor AuditActor, userID int, profileID int64, method string) (*entity.User, error) {
+	var user *entity.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = findUserByID(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var profile entity.UserProfile
+		if err := tx.First(&profile, profileID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProfileNotFound
+			}
+			return err
+		}
+		var linkedCount int64
+		if err := tx.Model(&entity.User{}).Where("profile_id = ? AND id <> ?", profileID, userID).Count(&linkedCount).Error; err != nil {
+			return err
+		}
+		if linkedCount > 0 {
+			return ErrProfileAlreadyLinked
+		}
+
+		before := *user
+		now := time.Now()
+		user.ProfileID = &profile.ID
+		user.ProfileLinkMethod = method
+		user.ProfileLinkedAt = &now
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserProfileLink,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			Before:     before,
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
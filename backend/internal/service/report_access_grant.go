@@ -0,0 +1,193 @@
+// File report_access_grant.go: grant akses laporan — cakupan template dan pohon satker dari permintaan akses yang disetujui, serta penegakannya saat generate laporan.
+//
+// Grant aktif = report_access_requests berstatus approved yang expires_at-nya kosong atau belum lewat. Templates kosong = semua template; SatkerIDs kosong = semua satker,
+// selain itu laporan hanya boleh mencakup pohon (root + semua turunan) dari satker yang disebut. Admin tidak butuh grant.
+// Pemegang grant diberi notifikasi ACCESS_EXPIRY_NOTICE sebelum kedaluwarsa (sekali per masa berlaku; direset saat review).
+package service
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidReportTemplate = errors.New("template laporan tidak dikenal")
+	ErrInvalidSatkerScope    = errors.New("satker pada cakupan akses tidak ditemukan")
+	ErrInvalidGrantExpiry    = errors.New("tanggal kedaluwarsa akses harus di masa depan")
+	ErrReportAccessDenied    = errors.New("tidak memiliki akses laporan yang aktif")
+	ErrReportTemplateDenied  = errors.New("akses laporan tidak mencakup template ini")
+	ErrReportSatkerDenied    = errors.New("akses laporan tidak mencakup satker ini")
+)
+
+// AccessScope cakupan grant akses laporan: Templates (id template) dan SatkerIDs (root pohon satker). Kosong = semua.
+type AccessScope struct {
+	Templates []string `json:"templates"`
+	SatkerIDs []int64  `json:"satker_ids"`
+}
+
+// GrantOverride perubahan grant oleh admin saat persetujuan tahap terakhir: cakupan dan/atau tanggal kedaluwarsa. Field nil = pakai nilai permintaan/config.
+type GrantOverride struct {
+	Scope     *AccessScope
+	ExpiresAt *time.Time
+}
+
+// ReportAuthorization hasil AuthorizeReport: SatkerIDs = satker yang boleh masuk laporan (nil = tanpa filter satker), Grant = grant yang dipakai (nil untuk admin).
+type ReportAuthorization struct {
+	SatkerIDs []int64
+	Grant     *entity.ReportAccessRequest
+}
+
+// normalizeScope memvalidasi template dan keberadaan satker, membuang duplikat, lalu mengembalikan bentuk kolom (dipisah koma).
+func normalizeScope(db *gorm.DB, scope AccessScope) (templates, satkerIDs string, err error) {
+	seenTemplate := map[string]bool{}
+	var ts []string
+	for _, t := range scope.Templates {
+		t = strings.TrimSpace(t)
+		if t == "" || seenTemplate[t] {
+			continue
+		}
+		if !entity.IsValidReportTemplate(t) || entity.IsAdminOnlyReportTemplate(t) {
+			return "", "", ErrInvalidReportTemplate
+		}
+		seenTemplate[t] = true
+		ts = append(ts, t)
+	}
+	if len(ts) == len(entity.ReportTemplateIDs)-len(entity.AdminOnlyReportTemplateIDs) {
+		ts = nil // Semua template yang bisa diajukan = tanpa batasan template.
+	}
+
+	seenSatker := map[int64]bool{}
+	var ids []int64
+	for _, id := range scope.SatkerIDs {
+		if id <= 0 || seenSatker[id] {
+			continue
+		}
+		seenSatker[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) > 0 {
+		var found int64
+		if err := db.Table("ref_satker_units").Where("id IN ?", ids).Count(&found).Error; err != nil {
+			return "", "", err
+		}
+		if found != int64(len(ids)) {
+			return "", "", ErrInvalidSatkerScope
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(ts, ","), strings.Join(parts, ","), nil
+}
+
+// ActiveGrant mengembalikan grant akses laporan aktif milik userID (terbaru), atau ErrReportAccessDenied jika tidak ada.
+func (s *AccessWorkflowService) ActiveGrant(userID int) (*entity.ReportAccessRequest, error) {
+	var grant entity.ReportAccessRequest
+	err := s.db.Where("user_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)", userID, entity.AccessRequestApproved, time.Now()).
+		Order("id DESC").First(&grant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportAccessDenied
+		}
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// AuthorizeReport memeriksa apakah userID boleh generate laporan templateID untuk pohon satkerID (nil = semua yang diizinkan) dan mengembalikan filter satker yang harus dipakai.
+func (s *AccessWorkflowService) AuthorizeReport(userID int, templateID string, satkerID *int64) (*ReportAuthorization, error) {
+	if !entity.IsValidReportTemplate(templateID) {
+		return nil, ErrInvalidReportTemplate
+	}
+	user, err := findUserByID(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewActivityLogRepository(s.db)
+
+	auth := &ReportAuthorization{}
+	if user.Role != entity.RoleAdmin {
+		if entity.IsAdminOnlyReportTemplate(templateID) {
+			return nil, ErrReportTemplateDenied
+		}
+		if auth.Grant, err = s.ActiveGrant(userID); err != nil {
+			return nil, err
+		}
+		if !auth.Grant.CoversTemplate(templateID) {
+			return nil, ErrReportTemplateDenied
+		}
+	}
+
+	var allowed map[int64]bool
+	if auth.Grant != nil {
+		if roots := auth.Grant.SatkerIDList(); len(roots) > 0 {
+			allowed = map[int64]bool{}
+			for _, root := range roots {
+				ids, err := repo.GetSatkerIdsUnderRoot(root)
+				if err != nil {
+					return nil, err
+				}
+				for _, id := range ids {
+					if !allowed[id] {
+						allowed[id] = true
+						auth.SatkerIDs = append(auth.SatkerIDs, id)
+					}
+				}
+			}
+			if auth.SatkerIDs == nil {
+				return nil, ErrReportSatkerDenied // Root sudah tidak ada: jangan jatuh ke "tanpa filter".
+			}
+		}
+	}
+
+	if satkerID != nil {
+		if allowed != nil && !allowed[*satkerID] {
+			return nil, ErrReportSatkerDenied
+		}
+		ids, err := repo.GetSatkerIdsUnderRoot(*satkerID)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, ErrInvalidSatkerScope
+		}
+		auth.SatkerIDs = ids
+	}
+	return auth, nil
+}
+
+// SendExpiryNotices memberi notifikasi ke pemegang grant yang kedaluwarsa dalam ACCESS_EXPIRY_NOTICE dan belum diberi tahu. Dipanggil job harian.
+func (s *AccessWorkflowService) SendExpiryNotices() (int, error) {
+	if s.cfg.ExpiryNotice <= 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	var expiring []entity.ReportAccessRequest
+	err := s.db.Where("status = ? AND expires_at > ? AND expires_at <= ? AND expiry_notified_at IS NULL",
+		entity.AccessRequestApproved, now, now.Add(s.cfg.ExpiryNotice)).Find(&expiring).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range expiring {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			message := "Akses laporan Anda akan berakhir pada " + r.ExpiresAt.Format("02-01-2006") + ". Hubungi admin untuk review jika masih diperlukan."
+			if err := notify(tx, r.UserID, "Akses Laporan Segera Berakhir", message, "warning", r.ID); err != nil {
+				return err
+			}
+			return tx.Model(&entity.ReportAccessRequest{}).Where("id = ?", r.ID).Update("expiry_notified_at", now).Error
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(expiring), nil
+}
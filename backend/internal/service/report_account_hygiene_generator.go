@@ -0,0 +1,343 @@
+// File report_account_hygiene_generator.go: generator CSV, Excel, dan PDF untuk template account-hygiene (akun dorman/yatim dan profil tanpa aktivitas).
+//
+// Data dari repository.GenerateReportData: Summary (dormant_days, flagged_accounts, dormant_accounts, orphaned_accounts, inactive_profiles)
+// dan Details dengan category "account" (akun users ditandai, kolom reasons) atau "profile" (user_profiles tanpa aktivitas).
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// hygieneSummary ringkasan laporan akun dorman/yatim dari data.Summary.
+type hygieneSummary struct {
+	DormantDays, Flagged, Dormant, Orphaned, InactiveProfiles int
+}
+
+// accountHygieneData memisahkan Details menjadi akun ditandai dan profil tanpa aktivitas, serta mengambil ringkasan.
+func accountHygieneData(data *repository.ReportData) (summary hygieneSummary, accounts, profiles []map[string]interface{}) {
+	summary.DormantDays, _ = data.Summary["dormant_days"].(int)
+	summary.Flagged, _ = data.Summary["flagged_accounts"].(int)
+	summary.Dormant, _ = data.Summary["dormant_accounts"].(int)
+	summary.Orphaned, _ = data.Summary["orphaned_accounts"].(int)
+	summary.InactiveProfiles, _ = data.Summary["inactive_profiles"].(int)
+	for _, detail := range data.Details {
+		if detail["category"] == "profile" {
+			profiles = append(profiles, detail)
+		} else {
+			accounts = append(accounts, detail)
+		}
+	}
+	return summary, accounts, profiles
+}
+
+// accountFlagLabels menerjemahkan alasan penandaan akun (entity.AccountFlag*) ke label laporan.
+func accountFlagLabels(detail map[string]interface{}, dormantDays int) string {
+	reasons, _ := detail["reasons"].([]string)
+	labels := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		switch reason {
+		case entity.AccountFlagDormantLogin:
+			labels = append(labels, fmt.Sprintf("Tidak login > %d hari", dormantDays))
+		case entity.AccountFlagOrphanedSatker:
+			labels = append(labels, "Satker tidak ada")
+		default:
+			labels = append(labels, reason)
+		}
+	}
+	return strings.Join(labels, "; ")
+}
+
+// lastLoginLabel mengembalikan waktu login terakhir atau "Belum pernah".
+func lastLoginLabel(detail map[string]interface{}) string {
+	if lastLogin, _ := detail["last_login"].(string); lastLogin != "" {
+		return lastLogin
+	}
+	return "Belum pernah"
+}
+
+// csvQuote membungkus nilai teks CSV dengan tanda kutip (kutip di dalam nilai digandakan).
+func csvQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// generateAccountHygieneCSV membuat file CSV laporan akun dorman dan yatim: header metadata, ringkasan, tabel akun ditandai, tabel profil tanpa aktivitas, footer.
+func (rg *ReportGenerator) generateAccountHygieneCSV(data *repository.ReportData, metadata ReportMetadata) (string, error) {
+	filename := rg.generateFilename("laporan_akun_dorman", "csv")
+	summary, accounts, profiles := accountHygieneData(data)
+
+	var content strings.Builder
+
+	// Header metadata
+	content.WriteString("LAPORAN AKUN DORMAN DAN YATIM\n")
+	content.WriteString(fmt.Sprintf("Dibuat oleh: %s (%s)\n", metadata.Username, metadata.Email))
+	content.WriteString(fmt.Sprintf("Tanggal Dibuat: %s\n", data.GeneratedAt.Format("02 January 2006 15:04:05")))
+	content.WriteString(fmt.Sprintf("Kondisi Data: %s\n", data.Period))
+	content.WriteString("\n")
+
+	content.WriteString("RINGKASAN\n")
+	content.WriteString(fmt.Sprintf("Akun Ditandai: %s\n", formatNumber(summary.Flagged)))
+	content.WriteString(fmt.Sprintf("Tidak Login > %d Hari: %s\n", summary.DormantDays, formatNumber(summary.Dormant)))
+	content.WriteString(fmt.Sprintf("Satker Tidak Ada: %s\n", formatNumber(summary.Orphaned)))
+	content.WriteString(fmt.Sprintf("Profil Tanpa Aktivitas: %s\n", formatNumber(summary.InactiveProfiles)))
+	content.WriteString("\n")
+
+	content.WriteString("AKUN DITANDAI\n")
+	content.WriteString("No,ID,Username,Nama Lengkap,Email,Role,Login Terakhir,Alasan\n")
+	for i, detail := range accounts {
+		content.WriteString(fmt.Sprintf("%d,%d,%s,%s,%s,%s,%s,%s\n", i+1, detail["id"],
+			csvQuote(fmt.Sprint(detail["username"])), csvQuote(fmt.Sprint(detail["full_name"])), csvQuote(fmt.Sprint(detail["email"])),
+			detail["role"], lastLoginLabel(detail), csvQuote(accountFlagLabels(detail, summary.DormantDays))))
+	}
+	content.WriteString("\n")
+
+	content.WriteString("PROFIL TANPA AKTIVITAS\n")
+	content.WriteString("No,ID,Nama,Email,Satker\n")
+	for i, detail := range profiles {
+		content.WriteString(fmt.Sprintf("%d,%d,%s,%s,%s\n", i+1, detail["id"],
+			csvQuote(fmt.Sprint(detail["nama"])), csvQuote(fmt.Sprint(detail["email"])), csvQuote(fmt.Sprint(detail["satker_name"]))))
+	}
+
+	// Footer
+	content.WriteString("\n")
+	content.WriteString(fmt.Sprintf("Laporan dibuat oleh: %s\n", metadata.GeneratedBy))
+	content.WriteString(fmt.Sprintf("Tanggal: %s\n", time.Now().Format("02/01/2006 15:04:05")))
+
+	return filename, rg.writeCSVFile(filename, content.String())
+}
+
+// generateAccountHygieneExcel membuat file Excel laporan akun dorman dan yatim: sheet Ringkasan, Akun Ditandai, dan Profil Tanpa Aktivitas.
+func (rg *ReportGenerator) generateAccountHygieneExcel(data *repository.ReportData, metadata ReportMetadata) (string, error) {
+	filename := rg.generateFilename("laporan_akun_dorman", "xlsx")
+	summary, accounts, profiles := accountHygieneData(data)
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	summarySheet := "Ringkasan"
+	accountSheet := "Akun Ditandai"
+	profileSheet := "Profil Tanpa Aktivitas"
+	f.SetSheetName("Sheet1", summarySheet)
+	f.NewSheet(accountSheet)
+	f.NewSheet(profileSheet)
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 11},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#4472C4"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+	titleStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14},
+	})
+	cellStyle, _ := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+
+	// Summary Sheet
+	f.SetCellValue(summarySheet, "A1", "LAPORAN AKUN DORMAN DAN YATIM")
+	f.SetCellStyle(summarySheet, "A1", "A1", titleStyle)
+	f.MergeCell(summarySheet, "A1", "D1")
+
+	f.SetCellValue(summarySheet, "A3", "Dibuat oleh:")
+	f.SetCellValue(summarySheet, "B3", fmt.Sprintf("%s (%s)", metadata.Username, metadata.Email))
+	f.SetCellValue(summarySheet, "A4", "Tanggal Dibuat:")
+	f.SetCellValue(summarySheet, "B4", data.GeneratedAt.Format("02 January 2006 15:04:05"))
+	f.SetCellValue(summarySheet, "A5", "Kondisi Data:")
+	f.SetCellValue(summarySheet, "B5", data.Period)
+
+	f.SetCellValue(summarySheet, "A7", "RINGKASAN")
+	f.SetCellStyle(summarySheet, "A7", "A7", titleStyle)
+	f.SetCellValue(summarySheet, "A8", "Akun Ditandai:")
+	f.SetCellValue(summarySheet, "B8", summary.Flagged)
+	f.SetCellValue(summarySheet, "A9", fmt.Sprintf("Tidak Login > %d Hari:", summary.DormantDays))
+	f.SetCellValue(summarySheet, "B9", summary.Dormant)
+	f.SetCellValue(summarySheet, "A10", "Satker Tidak Ada:")
+	f.SetCellValue(summarySheet, "B10", summary.Orphaned)
+	f.SetCellValue(summarySheet, "A11", "Profil Tanpa Aktivitas:")
+	f.SetCellValue(summarySheet, "B11", summary.InactiveProfiles)
+	f.SetColWidth(summarySheet, "A", "A", 24)
+	f.SetColWidth(summarySheet, "B", "B", 40)
+
+	// Account Sheet
+	accountHeaders := []string{"No", "ID", "Username", "Nama Lengkap", "Email", "Role", "Login Terakhir", "Alasan"}
+	for i, header := range accountHeaders {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetCellValue(accountSheet, col+"1", header)
+	}
+	f.SetCellStyle(accountSheet, "A1", "H1", headerStyle)
+	for i, detail := range accounts {
+		row := i + 2
+		f.SetCellValue(accountSheet, fmt.Sprintf("A%d", row), i+1)
+		f.SetCellValue(accountSheet, fmt.Sprintf("B%d", row), detail["id"])
+		f.SetCellValue(accountSheet, fmt.Sprintf("C%d", row), detail["username"])
+		f.SetCellValue(accountSheet, fmt.Sprintf("D%d", row), detail["full_name"])
+		f.SetCellValue(accountSheet, fmt.Sprintf("E%d", row), detail["email"])
+		f.SetCellValue(accountSheet, fmt.Sprintf("F%d", row), detail["role"])
+		f.SetCellValue(accountSheet, fmt.Sprintf("G%d", row), lastLoginLabel(detail))
+		f.SetCellValue(accountSheet, fmt.Sprintf("H%d", row), accountFlagLabels(detail, summary.DormantDays))
+		f.SetCellStyle(accountSheet, fmt.Sprintf("A%d", row), fmt.Sprintf("H%d", row), cellStyle)
+	}
+	f.SetColWidth(accountSheet, "A", "B", 8)
+	f.SetColWidth(accountSheet, "C", "E", 28)
+	f.SetColWidth(accountSheet, "F", "G", 18)
+	f.SetColWidth(accountSheet, "H", "H", 36)
+
+	// Profile Sheet
+	profileHeaders := []string{"No", "ID", "Nama", "Email", "Satker"}
+	for i, header := range profileHeaders {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetCellValue(profileSheet, col+"1", header)
+	}
+	f.SetCellStyle(profileSheet, "A1", "E1", headerStyle)
+	for i, detail := range profiles {
+		row := i + 2
+		f.SetCellValue(profileSheet, fmt.Sprintf("A%d", row), i+1)
+		f.SetCellValue(profileSheet, fmt.Sprintf("B%d", row), detail["id"])
+		f.SetCellValue(profileSheet, fmt.Sprintf("C%d", row), detail["nama"])
+		f.SetCellValue(profileSheet, fmt.Sprintf("D%d", row), detail["email"])
+		f.SetCellValue(profileSheet, fmt.Sprintf("E%d", row), detail["satker_name"])
+		f.SetCellStyle(profileSheet, fmt.Sprintf("A%d", row), fmt.Sprintf("E%d", row), cellStyle)
+	}
+	f.SetColWidth(profileSheet, "A", "B", 8)
+	f.SetColWidth(profileSheet, "C", "D", 32)
+	f.SetColWidth(profileSheet, "E", "E", 48)
+
+	if err := f.SaveAs(filename); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// generateAccountHygienePDF membuat file PDF (A4 landscape) laporan akun dorman dan yatim: ringkasan, tabel akun ditandai, tabel profil tanpa aktivitas, footer nomor halaman.
+func (rg *ReportGenerator) generateAccountHygienePDF(data *repository.ReportData, metadata ReportMetadata) (string, error) {
+	filename := rg.generateFilename("laporan_akun_dorman", "pdf")
+	summary, accounts, profiles := accountHygieneData(data)
+
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.AddPage()
+
+	// Header
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "LAPORAN AKUN DORMAN DAN YATIM")
+	pdf.Ln(12)
+
+	// Metadata
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(40, 6, "Dibuat oleh:")
+	pdf.Cell(0, 6, fmt.Sprintf("%s (%s)", metadata.Username, metadata.Email))
+	pdf.Ln(6)
+	pdf.Cell(40, 6, "Tanggal Dibuat:")
+	pdf.Cell(0, 6, data.GeneratedAt.Format("02 January 2006 15:04:05"))
+	pdf.Ln(6)
+	pdf.Cell(40, 6, "Kondisi Data:")
+	pdf.Cell(0, 6, data.Period)
+	pdf.Ln(10)
+
+	// Summary Box
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, "RINGKASAN")
+	pdf.Ln(8)
+
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Arial", "", 10)
+	summaryRow := func(label string, value int) {
+		pdf.CellFormat(95, 8, label, "1", 0, "L", true, 0, "")
+		pdf.CellFormat(95, 8, formatNumber(value), "1", 1, "R", true, 0, "")
+	}
+	summaryRow("Akun Ditandai:", summary.Flagged)
+	summaryRow(fmt.Sprintf("Tidak Login > %d Hari:", summary.DormantDays), summary.Dormant)
+	summaryRow("Satker Tidak Ada:", summary.Orphaned)
+	summaryRow("Profil Tanpa Aktivitas:", summary.InactiveProfiles)
+	pdf.Ln(10)
+
+	// table menulis satu tabel bergaris dengan header berwarna; header diulang di halaman baru.
+	table := func(title string, headers []string, widths []float64, rows [][]string) {
+		pdf.SetFont("Arial", "B", 12)
+		pdf.Cell(0, 8, title)
+		pdf.Ln(8)
+		header := func() {
+			pdf.SetFillColor(68, 114, 196)
+			pdf.SetTextColor(255, 255, 255)
+			pdf.SetFont("Arial", "B", 8)
+			for i, h := range headers {
+				pdf.CellFormat(widths[i], 8, h, "1", 0, "C", true, 0, "")
+			}
+			pdf.Ln(-1)
+			pdf.SetTextColor(0, 0, 0)
+			pdf.SetFont("Arial", "", 8)
+		}
+		header()
+		for i, row := range rows {
+			if i%2 == 0 {
+				pdf.SetFillColor(255, 255, 255)
+			} else {
+				pdf.SetFillColor(245, 245, 245)
+			}
+			for j, cell := range row {
+				pdf.CellFormat(widths[j], 6, truncateString(cell, int(widths[j]/1.6)), "1", 0, "L", true, 0, "")
+			}
+			pdf.Ln(-1)
+
+			// Check if we need a new page
+			if pdf.GetY() > 180 {
+				pdf.AddPage()
+				header()
+			}
+		}
+		pdf.Ln(8)
+	}
+
+	accountRows := make([][]string, 0, len(accounts))
+	for i, detail := range accounts {
+		accountRows = append(accountRows, []string{
+			fmt.Sprintf("%d", i+1), fmt.Sprint(detail["username"]), fmt.Sprint(detail["full_name"]), fmt.Sprint(detail["email"]),
+			fmt.Sprint(detail["role"]), lastLoginLabel(detail), accountFlagLabels(detail, summary.DormantDays),
+		})
+	}
+	table("AKUN DITANDAI", []string{"No", "Username", "Nama Lengkap", "Email", "Role", "Login Terakhir", "Alasan"},
+		[]float64{12, 35, 50, 60, 22, 30, 68}, accountRows)
+
+	profileRows := make([][]string, 0, len(profiles))
+	for i, detail := range profiles {
+		profileRows = append(profileRows, []string{
+			fmt.Sprintf("%d", i+1), fmt.Sprint(detail["nama"]), fmt.Sprint(detail["email"]), fmt.Sprint(detail["satker_name"]),
+		})
+	}
+	table("PROFIL TANPA AKTIVITAS", []string{"No", "Nama", "Email", "Satker"}, []float64{12, 70, 70, 125}, profileRows)
+
+	// Footer
+	pdf.SetFont("Arial", "I", 8)
+	pdf.Cell(0, 6, "Profil tanpa aktivitas tidak memiliki login; hanya akun ditandai yang dapat dinonaktifkan massal oleh admin.")
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Laporan dibuat oleh %s pada %s", metadata.GeneratedBy, time.Now().Format("02/01/2006 15:04:05")))
+
+	// Add page numbers
+	pdf.AliasNbPages("")
+	pdf.SetY(-15)
+	pdf.SetFont("Arial", "I", 8)
+	pdf.Cell(0, 10, fmt.Sprintf("Halaman %d dari {nb}", pdf.PageNo()))
+
+	if err := pdf.OutputFileAndClose(filename); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
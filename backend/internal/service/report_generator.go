@@ -1,4 +1,4 @@
-// File report_generator.go: pembuatan file laporan (CSV, Excel, PDF) berdasarkan template (org-performance, user-activity, feature-usage).
+// File report_generator.go: pembuatan file laporan (CSV, Excel, PDF) berdasarkan template (org-performance, user-activity, feature-usage, user-retention, account-hygiene).
 //
 // ReportGenerator punya OutputDir; GenerateCSV/GenerateExcel/GeneratePDF memilih fungsi generator sesuai templateID lalu menulis file ke OutputDir. Helper: generateFilename, writeCSVFile, formatNumber, calculatePercentage, categorizeFeature, truncateString.
 package service
@@ -36,7 +36,7 @@ func NewReportGenerator(outputDir string) *ReportGenerator {
 	}
 }
 
-// GenerateCSV memilih generator CSV sesuai templateID (org-performance, user-activity, feature-usage) lalu mengembalikan path file yang dibuat.
+// GenerateCSV memilih generator CSV sesuai templateID (org-performance, user-activity, feature-usage, user-retention, account-hygiene) lalu mengembalikan path file yang dibuat.
 func (rg *ReportGenerator) GenerateCSV(templateID string, data *repository.ReportData, metadata ReportMetadata) (string, error) {
 	switch templateID {
 	case "org-performance":
@@ -45,6 +45,10 @@ func (rg *ReportGenerator) GenerateCSV(templateID string, data *repository.Repor
 		return rg.generateUserActivityCSV(data, metadata)
 	case "feature-usage":
 		return rg.generateFeatureUsageCSV(data, metadata)
+	case "user-retention":
+		return rg.generateUserRetentionCSV(data, metadata)
+	case "account-hygiene":
+		return rg.generateAccountHygieneCSV(data, metadata)
 	default:
 		return "", fmt.Errorf("unknown template ID: %s", templateID)
 	}
@@ -59,6 +63,10 @@ func (rg *ReportGenerator) GenerateExcel(templateID string, data *repository.Rep
 		return rg.generateUserActivityExcel(data, metadata)
 	case "feature-usage":
 		return rg.generateFeatureUsageExcel(data, metadata)
+	case "user-retention":
+		return rg.generateUserRetentionExcel(data, metadata)
+	case "account-hygiene":
+		return rg.generateAccountHygieneExcel(data, metadata)
 	default:
 		return "", fmt.Errorf("unknown template ID: %s", templateID)
 	}
@@ -73,6 +81,10 @@ func (rg *ReportGenerator) GeneratePDF(templateID string, data *repository.Repor
 		return rg.generateUserActivityPDF(data, metadata)
 	case "feature-usage":
 		return rg.generateFeatureUsagePDF(data, metadata)
+	case "user-retention":
+		return rg.generateUserRetentionPDF(data, metadata)
+	case "account-hygiene":
+		return rg.generateAccountHygienePDF(data, metadata)
 	default:
 		return "", fmt.Errorf("unknown template ID: %s", templateID)
 	}
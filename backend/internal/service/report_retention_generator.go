@@ -0,0 +1,307 @@
+// File report_retention_generator.go: generator CSV, Excel, dan PDF untuk template user-retention (matriks kohort retensi bulanan).
+//
+// Data dari repository.GenerateReportData: Summary (total_users, cohort_count, periods, average_retention) dan Details per kohort (cohort, size, retention).
+// Retensi ditulis sebagai persentase; sel bulan yang belum berjalan dibiarkan kosong.
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// retentionSummary mengambil ringkasan laporan retensi dari data.Summary.
+func retentionSummary(data *repository.ReportData) (totalUsers, cohortCount, periods int, average []float64) {
+	totalUsers, _ = data.Summary["total_users"].(int)
+	cohortCount, _ = data.Summary["cohort_count"].(int)
+	periods, _ = data.Summary["periods"].(int)
+	average, _ = data.Summary["average_retention"].([]float64)
+	return totalUsers, cohortCount, periods, average
+}
+
+// retentionHeaders label kolom bulan ke-0..periods.
+func retentionHeaders(periods int) []string {
+	headers := make([]string, 0, periods+1)
+	for i := 0; i <= periods; i++ {
+		headers = append(headers, fmt.Sprintf("Bulan ke-%d", i))
+	}
+	return headers
+}
+
+// formatRetention memformat retensi (0–1) sebagai persentase 2 desimal.
+func formatRetention(v float64) string {
+	return fmt.Sprintf("%.2f", v*100)
+}
+
+// generateUserRetentionCSV membuat file CSV laporan retensi pengguna: header metadata, ringkasan, matriks kohort (Kohort, Jumlah Pengguna, retensi per bulan), footer.
+func (rg *ReportGenerator) generateUserRetentionCSV(data *repository.ReportData, metadata ReportMetadata) (string, error) {
+	filename := rg.generateFilename("laporan_retensi_pengguna", "csv")
+	totalUsers, cohortCount, periods, average := retentionSummary(data)
+
+	var content strings.Builder
+
+	// Header metadata
+	content.WriteString("LAPORAN RETENSI PENGGUNA\n")
+	content.WriteString(fmt.Sprintf("Dibuat oleh: %s (%s)\n", metadata.Username, metadata.Email))
+	content.WriteString(fmt.Sprintf("Tanggal Dibuat: %s\n", data.GeneratedAt.Format("02 January 2006 15:04:05")))
+	content.WriteString(fmt.Sprintf("Periode Kohort: %s\n", data.Period))
+	content.WriteString("\n")
+
+	content.WriteString("RINGKASAN\n")
+	content.WriteString(fmt.Sprintf("Total Pengguna Baru: %s\n", formatNumber(totalUsers)))
+	content.WriteString(fmt.Sprintf("Jumlah Kohort: %d\n", cohortCount))
+	if len(average) > 1 {
+		content.WriteString(fmt.Sprintf("Rata-rata Retensi Bulan ke-1 (%%): %s\n", formatRetention(average[1])))
+	}
+	content.WriteString("\n")
+
+	// Matriks: satu baris per kohort, kolom retensi (%) per bulan sejak aktivitas pertama.
+	content.WriteString("MATRIKS KOHORT (% PENGGUNA AKTIF)\n")
+	content.WriteString("Kohort,Jumlah Pengguna," + strings.Join(retentionHeaders(periods), ",") + "\n")
+	for _, detail := range data.Details {
+		retention, _ := detail["retention"].([]float64)
+		cells := make([]string, periods+1)
+		for i, v := range retention {
+			if i <= periods {
+				cells[i] = formatRetention(v)
+			}
+		}
+		content.WriteString(fmt.Sprintf("%s,%d,%s\n", detail["cohort"], detail["size"], strings.Join(cells, ",")))
+	}
+	avgCells := make([]string, periods+1)
+	for i, v := range average {
+		if i <= periods {
+			avgCells[i] = formatRetention(v)
+		}
+	}
+	content.WriteString(fmt.Sprintf("Rata-rata,%d,%s\n", totalUsers, strings.Join(avgCells, ",")))
+
+	// Footer
+	content.WriteString("\n")
+	content.WriteString(fmt.Sprintf("Laporan dibuat oleh: %s\n", metadata.GeneratedBy))
+	content.WriteString(fmt.Sprintf("Tanggal: %s\n", time.Now().Format("02/01/2006 15:04:05")))
+
+	return filename, rg.writeCSVFile(filename, content.String())
+}
+
+// generateUserRetentionExcel membuat file Excel laporan retensi pengguna: sheet Ringkasan dan Matriks Kohort (retensi sebagai angka persen).
+func (rg *ReportGenerator) generateUserRetentionExcel(data *repository.ReportData, metadata ReportMetadata) (string, error) {
+	filename := rg.generateFilename("laporan_retensi_pengguna", "xlsx")
+	totalUsers, cohortCount, periods, average := retentionSummary(data)
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	summarySheet := "Ringkasan"
+	matrixSheet := "Matriks Kohort"
+	f.SetSheetName("Sheet1", summarySheet)
+	f.NewSheet(matrixSheet)
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 11},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#4472C4"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+	titleStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14},
+	})
+	cellStyle, _ := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+
+	// Summary Sheet
+	f.SetCellValue(summarySheet, "A1", "LAPORAN RETENSI PENGGUNA")
+	f.SetCellStyle(summarySheet, "A1", "A1", titleStyle)
+	f.MergeCell(summarySheet, "A1", "D1")
+
+	f.SetCellValue(summarySheet, "A3", "Dibuat oleh:")
+	f.SetCellValue(summarySheet, "B3", fmt.Sprintf("%s (%s)", metadata.Username, metadata.Email))
+	f.SetCellValue(summarySheet, "A4", "Tanggal Dibuat:")
+	f.SetCellValue(summarySheet, "B4", data.GeneratedAt.Format("02 January 2006 15:04:05"))
+	f.SetCellValue(summarySheet, "A5", "Periode Kohort:")
+	f.SetCellValue(summarySheet, "B5", data.Period)
+
+	f.SetCellValue(summarySheet, "A7", "RINGKASAN")
+	f.SetCellStyle(summarySheet, "A7", "A7", titleStyle)
+	f.SetCellValue(summarySheet, "A8", "Total Pengguna Baru:")
+	f.SetCellValue(summarySheet, "B8", totalUsers)
+	f.SetCellValue(summarySheet, "A9", "Jumlah Kohort:")
+	f.SetCellValue(summarySheet, "B9", cohortCount)
+	if len(average) > 1 {
+		f.SetCellValue(summarySheet, "A10", "Retensi Bulan ke-1 (%):")
+		f.SetCellValue(summarySheet, "B10", average[1]*100)
+	}
+	f.SetColWidth(summarySheet, "A", "A", 24)
+	f.SetColWidth(summarySheet, "B", "B", 40)
+
+	// Matrix Sheet: kolom A = kohort, B = jumlah pengguna, C.. = retensi bulan ke-0..N.
+	lastCol, _ := excelize.ColumnNumberToName(periods + 3)
+	f.SetCellValue(matrixSheet, "A1", "Kohort")
+	f.SetCellValue(matrixSheet, "B1", "Jumlah Pengguna")
+	for i, header := range retentionHeaders(periods) {
+		col, _ := excelize.ColumnNumberToName(i + 3)
+		f.SetCellValue(matrixSheet, col+"1", header+" (%)")
+	}
+	f.SetCellStyle(matrixSheet, "A1", lastCol+"1", headerStyle)
+
+	writeRow := func(row int, label string, size int, retention []float64) {
+		f.SetCellValue(matrixSheet, fmt.Sprintf("A%d", row), label)
+		f.SetCellValue(matrixSheet, fmt.Sprintf("B%d", row), size)
+		for i, v := range retention {
+			if i > periods {
+				break
+			}
+			col, _ := excelize.ColumnNumberToName(i + 3)
+			f.SetCellValue(matrixSheet, fmt.Sprintf("%s%d", col, row), v*100)
+		}
+		f.SetCellStyle(matrixSheet, fmt.Sprintf("A%d", row), fmt.Sprintf("%s%d", lastCol, row), cellStyle)
+	}
+	for i, detail := range data.Details {
+		retention, _ := detail["retention"].([]float64)
+		size, _ := detail["size"].(int)
+		cohort, _ := detail["cohort"].(string)
+		writeRow(i+2, cohort, size, retention)
+	}
+	writeRow(len(data.Details)+2, "Rata-rata", totalUsers, average)
+
+	f.SetColWidth(matrixSheet, "A", "A", 14)
+	f.SetColWidth(matrixSheet, "B", "B", 18)
+	f.SetColWidth(matrixSheet, "C", lastCol, 14)
+
+	if err := f.SaveAs(filename); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// generateUserRetentionPDF membuat file PDF (A4 landscape) laporan retensi pengguna: ringkasan dan matriks kohort, footer nomor halaman.
+func (rg *ReportGenerator) generateUserRetentionPDF(data *repository.ReportData, metadata ReportMetadata) (string, error) {
+	filename := rg.generateFilename("laporan_retensi_pengguna", "pdf")
+	totalUsers, cohortCount, periods, average := retentionSummary(data)
+
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.AddPage()
+
+	// Header
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "LAPORAN RETENSI PENGGUNA")
+	pdf.Ln(12)
+
+	// Metadata
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(40, 6, "Dibuat oleh:")
+	pdf.Cell(0, 6, fmt.Sprintf("%s (%s)", metadata.Username, metadata.Email))
+	pdf.Ln(6)
+	pdf.Cell(40, 6, "Tanggal Dibuat:")
+	pdf.Cell(0, 6, data.GeneratedAt.Format("02 January 2006 15:04:05"))
+	pdf.Ln(6)
+	pdf.Cell(40, 6, "Periode Kohort:")
+	pdf.Cell(0, 6, data.Period)
+	pdf.Ln(10)
+
+	// Summary Box
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, "RINGKASAN")
+	pdf.Ln(8)
+
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(95, 8, "Total Pengguna Baru:", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(95, 8, formatNumber(totalUsers), "1", 1, "R", true, 0, "")
+	pdf.CellFormat(95, 8, "Jumlah Kohort:", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(95, 8, fmt.Sprintf("%d", cohortCount), "1", 1, "R", true, 0, "")
+	if len(average) > 1 {
+		pdf.CellFormat(95, 8, "Rata-rata Retensi Bulan ke-1:", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(95, 8, formatRetention(average[1])+"%", "1", 1, "R", true, 0, "")
+	}
+	pdf.Ln(10)
+
+	// Matrix Table: lebar kolom bulan dibagi rata dari sisa lebar halaman (277 mm).
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, "MATRIKS KOHORT (% PENGGUNA AKTIF)")
+	pdf.Ln(8)
+
+	cohortWidth, sizeWidth := 25.0, 20.0
+	periodWidth := (277 - cohortWidth - sizeWidth) / float64(periods+1)
+	tableHeader := func() {
+		pdf.SetFillColor(68, 114, 196)
+		pdf.SetTextColor(255, 255, 255)
+		pdf.SetFont("Arial", "B", 7)
+		pdf.CellFormat(cohortWidth, 8, "Kohort", "1", 0, "C", true, 0, "")
+		pdf.CellFormat(sizeWidth, 8, "Pengguna", "1", 0, "C", true, 0, "")
+		for i := 0; i <= periods; i++ {
+			pdf.CellFormat(periodWidth, 8, fmt.Sprintf("B-%d", i), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Arial", "", 7)
+	}
+	tableRow := func(i int, label string, size int, retention []float64) {
+		if i%2 == 0 {
+			pdf.SetFillColor(255, 255, 255)
+		} else {
+			pdf.SetFillColor(245, 245, 245)
+		}
+		pdf.CellFormat(cohortWidth, 6, label, "1", 0, "C", true, 0, "")
+		pdf.CellFormat(sizeWidth, 6, formatNumber(size), "1", 0, "R", true, 0, "")
+		for p := 0; p <= periods; p++ {
+			cell := ""
+			if p < len(retention) {
+				cell = formatRetention(retention[p])
+			}
+			pdf.CellFormat(periodWidth, 6, cell, "1", 0, "R", true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	tableHeader()
+	for i, detail := range data.Details {
+		retention, _ := detail["retention"].([]float64)
+		size, _ := detail["size"].(int)
+		cohort, _ := detail["cohort"].(string)
+		tableRow(i, cohort, size, retention)
+
+		// Check if we need a new page
+		if pdf.GetY() > 180 {
+			pdf.AddPage()
+			tableHeader()
+		}
+	}
+	pdf.SetFont("Arial", "B", 7)
+	tableRow(len(data.Details), "Rata-rata", totalUsers, average)
+
+	// Footer
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "I", 8)
+	pdf.Cell(0, 6, "B-n = porsi pengguna kohort yang aktif pada bulan ke-n sejak bulan aktivitas pertama (B-0 = bulan aktivitas pertama).")
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Laporan dibuat oleh %s pada %s", metadata.GeneratedBy, time.Now().Format("02/01/2006 15:04:05")))
+
+	// Add page numbers
+	pdf.AliasNbPages("")
+	pdf.SetY(-15)
+	pdf.SetFont("Arial", "I", 8)
+	pdf.Cell(0, 10, fmt.Sprintf("Halaman %d dari {nb}", pdf.PageNo()))
+
+	if err := pdf.OutputFileAndClose(filename); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
@@ -0,0 +1,409 @@
+// File security_alert_service.go: peringatan keamanan dari aktivitas yang baru diimpor (akses di luar jam kerja, lokasi tidak biasa).
+//
+// Evaluate menilai baris activity_logs_normalized dengan id > watermark (security_alert_state) per batch: setiap batch, peringatan baru,
+// notifikasi in-app ke tim keamanan (user aktif dengan SECURITY_ALERT_ROLE), dan watermark disimpan dalam satu transaksi.
+// Zona waktu aktivitas ditentukan dari provinsi lokasi akses (WIB/WITA/WIT), fallback SECURITY_DEFAULT_TIMEZONE. tanggal berisi jam dinding CSV yang
+// disimpan apa adanya sebagai UTC (cmd/import memakai time.Parse) dan ditampilkan apa adanya oleh dashboard, sehingga jam itu dipakai langsung sebagai
+// jam lokal zona tersebut (activityWallClock), bukan dikonversi dari UTC.
+// Lokasi normal user dipelajari dari riwayat sebelum batch (SECURITY_LOCATION_LEARNING_WINDOW); user dengan riwayat terlalu sedikit tidak dinilai.
+// Peringatan dideduplikasi per aturan, user, dan hari lokal (ditambah lokasi untuk unusual_location).
+package service
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/sqlbuilder"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notificationRelatedSecurity nilai notifications.related_entity untuk notifikasi peringatan keamanan.
+const notificationRelatedSecurity = "security_alert"
+
+// provinceZones kata kunci provinsi (huruf kecil) per zona waktu; dicek berurutan WIT, WITA, WIB.
+var provinceZones = []struct {
+	zone     string
+	keywords []string
+}{
+	{"WIT", []string{"maluku", "papua"}},
+	{"WITA", []string{"bali", "nusa tenggara", "ntb", "ntt", "kalimantan selatan", "kalimantan timur", "kalimantan utara", "sulawesi", "gorontalo"}},
+	{"WIB", []string{"aceh", "sumatera", "sumatra", "riau", "jambi", "bengkulu", "lampung", "bangka", "jakarta", "jawa", "banten", "yogyakarta", "kalimantan barat", "kalimantan tengah"}},
+}
+
+// dayNames nama hari (time.Weekday: Minggu = 0).
+var dayNames = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+// SecurityAlertResult ringkasan satu Evaluate.
+type SecurityAlertResult struct {
+	Processed       int   // Aktivitas baru yang dinilai.
+	OffHours        int   // Peringatan off_hours baru.
+	UnusualLocation int   // Peringatan unusual_location baru.
+	LastLogID       int64 // Watermark setelah evaluasi.
+}
+
+// SecurityAlertListFilter filter daftar peringatan; field kosong diabaikan.
+type SecurityAlertListFilter struct {
+	Rule      string
+	UserID    int64
+	StartDate string
+	EndDate   string
+	Page      int
+	PageSize  int
+}
+
+// securityActivity satu aktivitas baru yang dinilai.
+type securityActivity struct {
+	ID           int64
+	UserID       int64
+	UserName     string
+	SatkerID     *int64
+	LocationID   *int64
+	LocationName string
+	Province     string
+	Tanggal      time.Time
+}
+
+// SecurityAlertService evaluasi dan daftar peringatan keamanan.
+type SecurityAlertService struct {
+	db  *gorm.DB
+	cfg config.SecurityAlertConfig
+}
+
+// NewSecurityAlertService membuat SecurityAlertService dengan konfigurasi dari env.
+func NewSecurityAlertService(db *gorm.DB) *SecurityAlertService {
+	return &SecurityAlertService{db: db, cfg: config.GetSecurityAlertConfig()}
+}
+
+// Evaluate menilai semua aktivitas baru sejak watermark, batch demi batch. Tidak melakukan apa pun jika SECURITY_ALERTS_ENABLED=false
+// (watermark tidak maju; aktivitas yang tertunda dinilai setelah diaktifkan kembali).
+func (s *SecurityAlertService) Evaluate() (*SecurityAlertResult, error) {
+	result := &SecurityAlertResult{}
+	if !s.cfg.Enabled {
+		return result, nil
+	}
+	for {
+		n, err := s.evaluateBatch(result)
+		if err != nil {
+			return nil, err
+		}
+		if n < config.SecurityAlertBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// evaluateBatch menilai satu batch dalam satu transaksi dan mengembalikan jumlah aktivitas yang dinilai.
+func (s *SecurityAlertService) evaluateBatch(result *SecurityAlertResult) (int, error) {
+	var processed int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var watermark int64
+		if err := tx.Raw("SELECT last_log_id FROM security_alert_state WHERE id = 1 FOR UPDATE").Scan(&watermark).Error; err != nil {
+			return err
+		}
+
+		var activities []securityActivity
+		err := tx.Raw(`
+			SELECT a.id, a.user_id, COALESCE(up.nama, '') AS user_name, a.satker_id, a.location_id,
+				COALESCE(l.location_name, '') AS location_name, COALESCE(l.province, '') AS province, a.tanggal
+			FROM activity_logs_normalized a
+			LEFT JOIN user_profiles up ON up.id = a.user_id
+			LEFT JOIN ref_locations l ON l.id = a.location_id
+			WHERE a.id > ?
+			ORDER BY a.id
+			LIMIT ?
+		`, watermark, config.SecurityAlertBatchSize).Scan(&activities).Error
+		if err != nil {
+			return err
+		}
+		processed = len(activities)
+		result.LastLogID = watermark
+		if processed == 0 {
+			return nil
+		}
+
+		holidays, err := s.holidays(tx, activities)
+		if err != nil {
+			return err
+		}
+		history, err := s.locationHistory(tx, activities, watermark)
+		if err != nil {
+			return err
+		}
+
+		var created []entity.SecurityAlert
+		for _, a := range activities {
+			for _, alert := range s.evaluateActivity(a, holidays, history) {
+				res := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "dedup_key"}}, DoNothing: true}).Create(&alert)
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected == 1 {
+					created = append(created, alert)
+				}
+			}
+		}
+		if err := s.notify(tx, created); err != nil {
+			return err
+		}
+
+		last := activities[len(activities)-1].ID
+		if err := tx.Exec("UPDATE security_alert_state SET last_log_id = ?, evaluated_at = ? WHERE id = 1", last, time.Now()).Error; err != nil {
+			return err
+		}
+		result.Processed += processed
+		result.LastLogID = last
+		for _, alert := range created {
+			if alert.Rule == entity.SecurityRuleOffHours {
+				result.OffHours++
+			} else {
+				result.UnusualLocation++
+			}
+		}
+		return nil
+	})
+	return processed, err
+}
+
+// evaluateActivity menerapkan semua aturan ke satu aktivitas dan mengembalikan peringatan (belum disimpan).
+func (s *SecurityAlertService) evaluateActivity(a securityActivity, holidays map[string]bool, history map[int64]map[int64]int) []entity.SecurityAlert {
+	zone := s.zoneFor(a.Province)
+	local := activityWallClock(a.Tanggal, time.FixedZone(zone, config.TimezoneOffsets[zone]*3600))
+	localDate := local.Format(sqlbuilder.DateLayout)
+	localTime := local.Format("2006-01-02 15:04") + " " + zone
+	base := entity.SecurityAlert{
+		ActivityLogID: a.ID,
+		UserID:        a.UserID,
+		UserName:      a.UserName,
+		SatkerID:      a.SatkerID,
+		LocationID:    a.LocationID,
+		LocationName:  a.LocationName,
+		ActivityAt:    a.Tanggal,
+		LocalTime:     localTime,
+		CreatedAt:     time.Now(),
+	}
+	user := a.UserName
+	if user == "" {
+		user = "#" + strconv.FormatInt(a.UserID, 10)
+	}
+
+	var alerts []entity.SecurityAlert
+	if reason := s.offHoursReason(local, zone, holidays[localDate]); reason != "" {
+		alert := base
+		alert.Rule = entity.SecurityRuleOffHours
+		alert.DedupKey = fmt.Sprintf("%s:%d:%s", entity.SecurityRuleOffHours, a.UserID, localDate)
+		alert.Explanation = fmt.Sprintf("%s mengakses data pada %s (%s), %s.", user, localTime, dayNames[local.Weekday()], reason)
+		alerts = append(alerts, alert)
+	}
+
+	if a.LocationID != nil {
+		locations := history[a.UserID]
+		total := 0
+		for _, n := range locations {
+			total += n
+		}
+		if total >= s.cfg.LocationMinHistory && locations[*a.LocationID] < s.cfg.LocationMinCount {
+			alert := base
+			alert.Rule = entity.SecurityRuleUnusualLocation
+			alert.DedupKey = fmt.Sprintf("%s:%d:%s:%d", entity.SecurityRuleUnusualLocation, a.UserID, localDate, *a.LocationID)
+			alert.Explanation = fmt.Sprintf("%s mengakses data dari lokasi %s pada %s; lokasi ini muncul %d kali dari %d aktivitas dalam riwayat %d hari terakhir.",
+				user, a.LocationName, localTime, locations[*a.LocationID], total, int(s.cfg.LearningWindow.Hours()/24))
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// activityWallClock mengembalikan jam dinding tanggal aktivitas (field UTC, konvensi penyimpanan cmd/import) sebagai waktu di loc tanpa menggeser jam,
+// sehingga jam dan tanggal lokal sama dengan yang tampil di chart per jam dan heatmap dashboard.
+func activityWallClock(t time.Time, loc *time.Location) time.Time {
+	u := t.UTC()
+	return time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), u.Nanosecond(), loc)
+}
+
+// offHoursReason mengembalikan alasan aktivitas dianggap di luar jam kerja; kosong jika di dalam jam kerja.
+func (s *SecurityAlertService) offHoursReason(local time.Time, zone string, holiday bool) string {
+	if s.cfg.HolidaysOffHours && holiday {
+		return "hari libur nasional"
+	}
+	if s.cfg.WeekendsOffHours && (local.Weekday() == time.Saturday || local.Weekday() == time.Sunday) {
+		return "akhir pekan"
+	}
+	wh := s.cfg.WorkingHours[zone]
+	if minute := local.Hour()*60 + local.Minute(); minute < wh.Start || minute >= wh.End {
+		return fmt.Sprintf("di luar jam kerja %02d:%02d–%02d:%02d %s", wh.Start/60, wh.Start%60, wh.End/60, wh.End%60, zone)
+	}
+	return ""
+}
+
+// zoneFor menentukan zona waktu dari nama provinsi; fallback zona default.
+func (s *SecurityAlertService) zoneFor(province string) string {
+	p := strings.ToLower(province)
+	if p != "" {
+		for _, z := range provinceZones {
+			for _, kw := range z.keywords {
+				if strings.Contains(p, kw) {
+					return z.zone
+				}
+			}
+		}
+	}
+	return s.cfg.DefaultTimezone
+}
+
+// holidays mengembalikan tanggal libur (YYYY-MM-DD) di sekitar rentang batch (±1 hari sebagai margin).
+func (s *SecurityAlertService) holidays(tx *gorm.DB, activities []securityActivity) (map[string]bool, error) {
+	start := activities[0].Tanggal
+	end := start
+	for _, a := range activities {
+		if a.Tanggal.Before(start) {
+			start = a.Tanggal
+		}
+		if a.Tanggal.After(end) {
+			end = a.Tanggal
+		}
+	}
+	var days []string
+	err := tx.Raw("SELECT to_char(holiday_date, 'YYYY-MM-DD') FROM ref_holidays WHERE holiday_date BETWEEN ? AND ?",
+		start.UTC().AddDate(0, 0, -1).Format(sqlbuilder.DateLayout), end.UTC().AddDate(0, 0, 1).Format(sqlbuilder.DateLayout)).Scan(&days).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(days))
+	for _, d := range days {
+		out[d] = true
+	}
+	return out, nil
+}
+
+// locationHistory menghitung kemunculan lokasi per user dari aktivitas yang sudah dinilai (id <= watermark) dalam jendela belajar sebelum batch.
+func (s *SecurityAlertService) locationHistory(tx *gorm.DB, activities []securityActivity, watermark int64) (map[int64]map[int64]int, error) {
+	seen := map[int64]bool{}
+	var users []int64
+	since := activities[0].Tanggal
+	for _, a := range activities {
+		if a.LocationID != nil && !seen[a.UserID] {
+			seen[a.UserID] = true
+			users = append(users, a.UserID)
+		}
+		if a.Tanggal.Before(since) {
+			since = a.Tanggal
+		}
+	}
+	history := map[int64]map[int64]int{}
+	if len(users) == 0 {
+		return history, nil
+	}
+
+	var rows []struct {
+		UserID     int64
+		LocationID int64
+		Count      int
+	}
+	err := tx.Raw(`
+		SELECT user_id, location_id, COUNT(*) AS count
+		FROM activity_logs_normalized
+		WHERE user_id IN ? AND location_id IS NOT NULL AND id <= ? AND tanggal >= ?
+		GROUP BY user_id, location_id
+	`, users, watermark, since.Add(-s.cfg.LearningWindow)).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if history[row.UserID] == nil {
+			history[row.UserID] = map[int64]int{}
+		}
+		history[row.UserID][row.LocationID] = row.Count
+	}
+	return history, nil
+}
+
+// notify mengirim notifikasi in-app ke tim keamanan: satu per peringatan, atau satu ringkasan jika lebih dari SecurityAlertNotifyLimit.
+func (s *SecurityAlertService) notify(tx *gorm.DB, alerts []entity.SecurityAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	recipients, err := activeUsersWithRole(tx, s.cfg.RecipientRole, nil)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		log.Printf("Security alerts: %d peringatan baru tanpa penerima (tidak ada user aktif dengan role %s)", len(alerts), s.cfg.RecipientRole)
+		return nil
+	}
+
+	var notifications []entity.Notification
+	now := time.Now()
+	if len(alerts) > config.SecurityAlertNotifyLimit {
+		counts := map[string]int{}
+		for _, a := range alerts {
+			counts[a.Rule]++
+		}
+		for _, r := range recipients {
+			notifications = append(notifications, entity.Notification{
+				UserID: r.ID,
+				Title:  fmt.Sprintf("%d peringatan keamanan baru", len(alerts)),
+				Message: fmt.Sprintf("%d akses di luar jam kerja dan %d akses dari lokasi tidak biasa. Lihat daftar peringatan keamanan.",
+					counts[entity.SecurityRuleOffHours], counts[entity.SecurityRuleUnusualLocation]),
+				Type:          "warning",
+				RelatedEntity: notificationRelatedSecurity,
+				CreatedAt:     now,
+			})
+		}
+	} else {
+		for _, a := range alerts {
+			title := "Peringatan keamanan: akses di luar jam kerja"
+			if a.Rule == entity.SecurityRuleUnusualLocation {
+				title = "Peringatan keamanan: lokasi akses tidak biasa"
+			}
+			id := int(a.ID)
+			for _, r := range recipients {
+				notifications = append(notifications, entity.Notification{
+					UserID:        r.ID,
+					Title:         title,
+					Message:       a.Explanation,
+					Type:          "warning",
+					RelatedEntity: notificationRelatedSecurity,
+					RelatedID:     &id,
+					CreatedAt:     now,
+				})
+			}
+		}
+	}
+	return tx.Create(&notifications).Error
+}
+
+// List mengembalikan peringatan terbaru dulu sesuai filter beserta total.
+func (s *SecurityAlertService) List(f SecurityAlertListFilter) ([]entity.SecurityAlert, int64, error) {
+	query := s.db.Model(&entity.SecurityAlert{})
+	if f.Rule != "" {
+		query = query.Where("rule = ?", f.Rule)
+	}
+	if f.UserID > 0 {
+		query = query.Where("user_id = ?", f.UserID)
+	}
+	start, errStart := sqlbuilder.ParseDate(f.StartDate)
+	end, errEnd := sqlbuilder.ParseDate(f.EndDate)
+	if errStart != nil || errEnd != nil {
+		return nil, 0, sqlbuilder.ErrInvalidDate
+	}
+	if cond := sqlbuilder.DateRange("activity_at", start, end); !cond.IsEmpty() {
+		query = query.Where(cond.SQL, cond.Args...)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var alerts []entity.SecurityAlert
+	offset := (f.Page - 1) * f.PageSize
+	if err := query.Order("activity_at DESC").Order("id DESC").Offset(offset).Limit(f.PageSize).Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+	return alerts, total, nil
+}
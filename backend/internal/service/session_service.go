@@ -0,0 +1,167 @@
+// File session_service.go: sesi login (tabel auth_sessions) — dibuat saat login, divalidasi AuthMiddleware di setiap request, dicabut saat logout atau oleh user/admin.
+//
+// Setiap JWT membawa id sesi (klaim jti). Sesi yang dicabut (revoked_at) atau kedaluwarsa membuat token ditolak walau signature dan exp token masih valid;
+// begitu juga sesi milik user nonaktif (lapisan kedua selain pencabutan sesi saat akun dinonaktifkan).
+// last_seen_at diperbarui paling sering sekali per config.SessionTouchInterval agar tidak menulis ke DB di setiap request.
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionInvalid  = errors.New("sesi tidak valid, sudah dicabut, atau kedaluwarsa")
+	ErrSessionNotFound = errors.New("sesi tidak ditemukan")
+)
+
+// Alasan pencabutan sesi (auth_sessions.revoked_reason).
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedByUser         = "user_revoked"
+	SessionRevokedPasswordChange = "password_change"
+	SessionRevokedPasswordReset  = "password_reset"
+	SessionRevokedDeactivated    = "deactivated"
+)
+
+// SessionService menyimpan koneksi DB untuk mengelola auth_sessions.
+type SessionService struct {
+	db *gorm.DB
+}
+
+// NewSessionService membuat instance SessionService. db boleh berupa transaksi aktif.
+func NewSessionService(db *gorm.DB) *SessionService {
+	return &SessionService{db: db}
+}
+
+// Create membuat sesi baru untuk user dengan masa berlaku sama dengan JWT (JWT_EXPIRY).
+func (s *SessionService) Create(userID int, ip, userAgent string) (*entity.AuthSession, error) {
+	now := time.Now()
+	session := entity.AuthSession{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		Device:     describeDevice(userAgent),
+		IP:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(config.GetJWTExpiry()),
+	}
+	if err := s.db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Validate memastikan sesi ada, milik userID yang masih aktif (users.is_active), belum dicabut, dan belum kedaluwarsa; jika valid, last_seen_at (dan IP terakhir) diperbarui bila sudah lewat SessionTouchInterval.
+func (s *SessionService) Validate(sessionID string, userID int, ip string) (*entity.AuthSession, error) {
+	if sessionID == "" {
+		return nil, ErrSessionInvalid
+	}
+	var session entity.AuthSession
+	err := s.db.Where("id = ? AND user_id = ?", sessionID, userID).
+		Where("EXISTS (SELECT 1 FROM users u WHERE u.id = auth_sessions.user_id AND u.is_active)").
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionInvalid
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return nil, ErrSessionInvalid
+	}
+
+	if now.Sub(session.LastSeenAt) >= config.SessionTouchInterval {
+		session.LastSeenAt = now
+		session.IP = ip
+		if err := s.db.Model(&session).Updates(map[string]interface{}{"last_seen_at": now, "ip": ip}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &session, nil
+}
+
+// ListActive mengembalikan sesi user yang belum dicabut dan belum kedaluwarsa, terakhir aktif dulu.
+func (s *SessionService) ListActive(userID int) ([]entity.AuthSession, error) {
+	var sessions []entity.AuthSession
+	err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke mencabut satu sesi milik userID. Sesi milik user lain atau yang sudah tidak aktif → ErrSessionNotFound.
+func (s *SessionService) Revoke(userID int, sessionID, reason string) (*entity.AuthSession, error) {
+	var session entity.AuthSession
+	err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, time.Now()).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	session.RevokedReason = reason
+	if err := s.db.Model(&session).Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RevokeAll mencabut semua sesi aktif user kecuali exceptID (kosong = semua). Mengembalikan jumlah sesi yang dicabut.
+func (s *SessionService) RevokeAll(userID int, exceptID, reason string) (int64, error) {
+	query := s.db.Model(&entity.AuthSession{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	res := query.Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason})
+	return res.RowsAffected, res.Error
+}
+
+// describeDevice membuat ringkasan perangkat dari User-Agent, mis. "Chrome on Windows". Tidak dikenal → "Unknown device".
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "curl/"), strings.Contains(ua, "postman"), strings.Contains(ua, "go-http-client"):
+		return "API client"
+	}
+
+	platform := "Unknown OS"
+	switch {
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+	return browser + " on " + platform
+}
@@ -0,0 +1,452 @@
+// File user_admin_service.go: logika bisnis manajemen user oleh admin (tabel users).
+//
+// List (paginasi + filter role / is_active / report_access_status / kata kunci), Get, Create, Update, Deactivate (soft delete: is_active=false),
+// ResetPassword (reset paksa; user wajib ganti password saat login berikutnya). Setiap perubahan dicatat ke audit_events dalam transaksi yang sama.
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccountNotFound           = errors.New("user tidak ditemukan")
+	ErrInvalidRole               = errors.New("role tidak valid")
+	ErrReportAccessStatusManaged = errors.New("report_access_status hanya diubah lewat workflow akses laporan")
+	ErrSelfModification          = errors.New("admin tidak dapat menonaktifkan atau menurunkan role akun sendiri")
+	ErrLastAdmin                 = errors.New("harus ada minimal satu admin aktif")
+)
+
+// UserListFilter berisi filter dan paginasi untuk daftar user admin. Field kosong/nil tidak dipakai sebagai filter.
+type UserListFilter struct {
+	Role               string
+	IsActive           *bool
+	ReportAccessStatus string
+	Search             string // Cocokkan sebagian username, email, atau nama lengkap (case-insensitive).
+	Page               int
+	PageSize           int
+}
+
+// UserAdminService menyimpan koneksi DB untuk operasi manajemen user oleh admin.
+type UserAdminService struct {
+	db *gorm.DB
+}
+
+// NewUserAdminService membuat instance UserAdminService.
+func NewUserAdminService(db *gorm.DB) *UserAdminService {
+	return &UserAdminService{db: db}
+}
+
+// List mengembalikan satu halaman user sesuai filter (urut id) beserta total baris yang cocok.
+func (s *UserAdminService) List(f UserListFilter) ([]entity.User, int64, error) {
+	query := s.db.Model(&entity.User{})
+	if f.Role != "" {
+		query = query.Where("role = ?", f.Role)
+	}
+	if f.IsActive != nil {
+		query = query.Where("is_active = ?", *f.IsActive)
+	}
+	if f.ReportAccessStatus != "" {
+		query = query.Where("report_access_status = ?", f.ReportAccessStatus)
+	}
+	if q := strings.TrimSpace(f.Search); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("username ILIKE ? OR email ILIKE ? OR full_name ILIKE ?", like, like, like)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []entity.User
+	offset := (f.Page - 1) * f.PageSize
+	if err := query.Order("id").Offset(offset).Limit(f.PageSize).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Get mengembalikan user by id; ErrAccountNotFound jika tidak ada.
+func (s *UserAdminService) Get(id int) (*entity.User, error) {
+	return findUserByID(s.db, id)
+}
+
+// Create membuat user baru. Email wajib @bpk.go.id; role default user; is_active default true.
+// Jika password kosong dibuat password sementara acak yang dikembalikan ke pemanggil (string kedua). User baru selalu wajib ganti password saat login pertama.
+func (s *UserAdminService) Create(actor AuditActor, req entity.AdminCreateUserRequest) (*entity.User, string, error) {
+	email := strings.TrimSpace(req.Email)
+	if !strings.HasSuffix(email, "@bpk.go.id") {
+		return nil, "", ErrInvalidEmail
+	}
+	role := req.Role
+	if role == "" {
+		role = entity.RoleUser
+	}
+	if !entity.IsValidRole(role) {
+		return nil, "", ErrInvalidRole
+	}
+
+	now := time.Now()
+	user := entity.User{
+		Username:           strings.TrimSpace(req.Username),
+		Email:              email,
+		FullName:           strings.TrimSpace(req.FullName),
+		Role:               role,
+		SatkerID:           req.SatkerID,
+		IsActive:           true,
+		ReportAccessStatus: "none",
+		EmailVerifiedAt:    &now, // Email ditetapkan admin.
+	}
+	if req.IsActive != nil {
+		user.IsActive = *req.IsActive
+	}
+
+	password := req.Password
+	var tempPassword string
+	if password == "" {
+		generated, err := GenerateTemporaryPassword()
+		if err != nil {
+			return nil, "", err
+		}
+		password = generated
+		tempPassword = generated
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkUniqueUser(tx, 0, user.Username, user.Email); err != nil {
+			return err
+		}
+		if user.SatkerID != nil {
+			if err := checkSatkerExists(tx, *user.SatkerID); err != nil {
+				return err
+			}
+		}
+		if err := NewPasswordPolicy(tx).ApplyNewPassword(tx, &user, password); err != nil {
+			return err
+		}
+		user.MustChangePassword = true
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		// is_active punya default:true di GORM, jadi nilai false tidak ikut INSERT; set eksplisit.
+		if !user.IsActive {
+			if err := tx.Model(&user).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserCreate,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &user, tempPassword, nil
+}
+
+// Update mengubah field user yang dikirim (non-nil). Admin tidak boleh menonaktifkan/menurunkan role dirinya sendiri dan admin aktif terakhir tidak boleh hilang.
+// report_access_status ditolak (ErrReportAccessStatusManaged): status itu cermin grant dan hanya diubah workflow akses laporan (setUserAccessStatus).
+func (s *UserAdminService) Update(actor AuditActor, id int, req entity.AdminUpdateUserRequest) (*entity.User, error) {
+	if req.ReportAccessStatus != nil {
+		return nil, ErrReportAccessStatusManaged
+	}
+	var user *entity.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = findUserByID(tx, id)
+		if err != nil {
+			return err
+		}
+		before := *user
+
+		if req.Email != nil {
+			email := strings.TrimSpace(*req.Email)
+			if !strings.HasSuffix(email, "@bpk.go.id") {
+				return ErrInvalidEmail
+			}
+			if email != user.Email {
+				if err := checkUniqueUser(tx, user.ID, "", email); err != nil {
+					return err
+				}
+				now := time.Now()
+				user.EmailVerifiedAt = &now // Email ditetapkan admin.
+			}
+			user.Email = email
+		}
+		if req.FullName != nil {
+			user.FullName = strings.TrimSpace(*req.FullName)
+		}
+		if req.Role != nil {
+			if !entity.IsValidRole(*req.Role) {
+				return ErrInvalidRole
+			}
+			user.Role = *req.Role
+		}
+		if req.SatkerID != nil {
+			if err := checkSatkerExists(tx, *req.SatkerID); err != nil {
+				return err
+			}
+			user.SatkerID = req.SatkerID
+		}
+		if req.IsActive != nil {
+			user.IsActive = *req.IsActive
+			switch {
+			case user.IsActive:
+				user.DeactivatedAt = nil
+			case user.DeactivatedAt == nil:
+				now := time.Now()
+				user.DeactivatedAt = &now
+				if err := expireActivationTokens(tx, user.ID, now); err != nil {
+					return err
+				}
+			}
+			// Sama seperti Deactivate: JWT yang masih berlaku tidak boleh dipakai lagi setelah akun dinonaktifkan.
+			if before.IsActive && !user.IsActive {
+				if _, err := NewSessionService(tx).RevokeAll(user.ID, "", SessionRevokedDeactivated); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := checkAdminRetained(tx, actor, &before, user); err != nil {
+			return err
+		}
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserUpdate,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			Before:     before,
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Deactivate menonaktifkan user (soft delete: is_active=false); data dan riwayat aktivitas tetap tersimpan. Semua sesi login user dicabut dan link aktivasi yang belum dipakai tidak berlaku lagi.
+// Idempoten untuk user yang sudah dinonaktifkan admin; user yang masih menunggu aktivasi (nonaktif, belum pernah aktivasi) tetap diproses.
+func (s *UserAdminService) Deactivate(actor AuditActor, id int) (*entity.User, error) {
+	var user *entity.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = findUserByID(tx, id)
+		if err != nil {
+			return err
+		}
+		if !user.IsActive && user.DeactivatedAt != nil {
+			return nil
+		}
+		before := *user
+		if err := deactivateAccount(tx, actor, user); err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserDeactivate,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			Before:     before,
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// deactivateAccount menyetel is_active=false dan deactivated_at (dengan pengecekan admin terakhir/akun sendiri), menyimpan user,
+// membuat link aktivasi yang belum dipakai kedaluwarsa, dan mencabut semua sesi login-nya. Dipanggil di dalam transaksi; audit dicatat pemanggil.
+func deactivateAccount(tx *gorm.DB, actor AuditActor, user *entity.User) error {
+	before := *user
+	now := time.Now()
+	user.IsActive = false
+	user.DeactivatedAt = &now
+	if err := checkAdminRetained(tx, actor, &before, user); err != nil {
+		return err
+	}
+	if err := tx.Save(user).Error; err != nil {
+		return err
+	}
+	if err := expireActivationTokens(tx, user.ID, now); err != nil {
+		return err
+	}
+	_, err := NewSessionService(tx).RevokeAll(user.ID, "", SessionRevokedDeactivated)
+	return err
+}
+
+// expireActivationTokens membuat semua token aktivasi user yang belum dipakai kedaluwarsa per now.
+func expireActivationTokens(tx *gorm.DB, userID int, now time.Time) error {
+	return tx.Model(&entity.UserActivationToken{}).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > ?", userID, now).
+		Update("expires_at", now).Error
+}
+
+// ResetPassword mengganti password user secara paksa. newPassword kosong = dibuat password sementara acak (dikembalikan sebagai string kedua).
+// Password baru tetap melewati kebijakan password + riwayat, user wajib menggantinya saat login berikutnya, dan semua sesi login user dicabut.
+func (s *UserAdminService) ResetPassword(actor AuditActor, id int, newPassword string) (*entity.User, string, error) {
+	var tempPassword string
+	if newPassword == "" {
+		generated, err := GenerateTemporaryPassword()
+		if err != nil {
+			return nil, "", err
+		}
+		newPassword = generated
+		tempPassword = generated
+	}
+
+	var user *entity.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = findUserByID(tx, id)
+		if err != nil {
+			return err
+		}
+		before := *user
+		if err := NewPasswordPolicy(tx).ApplyNewPassword(tx, user, newPassword); err != nil {
+			return err
+		}
+		user.MustChangePassword = true
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		// Password direset admin → semua sesi lama user dicabut.
+		if _, err := NewSessionService(tx).RevokeAll(user.ID, "", SessionRevokedPasswordReset); err != nil {
+			return err
+		}
+		// Snapshot user tidak memuat hash password (json:"-"), jadi aman dicatat.
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserPasswordReset,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			Before:     before,
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return user, tempPassword, nil
+}
+
+// findUserByID mengambil user by id; gorm.ErrRecordNotFound diterjemahkan ke ErrAccountNotFound.
+func findUserByID(db *gorm.DB, id int) (*entity.User, error) {
+	var user entity.User
+	if err := db.First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// checkUniqueUser mengembalikan ErrUsernameExists / ErrEmailExists jika username atau email sudah dipakai user lain (excludeID = user yang sedang diubah; 0 untuk user baru). Nilai kosong tidak dicek.
+func checkUniqueUser(db *gorm.DB, excludeID int, username, email string) error {
+	var count int64
+	if username != "" {
+		if err := db.Model(&entity.User{}).Where("username = ? AND id <> ?", username, excludeID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUsernameExists
+		}
+	}
+	if email != "" {
+		if err := db.Model(&entity.User{}).Where("email = ? AND id <> ?", email, excludeID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrEmailExists
+		}
+	}
+	return nil
+}
+
+// checkAdminRetained mencegah admin mengunci dirinya sendiri (nonaktif / turun role) dan mencegah hilangnya admin aktif terakhir.
+func checkAdminRetained(db *gorm.DB, actor AuditActor, before, after *entity.User) error {
+	wasActiveAdmin := before.Role == entity.RoleAdmin && before.IsActive
+	isActiveAdmin := after.Role == entity.RoleAdmin && after.IsActive
+	if !wasActiveAdmin || isActiveAdmin {
+		return nil
+	}
+	if actor.UserID != nil && *actor.UserID == before.ID {
+		return ErrSelfModification
+	}
+	var others int64
+	if err := db.Model(&entity.User{}).
+		Where("role = ? AND is_active = ? AND id <> ?", entity.RoleAdmin, true, before.ID).
+		Count(&others).Error; err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// Kelompok karakter password sementara; karakter yang mirip (0/O, 1/l/I) dihilangkan agar mudah dibaca.
+const (
+	tempPasswordLower  = "abcdefghijkmnopqrstuvwxyz"
+	tempPasswordUpper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	tempPasswordDigit  = "23456789"
+	tempPasswordSymbol = "!@#$%*-_+="
+	tempPasswordLength = 16
+)
+
+// GenerateTemporaryPassword membuat password acak 16 karakter (crypto/rand) yang memuat huruf kecil, huruf besar, angka, dan simbol sehingga lolos kebijakan password default.
+func GenerateTemporaryPassword() (string, error) {
+	groups := []string{tempPasswordLower, tempPasswordUpper, tempPasswordDigit, tempPasswordSymbol}
+	all := strings.Join(groups, "")
+
+	buf := make([]byte, 0, tempPasswordLength)
+	// Satu karakter dari tiap kelompok, sisanya dari gabungan semua kelompok.
+	for _, g := range groups {
+		ch, err := randomChar(g)
+		if err != nil {
+			return "", err
+		}
+		buf = append(buf, ch)
+	}
+	for len(buf) < tempPasswordLength {
+		ch, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		buf = append(buf, ch)
+	}
+
+	// Acak urutan (Fisher–Yates) agar posisi kelompok wajib tidak bisa ditebak.
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		buf[i], buf[j.Int64()] = buf[j.Int64()], buf[i]
+	}
+	return string(buf), nil
+}
+
+// randomChar memilih satu karakter acak dari set memakai crypto/rand.
+func randomChar(set string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(set))))
+	if err != nil {
+		return 0, err
+	}
+	return set[n.Int64()], nil
+}
@@ -0,0 +1,109 @@
+// File user_engagement_service.go: status engagement profil aktivitas (active, dormant, never_active) untuk GET /api/users/engagement.
+//
+// Profil dorman = tidak ada aktivitas selama USER_DORMANT_DAYS hari. Admin melihat semua satker (opsional dibatasi root_satker_id);
+// unit_head hanya melihat pohon satker-nya sendiri agar bisa menindaklanjuti akun dorman di unitnya. RefreshStatus dipakai job user-engagement.
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEngagementForbidden = errors.New("hanya admin dan kepala unit yang boleh melihat engagement pengguna")
+	ErrEngagementNoSatker  = errors.New("akun kepala unit belum terhubung ke satker")
+	ErrEngagementScope     = errors.New("satker di luar cakupan unit Anda")
+)
+
+// EngagementSummary ringkasan engagement: batas dorman, total per status, dan rincian per satker (dorman terbanyak dulu).
+type EngagementSummary struct {
+	DormantAfterDays int                           `json:"dormant_after_days"`
+	ActiveSince      time.Time                     `json:"active_since"`
+	Totals           repository.EngagementCounts   `json:"totals"`
+	BySatker         []repository.SatkerEngagement `json:"by_satker"`
+}
+
+// UserEngagementService status engagement profil dengan batas dorman dari env USER_DORMANT_DAYS.
+type UserEngagementService struct {
+	db          *gorm.DB
+	dormantDays int
+}
+
+// NewUserEngagementService membuat UserEngagementService.
+func NewUserEngagementService(db *gorm.DB) *UserEngagementService {
+	return &UserEngagementService{db: db, dormantDays: config.UserDormantDays()}
+}
+
+// ActiveSince mengembalikan batas waktu status active: aktivitas terakhir pada atau setelah waktu ini.
+func (s *UserEngagementService) ActiveSince(now time.Time) time.Time {
+	return now.AddDate(0, 0, -s.dormantDays)
+}
+
+// Scope menentukan cakupan satker untuk user login. Admin: filter apa adanya. unit_head: pohon satker-nya; root_satker_id/satker_ids
+// yang diminta harus berada di dalam pohon itu. Role lain ditolak.
+func (s *UserEngagementService) Scope(userID int, role string, filter repository.ActivityFilter) (repository.ActivityFilter, error) {
+	switch role {
+	case entity.RoleAdmin:
+		return filter, nil
+	case entity.RoleUnitHead:
+	default:
+		return filter, ErrEngagementForbidden
+	}
+
+	var user entity.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return filter, err
+	}
+	if user.SatkerID == nil {
+		return filter, ErrEngagementNoSatker
+	}
+	tree, err := repository.NewActivityLogRepository(s.db).GetSatkerIdsUnderRoot(*user.SatkerID)
+	if err != nil {
+		return filter, err
+	}
+	allowed := map[int64]bool{}
+	for _, id := range tree {
+		allowed[id] = true
+	}
+	for _, ids := range [][]int64{filter.RootSatkerIDs, filter.SatkerIDs} {
+		for _, id := range ids {
+			if !allowed[id] {
+				return filter, ErrEngagementScope
+			}
+		}
+	}
+	if len(filter.RootSatkerIDs) == 0 && len(filter.SatkerIDs) == 0 {
+		filter.RootSatkerIDs = []int64{*user.SatkerID}
+	}
+	return filter, nil
+}
+
+// Summary menghitung jumlah profil per status engagement, total dan per satker, dalam cakupan satker.
+func (s *UserEngagementService) Summary(satker repository.ActivityFilter, now time.Time) (*EngagementSummary, error) {
+	since := s.ActiveSince(now)
+	bySatker, totals, err := repository.NewUserEngagementRepository(s.db).Summary(satker, since)
+	if err != nil {
+		return nil, err
+	}
+	return &EngagementSummary{DormantAfterDays: s.dormantDays, ActiveSince: since, Totals: totals, BySatker: bySatker}, nil
+}
+
+// Profiles mengembalikan profil dalam cakupan satker beserta statusnya, aktivitas terakhir paling lama dulu.
+func (s *UserEngagementService) Profiles(filter repository.EngagementFilter, now time.Time) ([]repository.EngagementProfile, int64, error) {
+	return repository.NewUserEngagementRepository(s.db).Profiles(filter, s.ActiveSince(now))
+}
+
+// RefreshStatus menyelaraskan user_profiles.is_active dengan status active per now; mengembalikan jumlah profil yang berubah.
+func (s *UserEngagementService) RefreshStatus(now time.Time) (int64, error) {
+	return repository.NewUserEngagementRepository(s.db).RefreshStatus(s.ActiveSince(now))
+}
+
+// Backfill menghitung ulang first_activity/last_activity semua profil dari activity_logs_normalized lalu menyelaraskan is_active (cmd/backfill).
+func (s *UserEngagementService) Backfill(now time.Time) (*repository.BackfillResult, error) {
+	return repository.NewUserEngagementRepository(s.db).Backfill(s.ActiveSince(now))
+}
@@ -0,0 +1,660 @@
+// File user_provisioning.go: provisioning user massal dari file CSV/XLSX (upload admin dan CLI cmd/provision).
+//
+// ParseProvisionFile membaca baris (username, email, full_name, role, satker) dari CSV (delimiter ; atau ,) atau XLSX (sheet pertama); baris pertama = header.
+// Provision memproses tiap baris dalam transaksi sendiri: user baru dibuat, user lama (by username) diperbarui; hasil dikembalikan per baris sehingga satu baris gagal tidak membatalkan baris lain.
+// Opsi SendActivation: user baru dibuat nonaktif dan dikirimi link aktivasi sekali pakai lewat Mailer; tanpa opsi ini dibuat password sementara yang wajib diganti saat login pertama.
+// User lama yang masih menunggu aktivasi tanpa token yang masih berlaku mendapat token baru saat file diproses ulang dengan SendActivation;
+// admin juga bisa menerbitkan ulang link kapan saja lewat ResendActivation (mis. setelah email aktivasi gagal terkirim).
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnsupportedProvisionFile = errors.New("format file harus .csv atau .xlsx")
+	ErrProvisionMissingColumns  = errors.New("header file harus memuat kolom username dan email")
+	ErrProvisionTooManyRows     = fmt.Errorf("file melebihi batas %d baris data", config.MaxProvisionRows)
+	ErrSatkerNotFound           = errors.New("satker tidak ditemukan")
+	ErrInvalidActivationToken   = errors.New("link aktivasi tidak valid atau sudah kedaluwarsa")
+	ErrActivationNotPending     = errors.New("user tidak sedang menunggu aktivasi")
+	ErrActivationEmailFailed    = errors.New("email aktivasi gagal dikirim")
+	ErrAccountDeactivated       = errors.New("akun dinonaktifkan admin")
+)
+
+// errDryRunRollback dipakai untuk membatalkan transaksi per baris saat DryRun.
+var errDryRunRollback = errors.New("dry run")
+
+// Status hasil per baris provisioning.
+const (
+	ProvisionStatusCreated   = "created"
+	ProvisionStatusUpdated   = "updated"
+	ProvisionStatusUnchanged = "unchanged"
+	ProvisionStatusError     = "error"
+)
+
+// provisionColumnAliases memetakan nama header (huruf kecil, spasi → _) ke kolom kanonik.
+var provisionColumnAliases = map[string]string{
+	"username":     "username",
+	"email":        "email",
+	"e-mail":       "email",
+	"full_name":    "full_name",
+	"nama":         "full_name",
+	"nama_lengkap": "full_name",
+	"name":         "full_name",
+	"role":         "role",
+	"satker":       "satker",
+	"home_satker":  "satker",
+	"satker_id":    "satker",
+	"unit":         "satker",
+}
+
+// ProvisionRow satu baris data dari file provisioning. Satker boleh berisi id atau nama satker (ref_satker_units).
+type ProvisionRow struct {
+	Line     int
+	Username string
+	Email    string
+	FullName string
+	Role     string
+	Satker   string
+}
+
+// ProvisionOptions mengatur perilaku Provision.
+type ProvisionOptions struct {
+	SendActivation bool // Kirim link aktivasi ke email user baru (user nonaktif sampai aktivasi).
+	DryRun         bool // Validasi dan simulasikan tanpa menyimpan perubahan atau mengirim email.
+}
+
+// ProvisionResult hasil pemrosesan satu baris.
+type ProvisionResult struct {
+	Line              int    `json:"line"`
+	Username          string `json:"username"`
+	Email             string `json:"email"`
+	Status            string `json:"status"`
+	UserID            int    `json:"user_id,omitempty"`
+	Error             string `json:"error,omitempty"`
+	ActivationSent    bool   `json:"activation_sent,omitempty"`
+	TemporaryPassword string `json:"temporary_password,omitempty"`
+}
+
+// ProvisionSummary jumlah baris per status.
+type ProvisionSummary struct {
+	Total     int  `json:"total"`
+	Created   int  `json:"created"`
+	Updated   int  `json:"updated"`
+	Unchanged int  `json:"unchanged"`
+	Failed    int  `json:"failed"`
+	DryRun    bool `json:"dry_run"`
+}
+
+// ParseProvisionFile membaca baris provisioning dari r; format ditentukan dari ekstensi filename (.csv atau .xlsx).
+func ParseProvisionFile(filename string, r io.Reader) ([]ProvisionRow, error) {
+	var records [][]string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.Comma = detectCSVDelimiter(data)
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+		if records, err = reader.ReadAll(); err != nil {
+			return nil, fmt.Errorf("gagal membaca CSV: %w", err)
+		}
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca XLSX: %w", err)
+		}
+		defer f.Close()
+		if records, err = f.GetRows(f.GetSheetName(0)); err != nil {
+			return nil, fmt.Errorf("gagal membaca XLSX: %w", err)
+		}
+	default:
+		return nil, ErrUnsupportedProvisionFile
+	}
+	return parseProvisionRecords(records)
+}
+
+// detectCSVDelimiter memilih ';' atau ',' berdasarkan mana yang lebih banyak muncul di baris pertama (format ekspor BIDICS memakai ';').
+func detectCSVDelimiter(data []byte) rune {
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	if bytes.Count(firstLine, []byte(",")) > bytes.Count(firstLine, []byte(";")) {
+		return ','
+	}
+	return ';'
+}
+
+// parseProvisionRecords memetakan header ke kolom kanonik lalu mengubah tiap baris data (non-kosong) menjadi ProvisionRow.
+func parseProvisionRecords(records [][]string) ([]ProvisionRow, error) {
+	if len(records) == 0 {
+		return nil, ErrProvisionMissingColumns
+	}
+
+	colIdx := make(map[string]int)
+	for i, h := range records[0] {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		key = strings.ReplaceAll(key, " ", "_")
+		if canon, ok := provisionColumnAliases[key]; ok {
+			if _, dup := colIdx[canon]; !dup {
+				colIdx[canon] = i
+			}
+		}
+	}
+	if _, ok := colIdx["username"]; !ok {
+		return nil, ErrProvisionMissingColumns
+	}
+	if _, ok := colIdx["email"]; !ok {
+		return nil, ErrProvisionMissingColumns
+	}
+
+	get := func(rec []string, col string) string {
+		i, ok := colIdx[col]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	var rows []ProvisionRow
+	for i, rec := range records[1:] {
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+		rows = append(rows, ProvisionRow{
+			Line:     i + 2, // Baris 1 = header
+			Username: get(rec, "username"),
+			Email:    get(rec, "email"),
+			FullName: get(rec, "full_name"),
+			Role:     strings.ToLower(get(rec, "role")),
+			Satker:   get(rec, "satker"),
+		})
+		if len(rows) > config.MaxProvisionRows {
+			return nil, ErrProvisionTooManyRows
+		}
+	}
+	return rows, nil
+}
+
+// UserProvisioningService menyimpan koneksi DB dan Mailer untuk provisioning massal dan aktivasi akun.
+type UserProvisioningService struct {
+	db     *gorm.DB
+	mailer Mailer
+}
+
+// NewUserProvisioningService membuat instance UserProvisioningService. mailer nil → Mailer dari env (NewMailer).
+func NewUserProvisioningService(db *gorm.DB, mailer Mailer) *UserProvisioningService {
+	if mailer == nil {
+		mailer = NewMailer()
+	}
+	return &UserProvisioningService{db: db, mailer: mailer}
+}
+
+// Provision memproses semua baris dan mengembalikan hasil per baris beserta ringkasan. Error per baris tidak menghentikan baris lain.
+func (s *UserProvisioningService) Provision(actor AuditActor, rows []ProvisionRow, opts ProvisionOptions) ([]ProvisionResult, ProvisionSummary) {
+	results := make([]ProvisionResult, 0, len(rows))
+	summary := ProvisionSummary{Total: len(rows), DryRun: opts.DryRun}
+	satkerCache := make(map[string]int64)
+	seenUsernames := make(map[string]int)
+	seenEmails := make(map[string]int)
+
+	for _, row := range rows {
+		res := ProvisionResult{Line: row.Line, Username: row.Username, Email: row.Email}
+
+		// Duplikat di dalam file yang sama dilaporkan sebagai error pada kemunculan kedua dan seterusnya.
+		if first, ok := seenUsernames[strings.ToLower(row.Username)]; ok && row.Username != "" {
+			res.Status, res.Error = ProvisionStatusError, fmt.Sprintf("username duplikat dengan baris %d", first)
+		} else if first, ok := seenEmails[strings.ToLower(row.Email)]; ok && row.Email != "" {
+			res.Status, res.Error = ProvisionStatusError, fmt.Sprintf("email duplikat dengan baris %d", first)
+		} else {
+			seenUsernames[strings.ToLower(row.Username)] = row.Line
+			seenEmails[strings.ToLower(row.Email)] = row.Line
+			s.provisionRow(actor, row, opts, satkerCache, &res)
+		}
+
+		switch res.Status {
+		case ProvisionStatusCreated:
+			summary.Created++
+		case ProvisionStatusUpdated:
+			summary.Updated++
+		case ProvisionStatusUnchanged:
+			summary.Unchanged++
+		default:
+			summary.Failed++
+		}
+		results = append(results, res)
+	}
+	return results, summary
+}
+
+// provisionRow memvalidasi dan menyimpan satu baris dalam transaksi sendiri, lalu (jika diminta) mengirim link aktivasi setelah commit.
+func (s *UserProvisioningService) provisionRow(actor AuditActor, row ProvisionRow, opts ProvisionOptions, satkerCache map[string]int64, res *ProvisionResult) {
+	fail := func(err error) {
+		res.Status = ProvisionStatusError
+		var policyErr *PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			res.Error = policyErr.Error()
+			return
+		}
+		switch {
+		case errors.Is(err, ErrInvalidEmail), errors.Is(err, ErrInvalidRole), errors.Is(err, ErrSatkerNotFound),
+			errors.Is(err, ErrUsernameExists), errors.Is(err, ErrEmailExists),
+			errors.Is(err, ErrSelfModification), errors.Is(err, ErrLastAdmin):
+			res.Error = err.Error()
+		default:
+			log.Printf("[ERROR] provisioning baris %d: %v", row.Line, err)
+			res.Error = "terjadi kesalahan saat menyimpan"
+		}
+	}
+
+	if len(row.Username) < 3 || len(row.Username) > 100 {
+		res.Status, res.Error = ProvisionStatusError, "username wajib diisi (3–100 karakter)"
+		return
+	}
+	if !strings.HasSuffix(strings.ToLower(row.Email), "@bpk.go.id") || strings.Count(row.Email, "@") != 1 {
+		fail(ErrInvalidEmail)
+		return
+	}
+	if row.Role != "" && !entity.IsValidRole(row.Role) {
+		fail(ErrInvalidRole)
+		return
+	}
+	var satkerID *int64
+	if row.Satker != "" {
+		id, err := resolveSatker(s.db, row.Satker, satkerCache)
+		if err != nil {
+			fail(err)
+			return
+		}
+		satkerID = &id
+	}
+
+	var activationToken string
+	var user entity.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("username = ?", row.Username).First(&user).Error
+		switch {
+		case err == nil:
+			status, err := updateProvisionedUser(tx, actor, &user, row, satkerID)
+			if err != nil {
+				return err
+			}
+			res.Status = status
+			if opts.SendActivation {
+				if activationToken, err = reissueExpiredActivation(tx, &user); err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			token, tempPassword, err := createProvisionedUser(tx, actor, &user, row, satkerID, opts.SendActivation)
+			if err != nil {
+				return err
+			}
+			res.Status = ProvisionStatusCreated
+			activationToken = token
+			res.TemporaryPassword = tempPassword
+		default:
+			return err
+		}
+		if opts.DryRun {
+			return errDryRunRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		res.TemporaryPassword = ""
+		fail(err)
+		return
+	}
+	if opts.DryRun {
+		// Simulasi: tidak ada user/password/token yang benar-benar tersimpan.
+		res.TemporaryPassword = ""
+		return
+	}
+	res.UserID = user.ID
+
+	if activationToken != "" {
+		if err := s.sendActivationEmail(&user, activationToken); err != nil {
+			log.Printf("[ERROR] kirim email aktivasi ke %s: %v", user.Email, err)
+			res.Error = "user disimpan, tetapi email aktivasi gagal dikirim; kirim ulang lewat POST /api/admin/users/:id/resend-activation"
+			return
+		}
+		res.ActivationSent = true
+	}
+}
+
+// createProvisionedUser membuat user baru dari baris provisioning. Dengan aktivasi: user nonaktif + token aktivasi (dikembalikan string pertama);
+// tanpa aktivasi: user aktif dengan password sementara (string kedua) yang wajib diganti saat login pertama.
+func createProvisionedUser(tx *gorm.DB, actor AuditActor, user *entity.User, row ProvisionRow, satkerID *int64, sendActivation bool) (string, string, error) {
+	if err := checkUniqueUser(tx, 0, row.Username, row.Email); err != nil {
+		return "", "", err
+	}
+	role := row.Role
+	if role == "" {
+		role = entity.RoleUser
+	}
+	*user = entity.User{
+		Username:           row.Username,
+		Email:              row.Email,
+		FullName:           row.FullName,
+		Role:               role,
+		SatkerID:           satkerID,
+		IsActive:           !sendActivation,
+		ReportAccessStatus: "none",
+	}
+	if !sendActivation {
+		// Tanpa aktivasi, email ditetapkan admin; dengan aktivasi, email baru terverifikasi saat link dipakai (ActivateAccount).
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	password, err := GenerateTemporaryPassword()
+	if err != nil {
+		return "", "", err
+	}
+	if err := NewPasswordPolicy(tx).ApplyNewPassword(tx, user, password); err != nil {
+		return "", "", err
+	}
+	user.MustChangePassword = true
+	if err := tx.Create(user).Error; err != nil {
+		return "", "", err
+	}
+	// is_active punya default:true di GORM, jadi nilai false tidak ikut INSERT; set eksplisit.
+	if !user.IsActive {
+		if err := tx.Model(user).Update("is_active", false).Error; err != nil {
+			return "", "", err
+		}
+	}
+	if err := NewAuditService(tx).Record(actor, AuditEntry{
+		Action:     AuditActionUserCreate,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+		After:      user,
+	}); err != nil {
+		return "", "", err
+	}
+
+	if !sendActivation {
+		return "", password, nil
+	}
+	token, err := createActivationToken(tx, user.ID)
+	if err != nil {
+		return "", "", err
+	}
+	return token, "", nil
+}
+
+// updateProvisionedUser memperbarui email, nama, role, dan satker user yang sudah ada (hanya kolom yang diisi di file). Password dan status aktif tidak diubah.
+func updateProvisionedUser(tx *gorm.DB, actor AuditActor, user *entity.User, row ProvisionRow, satkerID *int64) (string, error) {
+	before := *user
+	if row.Email != user.Email {
+		if err := checkUniqueUser(tx, user.ID, "", row.Email); err != nil {
+			return "", err
+		}
+		user.Email = row.Email
+		now := time.Now()
+		user.EmailVerifiedAt = &now // Email ditetapkan admin lewat file provisioning.
+	}
+	if row.FullName != "" {
+		user.FullName = row.FullName
+	}
+	if row.Role != "" {
+		user.Role = row.Role
+	}
+	if satkerID != nil {
+		user.SatkerID = satkerID
+	}
+
+	sameSatker := (before.SatkerID == nil && user.SatkerID == nil) ||
+		(before.SatkerID != nil && user.SatkerID != nil && *before.SatkerID == *user.SatkerID)
+	if before.Email == user.Email && before.FullName == user.FullName && before.Role == user.Role && sameSatker {
+		return ProvisionStatusUnchanged, nil
+	}
+
+	if err := checkAdminRetained(tx, actor, &before, user); err != nil {
+		return "", err
+	}
+	if err := tx.Save(user).Error; err != nil {
+		return "", err
+	}
+	if err := NewAuditService(tx).Record(actor, AuditEntry{
+		Action:     AuditActionUserUpdate,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+		Before:     before,
+		After:      user,
+	}); err != nil {
+		return "", err
+	}
+	return ProvisionStatusUpdated, nil
+}
+
+// resolveSatker mencari id satker dari nilai kolom satker: angka = id, selain itu nama satker (case-insensitive). Hasil di-cache per proses provisioning.
+func resolveSatker(db *gorm.DB, value string, cache map[string]int64) (int64, error) {
+	key := strings.ToLower(value)
+	if id, ok := cache[key]; ok {
+		return id, nil
+	}
+	var satker entity.SatkerUnit
+	var err error
+	if id, convErr := strconv.ParseInt(value, 10, 64); convErr == nil {
+		err = db.First(&satker, id).Error
+	} else {
+		err = db.Where("LOWER(satker_name) = ?", key).First(&satker).Error
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrSatkerNotFound
+		}
+		return 0, err
+	}
+	cache[key] = satker.ID
+	return satker.ID, nil
+}
+
+// checkSatkerExists mengembalikan ErrSatkerNotFound jika id tidak ada di ref_satker_units.
+func checkSatkerExists(db *gorm.DB, id int64) error {
+	var count int64
+	if err := db.Model(&entity.SatkerUnit{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSatkerNotFound
+	}
+	return nil
+}
+
+// createActivationToken membuat token acak 32 byte (hex), menyimpan hash SHA-256-nya dengan masa berlaku ACTIVATION_TOKEN_TTL, dan mengembalikan token mentah.
+func createActivationToken(tx *gorm.DB, userID int) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	record := entity.UserActivationToken{
+		UserID:    userID,
+		TokenHash: hashActivationToken(token),
+		ExpiresAt: time.Now().Add(config.ActivationTokenTTL()),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// activationPending mengembalikan true jika user dibuat lewat provisioning dengan aktivasi dan belum pernah mengaktifkan akun:
+// nonaktif (bukan dinonaktifkan admin), punya token aktivasi, dan belum ada token yang terpakai.
+func activationPending(tx *gorm.DB, user *entity.User) (bool, error) {
+	if user.IsActive || user.DeactivatedAt != nil {
+		return false, nil
+	}
+	var issued, used int64
+	if err := tx.Model(&entity.UserActivationToken{}).Where("user_id = ?", user.ID).Count(&issued).Error; err != nil {
+		return false, err
+	}
+	if err := tx.Model(&entity.UserActivationToken{}).Where("user_id = ? AND used_at IS NOT NULL", user.ID).Count(&used).Error; err != nil {
+		return false, err
+	}
+	return issued > 0 && used == 0, nil
+}
+
+// reissueExpiredActivation membuat token aktivasi baru untuk user yang masih menunggu aktivasi jika tidak ada lagi token yang belum dipakai dan belum kedaluwarsa;
+// string kosong jika user tidak memenuhi syarat atau tokennya masih berlaku.
+func reissueExpiredActivation(tx *gorm.DB, user *entity.User) (string, error) {
+	pending, err := activationPending(tx, user)
+	if err != nil || !pending {
+		return "", err
+	}
+	var valid int64
+	if err := tx.Model(&entity.UserActivationToken{}).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > ?", user.ID, time.Now()).Count(&valid).Error; err != nil {
+		return "", err
+	}
+	if valid > 0 {
+		return "", nil
+	}
+	return createActivationToken(tx, user.ID)
+}
+
+// ResendActivation menerbitkan ulang link aktivasi untuk user yang masih menunggu aktivasi (ErrActivationNotPending jika tidak):
+// token lama yang belum dipakai dibuat kedaluwarsa, token baru dibuat dan dicatat ke audit, lalu email dikirim setelah commit.
+// Jika pengiriman gagal, token baru tetap tersimpan dan dikembalikan ErrActivationEmailFailed; admin cukup mencoba lagi.
+func (s *UserProvisioningService) ResendActivation(actor AuditActor, id int) (*entity.User, error) {
+	var user *entity.User
+	var token string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = findUserByID(tx, id)
+		if err != nil {
+			return err
+		}
+		pending, err := activationPending(tx, user)
+		if err != nil {
+			return err
+		}
+		if !pending {
+			return ErrActivationNotPending
+		}
+		if err := expireActivationTokens(tx, user.ID, time.Now()); err != nil {
+			return err
+		}
+		if token, err = createActivationToken(tx, user.ID); err != nil {
+			return err
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserActivationResend,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sendActivationEmail(user, token); err != nil {
+		log.Printf("[ERROR] kirim ulang email aktivasi ke %s: %v", user.Email, err)
+		return user, ErrActivationEmailFailed
+	}
+	return user, nil
+}
+
+// hashActivationToken mengembalikan SHA-256 (hex) dari token; hanya hash yang disimpan di DB.
+func hashActivationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// sendActivationEmail mengirim link aktivasi (ACTIVATION_URL?token=...) ke email user.
+func (s *UserProvisioningService) sendActivationEmail(user *entity.User, token string) error {
+	link := config.ActivationURL() + "?token=" + token
+	name := user.FullName
+	if name == "" {
+		name = user.Username
+	}
+	body := fmt.Sprintf(`Yth. %s,
+
+Akun Dashboard Monitoring BIDICS BPK RI Anda telah dibuat dengan username: %s
+
+Aktifkan akun dan tetapkan password Anda melalui tautan berikut (berlaku sampai %s, hanya dapat dipakai sekali):
+%s
+
+Abaikan email ini jika Anda tidak merasa memerlukan akun tersebut.`,
+		name, user.Username, time.Now().Add(config.ActivationTokenTTL()).Format("02 Jan 2006 15:04"), link)
+	return s.mailer.Send(user.Email, "Aktivasi akun Dashboard Monitoring BPK", body)
+}
+
+// ActivateAccount memverifikasi token aktivasi (belum dipakai, belum kedaluwarsa), menetapkan password baru sesuai kebijakan, mengaktifkan user, dan menandai token terpakai.
+// User yang dinonaktifkan admin ditolak (ErrAccountDeactivated) walaupun tokennya masih ada; hanya admin yang bisa mengaktifkannya kembali.
+func (s *UserProvisioningService) ActivateAccount(actor AuditActor, token, newPassword string) (*entity.User, error) {
+	var user entity.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var record entity.UserActivationToken
+		err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashActivationToken(token), time.Now()).
+			First(&record).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidActivationToken
+			}
+			return err
+		}
+		if err := tx.First(&user, record.UserID).Error; err != nil {
+			return err
+		}
+		if user.DeactivatedAt != nil {
+			return ErrAccountDeactivated
+		}
+
+		before := user
+		if err := NewPasswordPolicy(tx).ApplyNewPassword(tx, &user, newPassword); err != nil {
+			return err
+		}
+		now := time.Now()
+		user.IsActive = true
+		user.EmailVerifiedAt = &now // Link diterima di email user.
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		record.UsedAt = &now
+		if err := tx.Save(&record).Error; err != nil {
+			return err
+		}
+
+		if actor.UserID == nil {
+			actor.UserID = &user.ID
+		}
+		return NewAuditService(tx).Record(actor, AuditEntry{
+			Action:     AuditActionUserActivate,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			Before:     before,
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,146 @@
+// File user_session_service.go: rekonstruksi sesi aktivitas user_profiles dari event LOGIN/LOGOUT (tabel user_sessions).
+//
+// Sessionize (job sessionization dan cmd/import) membangun ulang sesi user yang punya event baru sejak watermark (user_session_state) atau sesi yang
+// masih terbuka, mulai dari sesi paling awal yang bisa terpengaruh; data yang terlambat diimpor ikut tercermin. Aturan per user (urut waktu):
+// LOGIN sukses membuka sesi; aktivitas lain menambah jumlah aksi; LOGOUT menutup sesi (error logout jika scope mengandung error);
+// LOGIN berikutnya menutup sesi yang masih terbuka (relogin); jeda aktivitas lebih dari ACTIVITY_SESSION_IDLE_TIMEOUT menutup sesi (timeout)
+// pada aktivitas terakhirnya. Aktivitas tanpa LOGIN sebelumnya tidak masuk sesi mana pun.
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/config"
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+	"gorm.io/gorm"
+)
+
+// SessionizeResult ringkasan satu Sessionize.
+type SessionizeResult struct {
+	Users     int   // User yang sesinya dibangun ulang.
+	Sessions  int   // Sesi yang disimpan (baru atau dibangun ulang).
+	LastLogID int64 // Watermark setelah run.
+}
+
+// UserSessionService rekonstruksi sesi aktivitas user.
+type UserSessionService struct {
+	db          *gorm.DB
+	idleTimeout time.Duration
+}
+
+// NewUserSessionService membuat UserSessionService dengan batas jeda dari env ACTIVITY_SESSION_IDLE_TIMEOUT.
+func NewUserSessionService(db *gorm.DB) *UserSessionService {
+	return &UserSessionService{db: db, idleTimeout: config.ActivitySessionIdleTimeout()}
+}
+
+// Sessionize membangun ulang sesi user yang terdampak event baru (id > watermark) atau masih punya sesi terbuka, lalu memajukan watermark.
+// Setiap user diproses dalam transaksinya sendiri; jika satu user gagal, watermark tidak dimajukan sehingga run berikutnya mengulang (idempoten).
+func (s *UserSessionService) Sessionize(now time.Time) (*SessionizeResult, error) {
+	repo := repository.NewUserSessionRepository(s.db)
+	last, upTo, err := repo.Watermark()
+	if err != nil {
+		return nil, err
+	}
+	users, err := repo.AffectedUsers(last, upTo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SessionizeResult{LastLogID: last}
+	for _, u := range users {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			txRepo := repository.NewUserSessionRepository(tx)
+			from, err := txRepo.RebuildStart(u.UserID, u.Since, s.idleTimeout)
+			if err != nil {
+				return err
+			}
+			events, err := txRepo.Events(u.UserID, from, upTo)
+			if err != nil {
+				return err
+			}
+			sessions := buildSessions(u.UserID, events, s.idleTimeout, now)
+			if err := txRepo.Replace(u.UserID, from, sessions); err != nil {
+				return err
+			}
+			result.Sessions += len(sessions)
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Users++
+	}
+
+	if upTo > last {
+		if err := repo.SetWatermark(upTo); err != nil {
+			return result, err
+		}
+		result.LastLogID = upTo
+	}
+	return result, nil
+}
+
+// buildSessions menyusun sesi dari event satu user yang terurut waktu. Sesi yang aktivitas terakhirnya belum lewat idleTimeout dari now tetap terbuka;
+// now dibandingkan dalam konvensi kolom tanggal (config.ActivityNow), sedangkan UpdatedAt tetap instan sebenarnya.
+func buildSessions(userID int64, events []repository.SessionEvent, idleTimeout time.Duration, now time.Time) []entity.UserSession {
+	var sessions []entity.UserSession
+	var cur *entity.UserSession
+	closeSession := func(reason string, at time.Time) {
+		cur.EndedAt = &at
+		cur.EndReason = &reason
+		duration := int64(at.Sub(cur.StartedAt).Seconds())
+		cur.DurationSeconds = &duration
+		sessions = append(sessions, *cur)
+		cur = nil
+	}
+
+	for _, e := range events {
+		if cur != nil && e.Tanggal.Sub(cur.LastActivityAt) > idleTimeout {
+			closeSession(entity.UserSessionEndTimeout, cur.LastActivityAt)
+		}
+		switch {
+		case e.Type == "LOGIN" && loginSucceeded(e.Scope):
+			if cur != nil {
+				closeSession(entity.UserSessionEndRelogin, cur.LastActivityAt)
+			}
+			cur = &entity.UserSession{
+				UserID:         userID,
+				SatkerID:       e.SatkerID,
+				LocationID:     e.LocationID,
+				LoginLogID:     e.ID,
+				StartedAt:      e.Tanggal,
+				LastActivityAt: e.Tanggal,
+				UpdatedAt:      now,
+			}
+		case e.Type == "LOGOUT":
+			if cur != nil {
+				id := e.ID
+				cur.LogoutLogID = &id
+				cur.LastActivityAt = e.Tanggal
+				cur.ErrorLogout = strings.Contains(strings.ToLower(e.Scope), "error")
+				closeSession(entity.UserSessionEndLogout, e.Tanggal)
+			}
+		default:
+			if cur != nil {
+				cur.ActionCount++
+				cur.LastActivityAt = e.Tanggal
+			}
+		}
+	}
+	if cur != nil {
+		if config.ActivityNow(now).Sub(cur.LastActivityAt) > idleTimeout {
+			closeSession(entity.UserSessionEndTimeout, cur.LastActivityAt)
+		} else {
+			sessions = append(sessions, *cur)
+		}
+	}
+	return sessions
+}
+
+// loginSucceeded mengikuti definisi login sukses dashboard (GetCountByStatus SUCCESS): scope kosong atau mengandung success.
+func loginSucceeded(scope string) bool {
+	scope = strings.TrimSpace(scope)
+	return scope == "" || strings.Contains(strings.ToLower(scope), "success")
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bpk-ri/dashboard-monitoring/internal/entity"
+	"github.com/bpk-ri/dashboard-monitoring/internal/repository"
+)
+
+func TestBuildSessionsIdleMemakaiJamDindingAktivitas(t *testing.T) {
+	// 03:00 UTC = 12:00 WIT; tanggal menyimpan jam dinding WIT apa adanya sebagai UTC.
+	now := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+	wall := func(h, m int) time.Time { return time.Date(2026, 3, 10, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name       string
+		zone       string
+		lastAction time.Time
+		wantOpen   bool
+	}{
+		{"WIT, aktivitas 10 menit lalu tetap terbuka", "WIT", wall(11, 50), true},
+		{"WIT, aktivitas 2 jam lalu ditutup timeout", "WIT", wall(10, 0), false},
+		{"WIB, aktivitas 10 menit lalu tetap terbuka", "WIB", wall(10, 50), true},
+		{"WIB, aktivitas 31 menit lalu ditutup timeout", "WIB", wall(9, 29), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ACTIVITY_TIMEZONE", tt.zone)
+			events := []repository.SessionEvent{
+				{ID: 1, Tanggal: tt.lastAction.Add(-5 * time.Minute), Type: "LOGIN"},
+				{ID: 2, Tanggal: tt.lastAction, Type: "VIEW"},
+			}
+			sessions := buildSessions(7, events, 30*time.Minute, now)
+			if len(sessions) != 1 {
+				t.Fatalf("jumlah sesi = %d, ingin 1", len(sessions))
+			}
+			s := sessions[0]
+			if open := s.EndedAt == nil; open != tt.wantOpen {
+				t.Fatalf("sesi terbuka = %v, ingin %v", open, tt.wantOpen)
+			}
+			if !tt.wantOpen && (*s.EndReason != entity.UserSessionEndTimeout || !s.EndedAt.Equal(tt.lastAction)) {
+				t.Errorf("sesi ditutup %v pada %v, ingin %v pada %v", *s.EndReason, *s.EndedAt, entity.UserSessionEndTimeout, tt.lastAction)
+			}
+			if !s.UpdatedAt.Equal(now) {
+				t.Errorf("UpdatedAt = %v, ingin instan sebenarnya %v", s.UpdatedAt, now)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// File date.go: tipe Date — tanggal kalender tervalidasi (YYYY-MM-DD) untuk filter rentang pada query analitik.
+package sqlbuilder
+
+import (
+	"errors"
+	"time"
+)
+
+// DateLayout format tanggal filter (YYYY-MM-DD).
+const DateLayout = "2006-01-02"
+
+// ErrInvalidDate dikembalikan ParseDate jika string bukan tanggal YYYY-MM-DD yang valid.
+var ErrInvalidDate = errors.New("tanggal harus berformat YYYY-MM-DD")
+
+// Date tanggal kalender tanpa jam (UTC 00:00). Nilai nol = tanggal tidak diisi.
+type Date struct {
+	t time.Time
+}
+
+// ParseDate mengurai YYYY-MM-DD; string kosong menghasilkan Date nol tanpa error.
+func ParseDate(s string) (Date, error) {
+	if s == "" {
+		return Date{}, nil
+	}
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, ErrInvalidDate
+	}
+	return Date{t: t}, nil
+}
+
+// IsZero mengembalikan true jika tanggal tidak diisi.
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+// String mengembalikan tanggal dalam format YYYY-MM-DD ("" jika nol).
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.t.Format(DateLayout)
+}
+
+// Time mengembalikan tanggal sebagai time.Time (UTC 00:00).
+func (d Date) Time() time.Time {
+	return d.t
+}
+
+// AddDays mengembalikan tanggal n hari setelah d.
+func (d Date) AddDays(n int) Date {
+	return Date{t: d.t.AddDate(0, 0, n)}
+}
+
+// After mengembalikan true jika d setelah other.
+func (d Date) After(other Date) bool {
+	return d.t.After(other.t)
+}
+
+// DateRange membangun kondisi rentang tanggal inklusif [start, end] untuk kolom timestamp: column >= start AND column < end+1 hari,
+// sehingga indeks pada kolom tetap terpakai. Batas nol diabaikan; kosong jika keduanya nol.
+func DateRange(column string, start, end Date) Fragment {
+	var parts []Fragment
+	if !start.IsZero() {
+		parts = append(parts, Expr(column+" >= ?", start.String()))
+	}
+	if !end.IsZero() {
+		parts = append(parts, Expr(column+" < ?", end.AddDays(1).String()))
+	}
+	return And(parts...)
+}
+
+// DayRange membangun kondisi rentang tanggal inklusif [start, end] untuk kolom bertipe DATE: column >= start AND column <= end.
+func DayRange(column string, start, end Date) Fragment {
+	var parts []Fragment
+	if !start.IsZero() {
+		parts = append(parts, Expr(column+" >= ?", start.String()))
+	}
+	if !end.IsZero() {
+		parts = append(parts, Expr(column+" <= ?", end.String()))
+	}
+	return And(parts...)
+}
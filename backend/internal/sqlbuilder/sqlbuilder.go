@@ -0,0 +1,178 @@
+// File sqlbuilder.go: penyusun SQL mentah untuk query analitik (repository) tanpa menyambung nilai request ke string SQL.
+//
+// Fragment = potongan SQL dengan placeholder ? beserta argumennya; jumlah placeholder selalu dicocokkan dengan jumlah argumen.
+// Fragment bisa digabung (And, Or) dan dipasang ke SelectBuilder (JOIN, WHERE, subquery FROM). Hasil Build() dipakai langsung di gorm.DB.Raw.
+// Nilai request hanya boleh masuk lewat argumen; teks SQL (nama kolom, tabel, literal) harus berasal dari konstanta kode.
+package sqlbuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fragment potongan SQL dengan placeholder ? dan argumennya (urut sesuai kemunculan placeholder).
+type Fragment struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Expr membuat Fragment dari SQL dan argumen. Panic jika jumlah ? tidak sama dengan jumlah argumen (kesalahan pemrograman, bukan input user).
+func Expr(sql string, args ...interface{}) Fragment {
+	if n := strings.Count(sql, "?"); n != len(args) {
+		panic(fmt.Sprintf("sqlbuilder: %d placeholder tetapi %d argumen: %s", n, len(args), sql))
+	}
+	return Fragment{SQL: sql, Args: args}
+}
+
+// IsEmpty mengembalikan true jika fragment tidak berisi SQL.
+func (f Fragment) IsEmpty() bool {
+	return strings.TrimSpace(f.SQL) == ""
+}
+
+// And menggabungkan fragment tidak kosong dengan AND; setiap bagian dibungkus kurung jika lebih dari satu. Kosong jika semua kosong.
+func And(parts ...Fragment) Fragment {
+	return join(" AND ", parts)
+}
+
+// Or menggabungkan fragment tidak kosong dengan OR; setiap bagian dibungkus kurung jika lebih dari satu. Kosong jika semua kosong.
+func Or(parts ...Fragment) Fragment {
+	return join(" OR ", parts)
+}
+
+// join menggabungkan fragment tidak kosong dengan separator.
+func join(sep string, parts []Fragment) Fragment {
+	var nonEmpty []Fragment
+	for _, p := range parts {
+		if !p.IsEmpty() {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	switch len(nonEmpty) {
+	case 0:
+		return Fragment{}
+	case 1:
+		return nonEmpty[0]
+	}
+	sqls := make([]string, len(nonEmpty))
+	var args []interface{}
+	for i, p := range nonEmpty {
+		sqls[i] = "(" + p.SQL + ")"
+		args = append(args, p.Args...)
+	}
+	return Fragment{SQL: strings.Join(sqls, sep), Args: args}
+}
+
+// SelectBuilder penyusun SELECT: kolom, FROM (tabel atau subquery), JOIN, WHERE (digabung AND), GROUP BY, ORDER BY, LIMIT.
+type SelectBuilder struct {
+	columns []string
+	from    Fragment
+	joins   []Fragment
+	where   []Fragment
+	groupBy []string
+	orderBy []string
+	limit   int
+}
+
+// Select memulai SELECT dengan daftar kolom/ekspresi (konstanta kode).
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// From menetapkan tabel sumber beserta alias, mis. "activity_logs_normalized a".
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = Fragment{SQL: table}
+	return b
+}
+
+// FromSubquery menetapkan subquery sebagai sumber dengan alias; argumen subquery ikut di urutan yang benar.
+func (b *SelectBuilder) FromSubquery(sub *SelectBuilder, alias string) *SelectBuilder {
+	f := sub.Fragment()
+	b.from = Fragment{SQL: "(" + f.SQL + ") " + alias, Args: f.Args}
+	return b
+}
+
+// Join menambahkan INNER JOIN, mis. Join("ref_activity_types at ON a.activity_type_id = at.id").
+func (b *SelectBuilder) Join(sql string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, Expr("JOIN "+sql, args...))
+	return b
+}
+
+// LeftJoin menambahkan LEFT JOIN.
+func (b *SelectBuilder) LeftJoin(sql string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, Expr("LEFT JOIN "+sql, args...))
+	return b
+}
+
+// Where menambahkan kondisi (digabung AND dengan kondisi lain). Fragment kosong diabaikan.
+func (b *SelectBuilder) Where(f Fragment) *SelectBuilder {
+	if !f.IsEmpty() {
+		b.where = append(b.where, f)
+	}
+	return b
+}
+
+// WhereExpr singkatan Where(Expr(sql, args...)).
+func (b *SelectBuilder) WhereExpr(sql string, args ...interface{}) *SelectBuilder {
+	return b.Where(Expr(sql, args...))
+}
+
+// GroupBy menetapkan kolom GROUP BY.
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// OrderBy menetapkan urutan, mis. OrderBy("count DESC").
+func (b *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, columns...)
+	return b
+}
+
+// Limit menetapkan LIMIT (dikirim sebagai argumen); n <= 0 = tanpa limit.
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = n
+	return b
+}
+
+// Fragment menyusun query menjadi satu Fragment (dipakai untuk subquery atau Build).
+func (b *SelectBuilder) Fragment() Fragment {
+	var sb strings.Builder
+	var args []interface{}
+
+	sb.WriteString("SELECT ")
+	sb.WriteString(strings.Join(b.columns, ", "))
+	if !b.from.IsEmpty() {
+		sb.WriteString(" FROM ")
+		sb.WriteString(b.from.SQL)
+		args = append(args, b.from.Args...)
+	}
+	for _, j := range b.joins {
+		sb.WriteString(" ")
+		sb.WriteString(j.SQL)
+		args = append(args, j.Args...)
+	}
+	if where := And(b.where...); !where.IsEmpty() {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(where.SQL)
+		args = append(args, where.Args...)
+	}
+	if len(b.groupBy) > 0 {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(strings.Join(b.groupBy, ", "))
+	}
+	if len(b.orderBy) > 0 {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(b.orderBy, ", "))
+	}
+	if b.limit > 0 {
+		sb.WriteString(" LIMIT ?")
+		args = append(args, b.limit)
+	}
+	return Fragment{SQL: sb.String(), Args: args}
+}
+
+// Build mengembalikan SQL dan argumen siap untuk gorm.DB.Raw(sql, args...).
+func (b *SelectBuilder) Build() (string, []interface{}) {
+	f := b.Fragment()
+	return f.SQL, f.Args
+}
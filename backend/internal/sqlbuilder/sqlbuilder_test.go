@@ -0,0 +1,213 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+// assertFragment membandingkan SQL dan argumen fragment dengan yang diharapkan.
+func assertFragment(t *testing.T, got Fragment, wantSQL string, wantArgs []interface{}) {
+	t.Helper()
+	if got.SQL != wantSQL {
+		t.Errorf("SQL:\n got  %q\n want %q", got.SQL, wantSQL)
+	}
+	if !reflect.DeepEqual(got.Args, wantArgs) {
+		t.Errorf("args: got %#v, want %#v", got.Args, wantArgs)
+	}
+}
+
+func TestExpr(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		args     []interface{}
+		wantArgs []interface{}
+	}{
+		{"tanpa placeholder", "a.status IS NOT NULL", nil, nil},
+		{"satu placeholder", "a.status = ?", []interface{}{"SUCCESS"}, []interface{}{"SUCCESS"}},
+		{"beberapa placeholder urut", "a.id BETWEEN ? AND ?", []interface{}{1, 9}, []interface{}{1, 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFragment(t, Expr(tt.sql, tt.args...), tt.sql, tt.wantArgs)
+		})
+	}
+}
+
+func TestExprPanicsOnArgumentMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		args []interface{}
+	}{
+		{"argumen kurang", "a.id = ? AND a.user_id = ?", []interface{}{1}},
+		{"argumen lebih", "a.id = ?", []interface{}{1, 2}},
+		{"argumen tanpa placeholder", "a.id = 1", []interface{}{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expr(%q, %v) tidak panic", tt.sql, tt.args)
+				}
+			}()
+			Expr(tt.sql, tt.args...)
+		})
+	}
+}
+
+func TestAndOr(t *testing.T) {
+	a := Expr("a.status = ?", "SUCCESS")
+	b := Expr("a.user_id IN ?", []int64{1, 2})
+	c := Expr("a.satker_id = ?", int64(7))
+	tests := []struct {
+		name     string
+		got      Fragment
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{"And tanpa bagian", And(), "", nil},
+		{"And semua kosong", And(Fragment{}, Fragment{SQL: "  "}), "", nil},
+		{"And satu bagian tidak dibungkus", And(Fragment{}, a), "a.status = ?", []interface{}{"SUCCESS"}},
+		{"And beberapa bagian", And(a, Fragment{}, b), "(a.status = ?) AND (a.user_id IN ?)", []interface{}{"SUCCESS", []int64{1, 2}}},
+		{"Or semua kosong", Or(Fragment{}), "", nil},
+		{"Or satu bagian", Or(c, Fragment{}), "a.satker_id = ?", []interface{}{int64(7)}},
+		{"Or beberapa bagian", Or(a, c), "(a.status = ?) OR (a.satker_id = ?)", []interface{}{"SUCCESS", int64(7)}},
+		{"And berisi Or", And(Or(a, c), b), "((a.status = ?) OR (a.satker_id = ?)) AND (a.user_id IN ?)", []interface{}{"SUCCESS", int64(7), []int64{1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFragment(t, tt.got, tt.wantSQL, tt.wantArgs)
+			if tt.wantSQL == "" && !tt.got.IsEmpty() {
+				t.Errorf("fragment seharusnya kosong")
+			}
+		})
+	}
+}
+
+func TestSelectBuilderBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *SelectBuilder
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "kolom dan tabel saja",
+			builder: Select("a.id", "a.status").From("activity_logs_normalized a"),
+			wantSQL: "SELECT a.id, a.status FROM activity_logs_normalized a",
+		},
+		{
+			name: "join, where kosong diabaikan, group, order, limit",
+			builder: Select("at.name", "COUNT(*) AS count").
+				From("activity_logs_normalized a").
+				Join("ref_activity_types at ON at.id = a.activity_type_id").
+				LeftJoin("ref_locations l ON l.id = a.location_id AND l.province <> ?", "").
+				Where(Fragment{}).
+				WhereExpr("a.status = ?", "SUCCESS").
+				Where(Expr("a.satker_id IN ?", []int64{3})).
+				GroupBy("at.name").
+				OrderBy("count DESC", "at.name").
+				Limit(10),
+			wantSQL: "SELECT at.name, COUNT(*) AS count FROM activity_logs_normalized a" +
+				" JOIN ref_activity_types at ON at.id = a.activity_type_id" +
+				" LEFT JOIN ref_locations l ON l.id = a.location_id AND l.province <> ?" +
+				" WHERE (a.status = ?) AND (a.satker_id IN ?)" +
+				" GROUP BY at.name ORDER BY count DESC, at.name LIMIT ?",
+			wantArgs: []interface{}{"", "SUCCESS", []int64{3}, 10},
+		},
+		{
+			name:    "limit nol tanpa LIMIT",
+			builder: Select("1").From("users u").Limit(0),
+			wantSQL: "SELECT 1 FROM users u",
+		},
+		{
+			name: "argumen subquery FROM mendahului join dan where luar",
+			builder: Select("s.day", "SUM(s.cnt) AS total").
+				FromSubquery(
+					Select("DATE(a.tanggal) AS day", "COUNT(*) AS cnt").
+						From("activity_logs_normalized a").
+						WhereExpr("a.status = ?", "FAILED").
+						GroupBy("day").
+						Limit(5),
+					"s",
+				).
+				LeftJoin("ref_holidays h ON h.date = s.day AND h.kind = ?", "national").
+				WhereExpr("s.cnt > ?", 3).
+				GroupBy("s.day"),
+			wantSQL: "SELECT s.day, SUM(s.cnt) AS total FROM (SELECT DATE(a.tanggal) AS day, COUNT(*) AS cnt FROM activity_logs_normalized a" +
+				" WHERE a.status = ? GROUP BY day LIMIT ?) s" +
+				" LEFT JOIN ref_holidays h ON h.date = s.day AND h.kind = ?" +
+				" WHERE s.cnt > ? GROUP BY s.day",
+			wantArgs: []interface{}{"FAILED", 5, "national", 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.builder.Build()
+			assertFragment(t, Fragment{SQL: sql, Args: args}, tt.wantSQL, tt.wantArgs)
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"2026-03-09", "2026-03-09", false},
+		{"2026-02-30", "", true},
+		{"09-03-2026", "", true},
+		{"2026-03-09T10:00:00Z", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDate(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err != nil && err != ErrInvalidDate {
+				t.Errorf("ParseDate(%q) error = %v, want ErrInvalidDate", tt.in, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseDate(%q) = %q, want %q", tt.in, got.String(), tt.want)
+			}
+			if tt.want == "" && !got.IsZero() {
+				t.Errorf("ParseDate(%q) seharusnya nol", tt.in)
+			}
+		})
+	}
+}
+
+func TestDateRangeAndDayRange(t *testing.T) {
+	mustDate := func(s string) Date {
+		d, err := ParseDate(s)
+		if err != nil {
+			t.Fatalf("ParseDate(%q): %v", s, err)
+		}
+		return d
+	}
+	start, end, zero := mustDate("2026-01-30"), mustDate("2026-01-31"), Date{}
+	tests := []struct {
+		name     string
+		got      Fragment
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{"DateRange kedua batas", DateRange("a.tanggal", start, end), "(a.tanggal >= ?) AND (a.tanggal < ?)", []interface{}{"2026-01-30", "2026-02-01"}},
+		{"DateRange tanpa akhir", DateRange("a.tanggal", start, zero), "a.tanggal >= ?", []interface{}{"2026-01-30"}},
+		{"DateRange tanpa awal", DateRange("a.tanggal", zero, end), "a.tanggal < ?", []interface{}{"2026-02-01"}},
+		{"DateRange kosong", DateRange("a.tanggal", zero, zero), "", nil},
+		{"DayRange kedua batas", DayRange("r.day", start, end), "(r.day >= ?) AND (r.day <= ?)", []interface{}{"2026-01-30", "2026-01-31"}},
+		{"DayRange tanpa akhir", DayRange("r.day", start, zero), "r.day >= ?", []interface{}{"2026-01-30"}},
+		{"DayRange tanpa awal", DayRange("r.day", zero, end), "r.day <= ?", []interface{}{"2026-01-31"}},
+		{"DayRange kosong", DayRange("r.day", zero, zero), "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFragment(t, tt.got, tt.wantSQL, tt.wantArgs)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+-- Migration 009: Password policy support
+-- Kolom masa berlaku / wajib ganti password di users, dan tabel riwayat hash password (cegah pemakaian ulang N password terakhir).
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at  TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT false;
+
+-- Akun lama dianggap mengganti password saat akun dibuat.
+UPDATE users SET password_changed_at = created_at WHERE password_changed_at IS NULL;
+
+COMMENT ON COLUMN users.password_changed_at IS 'Last time the password was changed (used for PASSWORD_MAX_AGE expiry)';
+COMMENT ON COLUMN users.must_change_password IS 'Force password change at next login';
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id            SERIAL PRIMARY KEY,
+    user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE password_history IS 'Previous bcrypt password hashes per user (reuse prevention)';
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user ON password_history(user_id, created_at DESC);
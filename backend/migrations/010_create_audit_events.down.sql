@@ -0,0 +1,2 @@
+-- Migration 010 DOWN
+DROP TABLE IF EXISTS audit_events;
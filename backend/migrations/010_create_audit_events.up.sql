@@ -0,0 +1,25 @@
+-- Migration 010: Audit trail for administrative actions
+-- Satu baris per tindakan admin/keamanan (siapa, aksi apa, terhadap target apa, dari IP/user agent mana) beserta snapshot data sebelum dan sesudah.
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id          BIGSERIAL PRIMARY KEY,
+    actor_id    INTEGER,
+    action      VARCHAR(100) NOT NULL,
+    target_type VARCHAR(50),
+    target_id   VARCHAR(100),
+    before_data JSONB,
+    after_data  JSONB,
+    ip          VARCHAR(64),
+    user_agent  TEXT,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE audit_events IS 'Audit trail of administrative and security-relevant actions';
+COMMENT ON COLUMN audit_events.actor_id IS 'users.id of the actor (no FK so history survives user removal); NULL for system actions';
+COMMENT ON COLUMN audit_events.action IS 'Action name, e.g. user.create, user.update, user.deactivate, user.password_reset';
+COMMENT ON COLUMN audit_events.before_data IS 'Snapshot of the target before the change';
+COMMENT ON COLUMN audit_events.after_data IS 'Snapshot of the target after the change';
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id, created_at DESC);
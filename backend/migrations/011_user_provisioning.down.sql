@@ -0,0 +1,4 @@
+-- Migration 011 DOWN
+DROP TABLE IF EXISTS user_activation_tokens;
+DROP INDEX IF EXISTS idx_users_satker;
+ALTER TABLE users DROP COLUMN IF EXISTS satker_id;
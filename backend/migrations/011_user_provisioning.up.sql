@@ -0,0 +1,22 @@
+-- Migration 011: Bulk user provisioning
+-- Satker asal (home satker) per akun dan token aktivasi sekali pakai untuk akun yang dibuat admin secara massal.
+
+-- Tanpa FK: satker bisa dihapus/diganti dari data referensi; akun dengan satker yang sudah tidak ada tetap bisa dideteksi.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS satker_id INTEGER;
+
+COMMENT ON COLUMN users.satker_id IS 'Home satker (ref_satker_units.id) of the account holder';
+
+CREATE INDEX IF NOT EXISTS idx_users_satker ON users(satker_id);
+
+CREATE TABLE IF NOT EXISTS user_activation_tokens (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE user_activation_tokens IS 'One-time account activation links (only the SHA-256 hash of the token is stored)';
+
+CREATE INDEX IF NOT EXISTS idx_user_activation_tokens_user ON user_activation_tokens(user_id);
@@ -0,0 +1,7 @@
+-- Migration 012 DOWN
+DROP INDEX IF EXISTS idx_user_profiles_nama_lower;
+DROP INDEX IF EXISTS idx_user_profiles_email_lower;
+DROP INDEX IF EXISTS idx_users_profile_id;
+ALTER TABLE users DROP COLUMN IF EXISTS profile_linked_at;
+ALTER TABLE users DROP COLUMN IF EXISTS profile_link_method;
+ALTER TABLE users DROP COLUMN IF EXISTS profile_id;
@@ -0,0 +1,13 @@
+-- Migration 012: Link dashboard accounts (users) to monitored people (user_profiles)
+-- Satu akun paling banyak tertaut ke satu profil dan sebaliknya; cara penautan dicatat (email, name, manual).
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_id          INTEGER REFERENCES user_profiles(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_link_method VARCHAR(20);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_linked_at   TIMESTAMP;
+
+COMMENT ON COLUMN users.profile_id IS 'Linked user_profiles.id (person whose activity is logged)';
+COMMENT ON COLUMN users.profile_link_method IS 'How the link was made: email, name, manual';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_profile_id ON users(profile_id) WHERE profile_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_user_profiles_email_lower ON user_profiles(LOWER(email));
+CREATE INDEX IF NOT EXISTS idx_user_profiles_nama_lower ON user_profiles(LOWER(TRIM(nama)));
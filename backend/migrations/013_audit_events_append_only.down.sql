@@ -0,0 +1,9 @@
+-- Migration 013 DOWN
+DROP TRIGGER IF EXISTS trg_audit_events_no_truncate ON audit_events;
+DROP TRIGGER IF EXISTS trg_audit_events_no_update ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_block_modification();
+DROP INDEX IF EXISTS idx_audit_events_request;
+DROP INDEX IF EXISTS idx_audit_events_action;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS diff;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS request_id;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS actor_username;
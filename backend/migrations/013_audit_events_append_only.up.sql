@@ -0,0 +1,29 @@
+-- Migration 013: Audit trail hardening
+-- Tambah konteks request (request ID, username pelaku) dan diff perubahan; audit_events dibuat append-only (UPDATE/DELETE/TRUNCATE ditolak trigger).
+
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS actor_username VARCHAR(100);
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS request_id     VARCHAR(100);
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS diff           JSONB;
+
+COMMENT ON COLUMN audit_events.actor_username IS 'Username of the actor at the time of the event (or attempted username for failed logins)';
+COMMENT ON COLUMN audit_events.request_id IS 'X-Request-ID of the HTTP request that produced the event';
+COMMENT ON COLUMN audit_events.diff IS 'Changed fields: {"field": {"from": old, "to": new}}';
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_request ON audit_events(request_id);
+
+CREATE OR REPLACE FUNCTION audit_events_block_modification() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only (% not allowed)', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_events_no_update ON audit_events;
+CREATE TRIGGER trg_audit_events_no_update
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_block_modification();
+
+DROP TRIGGER IF EXISTS trg_audit_events_no_truncate ON audit_events;
+CREATE TRIGGER trg_audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_block_modification();
@@ -0,0 +1,4 @@
+-- Migration 014 DOWN
+DROP INDEX IF EXISTS idx_audit_events_hash;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS hash;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS prev_hash;
@@ -0,0 +1,10 @@
+-- Migration 014: Tamper-evident hash chain for audit_events
+-- Setiap baris menyimpan hash SHA-256 isi baris + hash baris sebelumnya (prev_hash). Baris lama (sebelum migrasi ini) tetap NULL dan dilaporkan sebagai belum berantai.
+
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS hash      VARCHAR(64);
+
+COMMENT ON COLUMN audit_events.prev_hash IS 'Hash of the previous chained row (empty string for the first chained row)';
+COMMENT ON COLUMN audit_events.hash IS 'SHA-256 (hex) of prev_hash and the canonical row content; NULL for rows written before migration 014';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_hash ON audit_events(hash) WHERE hash IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Migration 015 DOWN
+DROP TABLE IF EXISTS auth_sessions;
@@ -0,0 +1,21 @@
+-- Migration 015: Login sessions
+-- Satu baris per login (perangkat, IP, user agent, waktu dibuat/terakhir aktif/kedaluwarsa). JWT membawa id sesi (klaim jti) dan hanya berlaku selama sesi belum dicabut.
+
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id             VARCHAR(36) PRIMARY KEY,
+    user_id        INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device         VARCHAR(100),
+    ip             VARCHAR(64),
+    user_agent     TEXT,
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at     TIMESTAMP NOT NULL,
+    revoked_at     TIMESTAMP,
+    revoked_reason VARCHAR(50)
+);
+
+COMMENT ON TABLE auth_sessions IS 'Login sessions; every JWT carries its session id (jti) and is rejected once the session is revoked or expired';
+COMMENT ON COLUMN auth_sessions.device IS 'Human-readable device summary derived from the user agent, e.g. "Chrome on Windows"';
+COMMENT ON COLUMN auth_sessions.revoked_reason IS 'logout, user_revoked, password_change, password_reset, deactivated';
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user_active ON auth_sessions(user_id, expires_at) WHERE revoked_at IS NULL;
@@ -0,0 +1,8 @@
+-- Migration 016 DOWN
+DROP TABLE IF EXISTS report_access_request_events;
+DROP INDEX IF EXISTS idx_rar_expires_at;
+ALTER TABLE report_access_requests DROP COLUMN IF EXISTS review_notified_at;
+ALTER TABLE report_access_requests DROP COLUMN IF EXISTS review_due_at;
+ALTER TABLE report_access_requests DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE report_access_requests DROP COLUMN IF EXISTS current_step;
+ALTER TABLE report_access_requests DROP COLUMN IF EXISTS approval_steps;
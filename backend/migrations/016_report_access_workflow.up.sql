@@ -0,0 +1,59 @@
+-- Migration 016: Multi-step approval workflow for report access requests
+-- Permintaan akses melewati tahap persetujuan yang dapat dikonfigurasi (mis. unit_head lalu admin), punya masa berlaku + jadwal review, bisa dicabut, dan setiap langkah tercatat di report_access_request_events.
+
+ALTER TABLE report_access_requests ADD COLUMN IF NOT EXISTS approval_steps     VARCHAR(255) NOT NULL DEFAULT 'admin';
+ALTER TABLE report_access_requests ADD COLUMN IF NOT EXISTS current_step       INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE report_access_requests ADD COLUMN IF NOT EXISTS expires_at         TIMESTAMP;
+ALTER TABLE report_access_requests ADD COLUMN IF NOT EXISTS review_due_at      TIMESTAMP;
+ALTER TABLE report_access_requests ADD COLUMN IF NOT EXISTS review_notified_at TIMESTAMP;
+
+COMMENT ON COLUMN report_access_requests.status IS 'Request status: pending, approved, rejected, revoked, expired';
+COMMENT ON COLUMN report_access_requests.approval_steps IS 'Approver roles in order (comma separated), snapshot of ACCESS_APPROVAL_STEPS at submission';
+COMMENT ON COLUMN report_access_requests.current_step IS 'Index into approval_steps of the step awaiting a decision';
+COMMENT ON COLUMN report_access_requests.expires_at IS 'Approved access lapses at this time (NULL = no expiry)';
+COMMENT ON COLUMN report_access_requests.review_due_at IS 'Approved access must be reviewed (renewed or revoked) by this time';
+COMMENT ON COLUMN users.role IS 'User role: user, unit_head, or admin';
+
+CREATE TABLE IF NOT EXISTS report_access_request_events (
+    id          SERIAL PRIMARY KEY,
+    request_id  INTEGER NOT NULL REFERENCES report_access_requests(id) ON DELETE CASCADE,
+    step        INTEGER,
+    step_role   VARCHAR(20),
+    action      VARCHAR(30) NOT NULL,
+    actor_id    INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    from_status VARCHAR(20),
+    to_status   VARCHAR(20),
+    reason      TEXT,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE report_access_request_events IS 'Full history of each report access request (submission, per-step decisions, review, revocation, expiry)';
+COMMENT ON COLUMN report_access_request_events.action IS 'submitted, step_approved, step_skipped, approved, rejected, revoked, reviewed, expired';
+
+CREATE INDEX IF NOT EXISTS idx_rar_events_request ON report_access_request_events(request_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_rar_expires_at ON report_access_requests(expires_at) WHERE status = 'approved';
+
+-- Satukan status lama: user yang status-nya diubah lewat jalur profil (tanpa baris report_access_requests) dibuatkan permintaan yang sesuai.
+INSERT INTO report_access_requests (user_id, status, requested_at)
+SELECT u.id, 'pending', COALESCE(u.updated_at, CURRENT_TIMESTAMP)
+FROM users u
+WHERE u.report_access_status = 'pending'
+  AND NOT EXISTS (SELECT 1 FROM report_access_requests r WHERE r.user_id = u.id AND r.status = 'pending');
+
+INSERT INTO report_access_requests (user_id, status, requested_at, processed_at)
+SELECT u.id, 'approved', COALESCE(u.updated_at, CURRENT_TIMESTAMP), COALESCE(u.updated_at, CURRENT_TIMESTAMP)
+FROM users u
+WHERE u.report_access_status = 'approved'
+  AND NOT EXISTS (SELECT 1 FROM report_access_requests r WHERE r.user_id = u.id AND r.status = 'approved');
+
+-- Permintaan lama: riwayat minimal dari data yang ada.
+INSERT INTO report_access_request_events (request_id, action, to_status, reason, created_at)
+SELECT r.id, 'submitted', 'pending', r.reason, r.requested_at
+FROM report_access_requests r
+WHERE NOT EXISTS (SELECT 1 FROM report_access_request_events e WHERE e.request_id = r.id);
+
+INSERT INTO report_access_request_events (request_id, action, actor_id, from_status, to_status, reason, created_at)
+SELECT r.id, r.status, r.processed_by, 'pending', r.status, r.admin_notes, r.processed_at
+FROM report_access_requests r
+WHERE r.status IN ('approved', 'rejected') AND r.processed_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM report_access_request_events e WHERE e.request_id = r.id AND e.action = r.status);
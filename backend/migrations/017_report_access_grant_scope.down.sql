@@ -0,0 +1,5 @@
+-- Migration 017 DOWN
+DROP INDEX IF EXISTS idx_rar_user_status;
+ALTER TABLE report_access_requests DROP COLUMN IF EXISTS expiry_notified_at;
+ALTER TABLE report_access_requests DROP COLUMN IF EXISTS satker_ids;
+ALTER TABLE report_access_requests DROP COLUMN IF EXISTS templates;
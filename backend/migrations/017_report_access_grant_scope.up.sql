@@ -0,0 +1,12 @@
+-- Migration 017: Template- and satker-scoped report access grants
+-- Permintaan akses yang disetujui menjadi grant: daftar template laporan dan root pohon satker yang boleh dilaporkan, dengan masa berlaku dan pemberitahuan sebelum kedaluwarsa.
+
+ALTER TABLE report_access_requests ADD COLUMN IF NOT EXISTS templates          VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE report_access_requests ADD COLUMN IF NOT EXISTS satker_ids         TEXT NOT NULL DEFAULT '';
+ALTER TABLE report_access_requests ADD COLUMN IF NOT EXISTS expiry_notified_at TIMESTAMP;
+
+COMMENT ON COLUMN report_access_requests.templates IS 'Report templates covered by the grant (comma separated: org-performance, user-activity, feature-usage); empty = all';
+COMMENT ON COLUMN report_access_requests.satker_ids IS 'Root ref_satker_units ids whose subtrees the grant covers (comma separated); empty = all satker';
+COMMENT ON COLUMN report_access_requests.expiry_notified_at IS 'When the holder was notified of the upcoming expiry (reset on review)';
+
+CREATE INDEX IF NOT EXISTS idx_rar_user_status ON report_access_requests(user_id, status);
@@ -0,0 +1,3 @@
+-- Migration 018 DOWN
+DROP TABLE IF EXISTS activity_rollup_state;
+DROP TABLE IF EXISTS activity_rollup_hourly;
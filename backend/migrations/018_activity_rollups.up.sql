@@ -0,0 +1,31 @@
+-- Migration 018: Hourly activity rollups for dashboard queries
+-- Agregat activity_logs_normalized per jam, satker, cluster, jenis aktivitas, lokasi, status, dan kelas scope. Diperbarui inkremental setelah import (cmd/rollup atau cmd/import).
+
+CREATE TABLE IF NOT EXISTS activity_rollup_hourly (
+    day              DATE        NOT NULL,
+    hour             SMALLINT    NOT NULL,
+    satker_id        BIGINT      NOT NULL DEFAULT 0,
+    cluster_id       BIGINT      NOT NULL DEFAULT 0,
+    activity_type_id BIGINT      NOT NULL DEFAULT 0,
+    location_id      BIGINT      NOT NULL DEFAULT 0,
+    status           VARCHAR(50) NOT NULL DEFAULT '',
+    scope_class      VARCHAR(15) NOT NULL,
+    activity_count   BIGINT      NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, hour, satker_id, cluster_id, activity_type_id, location_id, status, scope_class)
+);
+
+COMMENT ON TABLE activity_rollup_hourly IS 'Pre-aggregated activity_logs_normalized counts per day/hour and dimension; 0 stands for a NULL reference id';
+COMMENT ON COLUMN activity_rollup_hourly.day IS 'DATE(tanggal) in the database session time zone';
+COMMENT ON COLUMN activity_rollup_hourly.hour IS 'EXTRACT(HOUR FROM tanggal) in the database session time zone';
+COMMENT ON COLUMN activity_rollup_hourly.scope_class IS 'empty (NULL/blank scope), success, error, success_error (matches both), or other';
+
+CREATE INDEX IF NOT EXISTS idx_activity_rollup_satker ON activity_rollup_hourly(satker_id, day);
+CREATE INDEX IF NOT EXISTS idx_activity_rollup_type ON activity_rollup_hourly(activity_type_id, day);
+
+CREATE TABLE IF NOT EXISTS activity_rollup_state (
+    id           SMALLINT PRIMARY KEY CHECK (id = 1),
+    last_log_id  BIGINT    NOT NULL DEFAULT 0,
+    refreshed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE activity_rollup_state IS 'Single-row watermark: activity_logs_normalized rows with id <= last_log_id are included in activity_rollup_hourly';
@@ -0,0 +1,2 @@
+-- Migration 019 DOWN
+DROP TABLE IF EXISTS data_versions;
@@ -0,0 +1,13 @@
+-- Migration 019: Data version counters for analytics cache invalidation
+-- Versi data dinaikkan setiap impor aktivitas dan refresh rollup; menjadi bagian kunci cache hasil query analitik.
+
+CREATE TABLE IF NOT EXISTS data_versions (
+    name       VARCHAR(50) PRIMARY KEY,
+    version    BIGINT      NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE data_versions IS 'Monotonic data version per dataset; bumped by imports so cached analytics results are no longer used';
+COMMENT ON COLUMN data_versions.name IS 'Dataset name, e.g. activity (activity_logs_normalized and its rollups)';
+
+INSERT INTO data_versions (name, version) VALUES ('activity', 0) ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,2 @@
+-- Migration 020 DOWN
+DROP TABLE IF EXISTS ref_holidays;
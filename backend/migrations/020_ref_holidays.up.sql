@@ -0,0 +1,14 @@
+-- Migration 020: National holiday calendar reference table
+-- Kalender hari libur nasional (dipelihara admin lewat /api/admin/holidays); dipakai heatmap aktivitas untuk mengecualikan hari libur.
+
+CREATE TABLE IF NOT EXISTS ref_holidays (
+    id           BIGSERIAL    PRIMARY KEY,
+    holiday_date DATE         NOT NULL UNIQUE,
+    name         VARCHAR(200) NOT NULL,
+    created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE ref_holidays IS 'National holidays and collective leave days, maintained by admins; excluded from activity heatmaps on request';
+COMMENT ON COLUMN ref_holidays.holiday_date IS 'Calendar date of the holiday (one row per date)';
+COMMENT ON COLUMN ref_holidays.name IS 'Holiday name, e.g. Hari Kemerdekaan';
@@ -0,0 +1,2 @@
+-- Migration 021 DOWN
+DROP TABLE IF EXISTS anomalies;
@@ -0,0 +1,38 @@
+-- Migration 021: Detected activity anomalies
+-- Temuan detektor anomali (volume harian satker, unduhan per user, lonjakan error logout) beserta skor, tingkat keparahan, penjelasan, dan status tindak lanjut.
+
+CREATE TABLE IF NOT EXISTS anomalies (
+    id                BIGSERIAL        PRIMARY KEY,
+    detector          VARCHAR(50)      NOT NULL,
+    subject_type      VARCHAR(20)      NOT NULL,
+    subject_id        BIGINT           NOT NULL DEFAULT 0,
+    subject_name      VARCHAR(255)     NOT NULL DEFAULT '',
+    day               DATE             NOT NULL,
+    observed          BIGINT           NOT NULL,
+    baseline_median   DOUBLE PRECISION NOT NULL,
+    baseline_mad      DOUBLE PRECISION NOT NULL,
+    score             DOUBLE PRECISION NOT NULL,
+    direction         VARCHAR(10)      NOT NULL,
+    severity          VARCHAR(10)      NOT NULL,
+    explanation       TEXT             NOT NULL,
+    status            VARCHAR(20)      NOT NULL DEFAULT 'open',
+    status_note       TEXT,
+    status_changed_by INTEGER          REFERENCES users(id) ON DELETE SET NULL,
+    status_changed_at TIMESTAMP,
+    detected_at       TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_anomalies_subject_day UNIQUE (detector, subject_id, day)
+);
+
+COMMENT ON TABLE anomalies IS 'Findings of the scheduled anomaly detector; one row per detector, subject and day (re-detection updates the row, status is kept)';
+COMMENT ON COLUMN anomalies.detector IS 'satker_volume, user_downloads, or failed_logouts';
+COMMENT ON COLUMN anomalies.subject_type IS 'satker (ref_satker_units.id), user (user_profiles.id), or global (subject_id = 0)';
+COMMENT ON COLUMN anomalies.baseline_median IS 'Median daily count on the same weekday over the baseline weeks (holidays excluded)';
+COMMENT ON COLUMN anomalies.baseline_mad IS 'Median absolute deviation of the baseline';
+COMMENT ON COLUMN anomalies.score IS 'Robust z-score: (observed - median) / (1.4826 * MAD), with fallbacks when MAD is 0';
+COMMENT ON COLUMN anomalies.direction IS 'spike or drop';
+COMMENT ON COLUMN anomalies.severity IS 'low, medium, or high (by absolute score)';
+COMMENT ON COLUMN anomalies.status IS 'open, acknowledged, or dismissed';
+
+CREATE INDEX IF NOT EXISTS idx_anomalies_status_day ON anomalies(status, day DESC);
+CREATE INDEX IF NOT EXISTS idx_anomalies_day ON anomalies(day DESC);
@@ -0,0 +1,3 @@
+-- Migration 022 DOWN
+DROP TABLE IF EXISTS security_alert_state;
+DROP TABLE IF EXISTS security_alerts;
@@ -0,0 +1,39 @@
+-- Migration 022: Security alerts for off-hours and unusual-location access
+-- Peringatan keamanan dari aktivitas baru: akses di luar jam kerja (per zona waktu, akhir pekan, hari libur) dan akses dari lokasi yang tidak biasa bagi user.
+
+CREATE TABLE IF NOT EXISTS security_alerts (
+    id              BIGSERIAL    PRIMARY KEY,
+    rule            VARCHAR(30)  NOT NULL,
+    dedup_key       VARCHAR(200) NOT NULL UNIQUE,
+    activity_log_id BIGINT       NOT NULL REFERENCES activity_logs_normalized(id) ON DELETE CASCADE,
+    user_id         INTEGER      NOT NULL REFERENCES user_profiles(id) ON DELETE CASCADE,
+    user_name       VARCHAR(255) NOT NULL DEFAULT '',
+    satker_id       INTEGER      REFERENCES ref_satker_units(id) ON DELETE SET NULL,
+    location_id     INTEGER      REFERENCES ref_locations(id) ON DELETE SET NULL,
+    location_name   VARCHAR(255) NOT NULL DEFAULT '',
+    activity_at     TIMESTAMPTZ  NOT NULL,
+    local_time      VARCHAR(30)  NOT NULL,
+    explanation     TEXT         NOT NULL,
+    created_at      TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE security_alerts IS 'Security alerts raised from newly ingested activities; at most one per rule, user and local day (and location for unusual_location)';
+COMMENT ON COLUMN security_alerts.rule IS 'off_hours or unusual_location';
+COMMENT ON COLUMN security_alerts.dedup_key IS 'rule:user_id:local_date (plus :location_id for unusual_location); duplicates are ignored';
+COMMENT ON COLUMN security_alerts.local_time IS 'Activity time in the local time zone of the access location, e.g. 2026-10-18 22:14 WITA';
+
+CREATE INDEX IF NOT EXISTS idx_security_alerts_created ON security_alerts(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_security_alerts_user ON security_alerts(user_id, activity_at DESC);
+
+CREATE TABLE IF NOT EXISTS security_alert_state (
+    id           SMALLINT  PRIMARY KEY CHECK (id = 1),
+    last_log_id  BIGINT    NOT NULL DEFAULT 0,
+    evaluated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE security_alert_state IS 'Single-row watermark: activity_logs_normalized rows with id <= last_log_id have been evaluated for security alerts';
+
+-- Mulai dari data yang sudah ada agar riwayat lama tidak menghasilkan banjir peringatan.
+INSERT INTO security_alert_state (id, last_log_id)
+SELECT 1, COALESCE(MAX(id), 0) FROM activity_logs_normalized
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Migration 023 DOWN
+DROP TABLE IF EXISTS alert_rule_firings;
+DROP TABLE IF EXISTS alert_rules;
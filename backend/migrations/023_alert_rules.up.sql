@@ -0,0 +1,55 @@
+-- Migration 023: Admin-defined alert rules and their firing history
+-- Aturan peringatan yang didefinisikan admin atas metrik dashboard (metrik, filter, jendela, pembanding, ambang, cooldown, penerima) dan riwayat firing-nya.
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id                 BIGSERIAL        PRIMARY KEY,
+    name               VARCHAR(150)     NOT NULL,
+    description        TEXT             NOT NULL DEFAULT '',
+    metric             VARCHAR(50)      NOT NULL,
+    filter             JSONB            NOT NULL DEFAULT '{}',
+    window_minutes     INTEGER          NOT NULL CHECK (window_minutes > 0),
+    comparator         VARCHAR(5)       NOT NULL,
+    threshold          DOUBLE PRECISION NOT NULL,
+    cooldown_minutes   INTEGER          NOT NULL DEFAULT 0 CHECK (cooldown_minutes >= 0),
+    recipient_roles    TEXT             NOT NULL DEFAULT '',
+    recipient_user_ids TEXT             NOT NULL DEFAULT '',
+    is_active          BOOLEAN          NOT NULL DEFAULT TRUE,
+    last_value         DOUBLE PRECISION,
+    last_evaluated_at  TIMESTAMP,
+    created_by         INTEGER          REFERENCES users(id) ON DELETE SET NULL,
+    created_at         TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at         TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE alert_rules IS 'Admin-defined threshold rules over dashboard metrics, evaluated by the alert-rules job';
+COMMENT ON COLUMN alert_rules.metric IS 'total_activities, successful_logins, logout_errors or unique_users';
+COMMENT ON COLUMN alert_rules.filter IS 'ActivityFilter JSON (clusters, satker_ids, statuses, ...) without dates; the window is applied at evaluation';
+COMMENT ON COLUMN alert_rules.window_minutes IS 'Rolling window ending at evaluation time';
+COMMENT ON COLUMN alert_rules.comparator IS 'gt, gte, lt, lte or eq: metric value compared to threshold';
+COMMENT ON COLUMN alert_rules.cooldown_minutes IS 'Minimum time after a firing starts before the rule may fire again';
+COMMENT ON COLUMN alert_rules.recipient_roles IS 'Comma-separated users.role values notified when the rule fires or resolves';
+COMMENT ON COLUMN alert_rules.recipient_user_ids IS 'Comma-separated users.id values notified when the rule fires or resolves';
+
+CREATE TABLE IF NOT EXISTS alert_rule_firings (
+    id                BIGSERIAL        PRIMARY KEY,
+    rule_id           BIGINT           NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    status            VARCHAR(20)      NOT NULL DEFAULT 'firing',
+    value             DOUBLE PRECISION NOT NULL,
+    peak_value        DOUBLE PRECISION NOT NULL,
+    threshold         DOUBLE PRECISION NOT NULL,
+    comparator        VARCHAR(5)       NOT NULL,
+    message           TEXT             NOT NULL,
+    fired_at          TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_evaluated_at TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at       TIMESTAMP,
+    resolved_value    DOUBLE PRECISION
+);
+
+COMMENT ON TABLE alert_rule_firings IS 'Firing history of alert rules; at most one firing per rule is open (status firing) at a time';
+COMMENT ON COLUMN alert_rule_firings.value IS 'Latest metric value while firing';
+COMMENT ON COLUMN alert_rule_firings.peak_value IS 'Value furthest past the threshold while firing';
+COMMENT ON COLUMN alert_rule_firings.threshold IS 'Rule threshold when the firing started (rules may be edited later)';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alert_rule_firings_open ON alert_rule_firings(rule_id) WHERE status = 'firing';
+CREATE INDEX IF NOT EXISTS idx_alert_rule_firings_rule ON alert_rule_firings(rule_id, fired_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alert_rule_firings_fired ON alert_rule_firings(fired_at DESC);
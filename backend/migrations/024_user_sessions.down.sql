@@ -0,0 +1,3 @@
+-- Migration 024 DOWN
+DROP TABLE IF EXISTS user_session_state;
+DROP TABLE IF EXISTS user_sessions;
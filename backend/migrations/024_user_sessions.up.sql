@@ -0,0 +1,40 @@
+-- Migration 024: User sessions reconstructed from LOGIN/LOGOUT activity events
+-- Sesi user_profiles hasil rekonstruksi dari event LOGIN/LOGOUT di activity_logs_normalized: waktu login, logout atau timeout, durasi, jumlah aksi, error logout.
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id               BIGSERIAL    PRIMARY KEY,
+    user_id          INTEGER      NOT NULL REFERENCES user_profiles(id) ON DELETE CASCADE,
+    satker_id        INTEGER      REFERENCES ref_satker_units(id) ON DELETE SET NULL,
+    location_id      INTEGER      REFERENCES ref_locations(id) ON DELETE SET NULL,
+    login_log_id     BIGINT       NOT NULL UNIQUE REFERENCES activity_logs_normalized(id) ON DELETE CASCADE,
+    logout_log_id    BIGINT       REFERENCES activity_logs_normalized(id) ON DELETE SET NULL,
+    started_at       TIMESTAMPTZ  NOT NULL,
+    last_activity_at TIMESTAMPTZ  NOT NULL,
+    ended_at         TIMESTAMPTZ,
+    end_reason       VARCHAR(20),
+    duration_seconds BIGINT,
+    action_count     INTEGER      NOT NULL DEFAULT 0,
+    error_logout     BOOLEAN      NOT NULL DEFAULT FALSE,
+    updated_at       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE user_sessions IS 'Sessions rebuilt by the sessionization job: a LOGIN event opens a session, closed by LOGOUT, the next LOGIN, or the idle timeout';
+COMMENT ON COLUMN user_sessions.ended_at IS 'LOGOUT time, or the last activity for relogin/timeout; NULL while the session is still open';
+COMMENT ON COLUMN user_sessions.end_reason IS 'logout, relogin or timeout; NULL while open';
+COMMENT ON COLUMN user_sessions.action_count IS 'Activities other than LOGIN/LOGOUT inside the session';
+COMMENT ON COLUMN user_sessions.error_logout IS 'Session ended by a LOGOUT whose scope contains error (same definition as the FAILED logout count)';
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_started ON user_sessions(started_at);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_open ON user_sessions(user_id) WHERE ended_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_session_state (
+    id           SMALLINT  PRIMARY KEY CHECK (id = 1),
+    last_log_id  BIGINT    NOT NULL DEFAULT 0,
+    refreshed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE user_session_state IS 'Single-row watermark: activity_logs_normalized rows with id <= last_log_id are reflected in user_sessions';
+
+-- Mulai dari 0 agar run pertama membangun sesi untuk seluruh riwayat.
+INSERT INTO user_session_state (id, last_log_id) VALUES (1, 0) ON CONFLICT (id) DO NOTHING;
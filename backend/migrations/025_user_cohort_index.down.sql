@@ -0,0 +1,2 @@
+-- Migration 025 DOWN
+DROP INDEX IF EXISTS idx_user_profiles_first_activity;
@@ -0,0 +1,4 @@
+-- Migration 025: Index user_profiles first activity for retention cohorts
+-- Indeks first_activity untuk analisis kohort retensi (kohort = minggu/bulan aktivitas pertama profil).
+
+CREATE INDEX IF NOT EXISTS idx_user_profiles_first_activity ON user_profiles(first_activity);
@@ -0,0 +1,5 @@
+-- Migration 026 DOWN
+DROP INDEX IF EXISTS idx_user_profiles_last_activity;
+COMMENT ON COLUMN user_profiles.is_active IS NULL;
+COMMENT ON COLUMN user_profiles.last_activity IS NULL;
+COMMENT ON COLUMN user_profiles.first_activity IS NULL;
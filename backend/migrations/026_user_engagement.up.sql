@@ -0,0 +1,24 @@
+-- Migration 026: User profile activity span and engagement status
+-- Isi first_activity dan last_activity user_profiles dari activity_logs_normalized (sebelumnya tidak pernah diisi cmd/import; selanjutnya dipelihara
+-- cmd/import per baris), indeks last_activity untuk status engagement profil (active, dormant, never_active), dan penyelarasan awal is_active
+-- dengan batas dorman default 30 hari.
+
+UPDATE user_profiles up
+SET first_activity = span.first_activity,
+    last_activity  = span.last_activity
+FROM (
+    SELECT user_id, MIN(tanggal) AS first_activity, MAX(tanggal) AS last_activity
+    FROM activity_logs_normalized
+    GROUP BY user_id
+) span
+WHERE span.user_id = up.id;
+
+UPDATE user_profiles
+SET is_active = COALESCE(last_activity >= NOW() - INTERVAL '30 days', FALSE)
+WHERE is_active IS DISTINCT FROM COALESCE(last_activity >= NOW() - INTERVAL '30 days', FALSE);
+
+COMMENT ON COLUMN user_profiles.first_activity IS 'Earliest activity_logs_normalized.tanggal of the profile; defines its retention cohort (maintained by cmd/import, recomputed by cmd/backfill)';
+COMMENT ON COLUMN user_profiles.last_activity IS 'Latest activity_logs_normalized.tanggal of the profile (maintained by cmd/import, recomputed by cmd/backfill)';
+COMMENT ON COLUMN user_profiles.is_active IS 'Engagement status active: last_activity within USER_DORMANT_DAYS (maintained by cmd/import and the daily user-engagement job)';
+
+CREATE INDEX IF NOT EXISTS idx_user_profiles_last_activity ON user_profiles(last_activity);
@@ -0,0 +1,2 @@
+-- Migration 027 DOWN
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
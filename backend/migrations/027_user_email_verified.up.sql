@@ -0,0 +1,18 @@
+-- Migration 027: User email verification
+-- users.email_verified_at: kapan kepemilikan email akun terbukti (aktivasi lewat link email atau dibuat/diubah oleh admin).
+-- Auto-link profil aktivitas saat membuka my-activity hanya untuk akun dengan email terverifikasi; akun registrasi mandiri ditautkan admin.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Akun yang pernah diaktifkan lewat token aktivasi sudah membuktikan emailnya.
+UPDATE users u
+SET email_verified_at = t.used_at
+FROM (
+    SELECT user_id, MIN(used_at) AS used_at
+    FROM user_activation_tokens
+    WHERE used_at IS NOT NULL
+    GROUP BY user_id
+) t
+WHERE t.user_id = u.id AND u.email_verified_at IS NULL;
+
+COMMENT ON COLUMN users.email_verified_at IS 'When ownership of users.email was proven (activation link or set by an admin); NULL for self-registered accounts';
@@ -0,0 +1,2 @@
+-- Migration 028 DOWN
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_at;
@@ -0,0 +1,23 @@
+-- Migration 028: User deactivation timestamp
+-- users.deactivated_at: kapan admin menonaktifkan akun (DELETE /api/admin/users/:id, nonaktif massal akun ditandai, atau PUT is_active=false).
+-- Akun dengan deactivated_at tidak bisa diaktifkan lewat link aktivasi dan tidak diterbitkan link baru; admin mengaktifkan ulang lewat PUT is_active=true.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ;
+
+-- Akun nonaktif selain yang masih menunggu aktivasi (punya token, belum ada yang terpakai) dianggap dinonaktifkan admin.
+UPDATE users u
+SET deactivated_at = u.updated_at
+WHERE u.is_active = false
+  AND u.deactivated_at IS NULL
+  AND NOT (
+      EXISTS (SELECT 1 FROM user_activation_tokens t WHERE t.user_id = u.id)
+      AND NOT EXISTS (SELECT 1 FROM user_activation_tokens t WHERE t.user_id = u.id AND t.used_at IS NOT NULL)
+  );
+
+-- Link aktivasi yang masih berlaku milik akun tersebut tidak boleh lagi dipakai.
+UPDATE user_activation_tokens t
+SET expires_at = NOW()
+FROM users u
+WHERE u.id = t.user_id AND u.deactivated_at IS NOT NULL AND t.used_at IS NULL AND t.expires_at > NOW();
+
+COMMENT ON COLUMN users.deactivated_at IS 'When an admin deactivated the account; NULL for active accounts and accounts awaiting activation';
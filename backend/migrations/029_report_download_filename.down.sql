@@ -0,0 +1,3 @@
+-- Migration 029 DOWN
+DROP INDEX IF EXISTS idx_report_downloads_filename;
+ALTER TABLE report_downloads DROP COLUMN IF EXISTS filename;
@@ -0,0 +1,9 @@
+-- Migration 029: Report download filename
+-- report_downloads.filename: nama file di generated_reports; GET /api/reports/download/:filename mencari baris ini untuk memeriksa pemilik
+-- (hanya pembuat laporan atau admin yang boleh mengunduh). File dari sebelum migrasi ini tidak punya baris dan tidak bisa diunduh lagi.
+
+ALTER TABLE report_downloads ADD COLUMN IF NOT EXISTS filename VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_report_downloads_filename ON report_downloads(filename) WHERE filename IS NOT NULL;
+
+COMMENT ON COLUMN report_downloads.filename IS 'Generated file name under generated_reports; downloads are authorized against this row';
@@ -0,0 +1,2 @@
+-- Migration 030 DOWN
+ALTER TABLE report_downloads DROP COLUMN IF EXISTS satker_id;
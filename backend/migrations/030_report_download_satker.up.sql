@@ -0,0 +1,7 @@
+-- Migration 030: Report download satker scope
+-- report_downloads.satker_id: root pohon satker yang diminta saat generate (NULL = semua satker dalam grant). Dipakai untuk memeriksa ulang
+-- grant akses laporan saat file diunduh, sehingga grant yang dicabut atau kedaluwarsa juga menutup unduhan file lama.
+
+ALTER TABLE report_downloads ADD COLUMN IF NOT EXISTS satker_id BIGINT;
+
+COMMENT ON COLUMN report_downloads.satker_id IS 'Satker subtree root requested at generation (NULL = whole grant scope); re-authorized on download';
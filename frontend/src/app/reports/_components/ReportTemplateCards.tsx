@@ -11,7 +11,7 @@ import {
   File,
   AlertCircle,
 } from "lucide-react";
-import { reportService, API_BASE_URL } from "@/services/api";
+import { reportService } from "@/services/api";
 import type { ReportTemplate } from "@/types/api";
 
 // Template icons mapping
@@ -75,16 +75,18 @@ export default function ReportTemplateCards({
       const result = await reportService.generateReport(templateId, format);
       
       if (result.success && result.download_url) {
-        // Trigger file download (pakai base URL terpusat dari api)
-        const fullUrl = `${API_BASE_URL}${result.download_url}`;
+        // Unduh lewat fetch ber-JWT (endpoint download butuh Authorization), lalu picu download dari Blob
+        const blob = await reportService.downloadReport(result.download_url);
+        const objectUrl = URL.createObjectURL(blob);
         
         // Create a temporary link and click it to trigger download
         const link = document.createElement("a");
-        link.href = fullUrl;
+        link.href = objectUrl;
         link.download = result.filename || "laporan";
         document.body.appendChild(link);
         link.click();
         document.body.removeChild(link);
+        URL.revokeObjectURL(objectUrl);
         
         // Show success message
         console.log(`Laporan berhasil dibuat: ${result.filename} (${result.file_size})`);
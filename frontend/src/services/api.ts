@@ -494,6 +494,22 @@ export const reportService = {
   },
 
   /** Daftar unduhan laporan terbaru (opsional limit & filter tanggal) */
+  /** Unduh file laporan (download_url dari generate) dengan JWT; mengembalikan isi file sebagai Blob */
+  downloadReport: async (downloadUrl: string): Promise<Blob> => {
+    const token = typeof window !== 'undefined' ? localStorage.getItem('token') : null;
+    const response = await fetch(`${API_BASE_URL}${downloadUrl}`, {
+      headers: token ? { Authorization: `Bearer ${token}` } : {},
+    });
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ error: "Unknown error" }));
+      if (response.status === 401) {
+        clearAuthAndRedirectToLogin();
+      }
+      throw new ApiError(response.status, error.error || response.statusText);
+    }
+    return response.blob();
+  },
+
   getRecentDownloads: (limit?: number, startDate?: string, endDate?: string) => {
     const params = new URLSearchParams();
     if (limit) params.append("limit", limit.toString());